@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -34,18 +35,49 @@ Commands:
                    "sabre parse-decl <file>"
   test-parse-decl  tests the declaration parsing against golden output
                    "sabre test-parse-decl <test-data-dir>"
-  check            type checks a program
-                   "sabre check <file>"
+  check            type checks a program, given a file or a package directory
+                   "sabre check [-I <search-dir>]... [-W <code>]... [-Wno <code>]... [-Werror] <file|dir>"
+                   warning codes: unreachable-code, unused-parameter (off by default), unused-symbol,
+                   unused-result, a //sabre:ignore [code]... comment suppresses warnings on the next line
   test-check       tests the type checking against golden output
                    "sabre test-check <test-data-dir>
   spirv            emits SPIR-V bytecode in text
-                   "sabre spirv <file>"
+                   "sabre spirv [-I <search-dir>]... [-discard kill|demote] [-target <env>] [-W <code>]... [-Wno <code>]... [-Werror] <file|dir>"
+                   the target env is one of spv1.0-spv1.6 (spv1.3 by default), vulkan1.0-vulkan1.3, opengl4.5,
+                   opencl2.1 or opencl2.2, opencl targets emit compute entry points as kernels
   test-spirv       tests the SPIR-V emission against golden output
                    "sabre test-spirv <test-data-dir>"
+                   a test can pass flags to the command in a <test>.flags file next to it
   spirv-bin        emits SPIR-V bytecode in binary
-                   "sabre spirv-bin <file>"
+                   "sabre spirv-bin [-I <search-dir>]... [-discard kill|demote] [-target <env>] [-W <code>]... [-Wno <code>]... [-Werror] <file|dir>"
   test-spirv-bin   tests the SPIR-V emission against golden binary output
                    "sabre test-spirv-bin <test-data-dir>"
+  spirv-as         assembles SPIR-V text written by the spirv command into binary, ids named %%<name>_<number> keep
+                   their number and other names are given fresh ids, -text prints the names back as they're written,
+                   OpName, OpMemberName, OpString, OpSource, OpLine and OpPhi are not supported
+                   "sabre spirv-as [-text] <file>"
+  test-spirv-as    tests the SPIR-V assembler against golden output of the module printed back in text
+                   "sabre test-spirv-as <test-data-dir>"
+  glsl             emits GLSL 4.50 source, the entry point becomes the shader's main function
+                   "sabre glsl [-I <search-dir>]... [-discard kill|demote] [-entry <name>] [-W <code>]... [-Wno <code>]... [-Werror] <file|dir>"
+  test-glsl        tests the GLSL emission against golden output
+                   "sabre test-glsl <test-data-dir>"
+  hlsl             emits HLSL source for shader model 6, entry points are marked with their shader stage
+                   "sabre hlsl [-I <search-dir>]... [-W <code>]... [-Wno <code>]... [-Werror] <file|dir>"
+  test-hlsl        tests the HLSL emission against golden output
+                   "sabre test-hlsl <test-data-dir>"
+  msl              emits Metal Shading Language source, entry points become vertex, fragment and kernel functions
+                   "sabre msl [-I <search-dir>]... [-W <code>]... [-Wno <code>]... [-Werror] <file|dir>"
+  test-msl         tests the MSL emission against golden output
+                   "sabre test-msl <test-data-dir>"
+  wgsl             emits WGSL source for WebGPU, entry points are marked with their shader stage
+                   "sabre wgsl [-I <search-dir>]... [-W <code>]... [-Wno <code>]... [-Werror] <file|dir>"
+  test-wgsl        tests the WGSL emission against golden output
+                   "sabre test-wgsl <test-data-dir>"
+  go               emits a Go package which runs the shaders on the CPU, entry points get exported functions running them
+                   "sabre go [-I <search-dir>]... [-package <name>] [-W <code>]... [-Wno <code>]... [-Werror] <file|dir>"
+  test-go          tests the Go emission against golden output
+                   "sabre test-go <test-data-dir>"
 `
 
 func helpString() string {
@@ -98,6 +130,17 @@ func scan(args []string, out io.Writer) error {
 	return nil
 }
 
+// testFlags reads the flags a test passes to the tested command from the optional <test>.flags file
+func testFlags(testFile string) ([]string, error) {
+	content, err := os.ReadFile(testFile + ".flags")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read test flags '%v': %v", testFile, err)
+	}
+	return strings.Fields(string(content)), nil
+}
+
 func testFunc(f func([]string, io.Writer) error, args []string, out io.Writer, outputExt string, outputIsBinary bool) error {
 	flagSet := flag.NewFlagSet("test-func", flag.ContinueOnError)
 	update := flagSet.Bool("update", false, "updates test outputs")
@@ -134,8 +177,13 @@ func testFunc(f func([]string, io.Writer) error, args []string, out io.Writer, o
 
 		fmt.Fprintf(out, "%v/%v) testing %v\n", i, len(goldenFiles), testFile)
 
+		testArgs, err := testFlags(testFile)
+		if err != nil {
+			return err
+		}
+
 		var actualOutputBuffer bytes.Buffer
-		err = f([]string{testFile}, &actualOutputBuffer)
+		err = f(append(testArgs, testFile), &actualOutputBuffer)
 		if err != nil {
 			return err
 		}
@@ -248,16 +296,124 @@ func parseDecl(args []string, out io.Writer) error {
 	return nil
 }
 
+// searchPathsFlag collects the directories passed using the repeatable -I flag
+type searchPathsFlag []string
+
+func (f *searchPathsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *searchPathsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// discardModeFlag selects how discard statements are emitted using the -discard flag
+type discardModeFlag compiler.DiscardMode
+
+func (f *discardModeFlag) String() string {
+	return compiler.DiscardMode(*f).String()
+}
+
+func (f *discardModeFlag) Set(value string) error {
+	mode, ok := compiler.DiscardModeFromName(value)
+	if !ok {
+		return fmt.Errorf("unknown discard mode '%v', expected kill or demote", value)
+	}
+	*f = discardModeFlag(mode)
+	return nil
+}
+
+// targetEnvFlag selects the environment the SPIR-V module is emitted for using the -target flag
+type targetEnvFlag compiler.TargetEnv
+
+func (f *targetEnvFlag) String() string {
+	return compiler.TargetEnv(*f).String()
+}
+
+func (f *targetEnvFlag) Set(value string) error {
+	target, ok := compiler.TargetEnvFromName(value)
+	if !ok {
+		return fmt.Errorf("unknown target environment '%v', expected spv1.0-spv1.6, vulkan1.0-vulkan1.3, opengl4.5, opencl2.1 or opencl2.2", value)
+	}
+	*f = targetEnvFlag(target)
+	return nil
+}
+
+// diagnosticCodeFlag enables or disables warnings using the repeatable -W and -Wno flags
+type diagnosticCodeFlag struct {
+	options *compiler.DiagnosticOptions
+	enable  bool
+}
+
+func (f diagnosticCodeFlag) String() string {
+	return ""
+}
+
+func (f diagnosticCodeFlag) Set(value string) error {
+	code, ok := compiler.DiagnosticCodeFromName(value)
+	if !ok {
+		return fmt.Errorf("unknown warning code '%v'", value)
+	}
+	if f.options.Enabled == nil {
+		f.options.Enabled = make(map[compiler.DiagnosticCode]bool)
+	}
+	f.options.Enabled[code] = f.enable
+	return nil
+}
+
+// addDiagnosticFlags adds the flags which control the reported warnings to the given flag set
+func addDiagnosticFlags(flagSet *flag.FlagSet, options *compiler.DiagnosticOptions) {
+	flagSet.Var(diagnosticCodeFlag{options: options, enable: true}, "W", "enables the warning with the given code")
+	flagSet.Var(diagnosticCodeFlag{options: options, enable: false}, "Wno", "disables the warning with the given code")
+	flagSet.BoolVar(&options.WarningsAsErrors, "Werror", false, "reports warnings as errors")
+}
+
+// unitFromPath creates a unit from the given file or package directory
+func unitFromPath(path string, searchPaths []string) (*compiler.Unit, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var unit *compiler.Unit
+	if info.IsDir() {
+		unit, err = compiler.UnitFromPackage(path)
+	} else {
+		unit, err = compiler.UnitFromFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range searchPaths {
+		unit.AddSearchPath(dir)
+	}
+	return unit, nil
+}
+
 func check(args []string, out io.Writer) error {
+	flagSet := flag.NewFlagSet("check", flag.ContinueOnError)
+	var searchPaths searchPathsFlag
+	flagSet.Var(&searchPaths, "I", "adds a directory to the import search paths")
+	var diagnostics compiler.DiagnosticOptions
+	addDiagnosticFlags(flagSet, &diagnostics)
+	err := flagSet.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	args = flagSet.Args()
 	if len(args) < 1 {
 		return fmt.Errorf("no file provided\n%v", helpString())
 	}
 
 	file := filepath.ToSlash(filepath.Clean(args[0]))
-	unit, err := compiler.UnitFromFile(file)
+	unit, err := unitFromPath(file, searchPaths)
 	if err != nil {
 		return fmt.Errorf("failed to create unit from file '%s': %v", file, err)
 	}
+	unit.SetDiagnosticOptions(diagnostics)
 
 	if !unit.Scan() {
 		unit.PrintErrors(out)
@@ -269,15 +425,23 @@ func check(args []string, out io.Writer) error {
 		return nil
 	}
 
-	if !unit.Check() {
-		unit.PrintErrors(out)
-	}
+	// warnings are reported even if the check succeeds
+	unit.Check()
+	unit.PrintErrors(out)
 
 	return nil
 }
 
 func emitSPIRV(args []string, out io.Writer, binary bool) error {
 	flagSet := flag.NewFlagSet("emit-spirv", flag.ContinueOnError)
+	var searchPaths searchPathsFlag
+	flagSet.Var(&searchPaths, "I", "adds a directory to the import search paths")
+	var discardMode discardModeFlag
+	flagSet.Var(&discardMode, "discard", "emits discard as OpKill (kill) or OpDemoteToHelperInvocation (demote)")
+	var target targetEnvFlag
+	flagSet.Var(&target, "target", "selects the target environment (spv1.0-spv1.6, vulkan1.0-vulkan1.3, opengl4.5, opencl2.1, opencl2.2)")
+	var diagnostics compiler.DiagnosticOptions
+	addDiagnosticFlags(flagSet, &diagnostics)
 	err := flagSet.Parse(args)
 	if err != nil {
 		return err
@@ -289,10 +453,11 @@ func emitSPIRV(args []string, out io.Writer, binary bool) error {
 	}
 
 	file := filepath.ToSlash(filepath.Clean(args[0]))
-	unit, err := compiler.UnitFromFile(file)
+	unit, err := unitFromPath(file, searchPaths)
 	if err != nil {
 		return fmt.Errorf("failed to create unit from file '%s': %v", file, err)
 	}
+	unit.SetDiagnosticOptions(diagnostics)
 
 	if !unit.Scan() {
 		unit.PrintErrors(out)
@@ -306,9 +471,20 @@ func emitSPIRV(args []string, out io.Writer, binary bool) error {
 
 	if !unit.Check() {
 		unit.PrintErrors(out)
+		return nil
+	}
+	// the output is the module, so warnings go to stderr
+	unit.PrintErrors(os.Stderr)
+
+	module := unit.EmitSPIRV(compiler.EmitOptions{
+		Discard: compiler.DiscardMode(discardMode),
+		Target:  compiler.TargetEnv(target),
+	})
+	if unit.HasErrors() {
+		unit.PrintErrors(out)
+		return nil
 	}
 
-	module := unit.EmitSPIRV()
 	if binary {
 		printer := spirv.NewBinaryPrinter(out, module)
 		printer.Emit()
@@ -328,6 +504,128 @@ func emitSPIRVBin(args []string, out io.Writer) error {
 	return emitSPIRV(args, out, true)
 }
 
+func assembleSPIRV(args []string, out io.Writer) error {
+	flagSet := flag.NewFlagSet("spirv-as", flag.ContinueOnError)
+	text := flagSet.Bool("text", false, "prints the assembled module in text instead of binary")
+	err := flagSet.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	args = flagSet.Args()
+	if len(args) < 1 {
+		return fmt.Errorf("no file provided\n%v", helpString())
+	}
+
+	file := filepath.ToSlash(filepath.Clean(args[0]))
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read file '%s': %v", file, err)
+	}
+
+	module, err := spirv.Assemble(string(source))
+	var asmErr *spirv.AssemblerError
+	if errors.As(err, &asmErr) {
+		fmt.Fprintf(out, "Error[%v:%v:%v]: %v\n", file, asmErr.Line, asmErr.Column, asmErr.Message)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if *text {
+		printer := spirv.NewTextPrinter(out, module)
+		printer.Emit()
+	} else {
+		printer := spirv.NewBinaryPrinter(out, module)
+		printer.Emit()
+	}
+	return nil
+}
+
+func assembleSPIRVText(args []string, out io.Writer) error {
+	return assembleSPIRV(append([]string{"-text"}, args...), out)
+}
+
+func emitGLSL(args []string, out io.Writer) error {
+	flagSet := flag.NewFlagSet("emit-glsl", flag.ContinueOnError)
+	var discardMode discardModeFlag
+	flagSet.Var(&discardMode, "discard", "emits discard as discard (kill) or demote (demote)")
+	entry := flagSet.String("entry", "", "selects the entry point if the program has more than one")
+	return emitSource(flagSet, args, out, func(unit *compiler.Unit) string {
+		return unit.EmitGLSL(compiler.GLSLOptions{Discard: compiler.DiscardMode(discardMode), Entry: *entry})
+	})
+}
+
+func emitHLSL(args []string, out io.Writer) error {
+	return emitSource(flag.NewFlagSet("emit-hlsl", flag.ContinueOnError), args, out, (*compiler.Unit).EmitHLSL)
+}
+
+func emitMSL(args []string, out io.Writer) error {
+	return emitSource(flag.NewFlagSet("emit-msl", flag.ContinueOnError), args, out, (*compiler.Unit).EmitMSL)
+}
+
+func emitWGSL(args []string, out io.Writer) error {
+	return emitSource(flag.NewFlagSet("emit-wgsl", flag.ContinueOnError), args, out, (*compiler.Unit).EmitWGSL)
+}
+
+func emitGo(args []string, out io.Writer) error {
+	flagSet := flag.NewFlagSet("emit-go", flag.ContinueOnError)
+	pkg := flagSet.String("package", "shader", "names the generated package")
+	return emitSource(flagSet, args, out, func(unit *compiler.Unit) string {
+		return unit.EmitGo(compiler.GoOptions{Package: *pkg})
+	})
+}
+
+// emitSource emits the source of a language using the given emit function, which reports the constructs the
+// language can't express as errors of the unit, the flag set can have flags of the language
+func emitSource(flagSet *flag.FlagSet, args []string, out io.Writer, emit func(unit *compiler.Unit) string) error {
+	var searchPaths searchPathsFlag
+	flagSet.Var(&searchPaths, "I", "adds a directory to the import search paths")
+	var diagnostics compiler.DiagnosticOptions
+	addDiagnosticFlags(flagSet, &diagnostics)
+	err := flagSet.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	args = flagSet.Args()
+	if len(args) < 1 {
+		return fmt.Errorf("no file provided\n%v", helpString())
+	}
+
+	file := filepath.ToSlash(filepath.Clean(args[0]))
+	unit, err := unitFromPath(file, searchPaths)
+	if err != nil {
+		return fmt.Errorf("failed to create unit from file '%s': %v", file, err)
+	}
+	unit.SetDiagnosticOptions(diagnostics)
+
+	if !unit.Scan() {
+		unit.PrintErrors(out)
+		return nil
+	}
+
+	if !unit.Parse() {
+		unit.PrintErrors(out)
+		return nil
+	}
+
+	if !unit.Check() {
+		unit.PrintErrors(out)
+		return nil
+	}
+	// the output is the shader, so warnings go to stderr
+	unit.PrintErrors(os.Stderr)
+
+	source := emit(unit)
+	if unit.HasErrors() {
+		unit.PrintErrors(out)
+		return nil
+	}
+	fmt.Fprint(out, source)
+	return nil
+}
+
 func main() {
 	if len(os.Args) < 2 {
 		fmt.Fprintf(os.Stderr, "Error: no command found\n")
@@ -368,6 +666,30 @@ func main() {
 		err = emitSPIRVBin(subArgs, os.Stdout)
 	case "test-spirv-bin":
 		err = testFunc(emitSPIRVBin, subArgs, os.Stdout, ".golden.bin", true)
+	case "spirv-as":
+		err = assembleSPIRV(subArgs, os.Stdout)
+	case "test-spirv-as":
+		err = testFunc(assembleSPIRVText, subArgs, os.Stdout, ".golden", false)
+	case "glsl":
+		err = emitGLSL(subArgs, os.Stdout)
+	case "test-glsl":
+		err = testFunc(emitGLSL, subArgs, os.Stdout, ".golden", false)
+	case "hlsl":
+		err = emitHLSL(subArgs, os.Stdout)
+	case "test-hlsl":
+		err = testFunc(emitHLSL, subArgs, os.Stdout, ".golden", false)
+	case "msl":
+		err = emitMSL(subArgs, os.Stdout)
+	case "test-msl":
+		err = testFunc(emitMSL, subArgs, os.Stdout, ".golden", false)
+	case "wgsl":
+		err = emitWGSL(subArgs, os.Stdout)
+	case "test-wgsl":
+		err = testFunc(emitWGSL, subArgs, os.Stdout, ".golden", false)
+	case "go":
+		err = emitGo(subArgs, os.Stdout)
+	case "test-go":
+		err = testFunc(emitGo, subArgs, os.Stdout, ".golden", false)
 	default:
 		fmt.Fprintf(os.Stderr, "Error: unknown command '%s'\n", os.Args[1])
 		help()
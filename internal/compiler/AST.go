@@ -73,6 +73,23 @@ func (e *IndexExpr) Visit(v NodeVisitor) {
 	v.VisitIndexExpr(e)
 }
 
+// IndexListExpr is a generic function instantiated with more than one type argument, a single type argument is
+// parsed as an IndexExpr
+type IndexListExpr struct {
+	Base     Expr
+	LBracket Token
+	Indices  []Expr
+	RBracket Token
+}
+
+func (e *IndexListExpr) exprNode() {}
+func (e *IndexListExpr) SourceRange() SourceRange {
+	return e.Base.SourceRange().Merge(e.RBracket.SourceRange())
+}
+func (e *IndexListExpr) Visit(v NodeVisitor) {
+	v.VisitIndexListExpr(e)
+}
+
 type CallExpr struct {
 	Base   Expr
 	LParen Token
@@ -177,6 +194,20 @@ func (e *ArrayTypeExpr) Visit(v NodeVisitor) {
 	v.VisitArrayType(e)
 }
 
+type PointerTypeExpr struct {
+	Star     Token
+	BaseType TypeExpr
+}
+
+func (e *PointerTypeExpr) exprNode() {}
+func (e *PointerTypeExpr) typeExpr() {}
+func (e *PointerTypeExpr) SourceRange() SourceRange {
+	return e.Star.SourceRange().Merge(e.BaseType.SourceRange())
+}
+func (e *PointerTypeExpr) Visit(v NodeVisitor) {
+	v.VisitPointerType(e)
+}
+
 type Field struct {
 	Names []*IdentifierExpr
 	Type  TypeExpr
@@ -205,6 +236,7 @@ func (e *StructTypeExpr) Visit(v NodeVisitor) {
 
 type FuncTypeExpr struct {
 	Func       Token
+	TypeParams *FieldList // nil for non generic functions
 	Parameters *FieldList
 	Result     *FieldList
 }
@@ -226,6 +258,20 @@ func (e *FuncTypeExpr) Visit(v NodeVisitor) {
 	v.VisitFuncType(e)
 }
 
+// UnionTypeExpr is a type parameter constraint which is satisfied by any of its terms, e.g. float32 | f32x2
+type UnionTypeExpr struct {
+	Terms []TypeExpr
+}
+
+func (e *UnionTypeExpr) exprNode() {}
+func (e *UnionTypeExpr) typeExpr() {}
+func (e *UnionTypeExpr) SourceRange() SourceRange {
+	return e.Terms[0].SourceRange().Merge(e.Terms[len(e.Terms)-1].SourceRange())
+}
+func (e *UnionTypeExpr) Visit(v NodeVisitor) {
+	v.VisitUnionType(e)
+}
+
 // Stmt Nodes
 type Stmt interface {
 	Node
@@ -311,6 +357,46 @@ func (e *ContinueStmt) Visit(v NodeVisitor) {
 	v.VisitContinueStmt(e)
 }
 
+type LabeledStmt struct {
+	Label Token
+	Colon Token
+	Stmt  Stmt
+}
+
+func (e *LabeledStmt) stmtNode() {}
+func (e *LabeledStmt) SourceRange() SourceRange {
+	return e.Label.SourceRange().Merge(e.Stmt.SourceRange())
+}
+func (e *LabeledStmt) Visit(v NodeVisitor) {
+	v.VisitLabeledStmt(e)
+}
+
+// EmptyStmt is the missing statement of a label at the end of a block, or one followed by a semicolon
+type EmptyStmt struct {
+	// Semicolon is the semicolon ending the statement, or the closing brace of the block if there's none
+	Semicolon Token
+}
+
+func (e *EmptyStmt) stmtNode() {}
+func (e *EmptyStmt) SourceRange() SourceRange {
+	return e.Semicolon.SourceRange()
+}
+func (e *EmptyStmt) Visit(v NodeVisitor) {
+	v.VisitEmptyStmt(e)
+}
+
+type DiscardStmt struct {
+	Discard Token
+}
+
+func (e *DiscardStmt) stmtNode() {}
+func (e *DiscardStmt) SourceRange() SourceRange {
+	return e.Discard.SourceRange()
+}
+func (e *DiscardStmt) Visit(v NodeVisitor) {
+	v.VisitDiscardStmt(e)
+}
+
 type IncDecStmt struct {
 	Expr     Expr
 	Operator Token
@@ -474,6 +560,22 @@ func (e *TypeSpec) Visit(v NodeVisitor) {
 	v.VisitTypeSpec(e)
 }
 
+type ImportSpec struct {
+	Name *IdentifierExpr // local package name or nil
+	Path Token           // import path string literal
+}
+
+func (e *ImportSpec) specNode() {}
+func (e *ImportSpec) SourceRange() SourceRange {
+	if e.Name != nil {
+		return e.Name.SourceRange().Merge(e.Path.SourceRange())
+	}
+	return e.Path.SourceRange()
+}
+func (e *ImportSpec) Visit(v NodeVisitor) {
+	v.VisitImportSpec(e)
+}
+
 type ValueSpec struct {
 	LHS    []*IdentifierExpr
 	Type   TypeExpr
@@ -502,10 +604,12 @@ type Decl interface {
 }
 
 type GenericDecl struct {
-	DeclToken Token // import, const, var, type
-	LParen    Token // if any
-	Specs     []Spec
-	RParen    Token // if any
+	// //sabre: comments written on the lines right above the declaration
+	Directives []Token
+	DeclToken  Token // import, const, var, type
+	LParen     Token // if any
+	Specs      []Spec
+	RParen     Token // if any
 }
 
 func (e *GenericDecl) declNode() {}
@@ -523,10 +627,12 @@ func (e *GenericDecl) Visit(v NodeVisitor) {
 }
 
 type FuncDecl struct {
-	Receiver *FieldList
-	Name     *IdentifierExpr
-	Type     *FuncTypeExpr
-	Body     *BlockStmt
+	// //sabre: comments written on the lines right above the function
+	Directives []Token
+	Receiver   *FieldList
+	Name       *IdentifierExpr
+	Type       *FuncTypeExpr
+	Body       *BlockStmt
 }
 
 func (e *FuncDecl) declNode() {}
@@ -553,6 +659,7 @@ type NodeVisitor interface {
 	VisitParenExpr(n *ParenExpr)
 	VisitSelectorExpr(n *SelectorExpr)
 	VisitIndexExpr(n *IndexExpr)
+	VisitIndexListExpr(n *IndexListExpr)
 	VisitCallExpr(n *CallExpr)
 	VisitUnaryExpr(n *UnaryExpr)
 	VisitBinaryExpr(n *BinaryExpr)
@@ -560,14 +667,19 @@ type NodeVisitor interface {
 
 	VisitNamedType(n *NamedTypeExpr)
 	VisitArrayType(n *ArrayTypeExpr)
+	VisitPointerType(n *PointerTypeExpr)
 	VisitStructType(n *StructTypeExpr)
 	VisitFuncType(n *FuncTypeExpr)
+	VisitUnionType(n *UnionTypeExpr)
 
 	VisitExprStmt(n *ExprStmt)
 	VisitReturnStmt(n *ReturnStmt)
 	VisitBreakStmt(n *BreakStmt)
 	VisitFallthroughStmt(n *FallthroughStmt)
 	VisitContinueStmt(n *ContinueStmt)
+	VisitLabeledStmt(n *LabeledStmt)
+	VisitEmptyStmt(n *EmptyStmt)
+	VisitDiscardStmt(n *DiscardStmt)
 	VisitIncDecStmt(n *IncDecStmt)
 	VisitBlockStmt(n *BlockStmt)
 	VisitAssignStmt(n *AssignStmt)
@@ -578,6 +690,7 @@ type NodeVisitor interface {
 	VisitForRangeStmt(n *ForRangeStmt)
 
 	VisitTypeSpec(n *TypeSpec)
+	VisitImportSpec(n *ImportSpec)
 	VisitValueSpec(n *ValueSpec)
 
 	VisitGenericDecl(n *GenericDecl)
@@ -599,6 +712,12 @@ func (v *DefaultVisitor) VisitIndexExpr(n *IndexExpr) {
 	n.Base.Visit(v)
 	n.Index.Visit(v)
 }
+func (v *DefaultVisitor) VisitIndexListExpr(n *IndexListExpr) {
+	n.Base.Visit(v)
+	for _, index := range n.Indices {
+		index.Visit(v)
+	}
+}
 func (v *DefaultVisitor) VisitCallExpr(n *CallExpr) {
 	n.Base.Visit(v)
 	for _, arg := range n.Args {
@@ -627,6 +746,9 @@ func (v *DefaultVisitor) VisitArrayType(n *ArrayTypeExpr) {
 	n.Length.Visit(v)
 	n.ElementType.Visit(v)
 }
+func (v *DefaultVisitor) VisitPointerType(n *PointerTypeExpr) {
+	n.BaseType.Visit(v)
+}
 func (v *DefaultVisitor) VisitStructType(n *StructTypeExpr) {
 	for _, e := range n.FieldList.Fields {
 		for _, name := range e.Names {
@@ -636,6 +758,15 @@ func (v *DefaultVisitor) VisitStructType(n *StructTypeExpr) {
 	}
 }
 func (v *DefaultVisitor) VisitFuncType(n *FuncTypeExpr) {
+	if n.TypeParams != nil {
+		for _, e := range n.TypeParams.Fields {
+			for _, name := range e.Names {
+				name.Visit(v)
+			}
+			e.Type.Visit(v)
+		}
+	}
+
 	for _, e := range n.Parameters.Fields {
 		for _, name := range e.Names {
 			name.Visit(v)
@@ -650,6 +781,11 @@ func (v *DefaultVisitor) VisitFuncType(n *FuncTypeExpr) {
 		e.Type.Visit(v)
 	}
 }
+func (v *DefaultVisitor) VisitUnionType(n *UnionTypeExpr) {
+	for _, t := range n.Terms {
+		t.Visit(v)
+	}
+}
 
 func (v *DefaultVisitor) VisitExprStmt(n *ExprStmt) {
 	n.Expr.Visit(v)
@@ -662,6 +798,11 @@ func (v *DefaultVisitor) VisitReturnStmt(n *ReturnStmt) {
 func (v *DefaultVisitor) VisitBreakStmt(n *BreakStmt)             {}
 func (v *DefaultVisitor) VisitFallthroughStmt(n *FallthroughStmt) {}
 func (v *DefaultVisitor) VisitContinueStmt(n *ContinueStmt)       {}
+func (v *DefaultVisitor) VisitEmptyStmt(n *EmptyStmt)             {}
+func (v *DefaultVisitor) VisitDiscardStmt(n *DiscardStmt)         {}
+func (v *DefaultVisitor) VisitLabeledStmt(n *LabeledStmt) {
+	n.Stmt.Visit(v)
+}
 func (v *DefaultVisitor) VisitIncDecStmt(n *IncDecStmt) {
 	n.Expr.Visit(v)
 }
@@ -730,6 +871,12 @@ func (v *DefaultVisitor) VisitTypeSpec(n *TypeSpec) {
 	n.Type.Visit(v)
 }
 
+func (v *DefaultVisitor) VisitImportSpec(n *ImportSpec) {
+	if n.Name != nil {
+		n.Name.Visit(v)
+	}
+}
+
 func (v *DefaultVisitor) VisitValueSpec(n *ValueSpec) {
 	for _, e := range n.LHS {
 		e.Visit(v)
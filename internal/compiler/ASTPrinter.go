@@ -130,6 +130,22 @@ func (v *ASTPrinter) VisitIndexExpr(n *IndexExpr) {
 	v.indentor.print(")")
 }
 
+func (v *ASTPrinter) VisitIndexListExpr(n *IndexListExpr) {
+	v.indentor.print("(IndexListExpr")
+	v.indentor.Push()
+	v.indentor.NewLine()
+
+	n.Base.Visit(v)
+	for _, index := range n.Indices {
+		v.indentor.NewLine()
+		index.Visit(v)
+	}
+
+	v.indentor.Pop()
+	v.indentor.NewLine()
+	v.indentor.print(")")
+}
+
 func (v *ASTPrinter) VisitCallExpr(n *CallExpr) {
 	v.indentor.print("(CallExpr")
 	v.indentor.Push()
@@ -228,6 +244,18 @@ func (v *ASTPrinter) VisitArrayType(n *ArrayTypeExpr) {
 	v.indentor.print(")")
 }
 
+func (v *ASTPrinter) VisitPointerType(n *PointerTypeExpr) {
+	v.indentor.print("(PointerType")
+	v.indentor.Push()
+
+	v.indentor.NewLine()
+	n.BaseType.Visit(v)
+
+	v.indentor.Pop()
+	v.indentor.NewLine()
+	v.indentor.print(")")
+}
+
 func (v *ASTPrinter) VisitStructType(n *StructTypeExpr) {
 	v.indentor.printf("(StructType %v", len(n.FieldList.Fields))
 
@@ -270,6 +298,12 @@ func (v *ASTPrinter) VisitFuncType(n *FuncTypeExpr) {
 	v.indentor.print("(FuncType")
 	v.indentor.Push()
 
+	typeParamsExist := n.TypeParams != nil && len(n.TypeParams.Fields) > 0
+	if typeParamsExist {
+		v.indentor.NewLine()
+		v.visitPhonyFieldListNode(n.TypeParams, "FuncType-TypeParams")
+	}
+
 	parametersExist := n.Parameters != nil && len(n.Parameters.Fields) > 0
 	if parametersExist {
 		v.indentor.NewLine()
@@ -283,12 +317,26 @@ func (v *ASTPrinter) VisitFuncType(n *FuncTypeExpr) {
 	}
 
 	v.indentor.Pop()
-	if parametersExist || resultExist {
+	if typeParamsExist || parametersExist || resultExist {
 		v.indentor.NewLine()
 	}
 	v.indentor.print(")")
 }
 
+func (v *ASTPrinter) VisitUnionType(n *UnionTypeExpr) {
+	v.indentor.print("(UnionType")
+	v.indentor.Push()
+
+	for _, t := range n.Terms {
+		v.indentor.NewLine()
+		t.Visit(v)
+	}
+
+	v.indentor.Pop()
+	v.indentor.NewLine()
+	v.indentor.print(")")
+}
+
 func (v *ASTPrinter) VisitExprStmt(n *ExprStmt) {
 	v.indentor.print("(ExprStmt")
 	v.indentor.Push()
@@ -325,6 +373,14 @@ func (v *ASTPrinter) VisitBreakStmt(n *BreakStmt) {
 	}
 }
 
+func (v *ASTPrinter) VisitEmptyStmt(n *EmptyStmt) {
+	v.indentor.print("(EmptyStmt)")
+}
+
+func (v *ASTPrinter) VisitDiscardStmt(n *DiscardStmt) {
+	v.indentor.print("(DiscardStmt)")
+}
+
 func (v *ASTPrinter) VisitFallthroughStmt(n *FallthroughStmt) {
 	v.indentor.print("(FallthroughStmt)")
 }
@@ -337,6 +393,18 @@ func (v *ASTPrinter) VisitContinueStmt(n *ContinueStmt) {
 	}
 }
 
+func (v *ASTPrinter) VisitLabeledStmt(n *LabeledStmt) {
+	v.indentor.printf("(LabeledStmt %v", n.Label)
+	v.indentor.Push()
+	v.indentor.NewLine()
+
+	n.Stmt.Visit(v)
+
+	v.indentor.Pop()
+	v.indentor.NewLine()
+	v.indentor.print(")")
+}
+
 func (v *ASTPrinter) VisitIncDecStmt(n *IncDecStmt) {
 	v.indentor.printf("(IncDecStmt %v", n.Operator.Value())
 	v.indentor.Push()
@@ -522,6 +590,23 @@ func (v *ASTPrinter) VisitTypeSpec(n *TypeSpec) {
 	v.indentor.print(")")
 }
 
+func (v *ASTPrinter) VisitImportSpec(n *ImportSpec) {
+	v.indentor.print("(ImportSpec")
+	v.indentor.Push()
+
+	if n.Name != nil {
+		v.indentor.NewLine()
+		n.Name.Visit(v)
+	}
+
+	v.indentor.NewLine()
+	v.indentor.print(n.Path)
+
+	v.indentor.Pop()
+	v.indentor.NewLine()
+	v.indentor.print(")")
+}
+
 func (v *ASTPrinter) VisitValueSpec(n *ValueSpec) {
 	v.indentor.print("(ValueSpec")
 	v.indentor.Push()
@@ -555,6 +640,11 @@ func (v *ASTPrinter) VisitGenericDecl(n *GenericDecl) {
 	v.indentor.printf("(GenericDecl %v", n.DeclToken.Value())
 	v.indentor.Push()
 
+	for _, d := range n.Directives {
+		v.indentor.NewLine()
+		v.indentor.printf("(Directive %v)", d.Value())
+	}
+
 	for _, s := range n.Specs {
 		v.indentor.NewLine()
 		s.Visit(v)
@@ -573,6 +663,11 @@ func (v *ASTPrinter) VisitFuncDecl(n *FuncDecl) {
 	}
 	v.indentor.Push()
 
+	for _, d := range n.Directives {
+		v.indentor.NewLine()
+		v.indentor.printf("(Directive %v)", d.Value())
+	}
+
 	if n.Receiver != nil {
 		v.indentor.NewLine()
 		v.visitPhonyFieldListNode(n.Receiver, "MethodDecl-Receiver")
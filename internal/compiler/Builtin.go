@@ -0,0 +1,393 @@
+package compiler
+
+import "slices"
+
+// BuiltinFunc is a function provided by the compiler, builtins are called by their names and declarations with the
+// same names shadow them
+type BuiltinFunc int
+
+const (
+	BuiltinFuncNone BuiltinFunc = iota
+	// BuiltinFuncDpdx is the partial derivative of its argument along the x axis of the framebuffer
+	BuiltinFuncDpdx
+	// BuiltinFuncDpdy is the partial derivative of its argument along the y axis of the framebuffer
+	BuiltinFuncDpdy
+	// BuiltinFuncFwidth is the sum of the absolute partial derivatives of its argument
+	BuiltinFuncFwidth
+	// BuiltinFuncWorkgroupBarrier waits for all the invocations of the workgroup to reach it
+	BuiltinFuncWorkgroupBarrier
+	// BuiltinFuncLocalInvocationIndex returns the index of the invocation inside its workgroup
+	BuiltinFuncLocalInvocationIndex
+	// BuiltinFuncFrontFacing returns whether the fragment belongs to a front facing primitive
+	BuiltinFuncFrontFacing
+	// BuiltinFuncTextureSample samples the texture at the given coordinates, the mip level is chosen from the
+	// derivatives of the coordinates
+	BuiltinFuncTextureSample
+	// BuiltinFuncTextureSampleLevel samples the given mip level of the texture at the given coordinates
+	BuiltinFuncTextureSampleLevel
+	// BuiltinFuncImageLoad reads the texel of the image at the given integer coordinates
+	BuiltinFuncImageLoad
+	// BuiltinFuncImageStore writes the texel of the image at the given integer coordinates
+	BuiltinFuncImageStore
+
+	// the math builtins take float32 or vectors of float32 and work on each component, they're lowered to the math
+	// functions of the target instead of being implemented in sabre
+
+	// BuiltinFuncAbs returns the absolute value of its argument
+	BuiltinFuncAbs
+	// BuiltinFuncFloor rounds its argument towards negative infinity
+	BuiltinFuncFloor
+	// BuiltinFuncCeil rounds its argument towards positive infinity
+	BuiltinFuncCeil
+	// BuiltinFuncFract returns its argument minus its floor
+	BuiltinFuncFract
+	// BuiltinFuncSqrt returns the square root of its argument
+	BuiltinFuncSqrt
+	// BuiltinFuncExp returns e raised to the power of its argument
+	BuiltinFuncExp
+	// BuiltinFuncLog returns the natural logarithm of its argument
+	BuiltinFuncLog
+	// BuiltinFuncPow returns its first argument raised to the power of the second
+	BuiltinFuncPow
+	// BuiltinFuncSin returns the sine of its radian argument
+	BuiltinFuncSin
+	// BuiltinFuncCos returns the cosine of its radian argument
+	BuiltinFuncCos
+	// BuiltinFuncTan returns the tangent of its radian argument
+	BuiltinFuncTan
+	// BuiltinFuncMin returns the smaller of its arguments
+	BuiltinFuncMin
+	// BuiltinFuncMax returns the larger of its arguments
+	BuiltinFuncMax
+	// BuiltinFuncClamp limits its first argument to the range of the other two
+	BuiltinFuncClamp
+	// BuiltinFuncMix linearly interpolates between its first two arguments using the third
+	BuiltinFuncMix
+)
+
+var builtinFuncNames = map[string]BuiltinFunc{
+	"dpdx":                 BuiltinFuncDpdx,
+	"dpdy":                 BuiltinFuncDpdy,
+	"fwidth":               BuiltinFuncFwidth,
+	"workgroupBarrier":     BuiltinFuncWorkgroupBarrier,
+	"localInvocationIndex": BuiltinFuncLocalInvocationIndex,
+	"frontFacing":          BuiltinFuncFrontFacing,
+	"textureSample":        BuiltinFuncTextureSample,
+	"textureSampleLevel":   BuiltinFuncTextureSampleLevel,
+	"imageLoad":            BuiltinFuncImageLoad,
+	"imageStore":           BuiltinFuncImageStore,
+	"abs":                  BuiltinFuncAbs,
+	"floor":                BuiltinFuncFloor,
+	"ceil":                 BuiltinFuncCeil,
+	"fract":                BuiltinFuncFract,
+	"sqrt":                 BuiltinFuncSqrt,
+	"exp":                  BuiltinFuncExp,
+	"log":                  BuiltinFuncLog,
+	"pow":                  BuiltinFuncPow,
+	"sin":                  BuiltinFuncSin,
+	"cos":                  BuiltinFuncCos,
+	"tan":                  BuiltinFuncTan,
+	"min":                  BuiltinFuncMin,
+	"max":                  BuiltinFuncMax,
+	"clamp":                BuiltinFuncClamp,
+	"mix":                  BuiltinFuncMix,
+}
+
+func (b BuiltinFunc) String() string {
+	switch b {
+	case BuiltinFuncNone:
+		return "none"
+	case BuiltinFuncDpdx:
+		return "dpdx"
+	case BuiltinFuncDpdy:
+		return "dpdy"
+	case BuiltinFuncFwidth:
+		return "fwidth"
+	case BuiltinFuncWorkgroupBarrier:
+		return "workgroupBarrier"
+	case BuiltinFuncLocalInvocationIndex:
+		return "localInvocationIndex"
+	case BuiltinFuncFrontFacing:
+		return "frontFacing"
+	case BuiltinFuncTextureSample:
+		return "textureSample"
+	case BuiltinFuncTextureSampleLevel:
+		return "textureSampleLevel"
+	case BuiltinFuncImageLoad:
+		return "imageLoad"
+	case BuiltinFuncImageStore:
+		return "imageStore"
+	case BuiltinFuncAbs:
+		return "abs"
+	case BuiltinFuncFloor:
+		return "floor"
+	case BuiltinFuncCeil:
+		return "ceil"
+	case BuiltinFuncFract:
+		return "fract"
+	case BuiltinFuncSqrt:
+		return "sqrt"
+	case BuiltinFuncExp:
+		return "exp"
+	case BuiltinFuncLog:
+		return "log"
+	case BuiltinFuncPow:
+		return "pow"
+	case BuiltinFuncSin:
+		return "sin"
+	case BuiltinFuncCos:
+		return "cos"
+	case BuiltinFuncTan:
+		return "tan"
+	case BuiltinFuncMin:
+		return "min"
+	case BuiltinFuncMax:
+		return "max"
+	case BuiltinFuncClamp:
+		return "clamp"
+	case BuiltinFuncMix:
+		return "mix"
+	default:
+		panic("unknown builtin function")
+	}
+}
+
+// Stage returns the shader stage the builtin is available in, ShaderStageNone if it's available in all of them
+func (b BuiltinFunc) Stage() ShaderStage {
+	switch b {
+	case BuiltinFuncDpdx, BuiltinFuncDpdy, BuiltinFuncFwidth, BuiltinFuncFrontFacing, BuiltinFuncTextureSample:
+		return ShaderStageFragment
+	case BuiltinFuncWorkgroupBarrier, BuiltinFuncLocalInvocationIndex:
+		return ShaderStageCompute
+	default:
+		if b.IsMath() || b == BuiltinFuncTextureSampleLevel || b == BuiltinFuncImageLoad || b == BuiltinFuncImageStore {
+			return ShaderStageNone
+		}
+		panic("unknown builtin function")
+	}
+}
+
+// IsMath returns whether the builtin is one of the math functions
+func (b BuiltinFunc) IsMath() bool {
+	return b >= BuiltinFuncAbs && b <= BuiltinFuncMix
+}
+
+// IsDerivative returns whether the builtin is one of the partial derivatives, they work on each component of vectors
+// like the math builtins
+func (b BuiltinFunc) IsDerivative() bool {
+	return b == BuiltinFuncDpdx || b == BuiltinFuncDpdy || b == BuiltinFuncFwidth
+}
+
+// arity returns the number of arguments the math builtin takes
+func (b BuiltinFunc) arity() int {
+	switch b {
+	case BuiltinFuncPow, BuiltinFuncMin, BuiltinFuncMax:
+		return 2
+	case BuiltinFuncClamp, BuiltinFuncMix:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// firstScalarParam returns the index of the first parameter which may be a float32 when the builtin works on vectors,
+// like GLSL the bounds of min, max and clamp and the weight of mix can be scalars
+func (b BuiltinFunc) firstScalarParam() int {
+	switch b {
+	case BuiltinFuncMin, BuiltinFuncMax, BuiltinFuncClamp:
+		return 1
+	case BuiltinFuncMix:
+		return 2
+	default:
+		return b.arity()
+	}
+}
+
+// IsInput returns whether the builtin reads an input of the entry point, inputs have a different value for each
+// invocation and they can only be read in the body of the entry point
+func (b BuiltinFunc) IsInput() bool {
+	return b == BuiltinFuncLocalInvocationIndex || b == BuiltinFuncFrontFacing
+}
+
+// RequiresUniformity returns whether all the invocations of the quad or the workgroup must call the builtin together,
+// derivatives and the implicit mip level of samples are computed from the neighbouring invocations and barriers wait
+// for the whole workgroup
+func (b BuiltinFunc) RequiresUniformity() bool {
+	switch b {
+	case BuiltinFuncDpdx, BuiltinFuncDpdy, BuiltinFuncFwidth, BuiltinFuncWorkgroupBarrier, BuiltinFuncTextureSample:
+		return true
+	default:
+		return false
+	}
+}
+
+// funcType returns the type of the builtin, math builtins and derivatives take the type of their first argument, see
+// mathParams
+func (b BuiltinFunc) funcType(interner *TypeInterner, args []*TypeAndValue) *FuncType {
+	var params, results []Type
+	if b.IsMath() || b.IsDerivative() {
+		params = b.mathParams(args)
+		return interner.InternFuncType(params, params[:1]).(*FuncType)
+	}
+	switch b {
+	case BuiltinFuncTextureSample:
+		params = []Type{BuiltinTexture2DType, BuiltinF32x2Type}
+		results = []Type{BuiltinF32x4Type}
+	case BuiltinFuncTextureSampleLevel:
+		params = []Type{BuiltinTexture2DType, BuiltinF32x2Type, BuiltinFloat32Type}
+		results = []Type{BuiltinF32x4Type}
+	case BuiltinFuncImageLoad:
+		params = []Type{BuiltinImage2DType, BuiltinI32x2Type}
+		results = []Type{BuiltinF32x4Type}
+	case BuiltinFuncImageStore:
+		params = []Type{BuiltinImage2DType, BuiltinI32x2Type, BuiltinF32x4Type}
+	case BuiltinFuncWorkgroupBarrier:
+	case BuiltinFuncLocalInvocationIndex:
+		results = []Type{BuiltinUintType}
+	case BuiltinFuncFrontFacing:
+		results = []Type{BuiltinBoolType}
+	default:
+		panic("unknown builtin function")
+	}
+	return interner.InternFuncType(params, results).(*FuncType)
+}
+
+// mathParams returns the parameter types of the math builtin or the derivative called with the given arguments,
+// they're float32 unless the first argument is a vector of float32, then the parameters which may be scalars are
+// float32 if their argument is
+func (b BuiltinFunc) mathParams(args []*TypeAndValue) []Type {
+	var genType Type = BuiltinFloat32Type
+	if len(args) > 0 {
+		if vectorType, ok := args[0].Type.Resolve(true).(*VectorType); ok && vectorElementType(vectorType) == BuiltinFloat32Type {
+			genType = args[0].Type
+		}
+	}
+	params := make([]Type, b.arity())
+	for i := range params {
+		params[i] = genType
+		if i >= b.firstScalarParam() && i < len(args) && (isUntyped(args[i].Type) || args[i].Type.Equal(BuiltinFloat32Type)) {
+			params[i] = BuiltinFloat32Type
+		}
+	}
+	return params
+}
+
+// builtinOf returns the builtin function named by the call base, BuiltinFuncNone if it doesn't name a builtin or the
+// name is declared
+func (checker *Checker) builtinOf(base Expr) BuiltinFunc {
+	ident, ok := base.(*IdentifierExpr)
+	if !ok || checker.currentScope().Find(ident.Token.Value()) != nil {
+		return BuiltinFuncNone
+	}
+	return builtinFuncNames[ident.Token.Value()]
+}
+
+func (checker *Checker) resolveBuiltinCall(e *CallExpr, builtin BuiltinFunc) *TypeAndValue {
+	info := checker.unit.semanticInfo
+	arguments, sourceRanges := checker.resolveAndUnpackTypesFromExprList(e.Args)
+	funcType := builtin.funcType(info.TypeInterner, arguments)
+	info.SetTypeOf(e.Base, &TypeAndValue{Mode: AddressModeType, Type: funcType})
+	info.Builtins[e] = builtin
+
+	res := &TypeAndValue{
+		Mode:  AddressModeInvalid,
+		Type:  BuiltinVoidType,
+		Value: nil,
+	}
+
+	if len(arguments) != len(funcType.ParameterTypes) {
+		argumentTypes := make([]Type, len(arguments))
+		for i, a := range arguments {
+			argumentTypes[i] = a.Type
+		}
+		checker.error(NewError(e.SourceRange(), "expected %v arguments, but found %v", len(funcType.ParameterTypes), len(e.Args)).
+			Note(e.SourceRange(), "have %v, want %v", TupleType{Types: argumentTypes}, TupleType{Types: funcType.ParameterTypes}),
+		)
+		return res
+	}
+	for i := range arguments {
+		parameterType := funcType.ParameterTypes[i]
+		if a := checker.convertUntypedAt(e.Args, arguments, i, parameterType); !a.Type.Equal(parameterType) {
+			checker.error(NewError(sourceRanges[i], "incorrect argument type '%v', expected '%v'", a.Type, parameterType))
+			return res
+		}
+	}
+
+	// the type of the call is known even if it's misplaced, so errors are reported without invalidating it
+	res.Mode = AddressModeComputedValue
+	if len(funcType.ReturnTypes) == 1 {
+		res.Type = funcType.ReturnTypes[0]
+	}
+
+	function := checker.currentFunction()
+	if function == nil {
+		checker.error(NewError(e.SourceRange(), "builtin '%v' can only be called inside functions", builtin))
+		return res
+	}
+	if builtin.IsInput() {
+		if function.Stage != builtin.Stage() {
+			checker.error(NewError(e.SourceRange(), "builtin '%v' can only be called in the body of %v entry points", builtin, builtin.Stage()))
+			return res
+		}
+		if !slices.Contains(info.Inputs[function], builtin) {
+			info.Inputs[function] = append(info.Inputs[function], builtin)
+		}
+	}
+	if builtin == BuiltinFuncWorkgroupBarrier || builtin == BuiltinFuncImageStore {
+		checker.sideEffects[function] = true
+	}
+	if builtin.Stage() == ShaderStageNone {
+		return res
+	}
+	if _, ok := checker.builtinCalls[function]; !ok {
+		checker.builtinFuncs = append(checker.builtinFuncs, function)
+	}
+	checker.builtinCalls[function] = append(checker.builtinCalls[function], e)
+	return res
+}
+
+// checkBuiltinStages makes sure that builtins are only reachable from entry points of the stage they're available
+// in, like discard statements this can only be done once the whole call graph is known
+func (checker *Checker) checkBuiltinStages() {
+	info := checker.unit.semanticInfo
+	reported := make(map[*CallExpr]bool)
+	reachableFrom := make(map[ShaderStage]map[*FuncSymbol]bool)
+	for _, entry := range info.EntryPoints {
+		order, reachedBy := info.reachableFrom(entry)
+		for _, function := range order {
+			if reachableFrom[entry.Stage] == nil {
+				reachableFrom[entry.Stage] = make(map[*FuncSymbol]bool)
+			}
+			reachableFrom[entry.Stage][function] = true
+
+			for _, call := range checker.builtinCalls[function] {
+				builtin := info.BuiltinOf(call)
+				if builtin.Stage() == entry.Stage || reported[call] {
+					continue
+				}
+				reported[call] = true
+
+				err := NewError(call.SourceRange(), "builtin '%v' is only available in %v shaders", builtin, builtin.Stage()).
+					Note(entry.SymDecl.(*FuncDecl).Name.SourceRange(), "reachable from %v entry point '%v'", entry.Stage, entry.Name())
+				var path []*Call
+				for c := reachedBy[function]; c != nil; c = reachedBy[c.Caller] {
+					path = append(path, c)
+				}
+				for i := len(path) - 1; i >= 0; i-- {
+					err = err.Note(path[i].Expr.SourceRange(), "'%v' is called here", path[i].Callee.Name())
+				}
+				checker.error(err)
+			}
+		}
+	}
+
+	for _, function := range checker.builtinFuncs {
+		for _, call := range checker.builtinCalls[function] {
+			builtin := info.BuiltinOf(call)
+			if reported[call] || reachableFrom[builtin.Stage()][function] {
+				continue
+			}
+			checker.error(NewError(call.SourceRange(), "builtin '%v' is only available in code reachable from %v entry points", builtin, builtin.Stage()))
+		}
+	}
+}
@@ -3,7 +3,13 @@ package compiler
 import (
 	"go/constant"
 	"go/token"
+	"maps"
+	"math"
+	"slices"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type SemanticInfo struct {
@@ -12,6 +18,34 @@ type SemanticInfo struct {
 	SymbolByIdentifier map[*IdentifierExpr]Symbol
 	TypeInterner       *TypeInterner
 	ReachableSymbols   []Symbol
+	Instances          map[Expr]*Instance
+	EntryPoints        []*FuncSymbol
+	Calls              map[*FuncSymbol][]*Call
+	// Uses counts the references reading each symbol, declarations and assignment targets aren't uses
+	Uses map[Symbol]int
+	// Selections maps the selectors of fields and methods to what they select
+	Selections map[*SelectorExpr]*Selection
+	// Builtins maps the calls of builtin functions to the builtins they call
+	Builtins map[*CallExpr]BuiltinFunc
+	// Inputs lists the builtin inputs read by each entry point in the order they're first read
+	Inputs map[*FuncSymbol][]BuiltinFunc
+	// Workgroups lists the workgroup variables used by each function in the order they're first used
+	Workgroups map[*FuncSymbol][]*VarSymbol
+}
+
+// Instance is an instantiation of a generic function at a call site or by a function value
+type Instance struct {
+	TypeArgs []Type
+}
+
+// Call is a call site of a function inside the body of another function
+type Call struct {
+	Caller *FuncSymbol
+	Callee *FuncSymbol
+	Expr   *CallExpr
+	// Value is the function value bound to a parameter of the caller which calls it through the parameter, the
+	// expression is the call passing the value. it's nil for direct calls
+	Value Expr
 }
 
 func NewSemanticInfo() *SemanticInfo {
@@ -21,9 +55,27 @@ func NewSemanticInfo() *SemanticInfo {
 		SymbolByIdentifier: make(map[*IdentifierExpr]Symbol),
 		TypeInterner:       NewTypeInterner(),
 		ReachableSymbols:   make([]Symbol, 0),
+		Instances:          make(map[Expr]*Instance),
+		Calls:              make(map[*FuncSymbol][]*Call),
+		Uses:               make(map[Symbol]int),
+		Selections:         make(map[*SelectorExpr]*Selection),
+		Builtins:           make(map[*CallExpr]BuiltinFunc),
+		Inputs:             make(map[*FuncSymbol][]BuiltinFunc),
+		Workgroups:         make(map[*FuncSymbol][]*VarSymbol),
 	}
 }
 
+// SelectionOf returns the field or method selected by the selector, nil for package qualified identifiers and
+// vector swizzles
+func (info *SemanticInfo) SelectionOf(e *SelectorExpr) *Selection {
+	return info.Selections[e]
+}
+
+// BuiltinOf returns the builtin function called by the call, BuiltinFuncNone if it doesn't call a builtin
+func (info *SemanticInfo) BuiltinOf(e *CallExpr) BuiltinFunc {
+	return info.Builtins[e]
+}
+
 func (info *SemanticInfo) SetTypeOf(e any, t *TypeAndValue) {
 	info.Types[e] = t
 }
@@ -51,6 +103,44 @@ func (info *SemanticInfo) createScopeFor(n any, parent *Scope, name string) *Sco
 	return scope
 }
 
+func (info *SemanticInfo) SetInstanceOf(e Expr, instance *Instance) {
+	info.Instances[e] = instance
+}
+
+// InstanceOf returns the instance of the generic function called by the call or referred to by the function value
+// given its type arguments, nil if it's not generic
+func (info *SemanticInfo) InstanceOf(e Expr) *Instance {
+	if instance, ok := info.Instances[e]; ok {
+		return instance
+	}
+	return nil
+}
+
+func (info *SemanticInfo) addCall(call *Call) {
+	info.Calls[call.Caller] = append(info.Calls[call.Caller], call)
+}
+
+// CallsOf returns the calls inside the body of the given function in the order they were checked
+func (info *SemanticInfo) CallsOf(caller *FuncSymbol) []*Call {
+	return info.Calls[caller]
+}
+
+// reachableFrom returns the functions reachable from the given function in breadth first order along with the
+// call which first reached each one of them
+func (info *SemanticInfo) reachableFrom(root *FuncSymbol) (order []*FuncSymbol, reachedBy map[*FuncSymbol]*Call) {
+	reachedBy = map[*FuncSymbol]*Call{root: nil}
+	order = append(order, root)
+	for i := 0; i < len(order); i++ {
+		for _, call := range info.CallsOf(order[i]) {
+			if _, ok := reachedBy[call.Callee]; !ok {
+				reachedBy[call.Callee] = call
+				order = append(order, call.Callee)
+			}
+		}
+	}
+	return
+}
+
 func (info *SemanticInfo) SetSymbolOfIdentifier(e *IdentifierExpr, s Symbol) {
 	info.SymbolByIdentifier[e] = s
 }
@@ -62,6 +152,18 @@ func (info *SemanticInfo) SymbolOfIdentifier(e *IdentifierExpr) Symbol {
 	return nil
 }
 
+func (info *SemanticInfo) addUse(s Symbol) {
+	info.Uses[s]++
+}
+
+func (info *SemanticInfo) removeUse(s Symbol) {
+	info.Uses[s]--
+}
+
+func (info *SemanticInfo) IsUsed(s Symbol) bool {
+	return info.Uses[s] > 0
+}
+
 type ResolveStmtProperties struct {
 	acceptsBreak       bool
 	acceptsContinue    bool
@@ -109,6 +211,8 @@ type TypeAndValue struct {
 	Mode  AddressMode
 	Type  Type
 	Value constant.Value
+	// Elements are the values of constant arrays, go/constant only represents scalars
+	Elements []*TypeAndValue
 }
 
 func (v TypeAndValue) IsVoid() bool {
@@ -184,7 +288,12 @@ func (a *TypeAndValue) UnaryOp(op TokenKind) (res *TypeAndValue) {
 		Type: a.Type,
 	}
 	if res.Mode == AddressModeConstant {
-		res.Value = constant.UnaryOp(convertTokenToConstantToken(op), a.Value, 0)
+		// complementing unsigned values needs their precision to stay positive
+		var precision uint
+		if props := a.Type.Properties(); props.Integral && !props.Signed && !isUntyped(a.Type) {
+			precision = uint(props.Size * 8)
+		}
+		res.Value = constant.UnaryOp(convertTokenToConstantToken(op), a.Value, precision)
 	} else {
 		res.Mode = AddressModeComputedValue
 	}
@@ -197,7 +306,12 @@ func (a *TypeAndValue) BinaryOpWithType(op TokenKind, b *TypeAndValue, t Type) (
 		Type: t,
 	}
 	if res.Mode == AddressModeConstant {
-		res.Value = constant.BinaryOp(a.Value, convertTokenToConstantToken(op), b.Value)
+		constantOp := convertTokenToConstantToken(op)
+		// go/constant does exact division unless asked for integer division
+		if constantOp == token.QUO && t.Properties().Integral {
+			constantOp = token.QUO_ASSIGN
+		}
+		res.Value = constant.BinaryOp(a.Value, constantOp, b.Value)
 	}
 	return
 }
@@ -232,7 +346,78 @@ type Checker struct {
 	DefaultVisitor
 	unit          *Unit
 	scopeStack    []*Scope
-	functionStack []*FuncDecl
+	functionStack []*FuncSymbol
+	methods       []*FuncSymbol
+	// value of iota in the constant spec being resolved, nil outside of constant declarations
+	iota constant.Value
+	// discard statements grouped by the functions containing them, in the order they were checked
+	discardFuncs []*FuncSymbol
+	discards     map[*FuncSymbol][]*DiscardStmt
+	// calls of builtin functions grouped by the functions containing them, in the order they were checked
+	builtinFuncs []*FuncSymbol
+	builtinCalls map[*FuncSymbol][]*CallExpr
+	// first use of each workgroup variable grouped by the functions containing them, in the order they were checked
+	workgroupFuncs []*FuncSymbol
+	workgroupUses  map[*FuncSymbol][]Expr
+	// names of the function local variables in the order they were declared
+	localVars []*IdentifierExpr
+	// functions which assign to package level variables or through pointers
+	sideEffects map[*FuncSymbol]bool
+	// first assignment to each package level variable assigned by a function
+	globalWrites map[*VarSymbol]Expr
+	// calls whose results are dropped by expression statements
+	droppedCalls []*CallExpr
+	// labels of the function being checked
+	labels *labelScope
+	// function values passed to parameters of function type, in the order they were checked
+	funcArgs []*funcArg
+	// calls binding functions to the parameters of function type of each function, the callers are the functions
+	// taking the parameters
+	boundFuncs map[*FuncSymbol][]*Call
+	// type arguments given explicitly to generic functions, keyed by the index expression listing them
+	typeArgLists map[Expr]*typeArgList
+	// types of the expressions in constant specs which use iota by their iota, specs repeating the expressions of a
+	// previous spec resolve them again with their own iota. the other expressions are only resolved once, so their
+	// errors aren't repeated
+	iotaTypes map[iotaExpr]*TypeAndValue
+	// expressions which use iota, and whether the expression being resolved used it
+	iotaExprs map[Expr]bool
+	usesIota  bool
+	// type expression of the parameter being resolved, textures are only allowed there
+	paramTypeExpr TypeExpr
+}
+
+// iotaExpr is an expression of a constant spec resolved with the given iota
+type iotaExpr struct {
+	expr Expr
+	iota int64
+}
+
+// typeArgList is the list of type arguments given explicitly to a generic function, the type parameters after them
+// are inferred from the call arguments
+type typeArgList struct {
+	types []Type
+	exprs []Expr
+}
+
+// funcArg is a function value passed to a parameter of function type, the callee is nil if it's called through a
+// parameter of the caller
+type funcArg struct {
+	caller, callee *FuncSymbol
+	value          Expr
+	expr           *CallExpr
+}
+
+// labelScope tracks the labeled statements of a function, labels are visible in the whole function body
+type labelScope struct {
+	labels map[string]*LabeledStmt
+	used   map[*LabeledStmt]bool
+	// labeled statements in the order they were declared
+	declared []*LabeledStmt
+	// labeled statements enclosing the statement being checked, innermost last
+	enclosing []*LabeledStmt
+	// branch statements whose labels don't refer to an enclosing statement, reported once all the labels are known
+	unresolved []Stmt
 }
 
 func NewChecker(u *Unit) *Checker {
@@ -256,11 +441,14 @@ func (checker *Checker) leaveScope() {
 	checker.scopeStack = checker.scopeStack[:len(checker.scopeStack)-1]
 }
 
-func (checker *Checker) currentFunction() *FuncDecl {
+func (checker *Checker) currentFunction() *FuncSymbol {
+	if len(checker.functionStack) == 0 {
+		return nil
+	}
 	return checker.functionStack[len(checker.functionStack)-1]
 }
 
-func (checker *Checker) enterFunction(function *FuncDecl) {
+func (checker *Checker) enterFunction(function *FuncSymbol) {
 	if function == nil {
 		panic("entering nil function")
 	}
@@ -273,22 +461,139 @@ func (checker *Checker) leaveFunction() {
 
 func (checker *Checker) Check() bool {
 	checker.unit.semanticInfo = NewSemanticInfo()
-	globalScope := checker.unit.semanticInfo.createScopeFor(checker.unit.rootFile, nil, "global")
+	checker.discards = make(map[*FuncSymbol][]*DiscardStmt)
+	checker.builtinCalls = make(map[*FuncSymbol][]*CallExpr)
+	checker.workgroupUses = make(map[*FuncSymbol][]Expr)
+	checker.sideEffects = make(map[*FuncSymbol]bool)
+	checker.globalWrites = make(map[*VarSymbol]Expr)
+	checker.typeArgLists = make(map[Expr]*typeArgList)
+	checker.iotaTypes = make(map[iotaExpr]*TypeAndValue)
+	checker.iotaExprs = make(map[Expr]bool)
+
+	// dependencies are checked before the packages that import them
+	for _, pkg := range checker.unit.sortedPackages {
+		checker.checkPackage(pkg)
+	}
+
+	checker.bindFuncArgs()
+	checker.checkRecursion()
+	checker.checkDiscards()
+	checker.checkBuiltinStages()
+	checker.checkWorkgroupStages()
+	checker.checkUniformity()
+	checker.checkUnused()
+	checker.checkDroppedResults()
+
+	return !checker.unit.HasErrors()
+}
 
-	checker.enterScope(globalScope)
+func (checker *Checker) checkPackage(pkg *Package) {
+	packageScope := checker.unit.semanticInfo.createScopeFor(pkg, nil, pkg.Name)
+
+	checker.enterScope(packageScope)
 	defer checker.leaveScope()
 
-	checker.shallowWalk()
+	checker.methods = nil
+	for _, file := range pkg.Files {
+		fileScope := checker.unit.semanticInfo.createScopeFor(file, packageScope, file.path)
+		checker.declareImports(file, fileScope)
+
+		firstSymbol := len(packageScope.Symbols)
+		checker.shallowWalk(file)
 
-	for _, sym := range globalScope.Symbols {
+		// package level symbols live in the package scope but are resolved in the scope of the file
+		// they were declared in so that they can see the file imports
+		for _, sym := range packageScope.Symbols[firstSymbol:] {
+			sym.SetScope(fileScope)
+		}
+	}
+	checker.checkImportsClashWithDecls(pkg)
+	checker.attachMethods()
+
+	for _, sym := range packageScope.Symbols {
 		checker.resolveSymbol(sym)
 	}
 
-	return !checker.unit.HasErrors()
+	for _, sym := range checker.methods {
+		if sym.IsMethod() {
+			checker.resolveSymbol(sym)
+		}
+	}
+}
+
+func (checker *Checker) declareImports(file *UnitFile, fileScope *Scope) {
+	checker.enterScope(fileScope)
+	defer checker.leaveScope()
+
+	for _, spec := range file.imports {
+		pkg := checker.unit.packageOfImport[spec]
+		if pkg == nil {
+			continue
+		}
+
+		name := Token{kind: TokenIdentifier, value: pkg.Name, sourceRange: spec.Path.SourceRange()}
+		if spec.Name != nil {
+			name = spec.Name.Token
+		}
+		if name.Value() == "_" {
+			continue
+		}
+
+		sym := NewPackageSymbol(name, nil, spec.SourceRange(), pkg)
+		sym.SetResolveState(ResolveStateResolved)
+		checker.unit.semanticInfo.SetTypeOf(sym, &TypeAndValue{
+			Mode: AddressModeInvalid,
+			Type: BuiltinVoidType,
+		})
+		if checker.addSymbol(sym) == sym && spec.Name != nil {
+			checker.unit.semanticInfo.SetSymbolOfIdentifier(spec.Name, sym)
+		}
+	}
+}
+
+func (checker *Checker) checkImportsClashWithDecls(pkg *Package) {
+	packageScope := checker.unit.semanticInfo.ScopeOf(pkg)
+	for _, file := range pkg.Files {
+		fileScope := checker.unit.semanticInfo.ScopeOf(file)
+		for _, sym := range fileScope.Symbols {
+			if decl := packageScope.ShallowFind(sym.Name()); decl != nil {
+				checker.error(
+					NewError(decl.SourceRange(), "symbol '%v' redefinition", sym.Name()).
+						Note(sym.SourceRange(), "imported here"),
+				)
+			}
+		}
+	}
+}
+
+// isPackageLevel returns true if the symbol is declared at package level, such symbols are resolved in the
+// scope of the file they were declared in
+func (checker *Checker) isPackageLevel(sym Symbol) bool {
+	fileScope := checker.unit.semanticInfo.ScopeOf(sym.SourceRange().File)
+	return fileScope != nil && sym.Scope() == fileScope
+}
+
+// packageScopeOf returns the package scope which encloses the given scope
+func packageScopeOf(scope *Scope) *Scope {
+	for scope != nil && scope.Parent != nil {
+		scope = scope.Parent
+	}
+	return scope
+}
+
+func isExported(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+// isFieldAccessible returns whether the field can be accessed from the code at the given source range, unexported
+// fields are only accessible from the package declaring their struct
+func isFieldAccessible(field *StructTypeField, from SourceRange) bool {
+	return isExported(field.Name()) || field.Package == from.File.pkg
 }
 
-func (checker *Checker) shallowWalk() {
-	for _, d := range checker.unit.rootFile.decls {
+func (checker *Checker) shallowWalk(file *UnitFile) {
+	for _, d := range file.decls {
 		switch decl := d.(type) {
 		case *GenericDecl:
 			checker.shallowWalkGenericDecl(decl)
@@ -301,7 +606,10 @@ func (checker *Checker) shallowWalk() {
 }
 
 func (checker *Checker) shallowWalkGenericDecl(d *GenericDecl) {
+	workgroup := checker.resolveDeclDirectives(d, true)
 	switch d.DeclToken.Kind() {
+	case TokenImport:
+		// imports are declared in the file scope before walking the file
 	case TokenConst:
 		for si, s := range d.Specs {
 			spec := s.(*ValueSpec)
@@ -315,6 +623,7 @@ func (checker *Checker) shallowWalkGenericDecl(d *GenericDecl) {
 			spec := s.(*ValueSpec)
 			for ei, name := range spec.LHS {
 				sym := NewVarSymbol(name.Token, d, d.SourceRange(), si, ei, nil)
+				sym.Workgroup = workgroup
 				checker.addSymbol(sym)
 			}
 		}
@@ -331,9 +640,68 @@ func (checker *Checker) shallowWalkGenericDecl(d *GenericDecl) {
 
 func (checker *Checker) shallowWalkFuncDecl(d *FuncDecl) {
 	sym := NewFuncSymbol(d.Name.Token, d, d.SourceRange())
+	if d.Receiver != nil {
+		// methods don't live in the package scope, they are attached to their receiver type
+		// after all the package level symbols are known
+		checker.methods = append(checker.methods, sym)
+		return
+	}
 	checker.addSymbol(sym)
 }
 
+func (checker *Checker) attachMethods() {
+	for _, sym := range checker.methods {
+		funcDecl := sym.SymDecl.(*FuncDecl)
+		receiver := funcDecl.Receiver
+		if len(receiver.Fields) != 1 || len(receiver.Fields[0].Names) > 1 {
+			checker.error(NewError(receiver.Open.SourceRange().Merge(receiver.Close.SourceRange()), "method is expected to have exactly one receiver"))
+			continue
+		}
+
+		receiverTypeExpr := receiver.Fields[0].Type
+		if pointerTypeExpr, ok := receiverTypeExpr.(*PointerTypeExpr); ok {
+			receiverTypeExpr = pointerTypeExpr.BaseType
+		}
+		namedType, ok := receiverTypeExpr.(*NamedTypeExpr)
+		if !ok {
+			checker.error(NewError(receiverTypeExpr.SourceRange(), "invalid receiver type"))
+			continue
+		}
+
+		if namedType.IsPackageQualified() {
+			checker.error(NewError(receiverTypeExpr.SourceRange(), "cannot define new methods on non-local type '%v.%v'", namedType.Package.Value(), namedType.TypeName.Value()))
+			continue
+		}
+
+		typeSym, ok := checker.currentScope().ShallowFind(namedType.TypeName.Value()).(*TypeSymbol)
+		if !ok {
+			if typeFromName(namedType.TypeName) != BuiltinVoidType {
+				checker.error(NewError(receiverTypeExpr.SourceRange(), "cannot define new methods on non-local type '%v'", namedType.TypeName.Value()))
+			} else {
+				checker.error(NewError(receiverTypeExpr.SourceRange(), "undeclared receiver type '%v'", namedType.TypeName.Value()))
+			}
+			continue
+		}
+
+		if !typeSym.IsStrong {
+			checker.error(
+				NewError(receiverTypeExpr.SourceRange(), "cannot define new methods on type alias '%v'", typeSym.Name()).
+					Note(typeSym.SourceRange(), "type alias declared here"),
+			)
+			continue
+		}
+
+		if !typeSym.AddMethod(sym) {
+			checker.error(
+				NewError(sym.SourceRange(), "method '%v.%v' redefinition", typeSym.Name(), sym.Name()).
+					Note(typeSym.FindMethod(sym.Name()).SourceRange(), "first declared here"),
+			)
+			continue
+		}
+		sym.SetScope(checker.unit.semanticInfo.ScopeOf(sym.SourceRange().File))
+	}
+}
+
 func (checker *Checker) addSymbol(sym Symbol) Symbol {
 	scope := checker.currentScope()
 	if oldSym := scope.ShallowFind(sym.Name()); oldSym != nil {
@@ -349,7 +717,23 @@ func (checker *Checker) addSymbol(sym Symbol) Symbol {
 }
 
 func (checker *Checker) error(e Error) {
-	checker.unit.rootFile.error(e)
+	file := e.SourceRange.File
+	if file == nil {
+		file = checker.unit.rootFile
+	}
+	if e.Severity == SeverityError && e.Code == "" {
+		e.Code = DiagnosticTypeError
+	}
+	if e.Severity == SeverityWarning {
+		options := checker.unit.diagnostics
+		if !options.IsEnabled(e.Code) || file.isSuppressed(e.Code, e.SourceRange.BeginPosition.Line) {
+			return
+		}
+		if options.WarningsAsErrors {
+			e.Severity = SeverityError
+		}
+	}
+	file.error(e)
 }
 
 func (checker *Checker) resolveSymbol(sym Symbol) *TypeAndValue {
@@ -365,6 +749,12 @@ func (checker *Checker) resolveSymbol(sym Symbol) *TypeAndValue {
 		}
 	}
 
+	// symbols are resolved in the scope they were declared in, not the scope they were used from
+	if sym.Scope() != nil {
+		checker.enterScope(sym.Scope())
+		defer checker.leaveScope()
+	}
+
 	var symType *TypeAndValue
 	sym.SetResolveState(ResolveStateResolving)
 	switch symbol := sym.(type) {
@@ -396,8 +786,7 @@ func (checker *Checker) resolveSymbol(sym Symbol) *TypeAndValue {
 		panic("unexpected symbol type")
 	}
 
-	globalScope := checker.unit.semanticInfo.ScopeOf(checker.unit.rootFile)
-	if sym.Scope() == globalScope {
+	if checker.isPackageLevel(sym) {
 		checker.unit.semanticInfo.ReachableSymbols = append(checker.unit.semanticInfo.ReachableSymbols, sym)
 	}
 
@@ -412,19 +801,189 @@ func (checker *Checker) resolveFuncSymbol(sym *FuncSymbol) *TypeAndValue {
 
 	funcDecl := sym.SymDecl.(*FuncDecl)
 
-	checker.enterFunction(funcDecl)
+	checker.enterFunction(sym)
 	defer checker.leaveFunction()
 
+	if sym.IsMethod() {
+		checker.resolveReceiver(funcDecl.Receiver)
+		if funcDecl.Type.TypeParams != nil {
+			checker.error(NewError(funcDecl.Type.TypeParams.Open.SourceRange().Merge(funcDecl.Type.TypeParams.Close.SourceRange()), "methods cannot have type parameters"))
+		}
+	}
+
 	funcType := checker.resolveFuncTypeExpr(funcDecl.Type)
+	checker.resolveDirectives(sym, funcType.Type.(*FuncType))
 
 	checker.unit.semanticInfo.SetTypeOf(sym.SymDecl, funcType)
 	return funcType
 }
 
+func (checker *Checker) resolveDirectives(sym *FuncSymbol, funcType *FuncType) {
+	funcDecl := sym.SymDecl.(*FuncDecl)
+
+	var stageDirective Token
+	for _, d := range funcDecl.Directives {
+		fields := strings.Fields(strings.TrimPrefix(d.Value(), "//sabre:"))
+		stage := ShaderStageNone
+		if len(fields) > 0 {
+			stage = shaderStageFromName(fields[0])
+		}
+		if stage == ShaderStageNone {
+			checker.error(NewError(d.SourceRange(), "unknown directive '%v'", d.Value()))
+			continue
+		}
+		if sym.IsEntryPoint() {
+			checker.error(NewError(d.SourceRange(), "function '%v' is already a %v entry point", sym.Name(), sym.Stage).
+				Note(stageDirective.SourceRange(), "previous directive is here"))
+			continue
+		}
+		size, ok := checker.resolveWorkgroupSize(d, stage, fields[1:])
+		if !ok {
+			continue
+		}
+		sym.Stage = stage
+		sym.WorkgroupSize = size
+		stageDirective = d
+	}
+
+	if !sym.IsEntryPoint() {
+		return
+	}
+
+	if sym.IsMethod() {
+		checker.error(NewError(funcDecl.Name.SourceRange(), "method '%v' can't be an entry point", sym.Name()))
+	} else if funcType.IsGeneric() {
+		checker.error(NewError(funcDecl.Name.SourceRange(), "generic function '%v' can't be an entry point", sym.Name()))
+	} else if sym.Stage == ShaderStageCompute && len(funcType.ReturnTypes) > 0 {
+		checker.error(NewError(funcDecl.Name.SourceRange(), "compute entry point '%v' can't have results", sym.Name()))
+	} else if sym.Stage != ShaderStageCompute && slices.ContainsFunc(funcType.ParameterTypes, func(t Type) bool { return !isSampledTexture(t) && !isUniform(t) && !isStageValue(t) }) {
+		// the resources of the entry points are bound by the pipeline, shaders take the textures they sample, the
+		// structs of uniform data and the values interpolated from the previous stage
+		checker.error(NewError(funcDecl.Name.SourceRange(), "%v entry point '%v' can only take textures, uniform structs and 32 bit numbers or vectors passed between stages", sym.Stage, sym.Name()))
+	} else if sym.Stage == ShaderStageCompute && slices.ContainsFunc(funcType.ParameterTypes, func(t Type) bool { return !isPointer(t) && !isTexture(t) && !isUniform(t) }) {
+		// compute entry points also take the buffers and the images they work on, kernels receive buffers as pointer
+		// parameters
+		checker.error(NewError(funcDecl.Name.SourceRange(), "compute entry point '%v' can only take pointers to buffers, textures, images and uniform structs", sym.Name()))
+	} else if slices.ContainsFunc(funcType.ReturnTypes, func(t Type) bool { return !isStageValue(t) }) {
+		// the results are passed to the next stage, or written to the color targets by fragment entry points
+		checker.error(NewError(funcDecl.Name.SourceRange(), "%v entry point '%v' can only return 32 bit numbers or vectors passed between stages", sym.Stage, sym.Name()))
+	} else if sym.Stage == ShaderStageVertex && len(funcType.ReturnTypes) > 0 && !isPosition(funcType.ReturnTypes[0]) {
+		checker.error(NewError(funcDecl.Name.SourceRange(), "vertex entry point '%v' must return the position of the vertex as its first result of type 'f32x4'", sym.Name()))
+	} else {
+		checker.unit.semanticInfo.EntryPoints = append(checker.unit.semanticInfo.EntryPoints, sym)
+		return
+	}
+	sym.Stage = ShaderStageNone
+}
+
+// resolveDeclDirectives returns whether the directives above the declaration make its variables workgroup variables,
+// which is the only directive of the declarations other than functions
+func (checker *Checker) resolveDeclDirectives(d *GenericDecl, packageLevel bool) bool {
+	workgroup := false
+	for _, directive := range d.Directives {
+		if strings.TrimSpace(strings.TrimPrefix(directive.Value(), "//sabre:")) != "workgroup" {
+			checker.error(NewError(directive.SourceRange(), "unknown directive '%v'", directive.Value()))
+		} else if d.DeclToken.Kind() != TokenVar || !packageLevel {
+			checker.error(NewError(directive.SourceRange(), "only package level variables can be workgroup variables"))
+		} else {
+			workgroup = true
+		}
+	}
+	return workgroup
+}
+
+// recordWorkgroupUse records the first use of the workgroup variable by the current function
+func (checker *Checker) recordWorkgroupUse(sym Symbol, e Expr) {
+	v, ok := sym.(*VarSymbol)
+	function := checker.currentFunction()
+	if !ok || !v.Workgroup || function == nil {
+		return
+	}
+	info := checker.unit.semanticInfo
+	if slices.Contains(info.Workgroups[function], v) {
+		return
+	}
+	if _, ok := checker.workgroupUses[function]; !ok {
+		checker.workgroupFuncs = append(checker.workgroupFuncs, function)
+	}
+	info.Workgroups[function] = append(info.Workgroups[function], v)
+	checker.workgroupUses[function] = append(checker.workgroupUses[function], e)
+}
+
+// resolveWorkgroupSize returns the workgroup size given by the arguments of the stage directive, only compute entry
+// points take it and the axes which aren't given have a single invocation
+func (checker *Checker) resolveWorkgroupSize(d Token, stage ShaderStage, args []string) ([3]uint32, bool) {
+	size := [3]uint32{1, 1, 1}
+	if len(args) == 0 {
+		return size, true
+	}
+	if stage != ShaderStageCompute {
+		checker.error(NewError(d.SourceRange(), "only compute entry points have a workgroup size"))
+		return size, false
+	}
+	if len(args) > len(size) {
+		checker.error(NewError(d.SourceRange(), "workgroup size has at most %v dimensions, but found %v", len(size), len(args)))
+		return size, false
+	}
+	for i, arg := range args {
+		n, err := strconv.ParseUint(arg, 10, 32)
+		if err != nil || n == 0 {
+			checker.error(NewError(d.SourceRange(), "workgroup size '%v' is not a positive integer", arg))
+			return size, false
+		}
+		size[i] = uint32(n)
+	}
+	return size, true
+}
+
+func (checker *Checker) resolveReceiver(receiver *FieldList) Type {
+	field := receiver.Fields[0]
+	receiverType := checker.resolveParamTypeExpr(field.Type)
+	for _, name := range field.Names {
+		v := NewVarSymbol(name.Token, nil, name.SourceRange(), -1, -1, nil)
+		v.IsParam = true
+		v.SetResolveState(ResolveStateResolved)
+		checker.unit.semanticInfo.SetTypeOf(v, &TypeAndValue{
+			Mode: AddressModeVariable,
+			Type: receiverType.Type,
+		})
+		checker.addSymbol(v)
+		checker.unit.semanticInfo.SetSymbolOfIdentifier(name, v)
+	}
+	return receiverType.Type
+}
+
+// resolveParamTypeExpr resolves the type of a function parameter, which is the only place pointer and texture types
+// are allowed
+func (checker *Checker) resolveParamTypeExpr(e TypeExpr) *TypeAndValue {
+	pointerTypeExpr, ok := e.(*PointerTypeExpr)
+	if !ok {
+		prevParamTypeExpr := checker.paramTypeExpr
+		checker.paramTypeExpr = e
+		defer func() { checker.paramTypeExpr = prevParamTypeExpr }()
+		return checker.resolveExpr(e)
+	}
+
+	res := &TypeAndValue{
+		Mode:  AddressModeType,
+		Type:  BuiltinVoidType,
+		Value: nil,
+	}
+	// pointers to pointers are reported when resolving the base type
+	if baseType := checker.resolveExpr(pointerTypeExpr.BaseType); baseType.IsType() {
+		res.Type = checker.unit.semanticInfo.TypeInterner.InternPointerType(baseType.Type)
+	}
+	checker.unit.semanticInfo.SetTypeOf(e, res)
+	return res
+}
+
 func (checker *Checker) resolveTypeSymbol(sym *TypeSymbol) *TypeAndValue {
 	t := checker.resolveExpr(sym.TypeExpr)
 	if sym.IsStrong {
-		t.Type = checker.unit.semanticInfo.TypeInterner.InternStrongTypeAlias(sym.Name(), t.Type)
+		strongAlias := checker.unit.semanticInfo.TypeInterner.InternStrongTypeAlias(sym.Name(), t.Type)
+		strongAlias.Methods = sym.Methods
+		checker.checkMethodsClashWithFields(sym, strongAlias)
+		t.Type = strongAlias
 	} else {
 		t.Type = checker.unit.semanticInfo.TypeInterner.InternWeakTypeAlias(sym.Name(), t.Type)
 	}
@@ -432,18 +991,180 @@ func (checker *Checker) resolveTypeSymbol(sym *TypeSymbol) *TypeAndValue {
 	return t
 }
 
+func (checker *Checker) checkMethodsClashWithFields(sym *TypeSymbol, t *StrongAliasType) {
+	structType, ok := t.UnderlyingType.Resolve(true).(*StructType)
+	if !ok {
+		return
+	}
+
+	for _, method := range sym.Methods {
+		field := structType.FindField(method.Name())
+		if field == nil {
+			continue
+		}
+
+		err := NewError(method.SymDecl.(*FuncDecl).Name.SourceRange(), "field and method with the same name '%v'", method.Name())
+		if field.Identifer != nil {
+			err = err.Note(field.Identifer.SourceRange(), "other declaration of '%v'", method.Name())
+		}
+		checker.error(err)
+	}
+}
+
 func (checker *Checker) resolveFuncBody(sym *FuncSymbol) {
 	scope := checker.unit.semanticInfo.ScopeOf(sym)
 	checker.enterScope(scope)
 	defer checker.leaveScope()
 
 	funcDecl := sym.SymDecl.(*FuncDecl)
-	checker.enterFunction(funcDecl)
+	checker.enterFunction(sym)
 	defer checker.leaveFunction()
 
+	// bodies resolved while checking a constant declaration aren't part of it
+	outerIota := checker.iota
+	checker.iota = nil
+	defer func() { checker.iota = outerIota }()
+
+	outerLabels := checker.labels
+	checker.labels = &labelScope{
+		labels: make(map[string]*LabeledStmt),
+		used:   make(map[*LabeledStmt]bool),
+	}
+	defer func() { checker.labels = outerLabels }()
+
 	for _, stmt := range funcDecl.Body.Stmts {
 		checker.resolveStmt(stmt, ResolveStmtProperties{})
 	}
+	checker.checkLabels()
+
+	if funcType, ok := checker.unit.semanticInfo.TypeOf(funcDecl).Type.(*FuncType); ok && len(funcType.ReturnTypes) > 0 {
+		if !isTerminatingStmtList(funcDecl.Body.Stmts) {
+			checker.error(NewError(funcDecl.Body.RBrace.SourceRange(), "missing return"))
+		}
+	}
+	checker.checkUnreachableStmts(funcDecl.Body.Stmts)
+}
+
+// isTerminatingStmt follows go's definition of terminating statements, control never flows past them
+func isTerminatingStmt(stmt Stmt) bool {
+	return isTerminatingLabeledStmt(stmt, "")
+}
+
+// isTerminatingLabeledStmt checks whether the statement with the given label, empty if it's not labeled, is terminating
+func isTerminatingLabeledStmt(stmt Stmt, label string) bool {
+	switch s := stmt.(type) {
+	case *LabeledStmt:
+		return isTerminatingLabeledStmt(s.Stmt, s.Label.Value())
+	case *ReturnStmt:
+		return true
+	case *DiscardStmt:
+		return true
+	case *BlockStmt:
+		return isTerminatingStmtList(s.Stmts)
+	case *IfStmt:
+		return s.Else != nil && isTerminatingStmtList(s.Body.Stmts) && isTerminatingStmt(s.Else)
+	case *ForStmt:
+		return s.Cond == nil && !hasBreakStmt(s.Body, label, false)
+	case *SwitchStmt:
+		hasDefault := false
+		for i, stmt := range s.Body.Stmts {
+			caseStmt, ok := stmt.(*SwitchCaseStmt)
+			if !ok {
+				return false
+			}
+			if caseStmt.Case.Kind() == TokenDefault {
+				hasDefault = true
+			}
+			if hasBreakStmt(caseStmt, label, false) {
+				return false
+			}
+			// falling through to the next case is fine as long as there's a next case
+			if len(caseStmt.RHS) > 0 && i+1 < len(s.Body.Stmts) {
+				if _, ok := caseStmt.RHS[len(caseStmt.RHS)-1].(*FallthroughStmt); ok {
+					continue
+				}
+			}
+			if !isTerminatingStmtList(caseStmt.RHS) {
+				return false
+			}
+		}
+		return hasDefault
+	default:
+		return false
+	}
+}
+
+func isTerminatingStmtList(stmts []Stmt) bool {
+	return len(stmts) > 0 && isTerminatingStmt(stmts[len(stmts)-1])
+}
+
+// hasBreakStmt returns true if the statement contains a break which exits the enclosing loop or switch with the
+// given label, unlabeled breaks inside nested loops and switches exit those instead
+func hasBreakStmt(stmt Stmt, label string, nested bool) bool {
+	hasBreak := func(stmt Stmt) bool {
+		return hasBreakStmt(stmt, label, nested)
+	}
+	switch s := stmt.(type) {
+	case *BreakStmt:
+		if s.IsLabeled() {
+			return s.Label.Value() == label
+		}
+		return !nested
+	case *BlockStmt:
+		return slices.ContainsFunc(s.Stmts, hasBreak)
+	case *SwitchCaseStmt:
+		return slices.ContainsFunc(s.RHS, hasBreak)
+	case *IfStmt:
+		return hasBreak(s.Body) || (s.Else != nil && hasBreak(s.Else))
+	case *LabeledStmt:
+		return hasBreak(s.Stmt)
+	case *ForStmt:
+		return label != "" && hasBreakStmt(s.Body, label, true)
+	case *SwitchStmt:
+		return label != "" && hasBreakStmt(s.Body, label, true)
+	default:
+		return false
+	}
+}
+
+// checkUnreachableStmts warns about the first statement in each list which follows a statement that never
+// completes normally
+func (checker *Checker) checkUnreachableStmts(stmts []Stmt) {
+	for i, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *BlockStmt:
+			checker.checkUnreachableStmts(s.Stmts)
+		case *IfStmt:
+			checker.checkUnreachableStmts(s.Body.Stmts)
+			if s.Else != nil {
+				checker.checkUnreachableStmts([]Stmt{s.Else})
+			}
+		case *ForStmt:
+			checker.checkUnreachableStmts(s.Body.Stmts)
+		case *LabeledStmt:
+			checker.checkUnreachableStmts([]Stmt{s.Stmt})
+		case *SwitchStmt:
+			for _, stmt := range s.Body.Stmts {
+				if caseStmt, ok := stmt.(*SwitchCaseStmt); ok {
+					checker.checkUnreachableStmts(caseStmt.RHS)
+				}
+			}
+		}
+
+		if i+1 == len(stmts) {
+			break
+		}
+
+		switch stmt.(type) {
+		case *BreakStmt, *ContinueStmt:
+		default:
+			if !isTerminatingStmt(stmt) {
+				continue
+			}
+		}
+		checker.error(NewWarning(DiagnosticUnreachableCode, stmts[i+1].SourceRange(), "unreachable code"))
+		break
+	}
 }
 
 func (checker *Checker) resolveVarSymbol(sym *VarSymbol) *TypeAndValue {
@@ -472,7 +1193,7 @@ func (checker *Checker) resolveVarSymbol(sym *VarSymbol) *TypeAndValue {
 			return invalidType
 		}
 
-		varType = rhsTypes[sym.ExprIndex].Type
+		varType = checker.convertUntypedAt(spec.RHS, rhsTypes, sym.ExprIndex, nil).Type
 	} else {
 		if len(rhsTypes) > 0 {
 			if sym.ExprIndex >= len(rhsTypes) {
@@ -480,13 +1201,29 @@ func (checker *Checker) resolveVarSymbol(sym *VarSymbol) *TypeAndValue {
 				return invalidType
 			}
 
-			if !rhsTypes[sym.ExprIndex].Type.Equal(varType) {
-				checker.error(NewError(sourceRanges[sym.ExprIndex], "type mismatch in variable declaration expected '%v', got '%v'", varType, rhsTypes[sym.ExprIndex].Type))
+			rhsType := checker.convertUntypedAt(spec.RHS, rhsTypes, sym.ExprIndex, varType)
+			if !rhsType.Type.Equal(varType) {
+				checker.error(NewError(sourceRanges[sym.ExprIndex], "type mismatch in variable declaration expected '%v', got '%v'", varType, rhsType.Type))
 				return invalidType
 			}
 		}
 	}
 
+	if isPointer(varType) {
+		checker.error(NewError(sym.SourceRange(), "pointers can't be stored in variables"))
+		return invalidType
+	}
+	if isFunc(varType) {
+		checker.error(NewError(sym.SourceRange(), "function values can't be stored in variables"))
+	}
+	if isTexture(varType) {
+		checker.error(NewError(sym.SourceRange(), "textures can't be stored in variables"))
+	}
+	if sym.Workgroup && len(spec.RHS) > 0 {
+		checker.error(NewError(spec.RHS[0].SourceRange().Merge(spec.RHS[len(spec.RHS)-1].SourceRange()), "workgroup variable '%v' can't be initialized", sym.Name()).
+			Note(sym.SourceRange(), "the memory of the workgroup is uninitialized when its invocations start"))
+	}
+
 	return &TypeAndValue{
 		Mode:  AddressModeVariable,
 		Type:  varType,
@@ -501,48 +1238,96 @@ func (checker *Checker) resolveConstSymbol(sym *ConstSymbol) *TypeAndValue {
 		Value: nil,
 	}
 
-	spec := sym.SymDecl.(*GenericDecl).Specs[sym.SpecIndex].(*ValueSpec)
+	// specs without an initializer repeat the type and expressions of the previous spec in the declaration
+	decl := sym.SymDecl.(*GenericDecl)
+	ownSpec := decl.Specs[sym.SpecIndex].(*ValueSpec)
+	spec := ownSpec
+	for i := sym.SpecIndex - 1; len(spec.RHS) == 0 && i >= 0; i-- {
+		spec = decl.Specs[i].(*ValueSpec)
+	}
 	if len(spec.RHS) == 0 {
 		checker.error(NewError(sym.SourceRange(), "constant declaration requires an initializer"))
 		return invalidType
 	}
 
+	prevIota, prevUsesIota := checker.iota, checker.usesIota
+	checker.iota = constant.MakeInt64(int64(sym.SpecIndex))
+	defer func() { checker.iota, checker.usesIota = prevIota, prevUsesIota }()
+
 	rhsValues, sourceRanges := checker.resolveAndUnpackTypesFromExprList(spec.RHS)
 
 	if sym.ExprIndex >= len(rhsValues) {
 		checker.error(NewError(sym.SourceRange(), "assignment mismatch: %v constants but %v values", sym.ExprIndex+1, len(rhsValues)))
 		return invalidType
 	}
+	// repeated specs must use all the repeated expressions, it's reported once for the spec
+	if ownSpec != spec && sym.ExprIndex == 0 && len(ownSpec.LHS) < len(rhsValues) {
+		checker.error(NewError(ownSpec.SourceRange(), "assignment mismatch: %v constants but %v values", len(ownSpec.LHS), len(rhsValues)))
+	}
 
 	rhsValue := rhsValues[sym.ExprIndex]
-	rhsType := rhsValue.Type
 	sourceRange := sourceRanges[sym.ExprIndex]
 
 	if rhsValue.Mode != AddressModeConstant {
-		checker.error(NewError(sourceRange, "constant declaration requires a constant expression"))
+		// invalid expressions are already reported
+		if rhsValue.Mode != AddressModeInvalid {
+			checker.error(NewError(sourceRange, "constant declaration requires a constant expression"))
+		}
 		return invalidType
 	}
 
 	if spec.Type != nil {
 		constType := checker.resolveExpr(spec.Type).Type
-		if !rhsType.Equal(constType) {
-			checker.error(NewError(sourceRange, "type mismatch in constant declaration expected '%v', got '%v'", constType, rhsType))
+		rhsValue = checker.convertUntypedAt(spec.RHS, rhsValues, sym.ExprIndex, constType)
+		if !rhsValue.Type.Equal(constType) {
+			checker.error(NewError(sourceRange, "type mismatch in constant declaration expected '%v', got '%v'", constType, rhsValue.Type))
 			return invalidType
 		}
 	}
 
 	return &TypeAndValue{
-		Mode:  AddressModeConstant,
-		Type:  rhsType,
-		Value: rhsValue.Value,
+		Mode:     AddressModeConstant,
+		Type:     rhsValue.Type,
+		Value:    rhsValue.Value,
+		Elements: rhsValue.Elements,
 	}
 }
 
-func (checker *Checker) resolveExpr(expr Expr) (t *TypeAndValue) {
+func (checker *Checker) resolveExpr(expr Expr) *TypeAndValue {
+	if checker.iota != nil {
+		return checker.resolveIotaExpr(expr)
+	}
 	if exprType := checker.unit.semanticInfo.TypeOf(expr); exprType != nil {
 		return exprType
 	}
+	return checker.resolveExprNode(expr)
+}
 
+// resolveIotaExpr resolves the expression of a constant spec, specs of constant declarations share the expressions
+// they repeat so the expressions using iota are resolved again for each iota
+func (checker *Checker) resolveIotaExpr(expr Expr) *TypeAndValue {
+	iota, _ := constant.Int64Val(checker.iota)
+	key := iotaExpr{expr: expr, iota: iota}
+	if exprType, ok := checker.iotaTypes[key]; ok {
+		checker.usesIota = true
+		return exprType
+	}
+	if exprType := checker.unit.semanticInfo.TypeOf(expr); exprType != nil && !checker.iotaExprs[expr] {
+		return exprType
+	}
+
+	outerUsesIota := checker.usesIota
+	checker.usesIota = false
+	t := checker.resolveExprNode(expr)
+	if checker.usesIota {
+		checker.iotaExprs[expr] = true
+		checker.iotaTypes[key] = t
+	}
+	checker.usesIota = checker.usesIota || outerUsesIota
+	return t
+}
+
+func (checker *Checker) resolveExprNode(expr Expr) (t *TypeAndValue) {
 	switch e := expr.(type) {
 	case *LiteralExpr:
 		t = checker.resolveLiteralExpr(e)
@@ -552,6 +1337,10 @@ func (checker *Checker) resolveExpr(expr Expr) (t *TypeAndValue) {
 		t = checker.resolveParenExpr(e)
 	case *SelectorExpr:
 		t = checker.resolveSelectorExpr(e)
+	case *IndexExpr:
+		t = checker.resolveIndexExpr(e)
+	case *IndexListExpr:
+		t = checker.resolveIndexListExpr(e)
 	case *UnaryExpr:
 		t = checker.resolveUnaryExpr(e)
 	case *BinaryExpr:
@@ -562,10 +1351,14 @@ func (checker *Checker) resolveExpr(expr Expr) (t *TypeAndValue) {
 		t = checker.resolveCallExpr(e)
 	case *ArrayTypeExpr:
 		t = checker.resolveArrayTypeExpr(e)
+	case *PointerTypeExpr:
+		t = checker.resolvePointerTypeExpr(e)
 	case *FuncTypeExpr:
 		t = checker.resolveFuncTypeExpr(e)
 	case *StructTypeExpr:
 		t = checker.resolveStructTypeExpr(e)
+	case *ComplitExpr:
+		t = checker.resolveComplitExpr(e)
 	default:
 		panic("unexpected expr type")
 	}
@@ -604,7 +1397,7 @@ func (checker *Checker) resolveLiteralExpr(e *LiteralExpr) *TypeAndValue {
 		if err == nil {
 			return &TypeAndValue{
 				Mode:  AddressModeConstant,
-				Type:  BuiltinIntType,
+				Type:  BuiltinUntypedIntType,
 				Value: constant.MakeInt64(i),
 			}
 		} else {
@@ -622,7 +1415,7 @@ func (checker *Checker) resolveLiteralExpr(e *LiteralExpr) *TypeAndValue {
 		if err == nil {
 			return &TypeAndValue{
 				Mode:  AddressModeConstant,
-				Type:  BuiltinFloat32Type,
+				Type:  BuiltinUntypedFloatType,
 				Value: constant.MakeFloat64(f),
 			}
 		} else {
@@ -638,13 +1431,13 @@ func (checker *Checker) resolveLiteralExpr(e *LiteralExpr) *TypeAndValue {
 	case TokenTrue:
 		return &TypeAndValue{
 			Mode:  AddressModeConstant,
-			Type:  BuiltinBoolType,
+			Type:  BuiltinUntypedBoolType,
 			Value: constant.MakeBool(true),
 		}
 	case TokenFalse:
 		return &TypeAndValue{
 			Mode:  AddressModeConstant,
-			Type:  BuiltinBoolType,
+			Type:  BuiltinUntypedBoolType,
 			Value: constant.MakeBool(false),
 		}
 	default:
@@ -657,9 +1450,49 @@ func (checker *Checker) resolveLiteralExpr(e *LiteralExpr) *TypeAndValue {
 }
 
 func (checker *Checker) resolveIdentifierExpr(e *IdentifierExpr) *TypeAndValue {
+	if isBlankIdentifier(e) {
+		checker.error(NewError(e.SourceRange(), "cannot use _ as value"))
+		return &TypeAndValue{
+			Mode:  AddressModeInvalid,
+			Type:  BuiltinVoidType,
+			Value: nil,
+		}
+	}
+
 	scope := checker.currentScope()
 	symbol := scope.Find(e.Token.Value())
 	if symbol == nil {
+		if e.Token.Value() == "iota" {
+			if checker.iota == nil {
+				checker.error(NewError(e.SourceRange(), "cannot use iota outside constant declaration"))
+				return &TypeAndValue{
+					Mode:  AddressModeInvalid,
+					Type:  BuiltinVoidType,
+					Value: nil,
+				}
+			}
+			checker.usesIota = true
+			return &TypeAndValue{
+				Mode:  AddressModeConstant,
+				Type:  BuiltinUntypedIntType,
+				Value: checker.iota,
+			}
+		}
+		// builtin type names are used as expressions in conversions like float32(x)
+		if t := typeFromName(e.Token); t != BuiltinVoidType {
+			return &TypeAndValue{
+				Mode: AddressModeType,
+				Type: t,
+			}
+		}
+		if builtin, ok := builtinFuncNames[e.Token.Value()]; ok {
+			checker.error(NewError(e.SourceRange(), "builtin '%v' must be called", builtin))
+			return &TypeAndValue{
+				Mode:  AddressModeInvalid,
+				Type:  BuiltinVoidType,
+				Value: nil,
+			}
+		}
 		checker.error(NewError(e.SourceRange(), "undeclared identifier"))
 		return &TypeAndValue{
 			Mode:  AddressModeInvalid,
@@ -669,45 +1502,433 @@ func (checker *Checker) resolveIdentifierExpr(e *IdentifierExpr) *TypeAndValue {
 	}
 
 	checker.unit.semanticInfo.SetSymbolOfIdentifier(e, symbol)
+	checker.unit.semanticInfo.addUse(symbol)
+	checker.recordWorkgroupUse(symbol, e)
+
+	if _, ok := symbol.(*PackageSymbol); ok {
+		checker.error(NewError(e.SourceRange(), "use of package '%v' without selector", e.Token.Value()))
+		return &TypeAndValue{
+			Mode:  AddressModeInvalid,
+			Type:  BuiltinVoidType,
+			Value: nil,
+		}
+	}
 
 	return checker.resolveSymbol(symbol)
 }
 
+// findPackageSymbol returns the package symbol the given name refers to, or nil if it doesn't refer to a package
+func (checker *Checker) findPackageSymbol(name string) *PackageSymbol {
+	if sym, ok := checker.currentScope().Find(name).(*PackageSymbol); ok {
+		return sym
+	}
+	return nil
+}
+
+// findQualifiedSymbol looks up the given name in the imported package and reports an error if it's not declared
+// or not exported
+func (checker *Checker) findQualifiedSymbol(pkgSym *PackageSymbol, name Token) Symbol {
+	packageScope := checker.unit.semanticInfo.ScopeOf(pkgSym.Package)
+	sym := packageScope.ShallowFind(name.Value())
+	if sym == nil {
+		checker.error(NewError(name.SourceRange(), "undeclared identifier '%v' in package '%v'", name.Value(), pkgSym.Name()))
+		return nil
+	}
+
+	if !isExported(name.Value()) {
+		checker.error(
+			NewError(name.SourceRange(), "cannot refer to unexported name '%v.%v'", pkgSym.Name(), name.Value()).
+				Note(sym.SourceRange(), "declared here"),
+		)
+		return nil
+	}
+	return sym
+}
+
+func (checker *Checker) resolveIndexExpr(e *IndexExpr) *TypeAndValue {
+	invalidResult := &TypeAndValue{
+		Mode: AddressModeInvalid,
+		Type: BuiltinVoidType,
+	}
+
+	baseType := checker.resolveExpr(e.Base)
+	if funcType, ok := baseType.Type.(*FuncType); ok && funcType.IsGeneric() {
+		return checker.resolveTypeArgs(e, baseType, funcType, []Expr{e.Index})
+	}
+	indexType := checker.convertUntyped(e.Index, checker.resolveExpr(e.Index), nil)
+
+	arrayType, ok := baseType.Type.Resolve(true).(*ArrayType)
+	if !ok || !baseType.IsValue() {
+		checker.error(NewError(e.Base.SourceRange(), "type '%v' does not support indexing", baseType.Type))
+		return invalidResult
+	}
+
+	if !indexType.Type.Properties().Integral {
+		checker.error(NewError(e.Index.SourceRange(), "array index should be integral type instead of '%v'", indexType.Type))
+		return invalidResult
+	}
+
+	// the element type is still known so out of range indices don't invalidate the expression
+	if indexType.Mode == AddressModeConstant {
+		if constant.Sign(indexType.Value) < 0 || constant.Compare(indexType.Value, token.GEQ, constant.MakeInt64(int64(arrayType.Length))) {
+			checker.error(NewError(
+				e.Index.SourceRange(),
+				"array index '%v' is out of range [0, %v)",
+				indexType.Value,
+				arrayType.Length,
+			))
+		} else if baseType.Mode == AddressModeConstant {
+			index, _ := constant.Int64Val(constant.ToInt(indexType.Value))
+			return baseType.Elements[index]
+		}
+	}
+
+	mode := AddressModeComputedValue
+	if baseType.Mode == AddressModeVariable {
+		mode = AddressModeVariable
+	}
+	return &TypeAndValue{
+		Mode: mode,
+		Type: arrayType.ElementType,
+	}
+}
+
+func (checker *Checker) resolveIndexListExpr(e *IndexListExpr) *TypeAndValue {
+	baseType := checker.resolveExpr(e.Base)
+	if funcType, ok := baseType.Type.(*FuncType); ok && funcType.IsGeneric() {
+		return checker.resolveTypeArgs(e, baseType, funcType, e.Indices)
+	}
+	checker.error(NewError(e.Indices[1].SourceRange(), "unexpected index, arrays are indexed by a single index"))
+	return &TypeAndValue{
+		Mode: AddressModeInvalid,
+		Type: BuiltinVoidType,
+	}
+}
+
+// resolveTypeArgs resolves the type arguments given explicitly to the generic function, the function is
+// instantiated when it's called so the type parameters left out can be inferred from the call arguments
+func (checker *Checker) resolveTypeArgs(e Expr, base *TypeAndValue, funcType *FuncType, exprs []Expr) *TypeAndValue {
+	invalidResult := &TypeAndValue{
+		Mode: AddressModeInvalid,
+		Type: BuiltinVoidType,
+	}
+
+	if len(exprs) > len(funcType.TypeParams) {
+		checker.error(NewError(exprs[len(funcType.TypeParams)].SourceRange(), "got %v type arguments but function has %v type parameters", len(exprs), len(funcType.TypeParams)))
+		return invalidResult
+	}
+
+	list := &typeArgList{exprs: exprs}
+	for _, expr := range exprs {
+		t := checker.resolveExpr(expr)
+		if !t.IsType() {
+			if t.Mode != AddressModeInvalid {
+				checker.error(NewError(expr.SourceRange(), "expected a type argument but found a value of type '%v'", t.Type))
+			}
+			return invalidResult
+		}
+		list.types = append(list.types, t.Type)
+	}
+	checker.typeArgLists[e] = list
+	return base
+}
+
 func (checker *Checker) resolveParenExpr(e *ParenExpr) *TypeAndValue {
 	return checker.resolveExpr(e.Base)
 }
 
-func (checker *Checker) resolveSelectorExpr(e *SelectorExpr) *TypeAndValue {
-	baseType := checker.resolveExpr(e.Base)
+func (checker *Checker) resolveComplitExpr(e *ComplitExpr) *TypeAndValue {
+	invalidResult := &TypeAndValue{
+		Mode: AddressModeInvalid,
+		Type: BuiltinVoidType,
+	}
+
+	t := checker.resolveExpr(e.Type)
+	if !t.IsType() {
+		checker.error(NewError(e.Type.SourceRange(), "'%v' is not a type", e.Type))
+		return invalidResult
+	}
+	if vectorType, ok := t.Type.Resolve(true).(*VectorType); ok {
+		return checker.resolveVectorLiteral(e, t.Type, vectorType)
+	}
+	structType, ok := t.Type.Resolve(true).(*StructType)
+	if !ok {
+		checker.error(NewError(e.Type.SourceRange(), "invalid composite literal type '%v'", t.Type))
+		return invalidResult
+	}
+
+	checkElement := func(element ComplitElement, field *StructTypeField) {
+		value := checker.convertUntyped(element.Value, checker.resolveExpr(element.Value), field.Type)
+		if value.Mode == AddressModeInvalid {
+			return
+		}
+		if !value.Type.Equal(field.Type) {
+			checker.error(NewError(
+				element.Value.SourceRange(),
+				"incorrect type '%v' for field '%v', expected '%v'",
+				value.Type,
+				field.Name(),
+				field.Type,
+			))
+		}
+	}
+
+	keyed := len(e.Elements) > 0 && e.Elements[0].Name != nil
+	for _, element := range e.Elements {
+		if (element.Name != nil) != keyed {
+			checker.error(NewError(element.Value.SourceRange(), "mixture of field:value and value elements in struct literal"))
+			return invalidResult
+		}
+	}
+
+	if keyed {
+		seen := make(map[string]bool)
+		for _, element := range e.Elements {
+			key, ok := element.Name.(*IdentifierExpr)
+			if !ok {
+				checker.error(NewError(element.Name.SourceRange(), "invalid field name '%v' in struct literal", element.Name))
+				continue
+			}
+			name := key.Token.Value()
+			index, ok := structType.FieldsByName[name]
+			if !ok {
+				if selection, _ := LookupFieldOrMethod(structType, name); selection != nil && selection.Field != nil {
+					checker.error(NewError(key.SourceRange(), "cannot use promoted field '%v' in struct literal of type '%v'", name, t.Type).
+						Note(key.SourceRange(), "initialize the embedded struct which declares it instead"))
+				} else {
+					checker.error(NewError(key.SourceRange(), "unknown field '%v' in struct literal of type '%v'", name, t.Type))
+				}
+				checker.resolveExpr(element.Value)
+				continue
+			}
+			if seen[name] {
+				checker.error(NewError(key.SourceRange(), "duplicate field name '%v' in struct literal", name))
+			}
+			seen[name] = true
+			if !isFieldAccessible(&structType.Fields[index], key.SourceRange()) {
+				checker.error(NewError(key.SourceRange(), "cannot refer to unexported field '%v' in struct literal of type '%v'", name, t.Type))
+				checker.resolveExpr(element.Value)
+				continue
+			}
+			checkElement(element, &structType.Fields[index])
+		}
+	} else if len(e.Elements) > 0 {
+		if len(e.Elements) < len(structType.Fields) {
+			checker.error(NewError(e.RBrace.SourceRange(), "too few values in struct literal of type '%v'", t.Type))
+		}
+		for i, element := range e.Elements {
+			if i >= len(structType.Fields) {
+				checker.error(NewError(element.Value.SourceRange(), "too many values in struct literal of type '%v'", t.Type))
+				break
+			}
+			if !isFieldAccessible(&structType.Fields[i], element.Value.SourceRange()) {
+				checker.error(NewError(element.Value.SourceRange(), "implicit assignment to unexported field '%v' in struct literal of type '%v'", structType.Fields[i].Name(), t.Type))
+				checker.resolveExpr(element.Value)
+				continue
+			}
+			checkElement(element, &structType.Fields[i])
+		}
+	}
+
+	return &TypeAndValue{
+		Mode: AddressModeComputedValue,
+		Type: t.Type,
+	}
+}
+
+// resolveVectorLiteral checks the literal of the vector type, its elements are the components in order and a literal
+// without elements is the zero vector
+func (checker *Checker) resolveVectorLiteral(e *ComplitExpr, t Type, vectorType *VectorType) *TypeAndValue {
+	elementType := vectorElementType(vectorType)
+	for i, element := range e.Elements {
+		if element.Name != nil {
+			checker.error(NewError(element.Name.SourceRange(), "vector literals can't name their components"))
+			checker.resolveExpr(element.Value)
+			continue
+		}
+		if i >= vectorType.Width {
+			checker.error(NewError(element.Value.SourceRange(), "too many values in vector literal of type '%v'", t))
+			break
+		}
+		value := checker.convertUntyped(element.Value, checker.resolveExpr(element.Value), elementType)
+		if value.Mode != AddressModeInvalid && !value.Type.Equal(elementType) {
+			checker.error(NewError(element.Value.SourceRange(), "incorrect type '%v' for component %v, expected '%v'", value.Type, i, elementType))
+		}
+	}
+	if len(e.Elements) > 0 && len(e.Elements) < vectorType.Width {
+		checker.error(NewError(e.RBrace.SourceRange(), "too few values in vector literal of type '%v'", t))
+	}
+	return &TypeAndValue{
+		Mode: AddressModeComputedValue,
+		Type: t,
+	}
+}
 
+func (checker *Checker) resolveSelectorExpr(e *SelectorExpr) *TypeAndValue {
 	invalidResult := &TypeAndValue{
 		Mode: AddressModeInvalid,
 		Type: BuiltinVoidType,
 	}
 
-	switch t := baseType.Type.Resolve(true).(type) {
-	case *StructType:
-		if structField := t.FindField(e.Selector.Token.Value()); structField != nil {
-			return &TypeAndValue{
-				Mode: baseType.Mode,
-				Type: structField.Type,
+	if base, ok := e.Base.(*IdentifierExpr); ok {
+		if pkgSym := checker.findPackageSymbol(base.Token.Value()); pkgSym != nil {
+			checker.unit.semanticInfo.SetSymbolOfIdentifier(base, pkgSym)
+			checker.unit.semanticInfo.addUse(pkgSym)
+			sym := checker.findQualifiedSymbol(pkgSym, e.Selector.Token)
+			if sym == nil {
+				return invalidResult
 			}
-		} else {
+			checker.unit.semanticInfo.SetSymbolOfIdentifier(e.Selector, sym)
+			checker.unit.semanticInfo.addUse(sym)
+			checker.recordWorkgroupUse(sym, e)
+			t := checker.resolveSymbol(sym)
+			checker.unit.semanticInfo.SetTypeOf(e.Selector, t)
+			return t
+		}
+	}
+
+	baseType := checker.resolveExpr(e.Base)
+
+	// selectors implicitly dereference pointers
+	isPointerBase := false
+	if pointerType, ok := baseType.Type.Resolve(false).(*PointerType); ok && baseType.IsValue() {
+		isPointerBase = true
+		baseType = &TypeAndValue{
+			Mode: AddressModeVariable,
+			Type: pointerType.ElementType,
+		}
+	}
+
+	selection, conflicts := LookupFieldOrMethod(baseType.Type, e.Selector.Token.Value())
+	if len(conflicts) > 0 {
+		err := NewError(e.Selector.SourceRange(), "ambiguous selector '%v' in type '%v'", e.Selector.Token.Value(), baseType.Type)
+		for _, conflict := range conflicts {
+			if embedded := EmbeddedFieldOf(baseType.Type, conflict); embedded != nil && embedded.Embedding != nil {
+				err = err.Note(embedded.Embedding.SourceRange(), "'%v' is promoted from embedded field '%v'", e.Selector.Token.Value(), embedded.Name())
+			}
+		}
+		checker.error(err)
+		return invalidResult
+	}
+
+	if baseType.IsType() {
+		return checker.resolveMethodExpr(e, baseType.Type, selection)
+	}
+
+	if selection != nil && selection.Method != nil && baseType.IsValue() {
+		method := selection.Method
+		if !isExported(method.Name()) && packageScopeOf(method.Scope()) != packageScopeOf(checker.currentScope()) {
 			checker.error(NewError(
 				e.Selector.SourceRange(),
-				"field '%v' cannot be found in struct '%v'",
-				e.Selector.Token.Value(),
+				"cannot refer to unexported method '%v' of type '%v'",
+				method.Name(),
+				baseType.Type,
+			))
+			return invalidResult
+		}
+		// pointer methods take the address of their receiver
+		if method.HasPointerReceiver() && !isPointerBase && !checker.checkCanTakeAddress(e.Base, baseType) {
+			return invalidResult
+		}
+		checker.unit.semanticInfo.Selections[e] = selection
+		checker.unit.semanticInfo.SetSymbolOfIdentifier(e.Selector, method)
+		checker.unit.semanticInfo.addUse(method)
+		return &TypeAndValue{
+			Mode: AddressModeComputedValue,
+			Type: checker.resolveSymbol(method).Type,
+		}
+	}
+
+	if selection != nil && selection.Field != nil {
+		if !isFieldAccessible(selection.Field, e.SourceRange()) {
+			checker.error(NewError(
+				e.Selector.SourceRange(),
+				"cannot refer to unexported field '%v' of type '%v'",
+				selection.Field.Name(),
 				baseType.Type,
 			))
+			return invalidResult
+		}
+		checker.unit.semanticInfo.Selections[e] = selection
+		return &TypeAndValue{
+			Mode: baseType.Mode,
+			Type: selection.Field.Type,
 		}
+	}
+
+	switch t := baseType.Type.Resolve(true).(type) {
+	case *StructType:
+		checker.error(NewError(
+			e.Selector.SourceRange(),
+			"field '%v' cannot be found in struct '%v'",
+			e.Selector.Token.Value(),
+			baseType.Type,
+		))
 	case *VectorType:
 		return checker.resolveVectorSwizzle(e, t)
 	default:
-		checker.error(NewError(e.SourceRange(), "type '%v' does not support selector expr", baseType.Type))
+		if _, ok := baseType.Type.Resolve(false).(*StrongAliasType); ok && baseType.IsValue() {
+			checker.error(NewError(
+				e.Selector.SourceRange(),
+				"method '%v' cannot be found in type '%v'",
+				e.Selector.Token.Value(),
+				baseType.Type,
+			))
+		} else {
+			checker.error(NewError(e.SourceRange(), "type '%v' does not support selector expr", baseType.Type))
+		}
 	}
 	return invalidResult
 }
 
+// resolveMethodExpr resolves T.Method which is a function taking the receiver as its first parameter, only value
+// receivers are supported since there's no (*T).Method syntax to name pointer receiver methods
+func (checker *Checker) resolveMethodExpr(e *SelectorExpr, receiverType Type, selection *Selection) *TypeAndValue {
+	invalidResult := &TypeAndValue{
+		Mode: AddressModeInvalid,
+		Type: BuiltinVoidType,
+	}
+
+	if selection == nil || selection.Method == nil {
+		checker.error(NewError(
+			e.Selector.SourceRange(),
+			"method '%v' cannot be found in type '%v'",
+			e.Selector.Token.Value(),
+			receiverType,
+		))
+		return invalidResult
+	}
+
+	method := selection.Method
+	if !isExported(method.Name()) && packageScopeOf(method.Scope()) != packageScopeOf(checker.currentScope()) {
+		checker.error(NewError(
+			e.Selector.SourceRange(),
+			"cannot refer to unexported method '%v' of type '%v'",
+			method.Name(),
+			receiverType,
+		))
+		return invalidResult
+	}
+	if method.HasPointerReceiver() {
+		checker.error(NewError(e.SourceRange(), "method expressions are not supported for pointer receivers").
+			Note(e.Selector.SourceRange(), "method '%v' has receiver '*%v'", method.Name(), method.Receiver.Name()))
+		return invalidResult
+	}
+
+	checker.unit.semanticInfo.Selections[e] = selection
+	checker.unit.semanticInfo.SetSymbolOfIdentifier(e.Selector, method)
+	checker.unit.semanticInfo.addUse(method)
+	methodType, ok := checker.resolveSymbol(method).Type.(*FuncType)
+	if !ok {
+		return invalidResult
+	}
+	parameterTypes := append([]Type{receiverType}, methodType.ParameterTypes...)
+	return &TypeAndValue{
+		Mode: AddressModeComputedValue,
+		Type: checker.unit.semanticInfo.TypeInterner.InternFuncType(parameterTypes, methodType.ReturnTypes),
+	}
+}
+
 func (checker *Checker) resolveVectorSwizzle(e *SelectorExpr, base *VectorType) *TypeAndValue {
 	isValidSwizzle := func(swizzle string, numComponents int) bool {
 		if len(swizzle) == 0 {
@@ -830,6 +2051,28 @@ func (checker *Checker) resolveBinaryExpr(e *BinaryExpr) *TypeAndValue {
 		Type: BuiltinVoidType,
 	}
 
+	// the errors of invalid operands are already reported
+	if lhsType.Mode == AddressModeInvalid || rhsType.Mode == AddressModeInvalid {
+		return invalidResult
+	}
+
+	// logical addressing doesn't allow any operation on the pointer itself
+	if isPointer(lhsType.Type) || isPointer(rhsType.Type) {
+		checker.error(NewError(e.SourceRange(), "operator %v is not allowed on pointers", e.Operator.Kind()))
+		return invalidResult
+	}
+
+	switch e.Operator.Kind() {
+	case TokenShl, TokenShr:
+		// constant shifts are folded without giving a type to their untyped operands
+		if lhsType.Mode != AddressModeConstant || rhsType.Mode != AddressModeConstant {
+			lhsType = checker.convertUntyped(e.LHS, lhsType, nil)
+			rhsType = checker.convertUntyped(e.RHS, rhsType, nil)
+		}
+	default:
+		lhsType, rhsType = checker.convertUntypedOperands(e, lhsType, rhsType)
+	}
+
 	isVectorType := func(t Type) (*VectorType, bool) {
 		if vt, ok := t.Resolve(true).(*VectorType); ok {
 			return vt, true
@@ -848,6 +2091,12 @@ func (checker *Checker) resolveBinaryExpr(e *BinaryExpr) *TypeAndValue {
 		vecWidth = rhsVecType.Width
 	}
 
+	// operations between a scalar and a vector result in the vector type
+	resultType := lhsType.Type
+	if rhsIsVec && !lhsIsVec {
+		resultType = rhsType.Type
+	}
+
 	vectorBooleanByWidth := func(width int) Type {
 		switch width {
 		case 1:
@@ -863,6 +2112,14 @@ func (checker *Checker) resolveBinaryExpr(e *BinaryExpr) *TypeAndValue {
 		}
 	}
 
+	// comparing untyped constants results in an untyped bool
+	compareResultType := func() Type {
+		if isUntyped(lhsType.Type) && isUntyped(rhsType.Type) {
+			return BuiltinUntypedBoolType
+		}
+		return vectorBooleanByWidth(vecWidth)
+	}
+
 	checkIsCompatibleTypes := func(e Expr, lhsType, rhsType Type) bool {
 		incompatible := false
 		if lhsIsVec && rhsIsVec {
@@ -911,37 +2168,39 @@ func (checker *Checker) resolveBinaryExpr(e *BinaryExpr) *TypeAndValue {
 		if checkIsCompatibleTypes(e, lhsType.Type, rhsType.Type) &&
 			hasTypeProperty(e.LHS, lhsType.Type, lhsType.Type.Properties().HasBitOps, "bitwise operations") &&
 			hasTypeProperty(e.RHS, rhsType.Type, rhsType.Type.Properties().HasBitOps, "bitwise operations") {
-			return lhsType.BinaryOpWithType(e.Operator.Kind(), rhsType, lhsType.Type)
+			return checker.checkConstantOverflow(e, lhsType.BinaryOpWithType(e.Operator.Kind(), rhsType, resultType))
 		}
 	case TokenAdd, TokenSub, TokenMul, TokenDiv:
 		if checkIsCompatibleTypes(e, lhsType.Type, rhsType.Type) &&
 			hasTypeProperty(e.LHS, lhsType.Type, lhsType.Type.Properties().HasArithmetic, "arithmetic operations") &&
-			hasTypeProperty(e.RHS, rhsType.Type, rhsType.Type.Properties().HasArithmetic, "arithmetic operations") {
-			return lhsType.BinaryOpWithType(e.Operator.Kind(), rhsType, lhsType.Type)
+			hasTypeProperty(e.RHS, rhsType.Type, rhsType.Type.Properties().HasArithmetic, "arithmetic operations") &&
+			(e.Operator.Kind() != TokenDiv || checker.checkConstantDivisor(lhsType, e.RHS, rhsType)) {
+			return checker.checkConstantOverflow(e, lhsType.BinaryOpWithType(e.Operator.Kind(), rhsType, resultType))
 		}
 	case TokenMod:
 		if checkIsCompatibleTypes(e, lhsType.Type, rhsType.Type) &&
 			hasTypeProperty(e.LHS, lhsType.Type, lhsType.Type.Properties().HasModulus, "modulus operations") &&
-			hasTypeProperty(e.RHS, rhsType.Type, rhsType.Type.Properties().HasModulus, "modulus operations") {
-			return lhsType.BinaryOpWithType(e.Operator.Kind(), rhsType, lhsType.Type)
+			hasTypeProperty(e.RHS, rhsType.Type, rhsType.Type.Properties().HasModulus, "modulus operations") &&
+			checker.checkConstantDivisor(lhsType, e.RHS, rhsType) {
+			return checker.checkConstantOverflow(e, lhsType.BinaryOpWithType(e.Operator.Kind(), rhsType, resultType))
 		}
 	case TokenLOr, TokenLAnd:
 		if checkIsCompatibleTypes(e, lhsType.Type, rhsType.Type) &&
 			hasTypeProperty(e.LHS, lhsType.Type, lhsType.Type.Properties().HasLogicOps, "logic operations") &&
 			hasTypeProperty(e.RHS, rhsType.Type, rhsType.Type.Properties().HasLogicOps, "logic operations") {
-			return lhsType.BinaryOpWithType(e.Operator.Kind(), rhsType, lhsType.Type)
+			return lhsType.BinaryOpWithType(e.Operator.Kind(), rhsType, resultType)
 		}
 	case TokenLT, TokenGT, TokenLE, TokenGE:
 		if checkIsCompatibleTypes(e, lhsType.Type, rhsType.Type) &&
 			hasTypeProperty(e.LHS, lhsType.Type, lhsType.Type.Properties().HasCompare, "compare operations") &&
 			hasTypeProperty(e.RHS, rhsType.Type, rhsType.Type.Properties().HasCompare, "compare operations") {
-			return lhsType.CompareWithType(e.Operator.Kind(), rhsType, vectorBooleanByWidth(vecWidth))
+			return lhsType.CompareWithType(e.Operator.Kind(), rhsType, compareResultType())
 		}
 	case TokenEQ, TokenNE:
 		if checkIsCompatibleTypes(e, lhsType.Type, rhsType.Type) &&
 			hasTypeProperty(e.LHS, lhsType.Type, lhsType.Type.Properties().HasEquality, "equality operations") &&
 			hasTypeProperty(e.RHS, rhsType.Type, rhsType.Type.Properties().HasEquality, "equality operations") {
-			return lhsType.CompareWithType(e.Operator.Kind(), rhsType, vectorBooleanByWidth(vecWidth))
+			return lhsType.CompareWithType(e.Operator.Kind(), rhsType, compareResultType())
 		}
 	case TokenShl, TokenShr:
 		if !rhsType.Type.Properties().Integral {
@@ -962,9 +2221,15 @@ func (checker *Checker) resolveBinaryExpr(e *BinaryExpr) *TypeAndValue {
 			return invalidResult
 		}
 
-		if hasTypeProperty(e.LHS, lhsType.Type, lhsType.Type.Properties().HasBitOps, "bitwise operations") {
-			return lhsType.ShiftWithType(e.Operator.Kind(), rhsType, lhsType.Type)
+		if !hasTypeProperty(e.LHS, lhsType.Type, lhsType.Type.Properties().HasBitOps, "bitwise operations") {
+			return invalidResult
+		}
+
+		if !checker.checkShiftWidth(lhsType.Type, e.RHS, rhsType) {
+			return invalidResult
 		}
+
+		return checker.checkConstantOverflow(e, lhsType.ShiftWithType(e.Operator.Kind(), rhsType, lhsType.Type))
 	default:
 		panic("unexpected binary operator")
 	}
@@ -972,16 +2237,47 @@ func (checker *Checker) resolveBinaryExpr(e *BinaryExpr) *TypeAndValue {
 }
 
 func (checker *Checker) resolveNamedTypeExpr(e *NamedTypeExpr) *TypeAndValue {
-	if e.Package.valid() {
-		panic("we don't support packages yet")
+	if e.IsPackageQualified() {
+		pkgSym := checker.findPackageSymbol(e.Package.Value())
+		if pkgSym == nil {
+			checker.error(NewError(e.SourceRange(), "undeclared package '%v'", e.Package.Value()))
+			return &TypeAndValue{
+				Mode: AddressModeInvalid,
+				Type: BuiltinVoidType,
+			}
+		}
+		checker.unit.semanticInfo.addUse(pkgSym)
+
+		typeSym := checker.findQualifiedSymbol(pkgSym, e.TypeName)
+		if typeSym == nil {
+			return &TypeAndValue{
+				Mode: AddressModeInvalid,
+				Type: BuiltinVoidType,
+			}
+		}
+		checker.unit.semanticInfo.addUse(typeSym)
+		return checker.resolveSymbol(typeSym)
 	}
 	scope := checker.currentScope()
 	if typeSym := scope.Find(e.TypeName.Value()); typeSym != nil {
+		if _, ok := typeSym.(*PackageSymbol); ok {
+			checker.error(NewError(e.SourceRange(), "use of package '%v' without selector", e.TypeName.Value()))
+			return &TypeAndValue{
+				Mode: AddressModeInvalid,
+				Type: BuiltinVoidType,
+			}
+		}
+		checker.unit.semanticInfo.addUse(typeSym)
 		return checker.resolveSymbol(typeSym)
 	}
+	t := typeFromName(e.TypeName)
+	if isTexture(t) && e != checker.paramTypeExpr {
+		checker.error(NewError(e.SourceRange(), "texture types are only allowed for function parameters"))
+		t = BuiltinVoidType
+	}
 	return &TypeAndValue{
 		Mode:  AddressModeType,
-		Type:  typeFromName(e.TypeName),
+		Type:  t,
 		Value: nil,
 	}
 }
@@ -1009,6 +2305,27 @@ func (checker *Checker) resolveUnaryExpr(e *UnaryExpr) *TypeAndValue {
 	}
 
 	switch e.Operator.Kind() {
+	case TokenMul:
+		if t.Mode == AddressModeInvalid {
+			return invalidResult
+		}
+		pointerType, ok := t.Type.Resolve(false).(*PointerType)
+		if !ok || !t.IsValue() {
+			checker.error(NewError(e.Base.SourceRange(), "cannot dereference non-pointer type '%v'", t.Type))
+			return invalidResult
+		}
+		return &TypeAndValue{
+			Mode: AddressModeVariable,
+			Type: pointerType.ElementType,
+		}
+	case TokenAnd:
+		if !checker.checkCanTakeAddress(e.Base, t) {
+			return invalidResult
+		}
+		return &TypeAndValue{
+			Mode: AddressModeComputedValue,
+			Type: checker.unit.semanticInfo.TypeInterner.InternPointerType(t.Type),
+		}
 	case TokenAdd:
 		fallthrough
 	case TokenSub:
@@ -1027,10 +2344,123 @@ func (checker *Checker) resolveUnaryExpr(e *UnaryExpr) *TypeAndValue {
 		panic("invalid unary operator")
 	}
 
-	return t.UnaryOp(e.Operator.Kind())
+	return checker.checkConstantOverflow(e, t.UnaryOp(e.Operator.Kind()))
+}
+
+// checkCanTakeAddress checks that the expression refers to function local memory, pointers to other memory can't be
+// passed to functions under SPIR-V logical addressing
+func (checker *Checker) checkCanTakeAddress(e Expr, t *TypeAndValue) bool {
+	if !t.IsAddressable() {
+		checker.error(NewError(e.SourceRange(), "cannot take the address of a non addressable expression"))
+		return false
+	}
+
+	sym := checker.rootVariableOf(e)
+	if sym == nil {
+		return true
+	}
+
+	if sym.IsParam {
+		checker.error(
+			NewError(e.SourceRange(), "cannot take the address of parameter '%v'", sym.Name()).
+				Note(sym.SourceRange(), "parameters are passed by value, copy it into a local variable first"),
+		)
+		return false
+	}
+
+	if checker.isPackageLevel(sym) {
+		checker.error(
+			NewError(e.SourceRange(), "cannot take the address of package level variable '%v'", sym.Name()).
+				Note(sym.SourceRange(), "only function local variables can be pointed to"),
+		)
+		return false
+	}
+
+	return true
+}
+
+// rootVariableOf returns the variable which holds the memory of the given expression, or nil if the memory is
+// reached through a pointer
+func (checker *Checker) rootVariableOf(e Expr) *VarSymbol {
+	switch n := e.(type) {
+	case *IdentifierExpr:
+		sym, _ := checker.unit.semanticInfo.SymbolOfIdentifier(n).(*VarSymbol)
+		return sym
+	case *ParenExpr:
+		return checker.rootVariableOf(n.Base)
+	case *SelectorExpr:
+		if sym, ok := checker.unit.semanticInfo.SymbolOfIdentifier(n.Selector).(*VarSymbol); ok {
+			return sym
+		}
+		if isPointer(checker.unit.semanticInfo.TypeOf(n.Base).Type) {
+			return nil
+		}
+		return checker.rootVariableOf(n.Base)
+	case *IndexExpr:
+		return checker.rootVariableOf(n.Base)
+	default:
+		return nil
+	}
+}
+
+func isBlankIdentifier(e Expr) bool {
+	ident, ok := e.(*IdentifierExpr)
+	return ok && ident.Token.Value() == "_"
+}
+
+// recordWrite is called on assignment targets, assigning to a variable doesn't count as using it and assigning
+// to package level variables or through pointers is a side effect of the current function
+func (checker *Checker) recordWrite(lhs Expr) {
+	if ident, ok := lhs.(*IdentifierExpr); ok {
+		if sym := checker.unit.semanticInfo.SymbolOfIdentifier(ident); sym != nil {
+			checker.unit.semanticInfo.removeUse(sym)
+		}
+	}
+
+	function := checker.currentFunction()
+	if function == nil {
+		return
+	}
+	root := checker.rootVariableOf(lhs)
+	if root == nil || checker.isPackageLevel(root) {
+		checker.sideEffects[function] = true
+	}
+	if _, ok := checker.globalWrites[root]; root != nil && checker.isPackageLevel(root) && !ok {
+		checker.globalWrites[root] = lhs
+	}
+}
+
+// convertUntypedOperands converts the untyped operand to the type of the other operand, or to the element type if
+// the other operand is a vector. if both operands are untyped they're converted to the larger kind
+func (checker *Checker) convertUntypedOperands(e *BinaryExpr, lhs, rhs *TypeAndValue) (*TypeAndValue, *TypeAndValue) {
+	operandType := func(t Type) Type {
+		if vectorType, ok := t.Resolve(true).(*VectorType); ok {
+			return vectorType.UnderlyingType
+		}
+		return t
+	}
+
+	lhsUntyped, rhsUntyped := isUntyped(lhs.Type), isUntyped(rhs.Type)
+	switch {
+	case lhsUntyped && rhsUntyped:
+		if lhs.Type.(*UntypedType).Kind < rhs.Type.(*UntypedType).Kind {
+			lhs = checker.convertUntyped(e.LHS, lhs, rhs.Type)
+		} else {
+			rhs = checker.convertUntyped(e.RHS, rhs, lhs.Type)
+		}
+	case lhsUntyped:
+		lhs = checker.convertUntyped(e.LHS, lhs, operandType(rhs.Type))
+	case rhsUntyped:
+		rhs = checker.convertUntyped(e.RHS, rhs, operandType(lhs.Type))
+	}
+	return lhs, rhs
 }
 
 func (checker *Checker) resolveCallExpr(e *CallExpr) *TypeAndValue {
+	if builtin := checker.builtinOf(e.Base); builtin != BuiltinFuncNone {
+		return checker.resolveBuiltinCall(e, builtin)
+	}
+
 	t := checker.resolveExpr(e.Base)
 
 	res := &TypeAndValue{
@@ -1039,39 +2469,665 @@ func (checker *Checker) resolveCallExpr(e *CallExpr) *TypeAndValue {
 		Value: nil,
 	}
 
+	if isConversion(t) {
+		return checker.resolveConversion(e, t.Type)
+	}
+
 	funcType, ok := t.Type.(*FuncType)
-	if !ok {
+	if !ok && t.Mode == AddressModeInvalid {
+		// the callee was already reported
+		return res
+	} else if !ok {
 		checker.error(NewError(e.SourceRange(), "invalid call expression, expected function type but found '%v'", t.Type))
 		return res
 	}
 
-	arguments, sourceRanges := checker.resolveAndUnpackTypesFromExprList(e.Args)
-	if len(arguments) != len(funcType.ParameterTypes) {
-		argumentTypes := make([]Type, len(arguments))
-		for i, a := range arguments {
-			argumentTypes[i] = a.Type
-		}
-		checker.error(NewError(e.SourceRange(), "expected %v arguments, but found %v", len(funcType.ParameterTypes), len(e.Args)).
-			Note(e.SourceRange(), "have %v, want %v", TupleType{Types: argumentTypes}, TupleType{Types: funcType.ParameterTypes}),
-		)
-		return res
+	callee := checker.calleeOf(e.Base)
+	if callee != nil && checker.currentFunction() != nil {
+		checker.unit.semanticInfo.addCall(&Call{Caller: checker.currentFunction(), Callee: callee, Expr: e})
+	}
+	// there are no function pointers, so every function value is resolved to a function at compile time
+	if callee == nil && !checker.isFuncParam(e.Base) {
+		switch unparen(e.Base).(type) {
+		case *CallExpr, *IdentifierExpr:
+			// function values of variables and call results are reported where they're stored and where the functions
+			// returning them are declared
+		default:
+			checker.error(NewError(e.Base.SourceRange(), "function value can't be resolved at compile time").
+				Note(e.Base.SourceRange(), "only functions and parameters of function type can be called"))
+		}
+	}
+
+	arguments, sourceRanges := checker.resolveAndUnpackTypesFromExprList(e.Args)
+	if len(arguments) == len(e.Args) {
+		for i, a := range arguments {
+			if arguments[i] = checker.instantiateFuncValue(e.Args[i], a); arguments[i] == nil {
+				return res
+			}
+		}
+	}
+	if funcType.IsGeneric() && len(arguments) == len(funcType.ParameterTypes) {
+		funcType = checker.instantiate(e, funcType, checker.typeArgLists[unparen(e.Base)], arguments, sourceRanges)
+		if funcType == nil {
+			return res
+		}
+	}
+
+	if len(arguments) != len(funcType.ParameterTypes) {
+		argumentTypes := make([]Type, len(arguments))
+		for i, a := range arguments {
+			argumentTypes[i] = a.Type
+		}
+		checker.error(NewError(e.SourceRange(), "expected %v arguments, but found %v", len(funcType.ParameterTypes), len(e.Args)).
+			Note(e.SourceRange(), "have %v, want %v", TupleType{Types: argumentTypes}, TupleType{Types: funcType.ParameterTypes}),
+		)
+		return res
+	}
+
+	for i := range arguments {
+		parameterType := funcType.ParameterTypes[i]
+		a := checker.convertUntypedAt(e.Args, arguments, i, parameterType)
+		if !a.Type.Equal(parameterType) {
+			checker.error(NewError(sourceRanges[i], "incorrect argument type '%v', expected '%v'", a.Type, parameterType))
+			return res
+		}
+		if isFunc(parameterType) {
+			checker.checkFuncArg(e, callee, e.Args[i])
+		}
+		arguments[i] = a
+	}
+
+	// calls of pure functions with constant arguments are evaluated in constant declarations
+	if checker.iota != nil && callee != nil {
+		if value := checker.evalConstantCall(e, callee, funcType, arguments); value != nil {
+			return value
+		}
+	}
+
+	res.Mode = AddressModeComputedValue
+	if len(funcType.ReturnTypes) == 1 {
+		res.Type = funcType.ReturnTypes[0]
+	} else if len(funcType.ReturnTypes) > 1 {
+		res.Type = checker.unit.semanticInfo.TypeInterner.InternTupleType(funcType.ReturnTypes)
+	}
+	return res
+}
+
+// checkFuncArg checks that the function value passed to a parameter of function type is known at compile time, the
+// callee is specialized for each function passed to it
+func (checker *Checker) checkFuncArg(e *CallExpr, callee *FuncSymbol, value Expr) {
+	if checker.isFuncParam(value) || checker.funcOfValue(value) != nil {
+		if checker.currentFunction() != nil {
+			checker.funcArgs = append(checker.funcArgs, &funcArg{
+				caller: checker.currentFunction(),
+				callee: callee,
+				value:  value,
+				expr:   e,
+			})
+		}
+		return
+	}
+	if method := checker.calleeOf(value); method != nil && method.IsMethod() && checker.isTypeSelector(value) {
+		checker.error(NewError(value.SourceRange(), "promoted method '%v' can't be used as a function value", method.Name()).
+			Note(value.SourceRange(), "use the method expression of type '%v' which declares it", method.Receiver.Name()))
+		return
+	}
+	checker.error(NewError(value.SourceRange(), "function value can't be resolved at compile time").
+		Note(value.SourceRange(), "only functions and parameters of function type can be passed as function values"))
+}
+
+// funcOfValue returns the function referred to by the function value, nil if it doesn't name a function. methods
+// selected on values aren't function values since they're bound to their receivers, method expressions are since
+// they take the receiver as their first argument. generic functions are function values once instantiated
+func (checker *Checker) funcOfValue(value Expr) *FuncSymbol {
+	function := checker.calleeOf(value)
+	if function == nil {
+		return nil
+	}
+	if function.IsMethod() && !checker.isMethodExpr(value) {
+		return nil
+	}
+	funcType, ok := checker.unit.semanticInfo.TypeOf(function).Type.(*FuncType)
+	if !ok || (funcType.IsGeneric() && checker.unit.semanticInfo.InstanceOf(unparen(value)) == nil) {
+		return nil
+	}
+	return function
+}
+
+// isMethodExpr returns whether the expression selects a method declared on the type it's selected from, promoted
+// methods need their receiver to be selected from the embedded field so they can't be used as function values
+func (checker *Checker) isMethodExpr(e Expr) bool {
+	if !checker.isTypeSelector(e) {
+		return false
+	}
+	selection := checker.unit.semanticInfo.SelectionOf(unparen(e).(*SelectorExpr))
+	return selection != nil && selection.Method != nil && len(selection.Path) == 0
+}
+
+// isTypeSelector returns whether the expression selects a method from a type instead of a value
+func (checker *Checker) isTypeSelector(e Expr) bool {
+	selector, ok := unparen(e).(*SelectorExpr)
+	return ok && checker.unit.semanticInfo.TypeOf(selector.Base).IsType()
+}
+
+// instantiateFuncValue instantiates the generic function used as a function value with its explicit type arguments,
+// there are no call arguments to infer the type arguments left out from. it returns nil if it can't be instantiated
+func (checker *Checker) instantiateFuncValue(e Expr, value *TypeAndValue) *TypeAndValue {
+	funcType, ok := value.Type.(*FuncType)
+	if !ok || !funcType.IsGeneric() {
+		return value
+	}
+
+	var typeArgs []Type
+	explicit := checker.typeArgLists[unparen(e)]
+	if explicit != nil {
+		typeArgs = explicit.types
+	}
+	for i, typeParam := range funcType.TypeParams {
+		if i >= len(typeArgs) {
+			checker.error(
+				NewError(e.SourceRange(), "cannot infer type argument for type parameter '%v'", typeParam).
+					Note(e.SourceRange(), "type arguments of function values must be given explicitly").
+					Note(typeParam.Identifier.SourceRange(), "type parameter declared here"),
+			)
+			return nil
+		}
+		if !typeParam.Constraint.SatisfiedBy(typeArgs[i]) {
+			checker.error(
+				NewError(explicit.exprs[i].SourceRange(), "type '%v' doesn't satisfy constraint '%v' of type parameter '%v'", typeArgs[i], typeParam.Constraint, typeParam).
+					Note(typeParam.Identifier.SourceRange(), "type parameter declared here"),
+			)
+			return nil
+		}
+	}
+
+	checker.unit.semanticInfo.SetInstanceOf(unparen(e), &Instance{TypeArgs: typeArgs})
+	res := &TypeAndValue{
+		Mode: value.Mode,
+		Type: checker.unit.semanticInfo.TypeInterner.Instantiate(funcType, typeArgs),
+	}
+	checker.unit.semanticInfo.SetTypeOf(e, res)
+	return res
+}
+
+// isFuncParam returns whether the expression refers to a parameter of function type, they're bound to the functions
+// passed by the callers
+func (checker *Checker) isFuncParam(e Expr) bool {
+	switch v := e.(type) {
+	case *IdentifierExpr:
+		sym, ok := checker.unit.semanticInfo.SymbolOfIdentifier(v).(*VarSymbol)
+		return ok && sym.IsParam && isFunc(checker.unit.semanticInfo.TypeOf(sym).Type)
+	case *ParenExpr:
+		return checker.isFuncParam(v.Base)
+	default:
+		return false
+	}
+}
+
+// bindFuncArgs adds the calls made through parameters of function type to the call graph, a function passed to a
+// parameter may be called by the callee or passed on to other functions so the functions are bound to the callees
+// until nothing changes
+func (checker *Checker) bindFuncArgs() {
+	bindings := make(map[*FuncSymbol][]*Call)
+	functionsBoundTo := func(function *FuncSymbol) (res []*FuncSymbol) {
+		for _, call := range bindings[function] {
+			res = append(res, call.Callee)
+		}
+		return
+	}
+
+	var calls []*Call
+	for changed := true; changed; {
+		changed = false
+		for _, arg := range checker.funcArgs {
+			values := functionsBoundTo(arg.caller)
+			if !checker.isFuncParam(arg.value) {
+				values = []*FuncSymbol{checker.funcOfValue(arg.value)}
+			}
+			callees := functionsBoundTo(arg.caller)
+			if arg.callee != nil {
+				callees = []*FuncSymbol{arg.callee}
+			}
+			for _, callee := range callees {
+				for _, value := range values {
+					if slices.ContainsFunc(bindings[callee], func(c *Call) bool { return c.Callee == value }) {
+						continue
+					}
+					call := &Call{Caller: callee, Callee: value, Expr: arg.expr, Value: arg.value}
+					bindings[callee] = append(bindings[callee], call)
+					calls = append(calls, call)
+					changed = true
+				}
+			}
+		}
+	}
+
+	for _, call := range calls {
+		checker.unit.semanticInfo.addCall(call)
+	}
+	checker.boundFuncs = bindings
+}
+
+// calleeOf returns the function symbol called by the given call base expression, or nil if it's not a direct call
+func (checker *Checker) calleeOf(base Expr) *FuncSymbol {
+	switch b := base.(type) {
+	case *IdentifierExpr:
+		sym, _ := checker.unit.semanticInfo.SymbolOfIdentifier(b).(*FuncSymbol)
+		return sym
+	case *SelectorExpr:
+		sym, _ := checker.unit.semanticInfo.SymbolOfIdentifier(b.Selector).(*FuncSymbol)
+		return sym
+	case *ParenExpr:
+		return checker.calleeOf(b.Base)
+	case *IndexExpr:
+		// generic functions instantiated with explicit type arguments
+		if _, ok := checker.typeArgLists[b]; ok {
+			return checker.calleeOf(b.Base)
+		}
+		return nil
+	case *IndexListExpr:
+		if _, ok := checker.typeArgLists[b]; ok {
+			return checker.calleeOf(b.Base)
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// unparen returns the expression inside any number of parentheses
+func unparen(e Expr) Expr {
+	for paren, ok := e.(*ParenExpr); ok; paren, ok = e.(*ParenExpr) {
+		e = paren.Base
+	}
+	return e
+}
+
+// instantiate infers the type arguments of the generic function which aren't given explicitly from the call
+// arguments and returns the instantiated function type
+func (checker *Checker) instantiate(e *CallExpr, funcType *FuncType, explicit *typeArgList, arguments []*TypeAndValue, sourceRanges []SourceRange) *FuncType {
+	typeArgsByParam := make(map[*TypeParamType]Type)
+	if explicit != nil {
+		for i, t := range explicit.types {
+			typeArgsByParam[funcType.TypeParams[i]] = t
+		}
+	}
+
+	var unify func(param, arg Type) (*TypeParamType, Type)
+	unify = func(param, arg Type) (*TypeParamType, Type) {
+		switch p := param.(type) {
+		case *TypeParamType:
+			if explicit != nil && slices.Index(funcType.TypeParams, p) < len(explicit.types) {
+				// arguments of explicit type parameters are checked against the instantiated function
+				return nil, nil
+			}
+			if inferred, ok := typeArgsByParam[p]; ok {
+				if !inferred.Equal(arg) {
+					return p, inferred
+				}
+				return nil, nil
+			}
+			typeArgsByParam[p] = arg
+		case *ArrayType:
+			if a, ok := arg.Resolve(false).(*ArrayType); ok && a.Length == p.Length {
+				return unify(p.ElementType, a.ElementType)
+			}
+		}
+		// other mismatches are reported when the arguments are checked against the instantiated function
+		return nil, nil
+	}
+
+	for i, a := range arguments {
+		if a.Mode == AddressModeInvalid {
+			return nil
+		}
+		// untyped constants are inferred after typed arguments, and only if nothing else inferred their parameter
+		if isUntyped(a.Type) {
+			continue
+		}
+		if typeParam, inferred := unify(funcType.ParameterTypes[i], a.Type); typeParam != nil {
+			checker.error(NewError(sourceRanges[i], "type '%v' of argument doesn't match type '%v' inferred for type parameter '%v'", a.Type, inferred, typeParam))
+			return nil
+		}
+	}
+
+	// type parameters only inferred from untyped constants take the default type of the largest kind
+	untypedArgsByParam := make(map[*TypeParamType]*UntypedType)
+	for i, a := range arguments {
+		typeParam, ok := funcType.ParameterTypes[i].(*TypeParamType)
+		if !ok || !isUntyped(a.Type) {
+			continue
+		}
+		if _, inferred := typeArgsByParam[typeParam]; inferred {
+			continue
+		}
+		if prev, ok := untypedArgsByParam[typeParam]; !ok || prev.Kind < a.Type.(*UntypedType).Kind {
+			untypedArgsByParam[typeParam] = a.Type.(*UntypedType)
+		}
+	}
+	for typeParam, untypedType := range untypedArgsByParam {
+		typeArgsByParam[typeParam] = untypedType.Default()
+	}
+
+	typeArgs := make([]Type, len(funcType.TypeParams))
+	for i, typeParam := range funcType.TypeParams {
+		typeArg, ok := typeArgsByParam[typeParam]
+		if !ok {
+			checker.error(
+				NewError(e.SourceRange(), "cannot infer type argument for type parameter '%v'", typeParam).
+					Note(typeParam.Identifier.SourceRange(), "type parameter declared here"),
+			)
+			return nil
+		}
+
+		if !typeParam.Constraint.SatisfiedBy(typeArg) {
+			sourceRange := e.SourceRange()
+			if explicit != nil && i < len(explicit.exprs) {
+				sourceRange = explicit.exprs[i].SourceRange()
+			}
+			checker.error(
+				NewError(sourceRange, "type '%v' doesn't satisfy constraint '%v' of type parameter '%v'", typeArg, typeParam.Constraint, typeParam).
+					Note(typeParam.Identifier.SourceRange(), "type parameter declared here"),
+			)
+			return nil
+		}
+		typeArgs[i] = typeArg
+	}
+
+	instance := checker.unit.semanticInfo.TypeInterner.Instantiate(funcType, typeArgs)
+	checker.unit.semanticInfo.SetInstanceOf(e, &Instance{TypeArgs: typeArgs})
+	// the callee has the type of the instantiated function, the same way it would if it wasn't generic
+	checker.unit.semanticInfo.SetTypeOf(e.Base, &TypeAndValue{
+		Mode: AddressModeType,
+		Type: instance,
+	})
+	return instance
+}
+
+// isConversion returns true if calling base is a type conversion, function symbols resolve to their
+// function type so they're excluded
+func isConversion(base *TypeAndValue) bool {
+	_, isFunc := base.Type.(*FuncType)
+	return base.IsType() && !isFunc
+}
+
+func isNumericScalar(t Type) bool {
+	switch x := t.Resolve(true).(type) {
+	case *IntType, *UintType, *Float32Type, *Float64Type:
+		return true
+	case *TypeParamType:
+		return x.Constraint.IsNumericScalar()
+	default:
+		return false
+	}
+}
+
+// isConvertible reports whether values of type from can be converted to type to, textures only come from the
+// resources bound to the entry points so nothing converts to them
+func isConvertible(from, to Type) bool {
+	if isTexture(to) {
+		return false
+	}
+	if from.Resolve(true).Equal(to.Resolve(true)) {
+		return true
+	}
+	return isNumericScalar(from) && isNumericScalar(to)
+}
+
+func (checker *Checker) resolveConversion(e *CallExpr, t Type) *TypeAndValue {
+	res := &TypeAndValue{
+		Mode:  AddressModeInvalid,
+		Type:  BuiltinVoidType,
+		Value: nil,
+	}
+
+	if len(e.Args) != 1 {
+		checker.error(NewError(e.SourceRange(), "conversion to type '%v' expects exactly one argument, but found %v", t, len(e.Args)))
+		return res
+	}
+
+	arg := checker.resolveExpr(e.Args[0])
+	if !arg.IsValue() {
+		checker.error(NewError(e.Args[0].SourceRange(), "expected a value to convert to type '%v'", t))
+		return res
+	}
+
+	// untyped constants are converted from their value, they only take their default type if the conversion isn't
+	// folded
+	argType := arg.Type
+	if untypedType, ok := argType.(*UntypedType); ok {
+		argType = untypedType.Default()
+	}
+	if !isConvertible(argType, t) {
+		checker.error(NewError(e.SourceRange(), "cannot convert '%v' to type '%v'", arg.Type, t))
+		return res
+	}
+
+	res.Type = t
+	// conversions to type parameters are folded when the function is instantiated
+	_, isTypeParam := t.(*TypeParamType)
+	if arg.Mode != AddressModeConstant || isTypeParam {
+		checker.convertUntyped(e.Args[0], arg, nil)
+		res.Mode = AddressModeComputedValue
+		return res
+	}
+
+	value, ok := convertConstant(arg.Value, t)
+	if !ok {
+		checker.error(NewError(e.SourceRange(), "cannot convert negative constant '%v' to unsigned type '%v'", arg.Value, t))
+		return &TypeAndValue{
+			Mode: AddressModeInvalid,
+			Type: BuiltinVoidType,
+		}
+	}
+	res.Mode = AddressModeConstant
+	res.Value = value
+	return res
+}
+
+// convertConstant converts the constant value to the given type, it fails if the value is negative and the
+// type is unsigned
+func convertConstant(value constant.Value, t Type) (constant.Value, bool) {
+	props := t.Properties()
+	switch {
+	case props.Integral:
+		if value.Kind() == constant.Float {
+			f, _ := constant.Float64Val(value)
+			value = constant.MakeInt64(int64(f))
+		}
+		if !props.Signed && constant.Sign(value) < 0 {
+			return nil, false
+		}
+		return value, true
+	case props.Floating:
+		return roundFloat(value, t), true
+	default:
+		return value, true
+	}
+}
+
+// convertUntyped converts the untyped constant expression to the target type, or to its default type if the target
+// is nil, the converted type is recorded for the expression so that it's emitted with its final type. typed values
+// and untyped constants which can't be converted to the target are returned as is, and reported by the caller as
+// a type mismatch
+func (checker *Checker) convertUntyped(e Expr, t *TypeAndValue, target Type) *TypeAndValue {
+	untypedType, ok := t.Type.(*UntypedType)
+	if !ok || t.Mode != AddressModeConstant {
+		return t
+	}
+	if target == nil {
+		target = untypedType.Default()
+	}
+
+	res := &TypeAndValue{
+		Mode:  AddressModeConstant,
+		Type:  target,
+		Value: t.Value,
+	}
+
+	switch targetType := target.Resolve(true).(type) {
+	case *UntypedType:
+		// untyped operands of the same expression are converted to the larger kind
+		if targetType.Kind == UntypedKindBool || untypedType.Kind == UntypedKindBool {
+			return t
+		}
+		if targetType.Kind == UntypedKindFloat {
+			res.Value = constant.ToFloat(t.Value)
+		}
+		return res
+	case *BoolType:
+		if untypedType.Kind != UntypedKindBool {
+			return t
+		}
+	case *TypeParamType:
+		// the value is converted to the type argument when the function is instantiated
+		if untypedType.Kind == UntypedKindBool || !targetType.Constraint.IsNumericScalar() {
+			return t
+		}
+	default:
+		if untypedType.Kind == UntypedKindBool || !isNumericScalar(targetType) {
+			return t
+		}
+		// values which can't be represented are reported, and the conversion goes on to avoid cascading errors
+		if value, ok := checker.representConstant(e, t.Value, target); ok {
+			res.Value = value
+		}
+	}
+
+	checker.unit.semanticInfo.SetTypeOf(e, res)
+	return res
+}
+
+// convertUntypedAt converts the i-th value of the expression list to the target type, values of tuple types are
+// never untyped so the index always refers to the expression of the untyped value
+func (checker *Checker) convertUntypedAt(exprs []Expr, values []*TypeAndValue, i int, target Type) *TypeAndValue {
+	if !isUntyped(values[i].Type) {
+		return values[i]
+	}
+	return checker.convertUntyped(exprs[i], values[i], target)
+}
+
+// representConstant converts the constant value to the numeric type, it reports an error if the value can't be
+// represented in the type without losing information
+func (checker *Checker) representConstant(e Expr, value constant.Value, t Type) (constant.Value, bool) {
+	props := t.Properties()
+	switch {
+	case props.Integral:
+		intValue := constant.ToInt(value)
+		if intValue.Kind() != constant.Int {
+			checker.error(NewError(e.SourceRange(), "constant '%v' is truncated when converted to '%v'", value, t))
+			return nil, false
+		}
+		if !props.Signed && constant.Sign(intValue) < 0 {
+			checker.error(NewError(e.SourceRange(), "cannot convert negative constant '%v' to unsigned type '%v'", value, t))
+			return nil, false
+		}
+		if constantOverflows(intValue, t) {
+			checker.error(NewError(e.SourceRange(), "constant '%v' overflows '%v'", value, t))
+			return nil, false
+		}
+		return intValue, true
+	case props.Floating:
+		floatValue := constant.ToFloat(value)
+		if constantOverflows(floatValue, t) {
+			checker.error(NewError(e.SourceRange(), "constant '%v' overflows '%v'", value, t))
+			return nil, false
+		}
+		return roundFloat(floatValue, t), true
+	default:
+		return value, true
+	}
+}
+
+// constantOverflows returns true if the constant value is outside the range of the numeric type
+func constantOverflows(value constant.Value, t Type) bool {
+	props := t.Properties()
+	switch {
+	case props.Integral:
+		bits := uint(props.Size * 8)
+		if props.Signed {
+			bits--
+		}
+		maxValue := constant.Shift(constant.MakeInt64(1), token.SHL, bits)
+		minValue := constant.MakeInt64(0)
+		if props.Signed {
+			minValue = constant.UnaryOp(token.SUB, maxValue, 0)
+		}
+		return constant.Compare(value, token.GEQ, maxValue) || constant.Compare(value, token.LSS, minValue)
+	case props.Floating:
+		maxValue := math.MaxFloat64
+		if props.Size == 4 {
+			maxValue = math.MaxFloat32
+		}
+		f, _ := constant.Float64Val(value)
+		return math.IsInf(f, 0) || math.Abs(f) > maxValue
+	default:
+		return false
+	}
+}
+
+// roundFloat rounds the constant to the precision of the floating point type, like Go typed float constants only
+// hold values their type can represent so folding them gives the same result as computing them at runtime
+func roundFloat(value constant.Value, t Type) constant.Value {
+	f, _ := constant.Float64Val(constant.ToFloat(value))
+	if t.Properties().Size == 4 {
+		f = float64(float32(f))
 	}
+	return constant.MakeFloat64(f)
+}
 
-	for i, a := range arguments {
-		parameterType := funcType.ParameterTypes[i]
-		if !a.Type.Equal(parameterType) {
-			checker.error(NewError(sourceRanges[i], "incorrect argument type '%v', expected '%v'", a.Type, parameterType))
-			return res
+// checkConstantOverflow reports typed constants which don't fit their type after folding, the result stops
+// being a constant so the error isn't reported again by the enclosing expressions. folded floats are rounded to
+// their type
+func (checker *Checker) checkConstantOverflow(e Expr, t *TypeAndValue) *TypeAndValue {
+	if t.Mode != AddressModeConstant || t.Value == nil || isUntyped(t.Type) || !isNumericScalar(t.Type) {
+		return t
+	}
+	if !constantOverflows(t.Value, t.Type) {
+		if t.Type.Properties().Floating {
+			return &TypeAndValue{Mode: t.Mode, Type: t.Type, Value: roundFloat(t.Value, t.Type)}
 		}
+		return t
 	}
+	checker.error(NewError(e.SourceRange(), "constant '%v' overflows '%v'", t.Value, t.Type))
+	return &TypeAndValue{
+		Mode: AddressModeComputedValue,
+		Type: t.Type,
+	}
+}
 
-	res.Mode = AddressModeComputedValue
-	if len(funcType.ReturnTypes) == 1 {
-		res.Type = funcType.ReturnTypes[0]
-	} else if len(funcType.ReturnTypes) > 1 {
-		res.Type = checker.unit.semanticInfo.TypeInterner.InternTupleType(funcType.ReturnTypes)
+// checkShiftWidth reports constant shifts of typed values by their bit width or more, which are undefined on the GPU
+func (checker *Checker) checkShiftWidth(lhs Type, rhsExpr Expr, rhs *TypeAndValue) bool {
+	if rhs.Mode != AddressModeConstant || rhs.Value == nil || isUntyped(lhs) {
+		return true
 	}
-	return res
+	if vectorType, ok := lhs.Resolve(true).(*VectorType); ok {
+		lhs = vectorType.UnderlyingType
+	}
+	bits := lhs.Properties().Size * 8
+	if constant.Compare(rhs.Value, token.LSS, constant.MakeInt64(int64(bits))) {
+		return true
+	}
+	checker.error(NewError(
+		rhsExpr.SourceRange(),
+		"shift operator should be less than the %v bits of '%v', but it has value '%v'",
+		bits,
+		lhs,
+		rhs.Value,
+	))
+	return false
+}
+
+// checkConstantDivisor reports division by a constant zero, which is only allowed for non constant floats
+func (checker *Checker) checkConstantDivisor(lhs *TypeAndValue, rhsExpr Expr, rhs *TypeAndValue) bool {
+	if rhs.Mode != AddressModeConstant || rhs.Value == nil || constant.Sign(rhs.Value) != 0 {
+		return true
+	}
+	if lhs.Mode != AddressModeConstant && !lhs.Type.Properties().Integral {
+		return true
+	}
+	checker.error(NewError(rhsExpr.SourceRange(), "division by zero"))
+	return false
 }
 
 func (checker *Checker) resolveArrayTypeExpr(e *ArrayTypeExpr) *TypeAndValue {
@@ -1108,13 +3164,92 @@ func (checker *Checker) resolveArrayTypeExpr(e *ArrayTypeExpr) *TypeAndValue {
 	return res
 }
 
+// resolvePointerTypeExpr handles pointer types outside of function parameters, see resolveParamTypeExpr
+func (checker *Checker) resolvePointerTypeExpr(e *PointerTypeExpr) *TypeAndValue {
+	checker.error(NewError(e.SourceRange(), "pointer types are only allowed for function parameters"))
+	return &TypeAndValue{
+		Mode:  AddressModeType,
+		Type:  BuiltinVoidType,
+		Value: nil,
+	}
+}
+
+// isStageValue reports whether values of the type can be passed between the stages of the pipeline, which are 32 bit
+// numbers and vectors of them
+func isStageValue(t Type) bool {
+	if vectorType, ok := t.Resolve(true).(*VectorType); ok {
+		return isStageValue(vectorElementType(vectorType))
+	}
+	switch t.Resolve(true).(type) {
+	case *IntType, *UintType, *Float32Type:
+		return true
+	default:
+		return false
+	}
+}
+
+// isInterpolated reports whether the values of the type passed between stages are interpolated between the vertices,
+// integers are taken from a single vertex
+func isInterpolated(t Type) bool {
+	if vectorType, ok := t.Resolve(true).(*VectorType); ok {
+		return isInterpolated(vectorElementType(vectorType))
+	}
+	_, ok := t.Resolve(true).(*Float32Type)
+	return ok
+}
+
+// isPosition reports whether the type is the one of the position returned by vertex entry points
+func isPosition(t Type) bool {
+	vectorType, ok := t.Resolve(true).(*VectorType)
+	if !ok || vectorType.Width != 4 {
+		return false
+	}
+	_, ok = vectorElementType(vectorType).(*Float32Type)
+	return ok
+}
+
+func isPointer(t Type) bool {
+	_, ok := t.Resolve(false).(*PointerType)
+	return ok
+}
+
+func isFunc(t Type) bool {
+	_, ok := t.Resolve(true).(*FuncType)
+	return ok
+}
+
+func isTexture(t Type) bool {
+	_, ok := t.Resolve(false).(*TextureType)
+	return ok
+}
+
+// isSampledTexture reports whether the type is a texture read through its sampler, which unlike images can be taken
+// by every stage
+func isSampledTexture(t Type) bool {
+	textureType, ok := t.Resolve(false).(*TextureType)
+	return ok && !textureType.Storage
+}
+
+// isUniform reports whether entry points take values of the type as uniform data, which is the same for all the
+// invocations of a draw or a dispatch
+func isUniform(t Type) bool {
+	_, ok := t.Resolve(true).(*StructType)
+	return ok
+}
+
 func (checker *Checker) resolveFuncTypeExpr(e *FuncTypeExpr) *TypeAndValue {
-	processFields := func(fields []Field) (types []Type) {
+	processFields := func(fields []Field, isParam bool) (types []Type) {
 		for _, field := range fields {
-			fieldType := checker.resolveExpr(field.Type)
+			var fieldType *TypeAndValue
+			if isParam {
+				fieldType = checker.resolveParamTypeExpr(field.Type)
+			} else {
+				fieldType = checker.resolveExpr(field.Type)
+			}
 			if len(field.Names) > 0 {
 				for _, name := range field.Names {
 					v := NewVarSymbol(name.Token, nil, name.SourceRange(), -1, -1, nil)
+					v.IsParam = isParam
 					v.SetResolveState(ResolveStateResolved)
 					checker.unit.semanticInfo.SetTypeOf(v, &TypeAndValue{
 						Mode: AddressModeVariable,
@@ -1131,22 +3266,91 @@ func (checker *Checker) resolveFuncTypeExpr(e *FuncTypeExpr) *TypeAndValue {
 		return types
 	}
 
-	parameterTypes := processFields(e.Parameters.Fields)
+	// type parameters are declared first so that the parameters and results can refer to them
+	var typeParams []*TypeParamType
+	if e.TypeParams != nil {
+		typeParams = checker.resolveTypeParams(e.TypeParams)
+	}
+
+	parameterTypes := processFields(e.Parameters.Fields, true)
 	var returnTypes []Type
 	if e.Result != nil {
-		returnTypes = processFields(e.Result.Fields)
+		returnTypes = processFields(e.Result.Fields, false)
+		// there are no function pointers, so the returned functions couldn't be resolved at compile time
+		for _, field := range e.Result.Fields {
+			if t := checker.unit.semanticInfo.TypeOf(field.Type); t != nil && isFunc(t.Type) {
+				checker.error(NewError(field.Type.SourceRange(), "functions can't return function values"))
+			}
+		}
 	}
 
 	return &TypeAndValue{
 		Mode:  AddressModeType,
-		Type:  checker.unit.semanticInfo.TypeInterner.InternFuncType(parameterTypes, returnTypes),
+		Type:  checker.unit.semanticInfo.TypeInterner.InternGenericFuncType(typeParams, parameterTypes, returnTypes),
 		Value: nil,
 	}
 }
 
+func (checker *Checker) resolveTypeParams(list *FieldList) (typeParams []*TypeParamType) {
+	for _, field := range list.Fields {
+		constraint := checker.resolveConstraint(field.Type)
+		for _, name := range field.Names {
+			typeParam := checker.unit.semanticInfo.TypeInterner.NewTypeParam(name, constraint)
+			sym := NewTypeSymbol(name.Token, nil, name.SourceRange(), field.Type, true)
+			sym.SetResolveState(ResolveStateResolved)
+			checker.unit.semanticInfo.SetTypeOf(sym, &TypeAndValue{
+				Mode: AddressModeType,
+				Type: typeParam,
+			})
+			checker.addSymbol(sym)
+			checker.unit.semanticInfo.SetSymbolOfIdentifier(name, sym)
+			typeParams = append(typeParams, typeParam)
+		}
+	}
+	return
+}
+
+func (checker *Checker) resolveConstraint(e TypeExpr) *ConstraintType {
+	switch c := e.(type) {
+	case *UnionTypeExpr:
+		terms := make([]Type, 0, len(c.Terms))
+		for _, term := range c.Terms {
+			constraint := checker.resolveConstraint(term)
+			if constraint.Terms != nil {
+				terms = append(terms, constraint.Terms...)
+			} else {
+				terms = append(terms, constraint)
+			}
+		}
+		return checker.unit.semanticInfo.TypeInterner.InternUnionConstraint(terms)
+	case *NamedTypeExpr:
+		if !c.IsPackageQualified() && checker.currentScope().Find(c.TypeName.Value()) == nil {
+			if constraint := constraintFromName(c.TypeName); constraint != nil {
+				return constraint
+			}
+			if typeFromName(c.TypeName) == BuiltinVoidType {
+				checker.error(NewError(e.SourceRange(), "undeclared type or constraint '%v'", c.TypeName.Value()))
+				return BuiltinAnyConstraint
+			}
+		}
+	}
+
+	t := checker.resolveExpr(e)
+	if t.Mode == AddressModeInvalid {
+		return BuiltinAnyConstraint
+	}
+
+	if _, ok := t.Type.(*TypeParamType); ok {
+		checker.error(NewError(e.SourceRange(), "cannot use type parameter '%v' as constraint", t.Type))
+		return BuiltinAnyConstraint
+	}
+	return checker.unit.semanticInfo.TypeInterner.InternUnionConstraint([]Type{t.Type})
+}
+
 func (checker *Checker) resolveStructTypeExpr(e *StructTypeExpr) *TypeAndValue {
 	var names []string
 	var types []StructTypeField
+	pkg := e.SourceRange().File.pkg
 
 	type fieldInfo struct {
 		name        string
@@ -1188,9 +3392,16 @@ func (checker *Checker) resolveStructTypeExpr(e *StructTypeExpr) *TypeAndValue {
 				types = append(types, StructTypeField{
 					Identifer: id,
 					Type:      checker.resolveExpr(field.Type).Type,
+					Package:   pkg,
 				})
 			}
 		} else {
+			if pointerType, ok := field.Type.(*PointerTypeExpr); ok {
+				baseType := checker.resolveExpr(pointerType.BaseType)
+				checker.error(NewError(field.Type.SourceRange(), "embedded field '*%v' can't be a pointer", baseType.Type).
+					Note(field.Type.SourceRange(), "pointer types are only allowed for function parameters, embed '%v' instead", baseType.Type))
+				continue
+			}
 			fieldType := checker.resolveExpr(field.Type)
 			if strongAlias, ok := fieldType.Type.(*StrongAliasType); ok {
 				if checkExistingFields(strongAlias.Name, field.Type.SourceRange()) {
@@ -1200,6 +3411,8 @@ func (checker *Checker) resolveStructTypeExpr(e *StructTypeExpr) *TypeAndValue {
 				types = append(types, StructTypeField{
 					Identifer: nil,
 					Type:      fieldType.Type,
+					Package:   pkg,
+					Embedding: field.Type,
 				})
 			} else if weakAlias, ok := fieldType.Type.(*WeakAliasType); ok {
 				if checkExistingFields(weakAlias.Name, field.Type.SourceRange()) {
@@ -1209,6 +3422,8 @@ func (checker *Checker) resolveStructTypeExpr(e *StructTypeExpr) *TypeAndValue {
 				types = append(types, StructTypeField{
 					Identifer: nil,
 					Type:      fieldType.Type,
+					Package:   pkg,
+					Embedding: field.Type,
 				})
 			} else {
 				checker.error(NewError(field.Type.SourceRange(), "Cannot embed type '%v'", field.Type))
@@ -1265,6 +3480,10 @@ func typeFromName(name Token) Type {
 		return BuiltinB32x3Type
 	case BuiltinB32x4Type.name:
 		return BuiltinB32x4Type
+	case BuiltinTexture2DType.name:
+		return BuiltinTexture2DType
+	case BuiltinImage2DType.name:
+		return BuiltinImage2DType
 	default:
 		return BuiltinVoidType
 	}
@@ -1273,7 +3492,7 @@ func typeFromName(name Token) Type {
 func (checker *Checker) resolveStmt(stmt Stmt, properties ResolveStmtProperties) {
 	switch s := stmt.(type) {
 	case *ExprStmt:
-		checker.resolveExpr(s.Expr)
+		checker.resolveExprStmt(s)
 	case *IncDecStmt:
 		checker.resolveIncDecStmt(s)
 	case *ReturnStmt:
@@ -1284,6 +3503,8 @@ func (checker *Checker) resolveStmt(stmt Stmt, properties ResolveStmtProperties)
 		checker.resolveFallthroughStmt(s, properties)
 	case *ContinueStmt:
 		checker.resolveContinueStmt(s, properties)
+	case *DiscardStmt:
+		checker.resolveDiscardStmt(s)
 	case *BlockStmt:
 		checker.resolveBlockStmt(s, properties)
 	case *AssignStmt:
@@ -1296,11 +3517,312 @@ func (checker *Checker) resolveStmt(stmt Stmt, properties ResolveStmtProperties)
 		checker.resolveSwitchStmt(s, properties)
 	case *DeclStmt:
 		checker.resolveDeclStmt(s)
+	case *LabeledStmt:
+		checker.resolveLabeledStmt(s, properties)
+	case *EmptyStmt:
+		// nothing to check
 	default:
 		panic("unexpected stmt type")
 	}
 }
 
+func (checker *Checker) resolveExprStmt(s *ExprStmt) {
+	checker.resolveExpr(s.Expr)
+	if call, ok := s.Expr.(*CallExpr); ok {
+		checker.droppedCalls = append(checker.droppedCalls, call)
+	}
+}
+
+func (checker *Checker) resolveDiscardStmt(s *DiscardStmt) {
+	function := checker.currentFunction()
+	if function == nil {
+		checker.error(NewError(s.SourceRange(), "unexpected discard statement"))
+		return
+	}
+
+	if _, ok := checker.discards[function]; !ok {
+		checker.discardFuncs = append(checker.discardFuncs, function)
+	}
+	checker.discards[function] = append(checker.discards[function], s)
+}
+
+// checkRecursion reports cycles in the call graph since SPIR-V doesn't support recursion, each cycle is reported
+// on the call which closes it
+func (checker *Checker) checkRecursion() {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[*FuncSymbol]int)
+	var path []*Call
+	var visit func(function *FuncSymbol)
+	visit = func(function *FuncSymbol) {
+		state[function] = visiting
+		for _, call := range checker.unit.semanticInfo.CallsOf(function) {
+			switch state[call.Callee] {
+			case unvisited:
+				path = append(path, call)
+				visit(call.Callee)
+				path = path[:len(path)-1]
+			case visiting:
+				checker.reportRecursion(path, call)
+			}
+		}
+		state[function] = visited
+	}
+
+	// functions are visited in declaration order to keep the reported cycles stable
+	for _, pkg := range checker.unit.sortedPackages {
+		for _, sym := range checker.unit.semanticInfo.ScopeOf(pkg).Symbols {
+			switch sym := sym.(type) {
+			case *FuncSymbol:
+				if state[sym] == unvisited {
+					visit(sym)
+				}
+			case *TypeSymbol:
+				for _, method := range sym.Methods {
+					if state[method] == unvisited {
+						visit(method)
+					}
+				}
+			}
+		}
+	}
+}
+
+// reportRecursion reports the cycle closed by the given call, the path is the chain of calls that led to its caller
+func (checker *Checker) reportRecursion(path []*Call, call *Call) {
+	cycle := []*Call{call}
+	for i, c := range path {
+		if c.Caller == call.Callee {
+			cycle = append(slices.Clone(path[i:]), call)
+			break
+		}
+	}
+
+	callRange := call.Expr.SourceRange()
+	if call.Value != nil {
+		callRange = call.Value.SourceRange()
+	}
+	err := NewError(callRange, "recursive call to '%v' is not allowed", call.Callee.Name())
+	for _, c := range cycle {
+		// calls through parameters are pointed at the function value passed to them
+		if c.Value != nil {
+			err = err.Note(c.Value.SourceRange(), "'%v' calls '%v' which is passed to it here", c.Caller.Name(), c.Callee.Name())
+		} else {
+			err = err.Note(c.Expr.SourceRange(), "'%v' calls '%v'", c.Caller.Name(), c.Callee.Name())
+		}
+	}
+	checker.error(err.WithCode(DiagnosticRecursiveCall))
+}
+
+// checkUnused reports unused local variables and imports as errors, and unused parameters and package level
+// symbols as warnings
+func (checker *Checker) checkUnused() {
+	info := checker.unit.semanticInfo
+	for _, name := range checker.localVars {
+		sym := info.SymbolOfIdentifier(name)
+		// redefined variables never made it into a scope
+		if isBlankIdentifier(name) || sym == nil || sym.Scope() == nil || info.IsUsed(sym) {
+			continue
+		}
+		checker.error(NewError(name.SourceRange(), "'%v' declared and not used", name.Token.Value()))
+	}
+
+	checkParams := func(function *FuncSymbol) {
+		parameters := function.SymDecl.(*FuncDecl).Type.Parameters
+		if parameters == nil {
+			return
+		}
+		for _, field := range parameters.Fields {
+			for _, name := range field.Names {
+				sym := info.SymbolOfIdentifier(name)
+				if isBlankIdentifier(name) || sym == nil || info.IsUsed(sym) {
+					continue
+				}
+				checker.error(NewWarning(DiagnosticUnusedParameter, name.SourceRange(), "unused parameter '%v'", name.Token.Value()))
+			}
+		}
+	}
+
+	for _, pkg := range checker.unit.sortedPackages {
+		for _, file := range pkg.Files {
+			for _, sym := range info.ScopeOf(file).Symbols {
+				if pkgSym, ok := sym.(*PackageSymbol); ok && !info.IsUsed(pkgSym) {
+					checker.error(NewError(pkgSym.SourceRange(), "package '%v' imported and not used", pkgSym.Name()))
+				}
+			}
+		}
+
+		packageScope := info.ScopeOf(pkg)
+		hasEntryPoints := slices.ContainsFunc(info.EntryPoints, func(entry *FuncSymbol) bool {
+			return packageScopeOf(entry.Scope()) == packageScope
+		})
+		for _, sym := range packageScope.Symbols {
+			switch sym := sym.(type) {
+			case *FuncSymbol:
+				checkParams(sym)
+			case *TypeSymbol:
+				for _, method := range sym.Methods {
+					checkParams(method)
+				}
+			}
+
+			// only shader packages have entry points to be used from, and exported symbols might be used by
+			// other packages
+			if !hasEntryPoints || sym.Name() == "_" || isExported(sym.Name()) || info.IsUsed(sym) {
+				continue
+			}
+			if function, ok := sym.(*FuncSymbol); ok && function.IsEntryPoint() {
+				continue
+			}
+			checker.error(NewWarning(DiagnosticUnusedSymbol, symbolNameRange(sym), "'%v' is declared but never used", sym.Name()))
+		}
+	}
+}
+
+// symbolNameRange returns the source range of the name of a package level symbol, since some symbols cover their
+// whole declaration
+func symbolNameRange(sym Symbol) SourceRange {
+	switch s := sym.(type) {
+	case *FuncSymbol:
+		return s.SymDecl.(*FuncDecl).Name.SourceRange()
+	case *VarSymbol:
+		return s.SymDecl.(*GenericDecl).Specs[s.SpecIndex].(*ValueSpec).LHS[s.ExprIndex].SourceRange()
+	case *ConstSymbol:
+		return s.SymDecl.(*GenericDecl).Specs[s.SpecIndex].(*ValueSpec).LHS[s.ExprIndex].SourceRange()
+	default:
+		return sym.SourceRange()
+	}
+}
+
+// checkDroppedResults warns about calls whose results are dropped, if the called function has no side effects
+// then the call does nothing
+func (checker *Checker) checkDroppedResults() {
+	info := checker.unit.semanticInfo
+	impure := maps.Clone(checker.sideEffects)
+	for function := range checker.discards {
+		impure[function] = true
+	}
+	for changed := true; changed; {
+		changed = false
+		for caller, calls := range info.Calls {
+			if !impure[caller] && slices.ContainsFunc(calls, func(call *Call) bool { return impure[call.Callee] }) {
+				impure[caller] = true
+				changed = true
+			}
+		}
+	}
+
+	for _, call := range checker.droppedCalls {
+		callee := checker.calleeOf(call.Base)
+		if callee == nil || impure[callee] {
+			continue
+		}
+		if t := info.TypeOf(call); t == nil || t.Mode == AddressModeInvalid || t.Type.Equal(BuiltinVoidType) {
+			continue
+		}
+		checker.error(
+			NewWarning(DiagnosticUnusedResult, call.SourceRange(), "result of '%v' is not used", callee.Name()).
+				Note(callee.SymDecl.(*FuncDecl).Name.SourceRange(), "'%v' has no side effects", callee.Name()),
+		)
+	}
+}
+
+// checkDiscards makes sure that discard statements are only reachable from fragment entry points, this can only
+// be done once the whole call graph is known
+func (checker *Checker) checkDiscards() {
+	reported := make(map[*DiscardStmt]bool)
+	reachableFromFragment := make(map[*FuncSymbol]bool)
+	for _, entry := range checker.unit.semanticInfo.EntryPoints {
+		order, reachedBy := checker.unit.semanticInfo.reachableFrom(entry)
+		for _, function := range order {
+			if entry.Stage == ShaderStageFragment {
+				reachableFromFragment[function] = true
+				continue
+			}
+
+			for _, s := range checker.discards[function] {
+				if reported[s] {
+					continue
+				}
+				reported[s] = true
+
+				err := NewError(s.SourceRange(), "discard is only allowed in fragment shaders").
+					Note(entry.SymDecl.(*FuncDecl).Name.SourceRange(), "reachable from %v entry point '%v'", entry.Stage, entry.Name())
+				var path []*Call
+				for call := reachedBy[function]; call != nil; call = reachedBy[call.Caller] {
+					path = append(path, call)
+				}
+				for i := len(path) - 1; i >= 0; i-- {
+					err = err.Note(path[i].Expr.SourceRange(), "'%v' is called here", path[i].Callee.Name())
+				}
+				checker.error(err)
+			}
+		}
+	}
+
+	for _, function := range checker.discardFuncs {
+		if reachableFromFragment[function] {
+			continue
+		}
+		for _, s := range checker.discards[function] {
+			if !reported[s] {
+				checker.error(NewError(s.SourceRange(), "discard is only allowed in code reachable from fragment entry points"))
+			}
+		}
+	}
+}
+
+// checkWorkgroupStages makes sure that workgroup variables are only used in code reachable from compute entry points,
+// since only compute shaders have workgroups
+func (checker *Checker) checkWorkgroupStages() {
+	reported := make(map[Expr]bool)
+	reachableFromCompute := make(map[*FuncSymbol]bool)
+	for _, entry := range checker.unit.semanticInfo.EntryPoints {
+		order, reachedBy := checker.unit.semanticInfo.reachableFrom(entry)
+		for _, function := range order {
+			if entry.Stage == ShaderStageCompute {
+				reachableFromCompute[function] = true
+				continue
+			}
+
+			for i, e := range checker.workgroupUses[function] {
+				if reported[e] {
+					continue
+				}
+				reported[e] = true
+
+				v := checker.unit.semanticInfo.Workgroups[function][i]
+				err := NewError(e.SourceRange(), "workgroup variable '%v' is only available in compute shaders", v.Name()).
+					Note(entry.SymDecl.(*FuncDecl).Name.SourceRange(), "reachable from %v entry point '%v'", entry.Stage, entry.Name())
+				var path []*Call
+				for call := reachedBy[function]; call != nil; call = reachedBy[call.Caller] {
+					path = append(path, call)
+				}
+				for i := len(path) - 1; i >= 0; i-- {
+					err = err.Note(path[i].Expr.SourceRange(), "'%v' is called here", path[i].Callee.Name())
+				}
+				checker.error(err)
+			}
+		}
+	}
+
+	for _, function := range checker.workgroupFuncs {
+		if reachableFromCompute[function] {
+			continue
+		}
+		for i, e := range checker.workgroupUses[function] {
+			if !reported[e] {
+				v := checker.unit.semanticInfo.Workgroups[function][i]
+				checker.error(NewError(e.SourceRange(), "workgroup variable '%v' is only available in code reachable from compute entry points", v.Name()))
+			}
+		}
+	}
+}
+
 func (checker *Checker) resolveIncDecStmt(s *IncDecStmt) {
 	t := checker.resolveExpr(s.Expr)
 
@@ -1308,6 +3830,7 @@ func (checker *Checker) resolveIncDecStmt(s *IncDecStmt) {
 		checker.error(NewError(s.SourceRange(), "expression is not assignable"))
 		return
 	}
+	checker.recordWrite(s.Expr)
 
 	if !t.Type.Properties().HasArithmetic {
 		checker.error(NewError(s.SourceRange(), "type '%v' doesn't support arithmetic operations", t.Type))
@@ -1316,17 +3839,18 @@ func (checker *Checker) resolveIncDecStmt(s *IncDecStmt) {
 }
 
 func (checker *Checker) resolveReturnStmt(s *ReturnStmt) {
-	funcDecl := checker.currentFunction()
-	if funcDecl == nil {
+	function := checker.currentFunction()
+	if function == nil {
 		checker.error(NewError(s.SourceRange(), "unexpected return statement"))
 		return
 	}
+	funcDecl := function.SymDecl.(*FuncDecl)
 
 	returnTypes, sourceRanges := checker.resolveAndUnpackTypesFromExprList(s.Exprs)
 	expectedReturnTypes := checker.unit.semanticInfo.TypeOf(funcDecl).Type.(*FuncType).ReturnTypes
 	if len(returnTypes) == len(expectedReturnTypes) {
 		for i, et := range expectedReturnTypes {
-			if t := returnTypes[i]; !t.Type.Equal(et) {
+			if t := checker.convertUntypedAt(s.Exprs, returnTypes, i, et); !t.Type.Equal(et) {
 				checker.error(NewError(sourceRanges[i], "incorrect return type '%v', expected '%v'", t.Type, et))
 			}
 		}
@@ -1345,8 +3869,16 @@ func (checker *Checker) resolveReturnStmt(s *ReturnStmt) {
 }
 
 func (checker *Checker) resolveBreakStmt(s *BreakStmt, properties ResolveStmtProperties) {
-	if s.Label.valid() {
-		panic("labeled break not supported yet")
+	if s.IsLabeled() {
+		if target := checker.resolveBranchLabel(s, s.Label); target != nil {
+			switch target.Stmt.(type) {
+			case *ForStmt, *SwitchStmt:
+			default:
+				checker.error(NewError(s.Label.SourceRange(), "invalid break label '%v'", s.Label.Value()).
+					Note(target.Label.SourceRange(), "label is not on a loop or switch"))
+			}
+		}
+		return
 	}
 
 	if !properties.acceptsBreak {
@@ -1365,8 +3897,14 @@ func (checker *Checker) resolveFallthroughStmt(s *FallthroughStmt, properties Re
 }
 
 func (checker *Checker) resolveContinueStmt(s *ContinueStmt, properties ResolveStmtProperties) {
-	if s.Label.valid() {
-		panic("labeled continue not supported yet")
+	if s.IsLabeled() {
+		if target := checker.resolveBranchLabel(s, s.Label); target != nil {
+			if _, ok := target.Stmt.(*ForStmt); !ok {
+				checker.error(NewError(s.Label.SourceRange(), "invalid continue label '%v'", s.Label.Value()).
+					Note(target.Label.SourceRange(), "label is not on a loop"))
+			}
+		}
+		return
 	}
 
 	if !properties.acceptsContinue {
@@ -1374,6 +3912,65 @@ func (checker *Checker) resolveContinueStmt(s *ContinueStmt, properties ResolveS
 	}
 }
 
+func (checker *Checker) resolveLabeledStmt(s *LabeledStmt, properties ResolveStmtProperties) {
+	labels := checker.labels
+	name := s.Label.Value()
+	if name == "_" {
+		checker.error(NewError(s.Label.SourceRange(), "cannot use _ as label"))
+	} else if other, ok := labels.labels[name]; ok {
+		checker.error(NewError(s.Label.SourceRange(), "label '%v' redefinition", name).
+			Note(other.Label.SourceRange(), "first declared here"))
+	} else {
+		labels.labels[name] = s
+		labels.declared = append(labels.declared, s)
+	}
+
+	labels.enclosing = append(labels.enclosing, s)
+	checker.resolveStmt(s.Stmt, properties)
+	labels.enclosing = labels.enclosing[:len(labels.enclosing)-1]
+}
+
+// resolveBranchLabel returns the enclosing labeled statement the given break or continue statement refers to
+func (checker *Checker) resolveBranchLabel(s Stmt, label Token) *LabeledStmt {
+	labels := checker.labels
+	for i := len(labels.enclosing) - 1; i >= 0; i-- {
+		if target := labels.enclosing[i]; target.Label.Value() == label.Value() {
+			labels.used[target] = true
+			return target
+		}
+	}
+	labels.unresolved = append(labels.unresolved, s)
+	return nil
+}
+
+// checkLabels reports the branches to labels which don't enclose them and the labels which are never used
+func (checker *Checker) checkLabels() {
+	labels := checker.labels
+	for _, s := range labels.unresolved {
+		kind, label := "break", Token{}
+		switch s := s.(type) {
+		case *BreakStmt:
+			label = s.Label
+		case *ContinueStmt:
+			kind, label = "continue", s.Label
+		}
+
+		if target, ok := labels.labels[label.Value()]; ok {
+			labels.used[target] = true
+			checker.error(NewError(label.SourceRange(), "invalid %v label '%v'", kind, label.Value()).
+				Note(target.Label.SourceRange(), "label is not enclosing the %v statement", kind))
+		} else {
+			checker.error(NewError(label.SourceRange(), "undefined label '%v'", label.Value()))
+		}
+	}
+
+	for _, s := range labels.declared {
+		if !labels.used[s] {
+			checker.error(NewError(s.Label.SourceRange(), "label '%v' defined and not used", s.Label.Value()))
+		}
+	}
+}
+
 func (checker *Checker) resolveBlockStmt(s *BlockStmt, properties ResolveStmtProperties) {
 	scope := checker.unit.semanticInfo.createScopeFor(s, checker.currentScope(), "block")
 	checker.enterScope(scope)
@@ -1466,14 +4063,32 @@ func (checker *Checker) resolveAssignStmt(s *AssignStmt) {
 			}
 		}
 
+		if !slices.ContainsFunc(s.LHS, func(e Expr) bool { return !isBlankIdentifier(e) }) {
+			checker.error(NewError(s.SourceRange(), "no new variables on left side of :="))
+		}
+
 		for i := range s.LHS {
 			lhs := s.LHS[i]
+			rhsTypes[i] = checker.convertUntypedAt(s.RHS, rhsTypes, i, nil)
+			if isPointer(rhsTypes[i].Type) {
+				checker.error(NewError(lhs.SourceRange(), "pointers can't be stored in variables"))
+			}
+			if isFunc(rhsTypes[i].Type) && !isBlankIdentifier(lhs) {
+				checker.error(NewError(lhs.SourceRange(), "function values can't be stored in variables"))
+			}
+			if isTexture(rhsTypes[i].Type) && !isBlankIdentifier(lhs) {
+				checker.error(NewError(lhs.SourceRange(), "textures can't be stored in variables"))
+			}
+			if isBlankIdentifier(lhs) {
+				continue
+			}
 			name := lhs.(*IdentifierExpr).Token
 			v := NewVarSymbol(name, nil, name.SourceRange(), -1, -1, rhsTypes[i])
 			v.SetResolveState(ResolveStateResolved)
 			checker.unit.semanticInfo.SetTypeOf(v, &TypeAndValue{Mode: AddressModeVariable, Type: rhsTypes[i].Type})
 			checker.addSymbol(v)
 			checker.unit.semanticInfo.SetSymbolOfIdentifier(lhs.(*IdentifierExpr), v)
+			checker.localVars = append(checker.localVars, lhs.(*IdentifierExpr))
 		}
 	case TokenAssign:
 		rhsTypes, rhsSourceRanges := checker.resolveAndUnpackTypesFromExprList(s.RHS)
@@ -1483,9 +4098,22 @@ func (checker *Checker) resolveAssignStmt(s *AssignStmt) {
 
 		for i := range s.LHS {
 			lhs := s.LHS[i]
+			// assigning to the blank identifier only evaluates the value
+			if isBlankIdentifier(lhs) {
+				checker.convertUntypedAt(s.RHS, rhsTypes, i, nil)
+				continue
+			}
 			lhsType := checker.resolveExpr(lhs)
 			checkIsAssignable(lhs, lhsType)
-			checkTypeEqual(lhsType.Type, rhsTypes[i].Type, lhs.SourceRange(), rhsSourceRanges[i])
+			checker.recordWrite(lhs)
+			rhsType := checker.convertUntypedAt(s.RHS, rhsTypes, i, lhsType.Type)
+			checkTypeEqual(lhsType.Type, rhsType.Type, lhs.SourceRange(), rhsSourceRanges[i])
+			if isPointer(lhsType.Type) {
+				checker.error(NewError(lhs.SourceRange(), "pointers can't be assigned"))
+			}
+			if isTexture(lhsType.Type) {
+				checker.error(NewError(lhs.SourceRange(), "textures can't be assigned"))
+			}
 		}
 	case TokenAddAssign, TokenSubAssign, TokenMulAssign, TokenDivAssign, TokenModAssign:
 		if !hasSingleValue(s) {
@@ -1494,6 +4122,7 @@ func (checker *Checker) resolveAssignStmt(s *AssignStmt) {
 		lhs := s.LHS[0]
 		lhsType := checker.resolveExpr(lhs)
 		checkIsAssignable(lhs, lhsType)
+		checker.recordWrite(lhs)
 		checkTypeProperty(
 			lhs.SourceRange(),
 			lhsType.Type,
@@ -1501,7 +4130,7 @@ func (checker *Checker) resolveAssignStmt(s *AssignStmt) {
 			"arithmetic operations",
 		)
 		rhs := s.RHS[0]
-		rhsType := checker.resolveExpr(rhs)
+		rhsType := checker.convertUntyped(rhs, checker.resolveExpr(rhs), lhsType.Type)
 		checkTypeProperty(
 			rhs.SourceRange(),
 			rhsType.Type,
@@ -1509,6 +4138,9 @@ func (checker *Checker) resolveAssignStmt(s *AssignStmt) {
 			"arithmetic operations",
 		)
 		checkTypeEqual(lhsType.Type, rhsType.Type, lhs.SourceRange(), rhs.SourceRange())
+		if s.Operator.Kind() == TokenDivAssign || s.Operator.Kind() == TokenModAssign {
+			checker.checkConstantDivisor(lhsType, rhs, rhsType)
+		}
 	case TokenAndAssign, TokenAndNotAssign, TokenOrAssign, TokenXorAssign:
 		if !hasSingleValue(s) {
 			return
@@ -1516,6 +4148,7 @@ func (checker *Checker) resolveAssignStmt(s *AssignStmt) {
 		lhs := s.LHS[0]
 		lhsType := checker.resolveExpr(lhs)
 		checkIsAssignable(lhs, lhsType)
+		checker.recordWrite(lhs)
 		checkTypeProperty(
 			lhs.SourceRange(),
 			lhsType.Type,
@@ -1523,7 +4156,7 @@ func (checker *Checker) resolveAssignStmt(s *AssignStmt) {
 			"bitwise operations",
 		)
 		rhs := s.RHS[0]
-		rhsType := checker.resolveExpr(rhs)
+		rhsType := checker.convertUntyped(rhs, checker.resolveExpr(rhs), lhsType.Type)
 		checkTypeProperty(
 			rhs.SourceRange(),
 			rhsType.Type,
@@ -1538,6 +4171,7 @@ func (checker *Checker) resolveAssignStmt(s *AssignStmt) {
 		lhs := s.LHS[0]
 		lhsType := checker.resolveExpr(lhs)
 		checkIsAssignable(lhs, lhsType)
+		checker.recordWrite(lhs)
 		checkTypeProperty(
 			lhs.SourceRange(),
 			lhsType.Type,
@@ -1545,7 +4179,7 @@ func (checker *Checker) resolveAssignStmt(s *AssignStmt) {
 			"bitwise operations",
 		)
 		rhs := s.RHS[0]
-		rhsType := checker.resolveExpr(rhs)
+		rhsType := checker.convertUntyped(rhs, checker.resolveExpr(rhs), nil)
 		if !rhsType.Type.Properties().Integral {
 			checker.error(NewError(
 				rhs.SourceRange(),
@@ -1559,6 +4193,8 @@ func (checker *Checker) resolveAssignStmt(s *AssignStmt) {
 					"shift operator should not be negative, but it has value '%v'",
 					rhsType.Value,
 				))
+			} else {
+				checker.checkShiftWidth(lhsType.Type, rhs, rhsType)
 			}
 		}
 	}
@@ -1573,7 +4209,7 @@ func (checker *Checker) resolveIfStmt(s *IfStmt, properties ResolveStmtPropertie
 		checker.resolveStmt(s.Init, properties)
 	}
 
-	condType := checker.resolveExpr(s.Cond)
+	condType := checker.convertUntyped(s.Cond, checker.resolveExpr(s.Cond), BuiltinBoolType)
 	if !condType.Type.Equal(BuiltinBoolType) {
 		checker.error(NewError(
 			s.Cond.SourceRange(),
@@ -1600,7 +4236,7 @@ func (checker *Checker) resolveForStmt(s *ForStmt, properties ResolveStmtPropert
 	}
 
 	if s.Cond != nil {
-		condType := checker.resolveExpr(s.Cond)
+		condType := checker.convertUntyped(s.Cond, checker.resolveExpr(s.Cond), BuiltinBoolType)
 		if !condType.Type.Equal(BuiltinBoolType) {
 			checker.error(NewError(
 				s.Cond.SourceRange(),
@@ -1632,7 +4268,7 @@ func (checker *Checker) resolveSwitchStmt(s *SwitchStmt, properties ResolveStmtP
 
 	var tag *TypeAndValue
 	if s.Tag != nil {
-		tag = checker.resolveExpr(s.Tag)
+		tag = checker.convertUntyped(s.Tag, checker.resolveExpr(s.Tag), nil)
 		if !tag.Type.Properties().Integral && !tag.Type.Properties().Floating && !tag.Type.Equal(BuiltinBoolType) {
 			checker.error(NewError(
 				s.Tag.SourceRange(),
@@ -1673,7 +4309,7 @@ func (checker *Checker) resolveSwitchCaseStmt(
 	defer checker.leaveScope()
 
 	for _, expr := range s.LHS {
-		t := checker.resolveExpr(expr)
+		t := checker.convertUntyped(expr, checker.resolveExpr(expr), tagType)
 
 		if !t.Type.Equal(tagType) {
 			checker.error(NewError(expr.SourceRange(),
@@ -1734,10 +4370,12 @@ func (checker *Checker) resolveDeclStmt(s *DeclStmt) {
 				}
 				sym := symbolFunc(name.Token, d, d.SourceRange(), si, ei, initTAV)
 				checker.unit.semanticInfo.SetSymbolOfIdentifier(name, sym)
+				checker.localVars = append(checker.localVars, name)
 			}
 		}
 	}
 
+	checker.resolveDeclDirectives(s.Decl.(*GenericDecl), false)
 	switch d := s.Decl.(*GenericDecl); d.DeclToken.Kind() {
 	case TokenVar:
 		resolveValueSymbol(d, func(name Token, decl Decl, sourceRange SourceRange, specIndex, exprIndex int, initTAV *TypeAndValue) Symbol {
@@ -1747,12 +4385,19 @@ func (checker *Checker) resolveDeclStmt(s *DeclStmt) {
 			return sym
 		})
 	case TokenConst:
-		resolveValueSymbol(d, func(name Token, decl Decl, sourceRange SourceRange, specIndex, exprIndex int, _ *TypeAndValue) Symbol {
-			sym := NewConstSymbol(name, decl, sourceRange, specIndex, exprIndex)
-			checker.addSymbol(sym)
-			checker.resolveSymbol(sym)
-			return sym
-		})
+		// constant specs are resolved one by one since each spec has its own iota
+		for si, spec := range d.Specs {
+			spec := spec.(*ValueSpec)
+			if spec.Assign.valid() && !hasMultiValue(s, len(spec.LHS), len(spec.RHS)) {
+				return
+			}
+			for ei, name := range spec.LHS {
+				sym := NewConstSymbol(name.Token, d, d.SourceRange(), si, ei)
+				checker.addSymbol(sym)
+				checker.resolveSymbol(sym)
+				checker.unit.semanticInfo.SetSymbolOfIdentifier(name, sym)
+			}
+		}
 	case TokenType:
 		for _, s := range d.Specs {
 			spec := s.(*TypeSpec)
@@ -0,0 +1,466 @@
+package compiler
+
+import (
+	"fmt"
+	"go/constant"
+	"slices"
+	"strings"
+)
+
+// GLSLOptions controls how the unit is translated to GLSL
+type GLSLOptions struct {
+	Discard DiscardMode
+	// Entry is the name of the entry point which becomes the main function of the shader, it can be left empty if
+	// the unit has a single entry point, units without entry points are translated to a library of functions
+	Entry string
+}
+
+type GLSLEmitter struct {
+	*sourceEmitter
+	cDialect
+	options    GLSLOptions
+	extensions []string
+	// global variables of the inputs and the outputs of the entry point, main passes the inputs to the entry point
+	// and writes its results to the outputs
+	inputs, outputs []string
+}
+
+func NewGLSLEmitter(u *Unit, options GLSLOptions) *GLSLEmitter {
+	g := &GLSLEmitter{options: options}
+	g.sourceEmitter = newSourceEmitter(u, g)
+	return g
+}
+
+func (g *GLSLEmitter) Emit() string {
+	entry, ok := g.entryPoint()
+	if !ok {
+		return ""
+	}
+
+	var entries []*FuncSymbol
+	if entry != nil {
+		entries = append(entries, entry)
+	}
+	g.emitFuncs(entries)
+	if g.unit.HasErrors() {
+		return ""
+	}
+
+	var out strings.Builder
+	out.WriteString("#version 450\n")
+	for _, extension := range g.extensions {
+		fmt.Fprintf(&out, "#extension %v : require\n", extension)
+	}
+	if entry != nil && entry.Stage == ShaderStageCompute {
+		size := workgroupSize(entry)
+		fmt.Fprintf(&out, "\nlayout(local_size_x = %v, local_size_y = %v, local_size_z = %v) in;\n", size[0], size[1], size[2])
+	}
+	for _, decl := range g.decls {
+		out.WriteString("\n")
+		out.WriteString(decl)
+	}
+	if entry != nil {
+		fmt.Fprintf(&out, "\nvoid main() {\n%v}\n", g.mainBody(entry))
+	}
+	return out.String()
+}
+
+// mainBody calls the entry point with its inputs and writes its results to the outputs, the results of entry points
+// with multiple results are returned in their struct
+func (g *GLSLEmitter) mainBody(entry *FuncSymbol) string {
+	call := fmt.Sprintf("%v(%v)", g.funcName(entry, nil, nil, nil), strings.Join(g.inputs, ", "))
+
+	var body strings.Builder
+	switch len(g.outputs) {
+	case 0:
+		fmt.Fprintf(&body, "\t%v;\n", call)
+	case 1:
+		fmt.Fprintf(&body, "\t%v = %v;\n", g.outputs[0], call)
+	default:
+		results := g.resultOf(g.typeOf(entry).Type.(*FuncType)).(*StructType)
+		fmt.Fprintf(&body, "\t%v = %v;\n", g.declaration(results, "sabre_results"), call)
+		for i, output := range g.outputs {
+			fmt.Fprintf(&body, "\t%v = sabre_results.%v;\n", output, results.Fields[i].Name())
+		}
+	}
+	return body.String()
+}
+
+// entryPoint returns the entry point selected by the options, or the only entry point of the unit, it's nil for
+// units without entry points. a missing or ambiguous entry point is reported on the package clause
+func (g *GLSLEmitter) entryPoint() (*FuncSymbol, bool) {
+	entries := g.unit.semanticInfo.EntryPoints
+	packageName := g.unit.rootFile.Package.Name.SourceRange()
+	if g.options.Entry != "" {
+		for _, entry := range entries {
+			if entry.Name() == g.options.Entry {
+				return entry, true
+			}
+		}
+		g.error(NewError(packageName, "entry point '%v' not found", g.options.Entry))
+		return nil, false
+	}
+
+	switch len(entries) {
+	case 0:
+		return nil, true
+	case 1:
+		return entries[0], true
+	default:
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = fmt.Sprintf("'%v'", entry.Name())
+		}
+		g.error(NewError(packageName, "a GLSL shader has a single entry point, select one of %v", strings.Join(names, ", ")))
+		return nil, false
+	}
+}
+
+func (g *GLSLEmitter) identifier(name string) string {
+	return glslIdentifier(name)
+}
+
+func (g *GLSLEmitter) scalarTypeName(t Type) string {
+	switch t.(type) {
+	case *BoolType:
+		return "bool"
+	case *IntType:
+		return "int"
+	case *UintType:
+		return "uint"
+	case *Float32Type:
+		return "float"
+	case *Float64Type:
+		return "double"
+	default:
+		panic("unexpected type")
+	}
+}
+
+func (g *GLSLEmitter) arrayTypeName(t *ArrayType) string {
+	// arrays of arrays list their sizes from the outermost array
+	element := g.typeName(t.ElementType)
+	if i := strings.IndexByte(element, '['); i >= 0 {
+		return fmt.Sprintf("%v[%v]%v", element[:i], t.Length, element[i:])
+	}
+	return fmt.Sprintf("%v[%v]", element, t.Length)
+}
+
+// vectorTypeName returns the vector type named by the prefix of its element type
+func (g *GLSLEmitter) vectorTypeName(t *VectorType) string {
+	var prefix string
+	switch vectorElementType(t).(type) {
+	case *BoolType:
+		prefix = "b"
+	case *IntType:
+		prefix = "i"
+	case *UintType:
+		prefix = "u"
+	case *Float32Type:
+	case *Float64Type:
+		prefix = "d"
+	default:
+		panic("unexpected vector element type")
+	}
+	return fmt.Sprintf("%vvec%v", prefix, t.Width)
+}
+
+func (g *GLSLEmitter) textureTypeName(t *TextureType) string {
+	if t.Storage {
+		return "image2D"
+	}
+	return "sampler2D"
+}
+
+// vectorBinary uses the operators for arithmetic and bitwise operations, comparisons call the functions comparing
+// each component since the operators compare whole vectors. the functions and shifts of scalars by vectors take two
+// vectors so the scalar operand is splatted
+func (g *GLSLEmitter) vectorBinary(operator TokenKind, lhsType, rhsType Type, lhs, rhs sourceExpr) sourceExpr {
+	function, compares := glslVectorComparisons[operator]
+	_, lhsIsVector := lhsType.Resolve(true).(*VectorType)
+	if !compares && lhsIsVector {
+		return g.infix(operator, lhs, rhs)
+	}
+	_, _, lhs, rhs = vectorOperands(lhsType, rhsType, lhs, rhs, g.splat)
+	if !compares {
+		return g.infix(operator, lhs, rhs)
+	}
+	return sourceExpr{fmt.Sprintf("%v(%v, %v)", function, lhs, rhs), precPostfix}
+}
+
+// splat returns the vector with all of its components set to the scalar
+func (g *GLSLEmitter) splat(t *VectorType, scalar sourceExpr) sourceExpr {
+	return sourceExpr{fmt.Sprintf("%v(%v)", g.vectorTypeName(t), scalar), precPostfix}
+}
+
+// vectorUnary calls not for the logical not, which is only an operator for scalars
+func (g *GLSLEmitter) vectorUnary(operator TokenKind, t *VectorType, operand sourceExpr) sourceExpr {
+	if operator == TokenNot {
+		return sourceExpr{fmt.Sprintf("not(%v)", operand), precPostfix}
+	}
+	return g.cDialect.vectorUnary(operator, t, operand)
+}
+
+var glslVectorComparisons = map[TokenKind]string{
+	TokenEQ: "equal",
+	TokenNE: "notEqual",
+	TokenLT: "lessThan",
+	TokenLE: "lessThanEqual",
+	TokenGT: "greaterThan",
+	TokenGE: "greaterThanEqual",
+}
+
+func (g *GLSLEmitter) declaration(t Type, name string) string {
+	if name == "" {
+		return g.typeName(t)
+	}
+	return fmt.Sprintf("%v %v", g.typeName(t), name)
+}
+
+func (g *GLSLEmitter) variableDeclaration(t Type, name string) string {
+	return g.declaration(t, name)
+}
+
+// paramDeclaration declares pointers as inout parameters which are copied back to the argument when the function
+// returns. image parameters have no format so loading from them needs the extension reading images without one
+func (g *GLSLEmitter) paramDeclaration(sym Symbol, t Type, name string, buffer bool) string {
+	switch u := t.Resolve(false).(type) {
+	case *PointerType:
+		return "inout " + g.declaration(u.ElementType, name)
+	case *TextureType:
+		if u.Storage {
+			g.require("GL_EXT_shader_image_load_formatted")
+		}
+	}
+	return g.declaration(t, name)
+}
+
+func (g *GLSLEmitter) fieldDeclaration(t Type, name string) string {
+	return g.declaration(t, name) + ";"
+}
+
+func (g *GLSLEmitter) signature(sym *FuncSymbol, name string, params []string, result Type) string {
+	resultName := "void"
+	if result != nil {
+		resultName = g.typeName(result)
+	}
+	return fmt.Sprintf("%v %v(%v)", resultName, name, strings.Join(params, ", "))
+}
+
+func (g *GLSLEmitter) floatLiteral(value float64, bitSize int) string {
+	if bitSize == 64 {
+		return formatFloat(value, bitSize) + "lf"
+	}
+	return formatFloat(value, bitSize)
+}
+
+func (g *GLSLEmitter) compositeValue(t Type, elements []string) sourceExpr {
+	return sourceExpr{fmt.Sprintf("%v(%v)", g.typeName(t), strings.Join(elements, ", ")), precPostfix}
+}
+
+func (g *GLSLEmitter) vectorLiteral(t *VectorType, components []string) sourceExpr {
+	return g.compositeValue(t, components)
+}
+
+func (g *GLSLEmitter) compositeLiteral(t Type, fields []string) sourceExpr {
+	structType := t.Resolve(true).(*StructType)
+	for i, field := range fields {
+		// omitted fields are zero initialized
+		if field == "" {
+			fields[i] = g.zeroValue(structType.Fields[i].Type).text
+		}
+	}
+	return g.compositeValue(t, fields)
+}
+
+func (g *GLSLEmitter) zeroValue(t Type) sourceExpr {
+	switch u := t.Resolve(true).(type) {
+	case *BoolType:
+		return g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: t, Value: constant.MakeBool(false)})
+	case *ArrayType:
+		elements := make([]string, u.Length)
+		for i := range elements {
+			elements[i] = g.zeroValue(u.ElementType).text
+		}
+		return g.compositeValue(t, elements)
+	case *StructType:
+		fields := make([]string, len(u.Fields))
+		for i, field := range u.Fields {
+			fields[i] = g.zeroValue(field.Type).text
+		}
+		return g.compositeValue(t, fields)
+	case *VectorType:
+		// constructing a vector from a single scalar sets all of its components
+		return g.compositeValue(t, []string{g.zeroValue(vectorElementType(u)).text})
+	default:
+		return g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: t, Value: constant.MakeInt64(0)})
+	}
+}
+
+func (g *GLSLEmitter) constantDeclaration(t Type, name string, value sourceExpr) string {
+	return fmt.Sprintf("const %v = %v;", g.declaration(t, name), value)
+}
+
+// workgroupDeclaration declares the workgroup variable as shared by the invocations of the workgroup
+func (g *GLSLEmitter) workgroupDeclaration(v *VarSymbol, t Type, name string) string {
+	return fmt.Sprintf("shared %v;", g.declaration(t, name))
+}
+
+func (g *GLSLEmitter) discardStmt() string {
+	switch g.options.Discard {
+	case DiscardModeKill:
+		return "discard"
+	case DiscardModeDemote:
+		g.require("GL_EXT_demote_to_helper_invocation")
+		return "demote"
+	default:
+		panic("unknown discard mode")
+	}
+}
+
+// require enables the extension in the emitted source
+func (g *GLSLEmitter) require(extension string) {
+	if !slices.Contains(g.extensions, extension) {
+		g.extensions = append(g.extensions, extension)
+	}
+}
+
+func (g *GLSLEmitter) discardDemotes() bool { return g.options.Discard == DiscardModeDemote }
+
+func (g *GLSLEmitter) builtinCall(builtin BuiltinFunc, t Type, args []sourceExpr) sourceExpr {
+	if builtin.IsMath() {
+		return funcCall(builtin.String(), args)
+	}
+	switch builtin {
+	case BuiltinFuncDpdx:
+		return sourceExpr{fmt.Sprintf("dFdx(%v)", args[0]), precPostfix}
+	case BuiltinFuncDpdy:
+		return sourceExpr{fmt.Sprintf("dFdy(%v)", args[0]), precPostfix}
+	case BuiltinFuncFwidth:
+		return sourceExpr{fmt.Sprintf("fwidth(%v)", args[0]), precPostfix}
+	case BuiltinFuncTextureSample:
+		return funcCall("texture", args)
+	case BuiltinFuncTextureSampleLevel:
+		return funcCall("textureLod", args)
+	case BuiltinFuncImageLoad, BuiltinFuncImageStore:
+		return funcCall(builtin.String(), args)
+	case BuiltinFuncWorkgroupBarrier:
+		// barrier also makes the writes to shared variables visible to the workgroup in compute shaders
+		return sourceExpr{"barrier()", precPostfix}
+	default:
+		panic("unexpected builtin function")
+	}
+}
+
+// resource declares buffers as shader storage blocks holding the value they point to, uniforms as uniform blocks
+// holding them, textures as uniform samplers and images as uniform images of float4 texels. the entry point uses the
+// member of the block, the sampler or the image directly
+func (g *GLSLEmitter) resource(binding int, t Type, name string) sourceResource {
+	name = resourceName(binding, name)
+	res := sourceResource{ref: sourceExpr{name, precPostfix}}
+	switch u := t.Resolve(false).(type) {
+	case *PointerType:
+		res.decl = fmt.Sprintf(
+			"layout(std430, binding = %v) buffer %v {\n\t%v;\n};\n",
+			binding, blockName(name), g.declaration(u.ElementType, name),
+		)
+	case *TextureType:
+		format := ""
+		if u.Storage {
+			format = ", rgba32f"
+		}
+		res.decl = fmt.Sprintf("layout(binding = %v%v) uniform %v;\n", binding, format, g.declaration(t, name))
+	default:
+		res.decl = fmt.Sprintf(
+			"layout(std140, binding = %v) uniform %v {\n\t%v;\n};\n",
+			binding, blockName(name), g.declaration(t, name),
+		)
+	}
+	return res
+}
+
+// inputParam declares nothing since the inputs are built-in variables of GLSL
+func (g *GLSLEmitter) inputParam(builtin BuiltinFunc, name string) string { return "" }
+
+func (g *GLSLEmitter) input(builtin BuiltinFunc) sourceExpr {
+	switch builtin {
+	case BuiltinFuncLocalInvocationIndex:
+		return sourceExpr{"gl_LocalInvocationIndex", precPostfix}
+	case BuiltinFuncFrontFacing:
+		return sourceExpr{"gl_FrontFacing", precPostfix}
+	default:
+		panic("unexpected builtin input")
+	}
+}
+
+// stageInputs declares the inputs as global variables at their locations, main passes them to the parameters of the
+// entry point
+func (g *GLSLEmitter) stageInputs(entry *FuncSymbol, inputs []stageVar) sourceStage {
+	var stage sourceStage
+	for _, input := range inputs {
+		name := fmt.Sprintf("sabre_input%v", input.location)
+		stage.decl += fmt.Sprintf("layout(location = %v) %vin %v;\n", input.location, glslInterpolation(input), g.declaration(input.t, name))
+		stage.params = append(stage.params, g.declaration(input.t, input.name))
+		g.inputs = append(g.inputs, name)
+	}
+	return stage
+}
+
+// stageOutputs declares the outputs as global variables at their locations, the position of vertex shaders is
+// written to gl_Position. main writes the results of the entry point to them
+func (g *GLSLEmitter) stageOutputs(entry *FuncSymbol, outputs []stageVar) sourceStage {
+	var stage sourceStage
+	for _, output := range outputs {
+		if output.position {
+			g.outputs = append(g.outputs, "gl_Position")
+			continue
+		}
+		stage.decl += fmt.Sprintf("layout(location = %v) %vout %v;\n", output.location, glslInterpolation(output), g.declaration(output.t, output.name))
+		g.outputs = append(g.outputs, output.name)
+	}
+	return stage
+}
+
+// glslInterpolation returns the qualifier of the input or the output which isn't interpolated
+func glslInterpolation(v stageVar) string {
+	if v.flat {
+		return "flat "
+	}
+	return ""
+}
+
+// glslIdentifier returns the name used for the identifier in GLSL, names reserved by GLSL and names starting with
+// the prefixes of GLSL built-ins and of the names generated by the emitter get an underscore suffix
+func glslIdentifier(name string) string {
+	if glslReservedNames[name] || strings.HasPrefix(name, "gl_") || strings.HasPrefix(name, "sabre_") {
+		return name + "_"
+	}
+	return name
+}
+
+var glslReservedNames = reservedNames(`
+	main
+	attribute const uniform varying buffer shared coherent volatile restrict readonly writeonly atomic_uint
+	layout centroid flat smooth noperspective patch sample invariant precise break continue do for while switch
+	case default if else subroutine in out inout int void bool true false float double discard demote return
+	struct uint lowp mediump highp precision
+	vec2 vec3 vec4 ivec2 ivec3 ivec4 bvec2 bvec3 bvec4 uvec2 uvec3 uvec4 dvec2 dvec3 dvec4
+	mat2 mat3 mat4 mat2x2 mat2x3 mat2x4 mat3x2 mat3x3 mat3x4 mat4x2 mat4x3 mat4x4
+	dmat2 dmat3 dmat4 dmat2x2 dmat2x3 dmat2x4 dmat3x2 dmat3x3 dmat3x4 dmat4x2 dmat4x3 dmat4x4
+	sampler1D sampler2D sampler3D samplerCube sampler2DRect sampler1DArray sampler2DArray samplerCubeArray
+	samplerBuffer sampler2DMS sampler2DMSArray sampler1DShadow sampler2DShadow samplerCubeShadow
+	sampler2DRectShadow sampler1DArrayShadow sampler2DArrayShadow samplerCubeArrayShadow
+	isampler1D isampler2D isampler3D isamplerCube usampler1D usampler2D usampler3D usamplerCube
+	image1D image2D image3D imageCube image2DRect image1DArray image2DArray imageCubeArray imageBuffer
+	iimage1D iimage2D iimage3D uimage1D uimage2D uimage3D
+	common partition active asm class union enum typedef template this resource goto inline noinline public
+	static extern external interface long short half fixed unsigned superp input output hvec2 hvec3 hvec4
+	fvec2 fvec3 fvec4 sampler3DRect filter sizeof cast namespace using
+	radians degrees sin cos tan asin acos atan sinh cosh tanh asinh acosh atanh pow exp log exp2 log2 sqrt
+	inversesqrt abs sign floor trunc round roundEven ceil fract mod modf min max clamp mix step smoothstep isnan
+	isinf floatBitsToInt floatBitsToUint intBitsToFloat uintBitsToFloat fma frexp ldexp length distance dot
+	cross normalize faceforward reflect refract matrixCompMult outerProduct transpose determinant inverse
+	lessThan lessThanEqual greaterThan greaterThanEqual equal notEqual any all not uaddCarry usubBorrow
+	umulExtended imulExtended bitfieldExtract bitfieldInsert bitfieldReverse bitCount findLSB findMSB
+	dFdx dFdy fwidth barrier memoryBarrier texture textureSize texelFetch
+`)
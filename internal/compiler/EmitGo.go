@@ -0,0 +1,792 @@
+package compiler
+
+import (
+	"fmt"
+	"go/constant"
+	"go/format"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GoOptions controls how the unit is translated to Go
+type GoOptions struct {
+	// Package is the name of the generated package, it's shader if it's left empty
+	Package string
+}
+
+// GoEmitter translates the checked unit to a Go package which runs the shaders on the CPU, vectors become structs
+// with functions for their operators and methods for their swizzles, and the entry points get exported functions which
+// run them
+type GoEmitter struct {
+	*sourceEmitter
+	cDialect
+	options GoOptions
+	// declared vector types, and the functions and methods of their operators and swizzles
+	vectorDecls map[string]bool
+	// declared functions of the builtins
+	builtinDecls map[BuiltinFunc]bool
+	// whether the functions of the math builtins use the math package
+	importsMath bool
+	// whether the source discards, discard panics with a value the fragment entry points recover from
+	discards bool
+	// whether the source uses the sync package to run the invocations of workgroups with barriers concurrently
+	importsSync bool
+	// whether the texture interface is declared
+	texture bool
+	// whether the image interface is declared
+	image bool
+}
+
+func NewGoEmitter(u *Unit, options GoOptions) *GoEmitter {
+	if options.Package == "" {
+		options.Package = "shader"
+	}
+	g := &GoEmitter{options: options, vectorDecls: make(map[string]bool), builtinDecls: make(map[BuiltinFunc]bool)}
+	g.sourceEmitter = newSourceEmitter(u, g)
+	return g
+}
+
+func (g *GoEmitter) Emit() string {
+	g.emitFuncs(g.unit.semanticInfo.EntryPoints)
+	if g.unit.HasErrors() {
+		return ""
+	}
+	for _, entry := range g.unit.semanticInfo.EntryPoints {
+		g.emitEntryPoint(entry)
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "// Code generated by sabre. DO NOT EDIT.\n\npackage %v\n", g.options.Package)
+	switch {
+	case g.importsMath && g.importsSync:
+		out.WriteString("\nimport (\n\t\"math\"\n\t\"sync\"\n)\n")
+	case g.importsMath:
+		out.WriteString("\nimport \"math\"\n")
+	case g.importsSync:
+		out.WriteString("\nimport \"sync\"\n")
+	}
+	for _, decl := range g.decls {
+		out.WriteString("\n")
+		out.WriteString(decl)
+	}
+	// the statements are emitted like in C and gofmt removes the semicolons and the parentheses around conditions
+	source, err := format.Source([]byte(out.String()))
+	if err != nil {
+		panic(fmt.Sprintf("generated Go source is invalid: %v", err))
+	}
+	return string(source)
+}
+
+// emitEntryPoint emits the exported function running the entry point, it takes an input struct holding the resources
+// and the inputs of the entry point. compute shaders are dispatched over a grid of workgroups, vertex and fragment
+// shaders return an output struct holding their results and fragment shaders also tell whether they were discarded
+func (g *GoEmitter) emitEntryPoint(entry *FuncSymbol) {
+	name := g.funcName(entry, nil, nil, nil)
+	exportedName := goExportedName(entry.Name())
+	inputType := exportedName + "Input"
+	args := g.entryPointArgs(entry, inputType)
+
+	var decl strings.Builder
+	outputType := exportedName + "Output"
+	switch entry.Stage {
+	case ShaderStageVertex:
+		outputs := g.entryPointOutputs(entry, outputType, nil)
+		fmt.Fprintf(&decl, "// Vertex%v runs the vertex shader %v\n", exportedName, entry.Name())
+		if outputs == "" {
+			fmt.Fprintf(&decl, "func Vertex%v(in %v) {\n\t%v(%v)\n}\n", exportedName, inputType, name, args)
+			break
+		}
+		fmt.Fprintf(&decl, "func Vertex%v(in %v) (out %v) {\n", exportedName, inputType, outputType)
+		fmt.Fprintf(&decl, "\t%v = %v(%v)\n\treturn out\n}\n", outputs, name, args)
+	case ShaderStageFragment:
+		outputs := g.entryPointOutputs(entry, outputType, []string{"Discarded bool"})
+		fmt.Fprintf(&decl, "// Fragment%v runs the fragment shader %v\n", exportedName, entry.Name())
+		fmt.Fprintf(&decl, "func Fragment%v(in %v) (out %v) {\n", exportedName, inputType, outputType)
+		if g.discards {
+			decl.WriteString("\tdefer func() {\n")
+			decl.WriteString("\t\tif r := recover(); r != nil {\n")
+			decl.WriteString("\t\t\tif _, ok := r.(sabre_discard); !ok {\n\t\t\t\tpanic(r)\n\t\t\t}\n")
+			decl.WriteString("\t\t\tout.Discarded = true\n")
+			decl.WriteString("\t\t}\n\t}()\n")
+		}
+		if outputs != "" {
+			fmt.Fprintf(&decl, "\t%v = %v(%v)\n\treturn out\n}\n", outputs, name, args)
+			break
+		}
+		fmt.Fprintf(&decl, "\t%v(%v)\n\treturn out\n}\n", name, args)
+	case ShaderStageCompute:
+		size := workgroupSize(entry)
+		invocations := size[0] * size[1] * size[2]
+		fmt.Fprintf(&decl, "// Dispatch%v runs the compute shader %v for every invocation of the given number of workgroups\n", exportedName, entry.Name())
+		fmt.Fprintf(&decl, "func Dispatch%v(in %v, groupsX, groupsY, groupsZ uint32) {\n", exportedName, inputType)
+		decl.WriteString("\tfor z := uint32(0); z < groupsZ; z++ {\n")
+		decl.WriteString("\t\tfor y := uint32(0); y < groupsY; y++ {\n")
+		decl.WriteString("\t\t\tfor x := uint32(0); x < groupsX; x++ {\n")
+		if g.builtinDecls[BuiltinFuncWorkgroupBarrier] {
+			// the invocations wait for each other at the barriers so they run concurrently
+			fmt.Fprintf(&decl, "\t\t\t\tsabre_workgroup = sabre_newBarrier(%v)\n", invocations)
+			decl.WriteString("\t\t\t\tvar wg sync.WaitGroup\n")
+			fmt.Fprintf(&decl, "\t\t\t\tfor i := uint32(0); i < %v; i++ {\n", invocations)
+			decl.WriteString("\t\t\t\t\twg.Add(1)\n")
+			fmt.Fprintf(&decl, "\t\t\t\t\tgo func(i uint32) {\n\t\t\t\t\t\tdefer wg.Done()\n\t\t\t\t\t\t%v(%v)\n\t\t\t\t\t}(i)\n", name, args)
+			decl.WriteString("\t\t\t\t}\n\t\t\t\twg.Wait()\n")
+		} else {
+			fmt.Fprintf(&decl, "\t\t\t\tfor i := uint32(0); i < %v; i++ {\n", invocations)
+			fmt.Fprintf(&decl, "\t\t\t\t\t%v(%v)\n", name, args)
+			decl.WriteString("\t\t\t\t}\n")
+		}
+		decl.WriteString("\t\t\t}\n\t\t}\n\t}\n}\n")
+	default:
+		panic("unexpected shader stage")
+	}
+	g.decls = append(g.decls, decl.String())
+}
+
+// entryPointArgs declares the input struct of the entry point and returns the arguments passing its fields to the
+// entry point. the struct holds the parameters followed by the builtin inputs, except for the index of the invocation
+// in its workgroup which is the loop variable of the dispatch. the entry point takes its resources before the inputs
+// of its stage
+func (g *GoEmitter) entryPointArgs(entry *FuncSymbol, inputType string) string {
+	var fields, args, stageArgs []string
+	funcType := g.typeOf(entry).Type.(*FuncType)
+	for i, paramSym := range g.paramSymbolsOf(entry) {
+		field := fmt.Sprintf("Resource%v", i)
+		if paramSym != nil && paramSym.Name() != "_" {
+			field = goExportedName(paramSym.Name())
+		}
+		fields = append(fields, g.paramDeclaration(nil, funcType.ParameterTypes[i], field, false))
+		if entry.Stage != ShaderStageCompute && isStageValue(funcType.ParameterTypes[i]) {
+			stageArgs = append(stageArgs, "in."+field)
+		} else {
+			args = append(args, "in."+field)
+		}
+	}
+	args = append(args, stageArgs...)
+	for _, input := range g.unit.semanticInfo.Inputs[entry] {
+		switch input {
+		case BuiltinFuncLocalInvocationIndex:
+			args = append(args, "i")
+		case BuiltinFuncFrontFacing:
+			fields = append(fields, "FrontFacing bool")
+			args = append(args, "in.FrontFacing")
+		default:
+			panic("unexpected builtin input")
+		}
+	}
+
+	decl := fmt.Sprintf("// %v is the input of the %v shader %v\n", inputType, entry.Stage, entry.Name())
+	g.decls = append(g.decls, decl+g.structDeclaration(inputType, fields))
+	return strings.Join(args, ", ")
+}
+
+// entryPointOutputs declares the output struct of the vertex or fragment entry point after the given fields and
+// returns the fields its results are assigned to, vertex entry points return their position first. it's empty for
+// entry points without results
+func (g *GoEmitter) entryPointOutputs(entry *FuncSymbol, outputType string, fields []string) string {
+	var targets []string
+	funcType := g.typeOf(entry).Type.(*FuncType)
+	for _, output := range stageOutputsOf(entry, funcType) {
+		field := fmt.Sprintf("Output%v", output.location)
+		if output.position {
+			field = "Position"
+		}
+		fields = append(fields, fmt.Sprintf("%v %v", field, g.typeName(output.t)))
+		targets = append(targets, "out."+field)
+	}
+	if len(fields) > 0 {
+		decl := fmt.Sprintf("// %v is the output of the %v shader %v\n", outputType, entry.Stage, entry.Name())
+		g.decls = append(g.decls, decl+g.structDeclaration(outputType, fields))
+	}
+	return strings.Join(targets, ", ")
+}
+
+// goExportedName returns the name with its first letter in upper case
+func goExportedName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func (g *GoEmitter) identifier(name string) string {
+	if goReservedNames[name] || strings.HasPrefix(name, "sabre_") {
+		return name + "_"
+	}
+	return name
+}
+
+func (g *GoEmitter) scalarTypeName(t Type) string {
+	switch t.(type) {
+	case *BoolType:
+		return "bool"
+	case *IntType:
+		return "int32"
+	case *UintType:
+		return "uint32"
+	case *Float32Type:
+		return "float32"
+	case *Float64Type:
+		return "float64"
+	default:
+		panic("unexpected type")
+	}
+}
+
+func (g *GoEmitter) arrayTypeName(t *ArrayType) string {
+	return fmt.Sprintf("[%v]%v", t.Length, g.typeName(t.ElementType))
+}
+
+func (g *GoEmitter) variableDeclaration(t Type, name string) string {
+	return fmt.Sprintf("var %v %v", name, g.typeName(t))
+}
+
+func (g *GoEmitter) paramDeclaration(sym Symbol, t Type, name string, buffer bool) string {
+	if name == "" {
+		// either all the parameters are named or none of them is
+		name = "_"
+	}
+	if pointerType, ok := t.Resolve(false).(*PointerType); ok {
+		return fmt.Sprintf("%v *%v", name, g.typeName(pointerType.ElementType))
+	}
+	return fmt.Sprintf("%v %v", name, g.typeName(t))
+}
+
+func (g *GoEmitter) fieldDeclaration(t Type, name string) string {
+	return fmt.Sprintf("%v %v", name, g.typeName(t))
+}
+
+func (g *GoEmitter) structDeclaration(name string, fields []string) string {
+	var decl strings.Builder
+	fmt.Fprintf(&decl, "type %v struct {\n", name)
+	for _, field := range fields {
+		fmt.Fprintf(&decl, "\t%v\n", field)
+	}
+	decl.WriteString("}\n")
+	return decl.String()
+}
+
+func (g *GoEmitter) signature(sym *FuncSymbol, name string, params []string, result Type) string {
+	signature := fmt.Sprintf("func %v(%v)", name, strings.Join(params, ", "))
+	switch result := result.(type) {
+	case nil:
+	case *TupleType:
+		results := make([]string, len(result.Types))
+		for i, t := range result.Types {
+			results[i] = g.typeName(t)
+		}
+		signature += fmt.Sprintf(" (%v)", strings.Join(results, ", "))
+	default:
+		signature += " " + g.typeName(result)
+	}
+	return signature
+}
+
+// multipleResults returns true since Go functions return multiple results
+func (g *GoEmitter) multipleResults() bool { return true }
+
+// floatLiteral returns untyped float constants, they take the type of the expression they're used in
+func (g *GoEmitter) floatLiteral(value float64, bitSize int) string {
+	return formatFloat(value, bitSize)
+}
+
+func (g *GoEmitter) uintLiteral(value uint64) string {
+	return strconv.FormatUint(value, 10)
+}
+
+func (g *GoEmitter) compositeValue(t Type, elements []string) sourceExpr {
+	return sourceExpr{fmt.Sprintf("%v{%v}", g.typeName(t), strings.Join(elements, ", ")), precPostfix}
+}
+
+// compositeLiteral names the fields if some of them are omitted, since they're zero initialized
+func (g *GoEmitter) vectorLiteral(t *VectorType, components []string) sourceExpr {
+	return g.compositeValue(t, components)
+}
+
+func (g *GoEmitter) compositeLiteral(t Type, fields []string) sourceExpr {
+	if !slices.Contains(fields, "") {
+		return g.compositeValue(t, fields)
+	}
+	structType := t.Resolve(true).(*StructType)
+	var elements []string
+	for i, field := range fields {
+		if field != "" {
+			elements = append(elements, fmt.Sprintf("%v: %v", g.identifier(structType.Fields[i].Name()), field))
+		}
+	}
+	return g.compositeValue(t, elements)
+}
+
+func (g *GoEmitter) zeroValue(t Type) sourceExpr {
+	switch t.Resolve(true).(type) {
+	case *BoolType:
+		return g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: t, Value: constant.MakeBool(false)})
+	case *ArrayType, *StructType, *VectorType:
+		return g.compositeValue(t, nil)
+	default:
+		return g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: t, Value: constant.MakeInt64(0)})
+	}
+}
+
+// constantDeclaration declares the constant array as a package level variable since Go has no array constants
+func (g *GoEmitter) constantDeclaration(t Type, name string, value sourceExpr) string {
+	return fmt.Sprintf("var %v = %v", name, value)
+}
+
+// workgroupDeclaration declares the workgroup variable as a package level variable, the workgroups of a dispatch run
+// one after the other so their invocations share it
+func (g *GoEmitter) workgroupDeclaration(v *VarSymbol, t Type, name string) string {
+	return fmt.Sprintf("var %v %v", name, g.typeName(t))
+}
+
+// discardStmt panics with the discard value, which is recovered by the fragment entry point
+func (g *GoEmitter) discardStmt() string {
+	if !g.discards {
+		g.discards = true
+		g.decls = append(g.decls, "// sabre_discard is the value discarded fragments panic with\ntype sabre_discard struct{}\n")
+	}
+	return "panic(sabre_discard{})"
+}
+
+// builtinCall calls the function of the builtin, it's declared first if it wasn't already. Each invocation runs on its
+// own, so derivatives are zero and barriers have nothing to wait for. math builtins called on vectors call the
+// function of the vector type, which calls the scalar function on each component
+func (g *GoEmitter) builtinCall(builtin BuiltinFunc, t Type, args []sourceExpr) sourceExpr {
+	name := builtinName(builtin)
+	switch builtin {
+	case BuiltinFuncTextureSample:
+		// there are no derivatives to pick the level of detail from so the base level is sampled
+		return sourceExpr{fmt.Sprintf("%v.SampleLevel(%v, 0)", args[0], args[1]), precPostfix}
+	case BuiltinFuncTextureSampleLevel:
+		return sourceExpr{fmt.Sprintf("%v.SampleLevel(%v, %v)", args[0], args[1], args[2]), precPostfix}
+	case BuiltinFuncImageLoad:
+		return sourceExpr{fmt.Sprintf("%v.Load(%v)", args[0], args[1]), precPostfix}
+	case BuiltinFuncImageStore:
+		return sourceExpr{fmt.Sprintf("%v.Store(%v, %v)", args[0], args[1], args[2]), precPostfix}
+	}
+	if !g.builtinDecls[builtin] {
+		g.builtinDecls[builtin] = true
+		switch builtin {
+		case BuiltinFuncDpdx, BuiltinFuncDpdy, BuiltinFuncFwidth:
+			// there are no neighboring invocations on the CPU so the derivatives are zero
+			g.decls = append(g.decls, fmt.Sprintf("func %v(v float32) float32 {\n\treturn 0\n}\n", name))
+		case BuiltinFuncWorkgroupBarrier:
+			g.importsSync = true
+			g.decls = append(g.decls, goWorkgroupBarrier)
+		default:
+			if !builtin.IsMath() {
+				panic("unexpected builtin function")
+			}
+			g.mathBuiltinDecl(builtin, name)
+		}
+	}
+	if builtin.IsMath() || builtin.IsDerivative() {
+		if vectorType, ok := t.Resolve(true).(*VectorType); ok {
+			name = g.vectorMathBuiltin(builtin, name, vectorType)
+		}
+	}
+	return funcCall(name, args)
+}
+
+// goWorkgroupBarrier declares the barrier the invocations of the workgroup being dispatched wait at, the dispatches of
+// the entry points using barriers can't run concurrently since they share it
+const goWorkgroupBarrier = `type sabre_barrier struct {
+	mu         sync.Mutex
+	cond       *sync.Cond
+	size       uint32
+	arrived    uint32
+	generation uint32
+}
+
+func sabre_newBarrier(size uint32) *sabre_barrier {
+	b := &sabre_barrier{size: size}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+func (b *sabre_barrier) wait() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	generation := b.generation
+	b.arrived++
+	if b.arrived == b.size {
+		b.arrived = 0
+		b.generation++
+		b.cond.Broadcast()
+		return
+	}
+	for generation == b.generation {
+		b.cond.Wait()
+	}
+}
+
+var sabre_workgroup *sabre_barrier
+
+func sabre_workgroupBarrier() {
+	sabre_workgroup.wait()
+}
+`
+
+// goMathFuncs are the functions of the math package implementing the math builtins, the other builtins are
+// implemented with Go's min and max
+var goMathFuncs = map[BuiltinFunc]string{
+	BuiltinFuncAbs:   "math.Abs",
+	BuiltinFuncFloor: "math.Floor",
+	BuiltinFuncCeil:  "math.Ceil",
+	BuiltinFuncSqrt:  "math.Sqrt",
+	BuiltinFuncExp:   "math.Exp",
+	BuiltinFuncLog:   "math.Log",
+	BuiltinFuncPow:   "math.Pow",
+	BuiltinFuncSin:   "math.Sin",
+	BuiltinFuncCos:   "math.Cos",
+	BuiltinFuncTan:   "math.Tan",
+}
+
+// mathBuiltinDecl declares the function of the math builtin on float32, the math package computes in float64
+func (g *GoEmitter) mathBuiltinDecl(builtin BuiltinFunc, name string) {
+	params := []string{"x", "y", "z"}[:builtin.arity()]
+	var body string
+	switch builtin {
+	case BuiltinFuncFract:
+		g.importsMath = true
+		body = "x - float32(math.Floor(float64(x)))"
+	case BuiltinFuncMin:
+		body = "min(x, y)"
+	case BuiltinFuncMax:
+		body = "max(x, y)"
+	case BuiltinFuncClamp:
+		body = "min(max(x, y), z)"
+	case BuiltinFuncMix:
+		body = "x + (y-x)*z"
+	default:
+		g.importsMath = true
+		args := make([]string, len(params))
+		for i, param := range params {
+			args[i] = fmt.Sprintf("float64(%v)", param)
+		}
+		body = fmt.Sprintf("float32(%v(%v))", goMathFuncs[builtin], strings.Join(args, ", "))
+	}
+	g.decls = append(g.decls, fmt.Sprintf("func %v(%v float32) float32 {\n\treturn %v\n}\n", name, strings.Join(params, ", "), body))
+}
+
+// vectorMathBuiltin returns the function of the math builtin on the vector type, it's declared first if it wasn't
+// already
+func (g *GoEmitter) vectorMathBuiltin(builtin BuiltinFunc, scalarName string, t *VectorType) string {
+	vectorName := g.vectorTypeName(t)
+	name := fmt.Sprintf("sabre_%v_%v", vectorName, builtin)
+	if g.vectorDecls[name] {
+		return name
+	}
+	g.vectorDecls[name] = true
+	params := []string{"x", "y", "z"}[:builtin.arity()]
+	components := make([]string, t.Width)
+	for i, c := range vectorComponents[:t.Width] {
+		args := make([]string, len(params))
+		for j, param := range params {
+			args[j] = fmt.Sprintf("%v.%c", param, c)
+		}
+		components[i] = fmt.Sprintf("%v(%v)", scalarName, strings.Join(args, ", "))
+	}
+	g.decls = append(g.decls, fmt.Sprintf(
+		"func %v(%v %v) %v {\n\treturn %v{%v}\n}\n",
+		name,
+		strings.Join(params, ", "),
+		vectorName,
+		vectorName,
+		vectorName,
+		strings.Join(components, ", "),
+	))
+	return name
+}
+
+// resource passes the resource as a parameter, buffers are pointers to the values they hold, uniforms are values and
+// textures and images implement their interfaces. the exported function running the entry point passes them from its
+// input struct
+func (g *GoEmitter) resource(binding int, t Type, name string) sourceResource {
+	return sourceResource{param: g.paramDeclaration(nil, t, name, false), ref: sourceExpr{name, precPostfix}}
+}
+
+// stageInputs passes the inputs as parameters following the resources, the exported function running the entry point
+// passes them from its input struct
+func (g *GoEmitter) stageInputs(entry *FuncSymbol, inputs []stageVar) sourceStage {
+	var stage sourceStage
+	for _, input := range inputs {
+		stage.params = append(stage.params, g.paramDeclaration(nil, input.t, input.name, false))
+	}
+	return stage
+}
+
+// stageOutputs declares nothing since the entry point returns its outputs as results, the exported function running
+// the entry point puts them in its output struct
+func (g *GoEmitter) stageOutputs(entry *FuncSymbol, outputs []stageVar) sourceStage {
+	return sourceStage{}
+}
+
+func (g *GoEmitter) inputParam(builtin BuiltinFunc, name string) string {
+	switch builtin {
+	case BuiltinFuncLocalInvocationIndex:
+		return name + " uint32"
+	case BuiltinFuncFrontFacing:
+		return name + " bool"
+	default:
+		panic("unexpected builtin input")
+	}
+}
+
+func (g *GoEmitter) discardedValue(e sourceExpr) string {
+	return "_ = " + e.text
+}
+
+// incDec adds one to vectors since they have no operators
+func (g *GoEmitter) incDec(t Type, operand string, operator Token) string {
+	vectorType, ok := t.Resolve(true).(*VectorType)
+	if !ok {
+		return operand + operator.Value()
+	}
+	op := TokenAdd
+	if operator.Kind() == TokenDec {
+		op = TokenSub
+	}
+	element := vectorElementType(vectorType)
+	one := g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: element, Value: constant.MakeInt64(1)})
+	return fmt.Sprintf("%v = %v", operand, g.vectorBinary(op, t, element, sourceExpr{operand, precPostfix}, one))
+}
+
+func (g *GoEmitter) addressOf(e sourceExpr) sourceExpr {
+	return sourceExpr{"&" + parenthesize(e, precPostfix), precUnary}
+}
+
+func (g *GoEmitter) dereference(e sourceExpr) sourceExpr {
+	return sourceExpr{"*" + parenthesize(e, precPostfix), precUnary}
+}
+
+// precedence returns the precedence of the operator in Go, which has five levels of binary operators
+func (g *GoEmitter) precedence(operator TokenKind) int {
+	switch operator {
+	case TokenMul, TokenDiv, TokenMod, TokenShl, TokenShr, TokenAnd, TokenAndNot:
+		return precMultiplicative
+	case TokenAdd, TokenSub, TokenOr, TokenXor:
+		return precAdditive
+	case TokenEQ, TokenNE, TokenLT, TokenGT, TokenLE, TokenGE:
+		return precRelational
+	case TokenLAnd:
+		return precLAnd
+	case TokenLOr:
+		return precLOr
+	default:
+		panic("unsupported binary operator")
+	}
+}
+
+func (g *GoEmitter) binaryOperandPrecedence(operator TokenKind) (int, int) {
+	prec := g.precedence(operator)
+	return prec, prec + 1
+}
+
+func (g *GoEmitter) complement(e sourceExpr) sourceExpr {
+	return sourceExpr{"^" + parenthesize(e, precPostfix), precUnary}
+}
+
+func (g *GoEmitter) andNot(operand sourceExpr) (string, sourceExpr) {
+	return "&^", operand
+}
+
+func (g *GoEmitter) loopHeader(init, cond, post string) string {
+	switch {
+	case init == "" && cond == "" && post == "":
+		return "for"
+	case init == "" && post == "":
+		return "for " + cond
+	default:
+		return fmt.Sprintf("for %v; %v; %v", init, cond, post)
+	}
+}
+
+// declaresInLoopHeader returns false since the init statement of a Go loop can't be a var declaration
+func (g *GoEmitter) declaresInLoopHeader() bool { return false }
+
+// caseClause lists the values of the case, each case is scoped on its own
+func (g *GoEmitter) caseClause(values []string) string {
+	if len(values) == 0 {
+		return "default:"
+	}
+	return fmt.Sprintf("case %v:", strings.Join(values, ", "))
+}
+
+func (g *GoEmitter) caseEnd() string { return "" }
+
+func (g *GoEmitter) casesFallThrough() bool { return false }
+
+// vectorTypeName returns the name of the vector struct, it's declared first if it wasn't already
+func (g *GoEmitter) vectorTypeName(t *VectorType) string {
+	name := t.String()
+	if g.vectorDecls[name] {
+		return name
+	}
+	g.vectorDecls[name] = true
+
+	components := strings.Split(vectorComponents[:t.Width], "")
+	decl := fmt.Sprintf("type %v struct {\n\t%v %v\n}\n", name, strings.Join(components, ", "), g.typeName(vectorElementType(t)))
+	g.decls = append(g.decls, decl)
+	return name
+}
+
+// textureTypeName returns the interface of the textures or the images, it's declared first if it wasn't already. The
+// textures and the images are implemented by the caller
+func (g *GoEmitter) textureTypeName(t *TextureType) string {
+	if t.Storage {
+		if !g.image {
+			g.image = true
+			coord, texel := g.typeName(builtinVectorType(BuiltinIntType, 2)), g.typeName(builtinVectorType(BuiltinFloat32Type, 4))
+			g.decls = append(g.decls, fmt.Sprintf(
+				"// Image2D is an image read and written by the shaders texel by texel\ntype Image2D interface {\n\tLoad(coord %v) %v\n\tStore(coord %v, value %v)\n}\n",
+				coord, texel, coord, texel,
+			))
+		}
+		return "Image2D"
+	}
+	if !g.texture {
+		g.texture = true
+		g.decls = append(g.decls, fmt.Sprintf(
+			"// Texture2D is a texture sampled by the shaders at the given level of detail\ntype Texture2D interface {\n\tSampleLevel(uv %v, lod float32) %v\n}\n",
+			g.typeName(builtinVectorType(BuiltinFloat32Type, 2)), g.typeName(builtinVectorType(BuiltinFloat32Type, 4)),
+		))
+	}
+	return "Texture2D"
+}
+
+// vectorBinary calls the function of the operator, scalar operands are splatted to vectors first
+func (g *GoEmitter) vectorBinary(operator TokenKind, lhsType, rhsType Type, lhs, rhs sourceExpr) sourceExpr {
+	lhsVector, rhsVector, lhs, rhs := vectorOperands(lhsType, rhsType, lhs, rhs, g.splat)
+	lhsName, rhsName := g.vectorTypeName(lhsVector), g.vectorTypeName(rhsVector)
+	name := fmt.Sprintf("sabre_%v_%v", lhsName, goVectorOperators[operator])
+	if rhsVector != lhsVector {
+		// shifts can shift by a vector of another type
+		name += "_" + rhsName
+	}
+	if !g.vectorDecls[name] {
+		g.vectorDecls[name] = true
+		result := lhsVector
+		switch operator {
+		case TokenEQ, TokenNE, TokenLT, TokenGT, TokenLE, TokenGE:
+			result = builtinVectorType(BuiltinBoolType, lhsVector.Width)
+		}
+		components := make([]string, lhsVector.Width)
+		for i, c := range vectorComponents[:lhsVector.Width] {
+			components[i] = fmt.Sprintf("a.%c %v b.%c", c, operator, c)
+		}
+		g.decls = append(g.decls, fmt.Sprintf(
+			"func %v(a %v, b %v) %v {\n\treturn %v{%v}\n}\n",
+			name,
+			lhsName,
+			rhsName,
+			g.vectorTypeName(result),
+			g.vectorTypeName(result),
+			strings.Join(components, ", "),
+		))
+	}
+	return sourceExpr{fmt.Sprintf("%v(%v, %v)", name, lhs, rhs), precPostfix}
+}
+
+// splat returns the vector with all of its components set to the scalar
+func (g *GoEmitter) splat(t *VectorType, scalar sourceExpr) sourceExpr {
+	vectorName := g.vectorTypeName(t)
+	name := fmt.Sprintf("sabre_%v_splat", vectorName)
+	if !g.vectorDecls[name] {
+		g.vectorDecls[name] = true
+		components := slices.Repeat([]string{"s"}, t.Width)
+		g.decls = append(g.decls, fmt.Sprintf(
+			"func %v(s %v) %v {\n\treturn %v{%v}\n}\n",
+			name,
+			g.typeName(vectorElementType(t)),
+			vectorName,
+			vectorName,
+			strings.Join(components, ", "),
+		))
+	}
+	return sourceExpr{fmt.Sprintf("%v(%v)", name, scalar), precPostfix}
+}
+
+func (g *GoEmitter) vectorUnary(operator TokenKind, t *VectorType, operand sourceExpr) sourceExpr {
+	vectorName := g.vectorTypeName(t)
+	name := fmt.Sprintf("sabre_%v_%v", vectorName, goVectorUnaryOperators[operator])
+	if !g.vectorDecls[name] {
+		g.vectorDecls[name] = true
+		components := make([]string, t.Width)
+		for i, c := range vectorComponents[:t.Width] {
+			components[i] = fmt.Sprintf("%vv.%c", operator, c)
+		}
+		g.decls = append(g.decls, fmt.Sprintf(
+			"func %v(v %v) %v {\n\treturn %v{%v}\n}\n",
+			name,
+			vectorName,
+			vectorName,
+			vectorName,
+			strings.Join(components, ", "),
+		))
+	}
+	return sourceExpr{fmt.Sprintf("%v(%v)", name, operand), precPostfix}
+}
+
+// swizzle returns single components as fields and calls a method returning a vector of the components otherwise,
+// the rgba and stqp swizzle sets are named by the xyzw components
+func (g *GoEmitter) swizzle(t *VectorType, base sourceExpr, components string) sourceExpr {
+	components = swizzleComponents(components)
+	if len(components) == 1 {
+		return sourceExpr{fmt.Sprintf("%v.%v", parenthesize(base, precPostfix), components), precPostfix}
+	}
+
+	vectorName := g.vectorTypeName(t)
+	key := vectorName + "." + components
+	if !g.vectorDecls[key] {
+		g.vectorDecls[key] = true
+		result := g.vectorTypeName(builtinVectorType(vectorElementType(t), len(components)))
+		fields := make([]string, len(components))
+		for i, c := range components {
+			fields[i] = fmt.Sprintf("v.%c", c)
+		}
+		g.decls = append(g.decls, fmt.Sprintf(
+			"func (v %v) %v() %v {\n\treturn %v{%v}\n}\n",
+			vectorName,
+			components,
+			result,
+			result,
+			strings.Join(fields, ", "),
+		))
+	}
+	return sourceExpr{fmt.Sprintf("%v.%v()", parenthesize(base, precPostfix), components), precPostfix}
+}
+
+var goVectorOperators = map[TokenKind]string{
+	TokenAdd:    "add",
+	TokenSub:    "sub",
+	TokenMul:    "mul",
+	TokenDiv:    "div",
+	TokenMod:    "mod",
+	TokenAnd:    "and",
+	TokenOr:     "or",
+	TokenXor:    "xor",
+	TokenAndNot: "andNot",
+	TokenShl:    "shl",
+	TokenShr:    "shr",
+	TokenLAnd:   "lAnd",
+	TokenLOr:    "lOr",
+	TokenEQ:     "eq",
+	TokenNE:     "ne",
+	TokenLT:     "lt",
+	TokenLE:     "le",
+	TokenGT:     "gt",
+	TokenGE:     "ge",
+}
+
+var goVectorUnaryOperators = map[TokenKind]string{
+	TokenSub: "neg",
+	TokenNot: "not",
+	TokenXor: "complement",
+}
+
+var goReservedNames = reservedNames(`
+	break case chan const continue default defer else fallthrough for func go goto if import interface map package
+	range return select struct switch type var
+	any bool byte comparable complex64 complex128 error float32 float64 int int8 int16 int32 int64 rune string uint
+	uint8 uint16 uint32 uint64 uintptr true false iota nil append cap clear close complex copy delete imag len make
+	max min new panic print println real recover init Texture2D Image2D
+	f32x2 f32x3 f32x4 f64x2 f64x3 f64x4 i32x2 i32x3 i32x4 u32x2 u32x3 u32x4 b32x2 b32x3 b32x4
+`)
@@ -0,0 +1,414 @@
+package compiler
+
+import (
+	"fmt"
+	"go/constant"
+	"strings"
+)
+
+// HLSLEmitter translates the checked unit to HLSL source for shader model 6, entry points are marked with the shader
+// attribute so the source is compiled as a library by DXC
+type HLSLEmitter struct {
+	*sourceEmitter
+	cDialect
+	// names of the functions constructing struct literals by the struct name and the fields they set
+	constructors map[string]string
+	// fields of the struct the entry point being emitted returns its outputs in
+	outputs []string
+}
+
+func NewHLSLEmitter(u *Unit) *HLSLEmitter {
+	g := &HLSLEmitter{constructors: make(map[string]string)}
+	g.sourceEmitter = newSourceEmitter(u, g)
+	return g
+}
+
+func (g *HLSLEmitter) Emit() string {
+	g.emitFuncs(g.unit.semanticInfo.EntryPoints)
+	if g.unit.HasErrors() {
+		return ""
+	}
+	return strings.Join(g.decls, "\n")
+}
+
+func (g *HLSLEmitter) identifier(name string) string {
+	if hlslReservedNames[name] || strings.HasPrefix(name, "SV_") || strings.HasPrefix(name, "sabre_") {
+		return name + "_"
+	}
+	return name
+}
+
+func (g *HLSLEmitter) scalarTypeName(t Type) string {
+	switch t.(type) {
+	case *BoolType:
+		return "bool"
+	case *IntType:
+		return "int"
+	case *UintType:
+		return "uint"
+	case *Float32Type:
+		return "float"
+	case *Float64Type:
+		return "double"
+	default:
+		panic("unexpected type")
+	}
+}
+
+func (g *HLSLEmitter) vectorTypeName(t *VectorType) string {
+	return fmt.Sprintf("%v%v", g.scalarTypeName(vectorElementType(t)), t.Width)
+}
+
+// textureTypeName returns the texture of float4 texels, its sampler is passed next to it. images are unordered access
+// views which are written without a sampler
+func (g *HLSLEmitter) textureTypeName(t *TextureType) string {
+	if t.Storage {
+		return "RWTexture2D<float4>"
+	}
+	return "Texture2D<float4>"
+}
+
+func (g *HLSLEmitter) textureArg(texture sourceExpr) string {
+	return textureWithSampler(texture)
+}
+
+// vectorBinary uses the operators which apply to each component, scalar operands are promoted to vectors implicitly
+func (g *HLSLEmitter) vectorBinary(operator TokenKind, lhsType, rhsType Type, lhs, rhs sourceExpr) sourceExpr {
+	return g.infix(operator, lhs, rhs)
+}
+
+// splat casts the scalar to the vector, which sets all of its components to it
+func (g *HLSLEmitter) splat(t *VectorType, scalar sourceExpr) sourceExpr {
+	return sourceExpr{fmt.Sprintf("(%v)%v", g.vectorTypeName(t), parenthesize(scalar, precPostfix)), precUnary}
+}
+
+// arrayTypeName returns the type with the array sizes after the element type, declarations move the sizes after the
+// declared name
+func (g *HLSLEmitter) arrayTypeName(t *ArrayType) string {
+	element := g.typeName(t.ElementType)
+	if i := strings.IndexByte(element, '['); i >= 0 {
+		return fmt.Sprintf("%v[%v]%v", element[:i], t.Length, element[i:])
+	}
+	return fmt.Sprintf("%v[%v]", element, t.Length)
+}
+
+func (g *HLSLEmitter) declaration(t Type, name string) string {
+	typeName := g.typeName(t)
+	if name == "" {
+		return typeName
+	}
+	if i := strings.IndexByte(typeName, '['); i >= 0 {
+		return fmt.Sprintf("%v %v%v", typeName[:i], name, typeName[i:])
+	}
+	return fmt.Sprintf("%v %v", typeName, name)
+}
+
+func (g *HLSLEmitter) variableDeclaration(t Type, name string) string {
+	return g.declaration(t, name)
+}
+
+// paramDeclaration declares pointers as inout parameters which are copied back to the argument when the function
+// returns, which works the same for the elements of buffers. textures are followed by their sampler
+func (g *HLSLEmitter) paramDeclaration(sym Symbol, t Type, name string, buffer bool) string {
+	switch t := t.Resolve(false).(type) {
+	case *PointerType:
+		return "inout " + g.declaration(t.ElementType, name)
+	case *TextureType:
+		if t.Storage {
+			return g.declaration(t, name)
+		}
+		if name == "" {
+			return fmt.Sprintf("%v, SamplerState", g.declaration(t, name))
+		}
+		return fmt.Sprintf("%v, SamplerState %v", g.declaration(t, name), samplerName(name))
+	default:
+		return g.declaration(t, name)
+	}
+}
+
+func (g *HLSLEmitter) fieldDeclaration(t Type, name string) string {
+	return g.declaration(t, name) + ";"
+}
+
+// signature marks the entry points with their stage, compute shaders declare their workgroup size. entry points
+// returning outputs return the struct holding them
+func (g *HLSLEmitter) signature(sym *FuncSymbol, name string, params []string, result Type) string {
+	var attributes string
+	switch sym.Stage {
+	case ShaderStageNone:
+	case ShaderStageVertex:
+		attributes = "[shader(\"vertex\")]\n"
+	case ShaderStageFragment:
+		attributes = "[shader(\"pixel\")]\n"
+	case ShaderStageCompute:
+		size := workgroupSize(sym)
+		attributes = fmt.Sprintf("[shader(\"compute\")]\n[numthreads(%v, %v, %v)]\n", size[0], size[1], size[2])
+	default:
+		panic("unexpected shader stage")
+	}
+
+	resultName := "void"
+	if sym.IsEntryPoint() && g.function.stageResult != "" {
+		resultName = g.function.stageResult
+	} else if result != nil {
+		if _, ok := result.Resolve(true).(*ArrayType); ok {
+			resultExpr := sym.Decl().(*FuncDecl).Type.Result.Fields[0].Type
+			g.error(NewError(resultExpr.SourceRange(), "HLSL functions can't return arrays, function '%v' returns '%v'", sym.Name(), result))
+		}
+		resultName = g.typeName(result)
+	}
+	return fmt.Sprintf("%v%v %v(%v)", attributes, resultName, name, strings.Join(params, ", "))
+}
+
+// builtinCall names the math builtins like GLSL except for fract and mix
+func (g *HLSLEmitter) builtinCall(builtin BuiltinFunc, t Type, args []sourceExpr) sourceExpr {
+	switch builtin {
+	case BuiltinFuncFract:
+		return funcCall("frac", args)
+	case BuiltinFuncMix:
+		return funcCall("lerp", args)
+	}
+	if builtin.IsMath() {
+		return funcCall(builtin.String(), args)
+	}
+	switch builtin {
+	case BuiltinFuncDpdx:
+		return sourceExpr{fmt.Sprintf("ddx(%v)", args[0]), precPostfix}
+	case BuiltinFuncDpdy:
+		return sourceExpr{fmt.Sprintf("ddy(%v)", args[0]), precPostfix}
+	case BuiltinFuncFwidth:
+		return sourceExpr{fmt.Sprintf("fwidth(%v)", args[0]), precPostfix}
+	case BuiltinFuncTextureSample:
+		return sourceExpr{fmt.Sprintf("%v.Sample(%v, %v)", args[0], samplerName(args[0].text), args[1]), precPostfix}
+	case BuiltinFuncTextureSampleLevel:
+		return sourceExpr{
+			fmt.Sprintf("%v.SampleLevel(%v, %v, %v)", args[0], samplerName(args[0].text), args[1], args[2]),
+			precPostfix,
+		}
+	case BuiltinFuncImageLoad:
+		return sourceExpr{fmt.Sprintf("%v[uint2(%v)]", args[0], args[1]), precPostfix}
+	case BuiltinFuncImageStore:
+		return sourceExpr{fmt.Sprintf("%v[uint2(%v)] = %v", args[0], args[1], args[2]), precLowest}
+	case BuiltinFuncWorkgroupBarrier:
+		return sourceExpr{"GroupMemoryBarrierWithGroupSync()", precPostfix}
+	default:
+		panic("unexpected builtin function")
+	}
+}
+
+// resource binds buffers as structured buffers of a single struct holding the value they point to, they're written
+// by the shaders so they're unordered access views rather than constant buffers. uniforms are the only member of
+// their constant buffer, textures are bound as shader resource views with their sampler at the same register index
+// and images as unordered access views
+func (g *HLSLEmitter) resource(binding int, t Type, name string) sourceResource {
+	name = resourceName(binding, name)
+	res := sourceResource{ref: sourceExpr{name, precPostfix}}
+	switch u := t.Resolve(false).(type) {
+	case *PointerType:
+		block := blockName(name)
+		var decl strings.Builder
+		fmt.Fprintf(&decl, "%v\n", g.structDeclaration(block, []string{g.fieldDeclaration(u.ElementType, "value")}))
+		fmt.Fprintf(&decl, "RWStructuredBuffer<%v> %v : register(u%v);\n", block, name, binding)
+		return sourceResource{decl: decl.String(), ref: sourceExpr{name + "[0].value", precPostfix}}
+	case *TextureType:
+		if u.Storage {
+			res.decl = fmt.Sprintf("%v : register(u%v);\n", g.declaration(t, name), binding)
+			return res
+		}
+	default:
+		res.decl = fmt.Sprintf("cbuffer %v : register(b%v) {\n\t%v\n};\n", blockName(name), binding, g.fieldDeclaration(t, name))
+		return res
+	}
+	res.decl = fmt.Sprintf(
+		"%v : register(t%v);\nSamplerState %v : register(s%v);\n",
+		g.declaration(t, name), binding, samplerName(name), binding,
+	)
+	return res
+}
+
+// inputParam declares the input with its system value semantic
+func (g *HLSLEmitter) inputParam(builtin BuiltinFunc, name string) string {
+	switch builtin {
+	case BuiltinFuncLocalInvocationIndex:
+		return fmt.Sprintf("uint %v : SV_GroupIndex", name)
+	case BuiltinFuncFrontFacing:
+		return fmt.Sprintf("bool %v : SV_IsFrontFace", name)
+	default:
+		panic("unexpected builtin input")
+	}
+}
+
+func (g *HLSLEmitter) floatLiteral(value float64, bitSize int) string {
+	if bitSize == 64 {
+		return formatFloat(value, bitSize) + "L"
+	}
+	return formatFloat(value, bitSize)
+}
+
+// compositeValue returns an initializer list, which can only be used to initialize declarations
+func (g *HLSLEmitter) compositeValue(t Type, elements []string) sourceExpr {
+	return sourceExpr{fmt.Sprintf("{%v}", strings.Join(elements, ", ")), precPostfix}
+}
+
+// vectorLiteral calls the constructor of the vector, unlike initializer lists it can be used in expressions
+func (g *HLSLEmitter) vectorLiteral(t *VectorType, components []string) sourceExpr {
+	return sourceExpr{fmt.Sprintf("%v(%v)", g.vectorTypeName(t), strings.Join(components, ", ")), precPostfix}
+}
+
+// compositeLiteral calls a function which constructs the struct from the given fields, since initializer lists
+// can't be used in expressions
+func (g *HLSLEmitter) compositeLiteral(t Type, fields []string) sourceExpr {
+	var args []string
+	for _, field := range fields {
+		if field != "" {
+			args = append(args, field)
+		}
+	}
+	if len(args) == 0 {
+		return g.zeroValue(t)
+	}
+	return sourceExpr{fmt.Sprintf("%v(%v)", g.constructorName(t, fields), strings.Join(args, ", ")), precPostfix}
+}
+
+// constructorName returns the name of the function constructing the struct from the given fields, it's declared
+// first if it wasn't already, the fields which aren't given are zero initialized
+func (g *HLSLEmitter) constructorName(t Type, fields []string) string {
+	structType := t.Resolve(true).(*StructType)
+	structName := g.typeName(t)
+	name := "sabre_make_" + structName
+	var given []StructTypeField
+	for i, field := range fields {
+		if field != "" {
+			given = append(given, structType.Fields[i])
+		}
+	}
+	if len(given) < len(fields) {
+		for _, field := range given {
+			name += "_" + field.Name()
+		}
+	}
+	if _, ok := g.constructors[name]; ok {
+		return name
+	}
+	g.constructors[name] = name
+
+	params := make([]string, len(given))
+	for i, field := range given {
+		params[i] = g.declaration(field.Type, g.identifier(field.Name()))
+	}
+	var decl strings.Builder
+	fmt.Fprintf(&decl, "%v %v(%v) {\n", structName, name, strings.Join(params, ", "))
+	fmt.Fprintf(&decl, "\t%v sabre_value = %v;\n", structName, g.zeroValue(t))
+	for _, field := range given {
+		fmt.Fprintf(&decl, "\tsabre_value.%v = %v;\n", g.identifier(field.Name()), g.identifier(field.Name()))
+	}
+	decl.WriteString("\treturn sabre_value;\n}\n")
+	g.decls = append(g.decls, decl.String())
+	return name
+}
+
+// zeroValue returns the zero value of the type, zero values of arrays are initializer lists which can only be used to
+// initialize declarations
+func (g *HLSLEmitter) zeroValue(t Type) sourceExpr {
+	switch u := t.Resolve(true).(type) {
+	case *BoolType:
+		return g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: t, Value: constant.MakeBool(false)})
+	case *ArrayType:
+		elements := make([]string, u.Length)
+		for i := range elements {
+			elements[i] = g.zeroValue(u.ElementType).text
+		}
+		return g.compositeValue(t, elements)
+	case *StructType, *VectorType:
+		// casting zero to a struct or a vector sets all of its fields or components to zero
+		return sourceExpr{fmt.Sprintf("(%v)0", g.typeName(t)), precUnary}
+	default:
+		return g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: t, Value: constant.MakeInt64(0)})
+	}
+}
+
+func (g *HLSLEmitter) constantDeclaration(t Type, name string, value sourceExpr) string {
+	return fmt.Sprintf("static const %v = %v;", g.declaration(t, name), value)
+}
+
+func (g *HLSLEmitter) workgroupDeclaration(v *VarSymbol, t Type, name string) string {
+	return fmt.Sprintf("groupshared %v;", g.declaration(t, name))
+}
+
+func (g *HLSLEmitter) discardStmt() string {
+	return "discard"
+}
+
+var hlslReservedNames = reservedNames(`
+	AppendStructuredBuffer asm asm_fragment BlendState bool break Buffer ByteAddressBuffer case cbuffer centroid class
+	column_major compile compile_fragment CompileShader const continue ComputeShader ConsumeStructuredBuffer default
+	DepthStencilState DepthStencilView discard do double DomainShader dword else export extern false float for
+	fxgroup GeometryShader groupshared half Hullshader if in inline inout InputPatch int interface line lineadj linear
+	LineStream matrix min16float min10float min16int min12int min16uint namespace nointerpolation noperspective NULL
+	out OutputPatch packoffset pass pixelfragment PixelShader point PointStream precise RasterizerState
+	RenderTargetView return register row_major RWBuffer RWByteAddressBuffer RWStructuredBuffer RWTexture1D
+	RWTexture1DArray RWTexture2D RWTexture2DArray RWTexture3D sample sampler SamplerState SamplerComparisonState
+	shared snorm stateblock stateblock_state static string struct switch StructuredBuffer tbuffer technique
+	technique10 technique11 texture Texture1D Texture1DArray Texture2D Texture2DArray Texture2DMS Texture2DMSArray
+	Texture3D TextureCube TextureCubeArray true typedef triangle triangleadj TriangleStream uint uniform unorm
+	unsigned vector vertexfragment VertexShader void volatile while
+	auto catch char const_cast delete dynamic_cast enum explicit friend goto long mutable new operator private
+	protected public reinterpret_cast short signed sizeof static_cast template this throw try typename union using
+	virtual
+	bool1 bool2 bool3 bool4 int1 int2 int3 int4 uint1 uint2 uint3 uint4 float1 float2 float3 float4 double1 double2
+	double3 double4 half2 half3 half4 float2x2 float3x3 float4x4 float2x3 float2x4 float3x2 float3x4 float4x2
+	float4x3 int64_t uint64_t int16_t uint16_t float16_t float32_t float64_t
+	abs acos all any asdouble asfloat asin asint asuint atan atan2 ceil clamp clip cos cosh countbits cross ddx
+	ddy degrees determinant distance dot exp exp2 f16tof32 f32tof16 faceforward firstbithigh firstbitlow floor fma
+	fmod frac frexp fwidth isfinite isinf isnan ldexp length lerp lit log log10 log2 mad max min modf mul noise
+	normalize pow radians rcp reflect refract reversebits round rsqrt saturate sign sin sincos sinh smoothstep sqrt
+	step tan tanh transpose trunc GroupMemoryBarrierWithGroupSync
+`)
+
+// stageInputs passes the inputs to the parameters of the entry point, they're matched with the outputs of the
+// previous stage by their TEXCOORD semantics
+func (g *HLSLEmitter) stageInputs(entry *FuncSymbol, inputs []stageVar) sourceStage {
+	var stage sourceStage
+	for _, input := range inputs {
+		stage.params = append(stage.params, g.stageVarDeclaration(input, fmt.Sprintf("TEXCOORD%v", input.location)))
+	}
+	return stage
+}
+
+// stageOutputs declares the struct the entry point returns its outputs in, the fields have the semantics of the
+// position, the values passed to the next stage or the render targets. the entry point fills the struct declared at
+// its start when it returns
+func (g *HLSLEmitter) stageOutputs(entry *FuncSymbol, outputs []stageVar) sourceStage {
+	name := fmt.Sprintf("sabre_%v_output", entry.Name())
+	fields := make([]string, len(outputs))
+	g.outputs = make([]string, len(outputs))
+	for i, output := range outputs {
+		semantic := fmt.Sprintf("TEXCOORD%v", output.location)
+		if output.position {
+			semantic = "SV_Position"
+		} else if entry.Stage == ShaderStageFragment {
+			semantic = fmt.Sprintf("SV_Target%v", output.location)
+		}
+		fields[i] = g.stageVarDeclaration(output, semantic) + ";"
+		g.outputs[i] = output.name
+	}
+	g.line("%v sabre_output;", name)
+	return sourceStage{decl: g.structDeclaration(name, fields), result: name}
+}
+
+// stageVarDeclaration declares the input or the output with its semantic, integers are declared nointerpolation
+func (g *HLSLEmitter) stageVarDeclaration(v stageVar, semantic string) string {
+	decl := fmt.Sprintf("%v : %v", g.declaration(v.t, v.name), semantic)
+	if v.flat {
+		return "nointerpolation " + decl
+	}
+	return decl
+}
+
+// stageReturn sets the fields of the outputs struct and returns it
+func (g *HLSLEmitter) stageReturn(values []sourceExpr) {
+	for i, value := range values {
+		g.line("sabre_output.%v = %v;", g.outputs[i], value)
+	}
+	g.line("return sabre_output;")
+}
@@ -3,30 +3,165 @@ package compiler
 import (
 	"fmt"
 	"go/constant"
+	"slices"
+	"strings"
+	"unicode"
 
 	"github.com/MoustaphaSaad/sabre-go/internal/compiler/spirv"
 )
 
+// DiscardMode selects how discard statements are lowered
+type DiscardMode int
+
+const (
+	// DiscardModeKill terminates the invocation with OpKill
+	DiscardModeKill DiscardMode = iota
+	// DiscardModeDemote turns the invocation into a helper invocation with OpDemoteToHelperInvocation, which keeps
+	// derivatives well defined for the rest of the quad
+	DiscardModeDemote
+)
+
+func DiscardModeFromName(name string) (DiscardMode, bool) {
+	switch name {
+	case "kill":
+		return DiscardModeKill, true
+	case "demote":
+		return DiscardModeDemote, true
+	default:
+		return DiscardModeKill, false
+	}
+}
+
+func (m DiscardMode) String() string {
+	switch m {
+	case DiscardModeKill:
+		return "kill"
+	case DiscardModeDemote:
+		return "demote"
+	default:
+		panic("unknown discard mode")
+	}
+}
+
+// EmitOptions controls how the unit is lowered to SPIR-V
+type EmitOptions struct {
+	Discard DiscardMode
+	Target  TargetEnv
+}
+
 type IREmitter struct {
-	unit           *Unit
+	instanceContext
+	options        EmitOptions
 	module         *spirv.Module
 	objectBySymbol map[Symbol]spirv.Object
 	blockStack     []*spirv.Block
-	loopStack      []loopContext
+	loopStack      []*loopContext
+	// function local variable used by labeled branches to outer loops, created on demand
+	loopJump  spirv.Object
+	instances map[instanceKey]spirv.Object
+	// type of the function instance being emitted
+	funcType *FuncType
+	// global variables holding the builtin inputs, shared by the entry points reading them
+	inputs map[BuiltinFunc]*spirv.Variable
+	// global variables the buffers and textures of each shader entry point are bound to
+	bindings map[*FuncSymbol][]*spirv.Variable
+	// input and output variables of the values each vertex or fragment entry point passes between stages
+	stageVars map[*FuncSymbol][]*spirv.Variable
+	// output variables the entry point being emitted writes its results to
+	outputs []*spirv.Variable
+	// layouts the types were decorated with in buffers and uniforms, the stride of arrays, the offsets of the fields
+	// of structs and the decoration of blocks
+	layouts map[spirv.Type][]int
+	// first type laid out differently by buffers and uniforms, which isn't reported yet
+	layoutConflict Type
+}
+
+// memoryLayout is the set of rules laying out the values of buffers and uniforms in memory
+type memoryLayout int
+
+const (
+	// layoutStd430 lays out buffers, arrays and structs are aligned like their elements and fields
+	layoutStd430 memoryLayout = iota
+	// layoutStd140 lays out uniforms, arrays and structs are aligned to 16 bytes
+	layoutStd140
+)
+
+// instanceContext tracks the function instance being emitted, it's shared by the backends since all of them
+// specialize functions for their type arguments and for the functions passed to their parameters of function type
+type instanceContext struct {
+	unit *Unit
+	// type arguments of the generic function instance being emitted
+	typeArgs map[*TypeParamType]Type
+	// functions bound to the parameters of function type of the function instance being emitted
+	funcArgs map[Symbol]boundFunc
+}
+
+// boundFunc is a function bound to a parameter of function type, generic functions are bound with the type
+// arguments of their instance
+type boundFunc struct {
+	sym      *FuncSymbol
+	typeArgs []Type
+}
+
+type instanceKey struct {
+	sym      *FuncSymbol
+	typeArgs string
+	funcArgs string
+}
+
+func newInstanceKey(sym *FuncSymbol, typeArgs []Type, funcArgs []boundFunc) instanceKey {
+	var typeKey, funcKey strings.Builder
+	for _, t := range typeArgs {
+		typeKey.WriteString(t.HashKey())
+		typeKey.WriteRune(';')
+	}
+	for _, f := range funcArgs {
+		fmt.Fprintf(&funcKey, "%p", f.sym)
+		for _, t := range f.typeArgs {
+			fmt.Fprintf(&funcKey, ",%v", t.HashKey())
+		}
+		funcKey.WriteRune(';')
+	}
+	return instanceKey{sym, typeKey.String(), funcKey.String()}
 }
 
 type loopContext struct {
 	mergeblock, continueBlock *spirv.Block
+	// label of the loop, empty if it's not labeled
+	label string
+	// labeled branches to outer loops which leave through this loop, they continue from its merge block
+	exits []loopExit
+}
+
+// loopExit is a labeled break or continue to an outer loop, SPIR-V only allows branching out of the innermost
+// loop so the branch is taken one loop at a time, the loop jump variable tells each merge block where to go next
+type loopExit struct {
+	// index of the target loop in the loop stack
+	target     int
+	isContinue bool
+}
+
+// code is the value of the loop jump variable while the exit is taken, zero means no exit is taken
+func (e loopExit) code() int64 {
+	if e.isContinue {
+		return int64(2*e.target + 2)
+	}
+	return int64(2*e.target + 1)
 }
 
-func NewIREmitter(u *Unit) *IREmitter {
+func NewIREmitter(u *Unit, options EmitOptions) *IREmitter {
 	return &IREmitter{
-		unit: u,
-		// we set the addressing and memory model to default values for now
-		module:         spirv.NewModule(spirv.AddressingModelLogical, spirv.MemoryModelGLSL450),
-		objectBySymbol: make(map[Symbol]spirv.Object),
-		blockStack:     make([]*spirv.Block, 0),
-		loopStack:      make([]loopContext, 0),
+		instanceContext: instanceContext{unit: u},
+		options:         options,
+		module:          spirv.NewModule(options.Target.addressingModel(), options.Target.memoryModel()),
+		objectBySymbol:  make(map[Symbol]spirv.Object),
+		blockStack:      make([]*spirv.Block, 0),
+		loopStack:       make([]*loopContext, 0),
+		instances:       make(map[instanceKey]spirv.Object),
+		inputs:          make(map[BuiltinFunc]*spirv.Variable),
+		bindings:        make(map[*FuncSymbol][]*spirv.Variable),
+		stageVars:       make(map[*FuncSymbol][]*spirv.Variable),
+		layouts:         make(map[spirv.Type][]int),
 	}
 }
 
@@ -47,7 +182,7 @@ func (ir *IREmitter) currentBlock() *spirv.Block {
 	return nil
 }
 
-func (ir *IREmitter) enterLoop(lc loopContext) {
+func (ir *IREmitter) enterLoop(lc *loopContext) {
 	ir.loopStack = append(ir.loopStack, lc)
 }
 
@@ -57,9 +192,9 @@ func (ir *IREmitter) leaveLoop() {
 	}
 }
 
-func (ir *IREmitter) currentLoop() loopContext {
+func (ir *IREmitter) currentLoop() *loopContext {
 	if len(ir.loopStack) == 0 {
-		return loopContext{}
+		return nil
 	}
 	return ir.loopStack[len(ir.loopStack)-1]
 }
@@ -75,15 +210,132 @@ func (ir *IREmitter) setObjectOfSymbol(sym Symbol, obj spirv.Object) {
 	ir.objectBySymbol[sym] = obj
 }
 
+// typeOf returns the type of the given node with the type parameters replaced by the type arguments of the
+// generic function instance being emitted
+func (c *instanceContext) typeOf(n any) *TypeAndValue {
+	tav := c.unit.semanticInfo.TypeOf(n)
+	if tav == nil || len(c.typeArgs) == 0 {
+		return tav
+	}
+
+	res := *tav
+	res.Type = c.unit.semanticInfo.TypeInterner.Substitute(tav.Type, c.typeArgs)
+	return &res
+}
+
+// enterInstance binds the type arguments and the functions passed to the parameters of function type of the given
+// function, it returns a function which restores the previous instance
+func (c *instanceContext) enterInstance(sym *FuncSymbol, typeArgs []Type, funcArgs []boundFunc) func() {
+	prevTypeArgs, prevFuncArgs := c.typeArgs, c.funcArgs
+
+	funcType := c.unit.semanticInfo.TypeOf(sym).Type.(*FuncType)
+	c.typeArgs = make(map[*TypeParamType]Type, len(typeArgs))
+	for i, typeParam := range funcType.TypeParams {
+		c.typeArgs[typeParam] = typeArgs[i]
+	}
+	c.funcArgs = make(map[Symbol]boundFunc, len(funcArgs))
+	paramSymbols := c.paramSymbolsOf(sym)
+	if sym.IsMethod() {
+		paramSymbols = paramSymbols[1:]
+	}
+	funcArgIndex := 0
+	for i, paramType := range funcType.ParameterTypes {
+		if !isFunc(paramType) {
+			continue
+		}
+		if paramSymbols[i] != nil {
+			c.funcArgs[paramSymbols[i]] = funcArgs[funcArgIndex]
+		}
+		funcArgIndex++
+	}
+
+	return func() { c.typeArgs, c.funcArgs = prevTypeArgs, prevFuncArgs }
+}
+
+// funcNameOf returns the name of the function instance being emitted, methods are prefixed with their receiver type
+// and functions of imported packages with their package name, instances of generic functions are suffixed with their
+// type arguments and the functions bound to their parameters of function type
+func (c *instanceContext) funcNameOf(sym *FuncSymbol) string {
+	funcName := sym.Name()
+	if sym.IsMethod() {
+		funcName = fmt.Sprintf("%v_%v", sym.Receiver.Name(), sym.Name())
+	}
+	if pkg := sym.SourceRange().File.pkg; pkg != c.unit.rootPackage {
+		funcName = fmt.Sprintf("%v_%v", pkg.Name, funcName)
+	}
+	funcType := c.typeOf(sym).Type.(*FuncType)
+	for _, typeParam := range funcType.TypeParams {
+		funcName = fmt.Sprintf("%v_%v", funcName, instanceNameOf(c.typeArgs[typeParam]))
+	}
+	if hasFuncParams(funcType) {
+		paramSymbols := c.paramSymbolsOf(sym)
+		receivers := len(paramSymbols) - len(funcType.ParameterTypes)
+		for i, paramType := range funcType.ParameterTypes {
+			if bound := c.funcArgs[paramSymbols[receivers+i]]; isFunc(paramType) && bound.sym != nil {
+				boundName := bound.sym.Name()
+				if bound.sym.IsMethod() {
+					boundName = fmt.Sprintf("%v_%v", bound.sym.Receiver.Name(), boundName)
+				}
+				funcName = fmt.Sprintf("%v_%v", funcName, boundName)
+				for _, t := range bound.typeArgs {
+					funcName = fmt.Sprintf("%v_%v", funcName, instanceNameOf(t))
+				}
+			}
+		}
+	}
+	return funcName
+}
+
+func (ir *IREmitter) error(e Error) {
+	file := e.SourceRange.File
+	if file == nil {
+		file = ir.unit.rootFile
+	}
+	if e.Code == "" {
+		e.Code = DiagnosticUnsupportedTarget
+	}
+	file.error(e)
+}
+
 func (ir *IREmitter) Emit() *spirv.Module {
-	// we add this hardcoded capabilities for now
-	ir.module.AddCapability(spirv.CapabilityShader)
-	ir.module.AddCapability(spirv.CapabilityLinkage)
+	target := ir.options.Target
+	ir.module.Version = target.SPIRVVersion()
+	if target.isKernel() {
+		ir.module.AddCapability(spirv.CapabilityKernel)
+		ir.module.AddCapability(spirv.CapabilityAddresses)
+		// kernels call their math builtins through OpenCL.std instead of GLSL.std.450
+		ir.module.ImportExtInstSet("OpenCL.std")
+	} else {
+		ir.module.AddCapability(spirv.CapabilityShader)
+	}
+	if target.allowsLinkage() {
+		ir.module.AddCapability(spirv.CapabilityLinkage)
+	}
 
+	// symbols are resolved before the symbols using them, so callees are emitted before their callers
+	emitted := ir.unit.emittedFuncs()
 	for _, sym := range ir.unit.semanticInfo.ReachableSymbols {
+		switch s := sym.(type) {
+		case *FuncSymbol:
+			if !emitted[s] {
+				continue
+			}
+		case *VarSymbol:
+			if !ir.usesWorkgroup(emitted, s) {
+				continue
+			}
+		}
 		ir.emitSymbol(sym)
 	}
 
+	if !target.allowsLinkage() && len(ir.module.EntryPoints()) == 0 {
+		ir.error(NewError(
+			ir.unit.rootFile.Package.Name.SourceRange(),
+			"modules without entry points are not supported by target '%v'",
+			target,
+		))
+	}
+
 	RewriteIR(ir.module)
 
 	return ir.module
@@ -93,30 +345,486 @@ func (ir *IREmitter) emitSymbol(sym Symbol) {
 	var obj spirv.Object
 	switch s := sym.(type) {
 	case *FuncSymbol:
-		obj = ir.emitFunc(s)
+		if funcType := ir.typeOf(s).Type.(*FuncType); funcType.IsGeneric() || hasFuncParams(funcType) {
+			// generic functions and functions taking function values are emitted once per instance when they're called
+			return
+		}
+		if s.IsEntryPoint() && !ir.checkEntryPointParams(s) {
+			return
+		}
+		obj = ir.emitFunc(s, nil)
+		if s.IsEntryPoint() {
+			ir.emitEntryPoint(s, obj.(*spirv.Function))
+		}
+	case *TypeSymbol:
+		// types are emitted on demand when they're used
+		return
+	case *ConstSymbol:
+		// constants are emitted inline where they're used
+		return
+	case *VarSymbol:
+		if !s.Workgroup {
+			panic("unsupported symbol")
+		}
+		obj = ir.module.NewGlobalVariable(s.Name(), ir.module.InternPtr(ir.emitType(ir.typeOf(s).Type), spirv.StorageClassWorkgroup))
 	default:
 		panic("unsupported symbol")
 	}
 	ir.setObjectOfSymbol(sym, obj)
 }
 
-func (ir *IREmitter) emitFunc(sym *FuncSymbol) spirv.Object {
-	paramSymbols := func() (syms []Symbol) {
-		funcDecl := sym.Decl().(*FuncDecl)
-		for _, f := range funcDecl.Type.Parameters.Fields {
+// addLocalSize declares the workgroup size of the compute entry point
+func (ir *IREmitter) addLocalSize(sym *FuncSymbol, function *spirv.Function) {
+	size := workgroupSize(sym)
+	ir.module.AddExecutionMode(function, spirv.ExecutionModeLocalSize, size[:]...)
+}
+
+func (ir *IREmitter) emitEntryPoint(sym *FuncSymbol, function *spirv.Function) {
+	if ir.options.Target.isKernel() {
+		if sym.Stage != ShaderStageCompute {
+			funcDecl := sym.Decl().(*FuncDecl)
+			ir.error(NewError(funcDecl.Name.SourceRange(), "%v entry points are not supported by target '%v'", sym.Stage, ir.options.Target))
+			return
+		}
+		entryPoint := ir.module.AddEntryPoint(spirv.ExecutionModelKernel, function, sym.Name())
+		ir.addLocalSize(sym, function)
+		for _, input := range ir.unit.semanticInfo.Inputs[sym] {
+			entryPoint.Interface = append(entryPoint.Interface, ir.inputs[input])
+		}
+		return
+	}
+
+	var entryPoint *spirv.EntryPoint
+	switch sym.Stage {
+	case ShaderStageVertex:
+		entryPoint = ir.module.AddEntryPoint(spirv.ExecutionModelVertex, function, sym.Name())
+	case ShaderStageFragment:
+		entryPoint = ir.module.AddEntryPoint(spirv.ExecutionModelFragment, function, sym.Name())
+		ir.module.AddExecutionMode(function, spirv.ExecutionModeOriginUpperLeft)
+	case ShaderStageCompute:
+		entryPoint = ir.module.AddEntryPoint(spirv.ExecutionModelGLCompute, function, sym.Name())
+		ir.addLocalSize(sym, function)
+	default:
+		panic("unexpected shader stage")
+	}
+	for _, input := range ir.unit.semanticInfo.Inputs[sym] {
+		entryPoint.Interface = append(entryPoint.Interface, ir.inputs[input])
+	}
+	entryPoint.Interface = append(entryPoint.Interface, ir.stageVars[sym]...)
+	// starting with SPIR-V 1.4 the interface lists all the global variables the entry point uses, not only its inputs
+	if ir.options.Target.interfaceListsAllGlobals() {
+		entryPoint.Interface = append(entryPoint.Interface, ir.bindings[sym]...)
+		entryPoint.Interface = append(entryPoint.Interface, ir.workgroupsOf(sym)...)
+	}
+}
+
+// usesWorkgroup returns whether one of the emitted functions uses the workgroup variable
+func (ir *IREmitter) usesWorkgroup(emitted map[*FuncSymbol]bool, v *VarSymbol) bool {
+	for f := range emitted {
+		if slices.Contains(ir.unit.semanticInfo.Workgroups[f], v) {
+			return true
+		}
+	}
+	return false
+}
+
+// workgroupsOf returns the workgroup variables used by the functions reachable from the entry point
+func (ir *IREmitter) workgroupsOf(entry *FuncSymbol) []*spirv.Variable {
+	var variables []*spirv.Variable
+	order, _ := ir.unit.semanticInfo.reachableFrom(entry)
+	for _, function := range order {
+		for _, v := range ir.unit.semanticInfo.Workgroups[function] {
+			if variable := ir.objectOfSymbol(v).(*spirv.Variable); !slices.Contains(variables, variable) {
+				variables = append(variables, variable)
+			}
+		}
+	}
+	return variables
+}
+
+// checkEntryPointParams reports the parameters of the entry point the target can't pass to it, shaders receive their
+// buffers, textures and uniforms through global variables while kernels receive their buffers as arguments
+func (ir *IREmitter) checkEntryPointParams(sym *FuncSymbol) bool {
+	funcDecl := sym.Decl().(*FuncDecl)
+	funcType := ir.typeOf(sym).Type.(*FuncType)
+	for _, paramType := range funcType.ParameterTypes {
+		if ir.options.Target.isKernel() && isTexture(paramType) {
+			ir.error(NewError(funcDecl.Name.SourceRange(), "texture parameters of entry point '%v' are not supported by target '%v'", sym.Name(), ir.options.Target))
+			return false
+		}
+		if ir.options.Target.isKernel() && isUniform(paramType) {
+			ir.error(NewError(funcDecl.Name.SourceRange(), "uniform parameters of entry point '%v' are not supported by target '%v'", sym.Name(), ir.options.Target))
+			return false
+		}
+		// bools have no layout in the memory of shader buffers and uniforms
+		if !ir.options.Target.isKernel() && isPointer(paramType) && hasBool(paramType.Resolve(false).(*PointerType).ElementType) {
+			ir.error(NewError(funcDecl.Name.SourceRange(), "buffer parameters of entry point '%v' holding bools are not supported by target '%v'", sym.Name(), ir.options.Target))
+			return false
+		}
+		if !ir.options.Target.isKernel() && isUniform(paramType) && hasBool(paramType) {
+			ir.error(NewError(funcDecl.Name.SourceRange(), "uniform parameters of entry point '%v' holding bools are not supported by target '%v'", sym.Name(), ir.options.Target))
+			return false
+		}
+	}
+	return true
+}
+
+// hasBool reports whether the values of the type hold bools
+func hasBool(t Type) bool {
+	switch t := t.Resolve(true).(type) {
+	case *BoolType:
+		return true
+	case *VectorType:
+		return hasBool(vectorElementType(t))
+	case *ArrayType:
+		return hasBool(t.ElementType)
+	case *StructType:
+		return slices.ContainsFunc(t.Fields, func(field StructTypeField) bool { return hasBool(field.Type) })
+	default:
+		return false
+	}
+}
+
+// emitResourceBindings declares the buffers, textures and uniforms taken by the shader entry point as global
+// variables, they're bound to the descriptor set 0 at the index of their parameter. the inputs of vertex and fragment
+// entry points are input variables at their locations
+func (ir *IREmitter) emitResourceBindings(sym *FuncSymbol) {
+	funcType := ir.typeOf(sym).Type.(*FuncType)
+	locations := 0
+	for i, paramSym := range ir.paramSymbolsOf(sym) {
+		name := fmt.Sprintf("UnnamedParam%v", i)
+		if paramSym != nil {
+			name = paramSym.Name()
+		}
+		if paramType := funcType.ParameterTypes[i]; sym.Stage != ShaderStageCompute && isStageValue(paramType) {
+			input := stageVar{name: name, t: paramType, location: locations, flat: sym.Stage == ShaderStageFragment && !isInterpolated(paramType)}
+			locations++
+			variable := ir.emitStageVar(sym, input, spirv.StorageClassInput)
+			if paramSym != nil {
+				ir.setObjectOfSymbol(paramSym, variable)
+			}
+			continue
+		}
+		var variable *spirv.Variable
+		if pointerType, ok := funcType.ParameterTypes[i].Resolve(false).(*PointerType); ok {
+			variable = ir.emitBufferVariable(name, pointerType.ElementType)
+		} else if isUniform(funcType.ParameterTypes[i]) {
+			variable = ir.emitUniformVariable(name, funcType.ParameterTypes[i])
+		} else {
+			variable = ir.module.NewGlobalVariable(name, ir.emitType(funcType.ParameterTypes[i]).(*spirv.PtrType))
+		}
+		if ir.layoutConflict != nil {
+			funcDecl := sym.Decl().(*FuncDecl)
+			ir.error(NewError(funcDecl.Name.SourceRange(), "type '%v' is laid out differently in buffers and in uniforms, entry point '%v' can't take both", ir.layoutConflict, sym.Name()))
+			ir.layoutConflict = nil
+		}
+		ir.module.Decorate(variable, spirv.DecorationDescriptorSet, 0)
+		ir.module.Decorate(variable, spirv.DecorationBinding, spirv.Word(i))
+		ir.bindings[sym] = append(ir.bindings[sym], variable)
+		if paramSym != nil {
+			ir.setObjectOfSymbol(paramSym, variable)
+		}
+	}
+}
+
+// emitStageVar declares the input or the output variable of the value the entry point passes between stages
+func (ir *IREmitter) emitStageVar(sym *FuncSymbol, v stageVar, storageClass spirv.StorageClass) *spirv.Variable {
+	variable := ir.module.NewGlobalVariable(v.name, ir.module.InternPtr(ir.emitType(v.t), storageClass))
+	if v.position {
+		ir.module.Decorate(variable, spirv.DecorationBuiltIn, spirv.Word(spirv.BuiltInPosition))
+	} else {
+		ir.module.Decorate(variable, spirv.DecorationLocation, spirv.Word(v.location))
+	}
+	if v.flat {
+		ir.module.Decorate(variable, spirv.DecorationFlat)
+	}
+	ir.stageVars[sym] = append(ir.stageVars[sym], variable)
+	return variable
+}
+
+// emitStageOutputs declares the output variables the vertex or fragment entry point writes its results to, vertex
+// entry points write their position to the Position builtin
+func (ir *IREmitter) emitStageOutputs(sym *FuncSymbol) []*spirv.Variable {
+	var outputs []*spirv.Variable
+	for _, output := range stageOutputsOf(sym, ir.typeOf(sym).Type.(*FuncType)) {
+		outputs = append(outputs, ir.emitStageVar(sym, output, spirv.StorageClassOutput))
+	}
+	return outputs
+}
+
+// emitInputCopies copies the inputs the entry point assigns into function variables, since input variables are
+// read only. uniforms are always copied out of their blocks
+func (ir *IREmitter) emitInputCopies(sym *FuncSymbol) {
+	funcType := ir.typeOf(sym).Type.(*FuncType)
+	body := sym.Decl().(*FuncDecl).Body
+	for i, paramSym := range ir.paramSymbolsOf(sym) {
+		paramType := funcType.ParameterTypes[i]
+		if paramSym == nil {
+			continue
+		}
+		input := ir.objectOfSymbol(paramSym)
+		if isUniform(paramType) {
+			input = ir.emitBlockContents(input.(*spirv.Variable), paramType)
+		} else if !isStageValue(paramType) || !assignsVariable(ir.unit.semanticInfo, body.Stmts, paramSym) {
+			continue
+		}
+		variable := ir.module.NewVariable(paramSym.Name(), ir.module.InternPtr(ir.emitType(paramType), spirv.StorageClassFunction), spirv.StorageClassFunction)
+		ir.currentBlock().Push(&spirv.VariableInstruction{
+			ResultType:   variable.Type.ID(),
+			ResultID:     variable.ID(),
+			StorageClass: variable.StorageClass,
+		})
+		ir.currentBlock().Push(&spirv.StoreInstruction{Pointer: variable.ID(), Object: ir.emitLoad(input, paramType).ID()})
+		ir.setObjectOfSymbol(paramSym, variable)
+	}
+}
+
+// emitBufferVariable declares the global variable of a buffer, the contents of the buffer are wrapped in a block
+// struct laid out as std430, the StorageBuffer storage class is only core since SPIR-V 1.3 so older versions bind
+// buffers as BufferBlock structs in the Uniform storage class
+func (ir *IREmitter) emitBufferVariable(name string, elementType Type) *spirv.Variable {
+	block := ir.module.InternBlock([]spirv.Type{ir.emitType(elementType)})
+	storageClass, decoration := spirv.StorageClassStorageBuffer, spirv.DecorationBlock
+	if !ir.options.Target.hasStorageBufferClass() {
+		storageClass, decoration = spirv.StorageClassUniform, spirv.DecorationBufferBlock
+	}
+	if ir.recordLayout(block, elementType, []int{int(decoration)}) {
+		ir.module.Decorate(block, decoration)
+		ir.module.MemberDecorate(block, 0, spirv.DecorationOffset, 0)
+	}
+	ir.emitLayout(elementType, layoutStd430)
+	return ir.module.NewGlobalVariable(name, ir.module.InternPtr(block, storageClass))
+}
+
+// emitUniformVariable declares the global variable of a uniform, the value is wrapped in a block struct laid out as
+// std140 in the Uniform storage class
+func (ir *IREmitter) emitUniformVariable(name string, t Type) *spirv.Variable {
+	block := ir.module.InternBlock([]spirv.Type{ir.emitType(t)})
+	if ir.recordLayout(block, t, []int{int(spirv.DecorationBlock)}) {
+		ir.module.Decorate(block, spirv.DecorationBlock)
+		ir.module.MemberDecorate(block, 0, spirv.DecorationOffset, 0)
+	}
+	ir.emitLayout(t, layoutStd140)
+	return ir.module.NewGlobalVariable(name, ir.module.InternPtr(block, spirv.StorageClassUniform))
+}
+
+// emitBufferPointers points the buffer parameters of the shader entry point to the contents of their blocks
+func (ir *IREmitter) emitBufferPointers(sym *FuncSymbol) {
+	funcType := ir.typeOf(sym).Type.(*FuncType)
+	for i, paramSym := range ir.paramSymbolsOf(sym) {
+		pointerType, ok := funcType.ParameterTypes[i].Resolve(false).(*PointerType)
+		if !ok || paramSym == nil {
+			continue
+		}
+		variable := ir.objectOfSymbol(paramSym).(*spirv.Variable)
+		ir.setObjectOfSymbol(paramSym, ir.emitBlockContents(variable, pointerType.ElementType))
+	}
+}
+
+// emitBlockContents returns the pointer to the contents of the block the buffer or uniform variable holds
+func (ir *IREmitter) emitBlockContents(variable *spirv.Variable, t Type) spirv.Object {
+	resultType := ir.module.InternPtr(ir.emitType(t), variable.StorageClass)
+	result := ir.module.NewValue(resultType)
+	ir.currentBlock().Push(&spirv.AccessChainInstruction{
+		ResultType: resultType.ID(),
+		ResultID:   result.ID(),
+		Base:       variable.ID(),
+		Indexes:    []spirv.ID{ir.module.InternIntConstant(0, ir.internInt(true)).ID()},
+	})
+	return result
+}
+
+// recordLayout records the layout the type is decorated with and returns whether it wasn't decorated yet, SPIR-V
+// types have a single layout so the types laid out differently by buffers and uniforms are kept to be reported
+func (ir *IREmitter) recordLayout(spirvType spirv.Type, t Type, layout []int) bool {
+	prev, ok := ir.layouts[spirvType]
+	if !ok {
+		ir.layouts[spirvType] = layout
+		return true
+	}
+	if !slices.Equal(prev, layout) && ir.layoutConflict == nil {
+		ir.layoutConflict = t
+	}
+	return false
+}
+
+// emitLayout decorates the arrays and structs of the type with their std430 or std140 layout and returns the size and
+// alignment of the type
+func (ir *IREmitter) emitLayout(t Type, layout memoryLayout) (size, align int) {
+	switch t := t.Resolve(true).(type) {
+	case *IntType, *UintType, *Float32Type:
+		return 4, 4
+	case *Float64Type:
+		return 8, 8
+	case *VectorType:
+		size, _ := ir.emitLayout(vectorElementType(t), layout)
+		// 3 component vectors are aligned like 4 component ones
+		if t.Width == 2 {
+			return size * 2, size * 2
+		}
+		return size * t.Width, size * 4
+	case *ArrayType:
+		elementSize, elementAlign := ir.emitLayout(t.ElementType, layout)
+		if layout == layoutStd140 {
+			elementAlign = alignTo(elementAlign, 16)
+		}
+		stride := alignTo(elementSize, elementAlign)
+		if spirvType := ir.emitType(t); ir.recordLayout(spirvType, t, []int{stride}) {
+			ir.module.Decorate(spirvType, spirv.DecorationArrayStride, spirv.Word(stride))
+		}
+		return stride * t.Length, elementAlign
+	case *StructType:
+		offsets := make([]int, len(t.Fields))
+		offset, align := 0, 1
+		for i, field := range t.Fields {
+			fieldSize, fieldAlign := ir.emitLayout(field.Type, layout)
+			offset = alignTo(offset, fieldAlign)
+			offsets[i] = offset
+			offset += fieldSize
+			align = max(align, fieldAlign)
+		}
+		if layout == layoutStd140 {
+			align = alignTo(align, 16)
+		}
+		if spirvType := ir.emitType(t).(*spirv.StructType); ir.recordLayout(spirvType, t, offsets) {
+			for i, offset := range offsets {
+				ir.module.MemberDecorate(spirvType, i, spirv.DecorationOffset, spirv.Word(offset))
+			}
+		}
+		return alignTo(offset, align), align
+	default:
+		panic("unexpected buffer type")
+	}
+}
+
+// alignTo rounds the offset up to a multiple of the alignment
+func alignTo(offset, align int) int {
+	return (offset + align - 1) / align * align
+}
+
+// emitFuncInstance emits the instantiation of the generic function with the given type arguments, specialized for
+// the functions passed to its parameters of function type, each instance is emitted once and reused by all of its
+// call sites
+func (ir *IREmitter) emitFuncInstance(sym *FuncSymbol, typeArgs []Type, funcArgs []boundFunc) spirv.Object {
+	key := newInstanceKey(sym, typeArgs, funcArgs)
+	if obj, ok := ir.instances[key]; ok {
+		return obj
+	}
+
+	defer ir.enterInstance(sym, typeArgs, funcArgs)()
+	obj := ir.emitFunc(sym, func(obj spirv.Object) {
+		ir.instances[key] = obj
+	})
+	return obj
+}
+
+// paramSymbols returns the symbols of the parameters of the function preceded by its receiver, unnamed parameters
+// have no symbols
+func (c *instanceContext) paramSymbolsOf(sym *FuncSymbol) (syms []Symbol) {
+	funcDecl := sym.Decl().(*FuncDecl)
+	// methods receive their receiver as the first parameter
+	if sym.IsMethod() {
+		for _, f := range funcDecl.Receiver.Fields {
 			if len(f.Names) == 0 {
 				syms = append(syms, nil)
 			} else {
 				for _, idExpr := range f.Names {
-					syms = append(syms, ir.unit.semanticInfo.SymbolOfIdentifier(idExpr))
+					syms = append(syms, c.unit.semanticInfo.SymbolOfIdentifier(idExpr))
 				}
 			}
 		}
-		return
-	}()
+	}
+	for _, f := range funcDecl.Type.Parameters.Fields {
+		if len(f.Names) == 0 {
+			syms = append(syms, nil)
+		} else {
+			for _, idExpr := range f.Names {
+				syms = append(syms, c.unit.semanticInfo.SymbolOfIdentifier(idExpr))
+			}
+		}
+	}
+	return
+}
+
+// hasFuncParams returns whether the function takes function values, such functions are specialized for each of the
+// functions passed to them since there are no function pointers
+func hasFuncParams(t *FuncType) bool {
+	return slices.ContainsFunc(t.ParameterTypes, isFunc)
+}
+
+// emitKernelType emits the type of the kernel entry point, kernels receive their buffers as pointers to the memory
+// shared by all the workgroups
+func (ir *IREmitter) emitKernelType(funcType *FuncType) *spirv.FuncType {
+	argTypes := make([]spirv.Type, len(funcType.ParameterTypes))
+	for i, t := range funcType.ParameterTypes {
+		elementType := ir.emitType(t.Resolve(false).(*PointerType).ElementType)
+		argTypes[i] = ir.module.InternPtr(elementType, spirv.StorageClassCrossWorkgroup)
+	}
+	return ir.module.InternFunc(ir.module.InternVoid(), argTypes)
+}
+
+// emitFuncType emits the type of a function, methods take their receiver as the first argument so the receiver type
+// is given for them and nil otherwise
+func (ir *IREmitter) emitFuncType(t *FuncType, receiverType Type) *spirv.FuncType {
+	var spirvReturnType spirv.Type
+	if len(t.ReturnTypes) > 0 {
+		// TODO: Handle multiple return types
+		spirvReturnType = ir.emitType(t.ReturnTypes[0])
+	} else {
+		spirvReturnType = ir.module.InternVoid()
+	}
 
-	funcType := ir.unit.semanticInfo.TypeOf(sym).Type.(*FuncType)
-	spirvFuncType := ir.emitType(funcType).(*spirv.FuncType)
+	var parameterTypes []spirv.Type
+	if receiverType != nil {
+		parameterTypes = append(parameterTypes, ir.emitType(receiverType))
+	}
+	for _, paramType := range t.ParameterTypes {
+		// function values are bound at compile time
+		if isFunc(paramType) {
+			continue
+		}
+		parameterTypes = append(parameterTypes, ir.emitType(paramType))
+	}
+
+	return ir.module.InternFunc(spirvReturnType, parameterTypes)
+}
+
+// emitFunc emits the function of the given symbol, onCreate is called once the function object is created
+// and before its body is emitted
+func (ir *IREmitter) emitFunc(sym *FuncSymbol, onCreate func(obj spirv.Object)) spirv.Object {
+	paramSymbols := ir.paramSymbolsOf(sym)
+
+	funcType := ir.typeOf(sym).Type.(*FuncType)
+	var spirvFuncType *spirv.FuncType
+	var outputs []*spirv.Variable
+	if sym.IsEntryPoint() && ir.options.Target.isKernel() {
+		spirvFuncType = ir.emitKernelType(funcType)
+	} else if sym.IsEntryPoint() {
+		// shaders take no arguments and return nothing, their buffers, textures, inputs and outputs are global
+		// variables
+		ir.emitResourceBindings(sym)
+		outputs = ir.emitStageOutputs(sym)
+		spirvFuncType = ir.module.InternFunc(ir.module.InternVoid(), nil)
+		paramSymbols = nil
+	} else if sym.IsMethod() {
+		// pointer receivers are passed as pointers, so we use the type of the receiver field not the named type
+		receiverType := ir.typeOf(sym.Decl().(*FuncDecl).Receiver.Fields[0].Type).Type
+		spirvFuncType = ir.emitFuncType(funcType, receiverType)
+	} else {
+		spirvFuncType = ir.emitFuncType(funcType, nil)
+	}
+	funcName := ir.funcNameOf(sym)
+	// functions bound to parameters of function type aren't passed at runtime
+	if hasFuncParams(funcType) {
+		receivers := len(paramSymbols) - len(funcType.ParameterTypes)
+		var runtimeParams []Symbol
+		for i, paramSym := range paramSymbols {
+			if i < receivers || !isFunc(funcType.ParameterTypes[i-receivers]) {
+				runtimeParams = append(runtimeParams, paramSym)
+			}
+		}
+		paramSymbols = runtimeParams
+	}
 
 	if len(paramSymbols) != len(spirvFuncType.ArgTypes) {
 		panic(fmt.Sprintf(
@@ -134,31 +842,61 @@ func (ir *IREmitter) emitFunc(sym *FuncSymbol) spirv.Object {
 			ir.setObjectOfSymbol(paramSymbols[i], params[i])
 		}
 	}
-	spirvFunction := ir.module.NewFunction(sym.Name(), spirvFuncType, params)
+	spirvFunction := ir.module.NewFunction(funcName, spirvFuncType, params)
+	if onCreate != nil {
+		onCreate(spirvFunction)
+	}
 
 	funcDecl := sym.Decl().(*FuncDecl)
 	if funcDecl.Body == nil {
 		return spirvFunction
 	}
 
-	spirvBlock := spirvFunction.NewBlock(fmt.Sprintf("entry_%v", sym.Name()))
+	// generic instances are emitted while emitting their callers, so the loops of the caller are put aside
+	prevLoopStack, prevLoopJump, prevFuncType, prevOutputs := ir.loopStack, ir.loopJump, ir.funcType, ir.outputs
+	ir.loopStack, ir.loopJump, ir.funcType, ir.outputs = make([]*loopContext, 0), nil, funcType, outputs
+	defer func() {
+		ir.loopStack, ir.loopJump, ir.funcType, ir.outputs = prevLoopStack, prevLoopJump, prevFuncType, prevOutputs
+	}()
+
+	spirvBlock := spirvFunction.NewBlock(fmt.Sprintf("entry_%v", funcName))
 	ir.enterBlock(spirvBlock)
 	defer ir.leaveBlock()
 
+	if sym.IsEntryPoint() && !ir.options.Target.isKernel() {
+		ir.emitBufferPointers(sym)
+		ir.emitInputCopies(sym)
+	}
 	ir.emitStatement(funcDecl.Body)
 
 	return spirvFunction
 }
 
+// instanceNameOf returns the name of the type argument as used in the name of generic function instances
+func instanceNameOf(t Type) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, t.String())
+}
+
 func (ir *IREmitter) emitVar(symbol *VarSymbol, sc spirv.StorageClass, initExpr Expr) spirv.Object {
-	tav := ir.unit.semanticInfo.TypeOf(symbol)
+	tav := ir.typeOf(symbol)
 	spirvType := ir.emitType(tav.Type)
 	ptrType := ir.module.InternPtr(spirvType, sc)
 	variable := ir.module.NewVariable(symbol.Name(), ptrType, sc)
 
 	var initValueID spirv.ID
 	if initTAV := symbol.InitTypeAndValue; initTAV != nil && initTAV.Mode == AddressModeConstant {
-		initValueID = ir.emitConstantValue(initTAV).ID()
+		// untyped initializers take the type of the variable
+		initValueID = ir.emitConstantValue(&TypeAndValue{
+			Mode:     AddressModeConstant,
+			Type:     tav.Type,
+			Value:    initTAV.Value,
+			Elements: initTAV.Elements,
+		}).ID()
 	}
 
 	block := ir.currentBlock()
@@ -182,6 +920,11 @@ func (ir *IREmitter) emitVar(symbol *VarSymbol, sc spirv.StorageClass, initExpr
 }
 
 func (ir *IREmitter) emitExpression(expr Expr) spirv.Object {
+	// constant expressions are folded by the checker
+	if tav := ir.typeOf(expr); tav != nil && tav.Mode == AddressModeConstant {
+		return ir.emitConstantValue(tav)
+	}
+
 	switch e := expr.(type) {
 	case *LiteralExpr:
 		return ir.emitLiteralExpr(e)
@@ -195,27 +938,39 @@ func (ir *IREmitter) emitExpression(expr Expr) spirv.Object {
 		return ir.emitCallExpr(e)
 	case *ParenExpr:
 		return ir.emitExpression(e.Base)
+	case *SelectorExpr:
+		return ir.emitSelectorExpr(e)
+	case *IndexExpr:
+		return ir.emitIndexExpr(e)
+	case *ComplitExpr:
+		return ir.emitComplitExpr(e)
 	default:
 		panic("unsupported expression")
 	}
 }
 
 func (ir *IREmitter) emitLiteralExpr(e *LiteralExpr) spirv.Object {
-	tav := ir.unit.semanticInfo.TypeOf(e)
+	tav := ir.typeOf(e)
 	return ir.emitConstantValue(tav)
 }
 
-func (ir *IREmitter) emitConstantValue(tav *TypeAndValue) spirv.Object {
+func (ir *IREmitter) emitConstantValue(tav *TypeAndValue) spirv.ConstantValue {
 	switch t := ir.emitType(tav.Type).(type) {
 	case *spirv.BoolType:
 		val := constant.BoolVal(tav.Value)
 		return ir.module.InternBoolConstant(val, t)
 	case *spirv.IntType:
-		val, _ := constant.Int64Val(tav.Value)
+		val, _ := constant.Int64Val(constant.ToInt(tav.Value))
 		return ir.module.InternIntConstant(val, t)
 	case *spirv.FloatType:
-		val, _ := constant.Float64Val(tav.Value)
+		val, _ := constant.Float64Val(constant.ToFloat(tav.Value))
 		return ir.module.InternFloatConstant(val, t)
+	case *spirv.ArrayType:
+		constituents := make([]spirv.ConstantValue, len(tav.Elements))
+		for i, element := range tav.Elements {
+			constituents[i] = ir.emitConstantValue(element)
+		}
+		return ir.module.InternCompositeConstant(t, constituents)
 	default:
 		panic("unsupported literal type")
 	}
@@ -227,10 +982,15 @@ func (ir *IREmitter) emitIdentifierExpr(e *IdentifierExpr) spirv.Object {
 		panic(fmt.Sprintf("unable to find symbol for identifier: %v", e.Token.Value()))
 	}
 
+	if _, ok := symbol.(*ConstSymbol); ok {
+		return ir.emitConstantValue(ir.typeOf(e))
+	}
+
 	obj := ir.objectOfSymbol(symbol)
 
-	if variable, ok := obj.(*spirv.Variable); ok {
-		tav := ir.unit.semanticInfo.TypeOf(e)
+	// textures are always used through their pointers
+	if variable, ok := obj.(*spirv.Variable); ok && !isTexture(ir.typeOf(e).Type) {
+		tav := ir.typeOf(e)
 		resultType := ir.emitType(tav.Type)
 		loadedValue := ir.module.NewValue(resultType)
 		block := ir.currentBlock()
@@ -242,12 +1002,343 @@ func (ir *IREmitter) emitIdentifierExpr(e *IdentifierExpr) spirv.Object {
 		return loadedValue
 	}
 
-	return obj
+	return obj
+}
+
+func (ir *IREmitter) emitSelectorExpr(e *SelectorExpr) spirv.Object {
+	// package qualified identifiers are emitted the same way as plain identifiers
+	if base, ok := e.Base.(*IdentifierExpr); ok {
+		if _, ok := ir.unit.semanticInfo.SymbolOfIdentifier(base).(*PackageSymbol); ok {
+			return ir.emitIdentifierExpr(e.Selector)
+		}
+	}
+	if selection := ir.unit.semanticInfo.SelectionOf(e); selection != nil && selection.Field != nil {
+		return ir.emitFieldValue(e.Base, selection.Path, ir.typeOf(e).Type)
+	}
+	if _, ok := ir.typeOf(e.Base).Type.Resolve(true).(*VectorType); ok {
+		return ir.emitSwizzle(e)
+	}
+	panic("unsupported selector expression")
+}
+
+// emitSwizzle emits the components selected by the swizzle, a single component is extracted from the vector and
+// multiple components are shuffled into a new vector
+func (ir *IREmitter) emitSwizzle(e *SelectorExpr) spirv.Object {
+	var components []spirv.Word
+	for _, c := range swizzleComponents(e.Selector.Token.Value()) {
+		components = append(components, spirv.Word(strings.IndexRune(vectorComponents, c)))
+	}
+	vector := ir.emitExpression(e.Base)
+	resultType := ir.emitType(ir.typeOf(e).Type)
+	result := ir.module.NewValue(resultType)
+	if len(components) == 1 {
+		ir.currentBlock().Push(&spirv.CompositeExtractInstruction{
+			ResultType: resultType.ID(),
+			ResultID:   result.ID(),
+			Composite:  vector.ID(),
+			Indexes:    components,
+		})
+		return result
+	}
+	ir.currentBlock().Push(&spirv.VectorShuffleInstruction{
+		ResultType: resultType.ID(),
+		ResultID:   result.ID(),
+		Vector1:    vector.ID(),
+		Vector2:    vector.ID(),
+		Components: components,
+	})
+	return result
+}
+
+// emitFieldValue emits the value of the field found by following the path of field indexes from the base, promoted
+// fields have a path through the embedded structs
+func (ir *IREmitter) emitFieldValue(base Expr, path []int, fieldType Type) spirv.Object {
+	if ir.hasPointer(base) {
+		return ir.emitLoad(ir.emitFieldPointer(base, path, fieldType), fieldType)
+	}
+
+	indexes := make([]spirv.Word, len(path))
+	for i, index := range path {
+		indexes[i] = spirv.Word(index)
+	}
+	resultType := ir.emitType(fieldType)
+	result := ir.module.NewValue(resultType)
+	composite := ir.emitExpression(base)
+	ir.currentBlock().Push(&spirv.CompositeExtractInstruction{
+		ResultType: resultType.ID(),
+		ResultID:   result.ID(),
+		Composite:  composite.ID(),
+		Indexes:    indexes,
+	})
+	return result
+}
+
+// emitFieldPointer emits the pointer to the field found by following the path of field indexes from the base, the
+// base is either addressable or a pointer to a struct
+func (ir *IREmitter) emitFieldPointer(base Expr, path []int, fieldType Type) spirv.Object {
+	var basePointer spirv.Object
+	if isPointer(ir.typeOf(base).Type) {
+		basePointer = ir.emitExpression(base)
+	} else {
+		basePointer = ir.emitPointerTo(base)
+	}
+
+	indexType := ir.internInt(true)
+	indexes := make([]spirv.ID, len(path))
+	for i, index := range path {
+		indexes[i] = ir.module.InternIntConstant(int64(index), indexType).ID()
+	}
+	resultType := ir.module.InternPtr(ir.emitType(fieldType), storageClassOf(basePointer))
+	result := ir.module.NewValue(resultType)
+	ir.currentBlock().Push(&spirv.AccessChainInstruction{
+		ResultType: resultType.ID(),
+		ResultID:   result.ID(),
+		Base:       basePointer.ID(),
+		Indexes:    indexes,
+	})
+	return result
+}
+
+// emitIndexExpr emits the element of the array, arrays in memory are indexed through a pointer to the element and
+// array values are indexed directly when the index is constant
+func (ir *IREmitter) emitIndexExpr(e *IndexExpr) spirv.Object {
+	elementType := ir.typeOf(e).Type
+	if ir.hasPointer(e.Base) {
+		return ir.emitLoad(ir.emitElementPointer(e), elementType)
+	}
+
+	if index := ir.typeOf(e.Index); index.Mode == AddressModeConstant {
+		value, _ := constant.Int64Val(constant.ToInt(index.Value))
+		resultType := ir.emitType(elementType)
+		result := ir.module.NewValue(resultType)
+		composite := ir.emitExpression(e.Base)
+		ir.currentBlock().Push(&spirv.CompositeExtractInstruction{
+			ResultType: resultType.ID(),
+			ResultID:   result.ID(),
+			Composite:  composite.ID(),
+			Indexes:    []spirv.Word{spirv.Word(value)},
+		})
+		return result
+	}
+
+	// logical addressing only indexes arrays with a runtime index through pointers, so the array is copied to a
+	// variable first, constant arrays initialize the variable directly
+	array := ir.emitExpression(e.Base)
+	ptrType := ir.module.InternPtr(ir.emitType(ir.typeOf(e.Base).Type), spirv.StorageClassFunction)
+	tmp := ir.module.NewVariable("tmp", ptrType, spirv.StorageClassFunction)
+	instruction := &spirv.VariableInstruction{
+		ResultType:   ptrType.ID(),
+		ResultID:     tmp.ID(),
+		StorageClass: spirv.StorageClassFunction,
+	}
+	block := ir.currentBlock()
+	block.Push(instruction)
+	if _, ok := array.(spirv.ConstantValue); ok {
+		instruction.Initializer = array.ID()
+	} else {
+		block.Push(&spirv.StoreInstruction{
+			Pointer: tmp.ID(),
+			Object:  array.ID(),
+		})
+	}
+	return ir.emitLoad(ir.emitAccessChain(tmp, ir.emitExpression(e.Index), elementType), elementType)
+}
+
+// emitElementPointer emits the pointer to the element of the addressable array
+func (ir *IREmitter) emitElementPointer(e *IndexExpr) spirv.Object {
+	basePointer := ir.emitPointerTo(e.Base)
+	return ir.emitAccessChain(basePointer, ir.emitExpression(e.Index), ir.typeOf(e).Type)
+}
+
+func (ir *IREmitter) emitAccessChain(base, index spirv.Object, elementType Type) spirv.Object {
+	resultType := ir.module.InternPtr(ir.emitType(elementType), storageClassOf(base))
+	result := ir.module.NewValue(resultType)
+	ir.currentBlock().Push(&spirv.AccessChainInstruction{
+		ResultType: resultType.ID(),
+		ResultID:   result.ID(),
+		Base:       base.ID(),
+		Indexes:    []spirv.ID{index.ID()},
+	})
+	return result
+}
+
+// storageClassOf returns the storage class of the memory the pointer points to, pointers into the buffers of kernels
+// point to CrossWorkgroup memory and the rest point to function local variables
+func storageClassOf(pointer spirv.Object) spirv.StorageClass {
+	return pointer.(spirv.Value).GetType().(*spirv.PtrType).StorageClass
+}
+
+// hasPointer reports whether the fields of the expression are reached through memory, it's either a pointer to a
+// struct or an addressable struct, other struct values like parameters and call results are composite values
+func (ir *IREmitter) hasPointer(expr Expr) bool {
+	if isPointer(ir.typeOf(expr).Type) {
+		return true
+	}
+	switch e := expr.(type) {
+	case *IdentifierExpr:
+		_, ok := ir.objectOfSymbol(ir.unit.semanticInfo.SymbolOfIdentifier(e)).(*spirv.Variable)
+		return ok
+	case *SelectorExpr:
+		if selection := ir.unit.semanticInfo.SelectionOf(e); selection != nil {
+			return selection.Field != nil && ir.hasPointer(e.Base)
+		}
+		_, ok := ir.objectOfSymbol(ir.unit.semanticInfo.SymbolOfIdentifier(e.Selector)).(*spirv.Variable)
+		return ok
+	case *ParenExpr:
+		return ir.hasPointer(e.Base)
+	case *IndexExpr:
+		return ir.hasPointer(e.Base)
+	case *UnaryExpr:
+		return e.Operator.Kind() == TokenMul
+	default:
+		return false
+	}
+}
+
+func (ir *IREmitter) emitComplitExpr(e *ComplitExpr) spirv.Object {
+	tav := ir.typeOf(e)
+	var constituents []spirv.ID
+	if _, ok := tav.Type.Resolve(true).(*VectorType); ok {
+		// vector literals have either all of their components or none of them
+		if len(e.Elements) == 0 {
+			return ir.emitZeroValue(tav.Type)
+		}
+		constituents = make([]spirv.ID, len(e.Elements))
+		for i, element := range e.Elements {
+			constituents[i] = ir.emitExpression(element.Value).ID()
+		}
+	} else {
+		constituents = ir.emitStructLiteralFields(e, tav.Type.Resolve(true).(*StructType))
+	}
+
+	resultType := ir.emitType(tav.Type)
+	result := ir.module.NewValue(resultType)
+	ir.currentBlock().Push(&spirv.CompositeConstructInstruction{
+		ResultType:   resultType.ID(),
+		ResultID:     result.ID(),
+		Constituents: constituents,
+	})
+	return result
+}
+
+// emitStructLiteralFields emits the fields of the struct literal in the order of the struct
+func (ir *IREmitter) emitStructLiteralFields(e *ComplitExpr, structType *StructType) []spirv.ID {
+	values := make([]spirv.Object, len(structType.Fields))
+	for i, element := range e.Elements {
+		index := i
+		if element.Name != nil {
+			index = structType.FieldsByName[element.Name.(*IdentifierExpr).Token.Value()]
+		}
+		values[index] = ir.emitExpression(element.Value)
+	}
+
+	constituents := make([]spirv.ID, len(values))
+	for i, value := range values {
+		// omitted fields are zero initialized
+		if value == nil {
+			value = ir.emitZeroValue(structType.Fields[i].Type)
+		}
+		constituents[i] = value.ID()
+	}
+	return constituents
+}
+
+func (ir *IREmitter) emitZeroValue(t Type) spirv.Object {
+	switch u := t.Resolve(true).(type) {
+	case *BoolType:
+		return ir.emitConstantValue(&TypeAndValue{Mode: AddressModeConstant, Type: t, Value: constant.MakeBool(false)})
+	case *ArrayType:
+		constituents := make([]spirv.ID, u.Length)
+		for i := range constituents {
+			constituents[i] = ir.emitZeroValue(u.ElementType).ID()
+		}
+		resultType := ir.emitType(t)
+		result := ir.module.NewValue(resultType)
+		ir.currentBlock().Push(&spirv.CompositeConstructInstruction{
+			ResultType:   resultType.ID(),
+			ResultID:     result.ID(),
+			Constituents: constituents,
+		})
+		return result
+	case *VectorType:
+		zero := ir.emitZeroValue(vectorElementType(u)).(spirv.ConstantValue)
+		return ir.emitVectorConstant(t, zero)
+	case *StructType:
+		constituents := make([]spirv.ID, len(u.Fields))
+		for i, field := range u.Fields {
+			constituents[i] = ir.emitZeroValue(field.Type).ID()
+		}
+		resultType := ir.emitType(t)
+		result := ir.module.NewValue(resultType)
+		ir.currentBlock().Push(&spirv.CompositeConstructInstruction{
+			ResultType:   resultType.ID(),
+			ResultID:     result.ID(),
+			Constituents: constituents,
+		})
+		return result
+	default:
+		return ir.emitConstantValue(&TypeAndValue{Mode: AddressModeConstant, Type: t, Value: constant.MakeInt64(0)})
+	}
+}
+
+// emitVectorConstant emits the constant vector of the given type with all of its components set to the scalar
+func (ir *IREmitter) emitVectorConstant(t Type, scalar spirv.ConstantValue) spirv.ConstantValue {
+	vectorType := ir.emitType(t).(*spirv.VectorType)
+	constituents := make([]spirv.ConstantValue, vectorType.Count)
+	for i := range constituents {
+		constituents[i] = scalar
+	}
+	return ir.module.InternCompositeConstant(vectorType, constituents)
+}
+
+// componentType returns the type of the components of the vector type, scalar types are their own component type
+func componentType(t spirv.Type) spirv.Type {
+	if vectorType, ok := t.(*spirv.VectorType); ok {
+		return vectorType.ComponentType
+	}
+	return t
+}
+
+// emitVectorOperands splats the scalar operand of an operation between a vector and a scalar, SPIR-V only operates on
+// operands with the same number of components
+func (ir *IREmitter) emitVectorOperands(lhs, rhs spirv.Object) (spirv.Object, spirv.Object) {
+	lhsVector, lhsIsVector := lhs.(spirv.Value).GetType().(*spirv.VectorType)
+	rhsVector, rhsIsVector := rhs.(spirv.Value).GetType().(*spirv.VectorType)
+	switch {
+	case lhsIsVector && !rhsIsVector:
+		rhs = ir.emitSplat(rhs, lhsVector.Count)
+	case rhsIsVector && !lhsIsVector:
+		lhs = ir.emitSplat(lhs, rhsVector.Count)
+	}
+	return lhs, rhs
+}
+
+// emitSplat emits the vector with all of its components set to the scalar
+func (ir *IREmitter) emitSplat(scalar spirv.Object, count int) spirv.Object {
+	resultType := ir.module.InternVector(scalar.(spirv.Value).GetType(), count)
+	constituents := make([]spirv.ID, count)
+	for i := range constituents {
+		constituents[i] = scalar.ID()
+	}
+	result := ir.module.NewValue(resultType)
+	ir.currentBlock().Push(&spirv.CompositeConstructInstruction{
+		ResultType:   resultType.ID(),
+		ResultID:     result.ID(),
+		Constituents: constituents,
+	})
+	return result
 }
 
 func (ir *IREmitter) emitUnaryExpr(e *UnaryExpr) spirv.Object {
+	switch e.Operator.Kind() {
+	case TokenAnd:
+		return ir.emitPointerTo(e.Base)
+	case TokenMul:
+		return ir.emitLoad(ir.emitPointerTo(e), ir.typeOf(e).Type)
+	}
+
 	base := ir.emitExpression(e.Base)
-	tav := ir.unit.semanticInfo.TypeOf(e)
+	tav := ir.typeOf(e)
 	resultType := ir.emitType(tav.Type)
 	result := ir.module.NewValue(resultType)
 	block := ir.currentBlock()
@@ -298,10 +1389,43 @@ func (ir *IREmitter) emitUnaryExpr(e *UnaryExpr) spirv.Object {
 	}
 }
 
+// emitPointerTo emits the pointer to the memory of the given addressable expression
+func (ir *IREmitter) emitPointerTo(expr Expr) spirv.Object {
+	switch e := expr.(type) {
+	case *IdentifierExpr:
+		return ir.objectOfSymbol(ir.unit.semanticInfo.SymbolOfIdentifier(e))
+	case *SelectorExpr:
+		if selection := ir.unit.semanticInfo.SelectionOf(e); selection != nil && selection.Field != nil {
+			return ir.emitFieldPointer(e.Base, selection.Path, ir.typeOf(e).Type)
+		}
+		// package level variables of imported packages
+		return ir.objectOfSymbol(ir.unit.semanticInfo.SymbolOfIdentifier(e.Selector))
+	case *IndexExpr:
+		return ir.emitElementPointer(e)
+	case *ParenExpr:
+		return ir.emitPointerTo(e.Base)
+	case *UnaryExpr:
+		if e.Operator.Kind() == TokenMul {
+			return ir.emitExpression(e.Base)
+		}
+	}
+	panic("unsupported addressable expression")
+}
+
+func (ir *IREmitter) emitLoad(pointer spirv.Object, t Type) spirv.Object {
+	resultType := ir.emitType(t)
+	loadedValue := ir.module.NewValue(resultType)
+	ir.currentBlock().Push(&spirv.LoadInstruction{
+		ResultType: resultType.ID(),
+		ResultID:   loadedValue.ID(),
+		Pointer:    pointer.ID(),
+	})
+	return loadedValue
+}
+
 func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
-	lhs := ir.emitExpression(e.LHS)
-	rhs := ir.emitExpression(e.RHS)
-	tav := ir.unit.semanticInfo.TypeOf(e)
+	lhs, rhs := ir.emitVectorOperands(ir.emitExpression(e.LHS), ir.emitExpression(e.RHS))
+	tav := ir.typeOf(e)
 	resultType := ir.emitType(tav.Type)
 	result := ir.module.NewValue(resultType)
 	block := ir.currentBlock()
@@ -327,7 +1451,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 		return result
 	case TokenLT:
 		// Less than comparison - need to check operand types
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Floating {
@@ -362,7 +1486,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 		return result
 	case TokenGT:
 		// Greater than comparison - need to check operand types
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Floating {
@@ -397,7 +1521,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 		return result
 	case TokenLE:
 		// Less than or equal comparison - need to check operand types
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Floating {
@@ -433,7 +1557,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 
 	case TokenGE:
 		// Greater than or equal comparison - need to check operand types
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Floating {
@@ -469,7 +1593,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 
 	case TokenEQ:
 		// Equality comparison - need to check operand types
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Floating {
@@ -503,7 +1627,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 
 	case TokenNE:
 		// Not equal comparison - need to check operand types
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Floating {
@@ -537,7 +1661,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 
 	case TokenAdd:
 		// Addition - need to check operand types
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Floating {
@@ -563,7 +1687,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 
 	case TokenSub:
 		// Subtraction - need to check operand types
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Floating {
@@ -589,7 +1713,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 
 	case TokenXor:
 		// Bitwise XOR - only for integers
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Integral {
@@ -607,7 +1731,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 
 	case TokenOr:
 		// Bitwise OR - only for integers
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Integral {
@@ -625,7 +1749,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 
 	case TokenMul:
 		// Multiplication - need to check operand types
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Floating {
@@ -651,7 +1775,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 
 	case TokenDiv:
 		// Division - need to check operand types
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Floating {
@@ -687,7 +1811,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 
 	case TokenMod:
 		// Modulo/Remainder - need to check operand types
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Floating {
@@ -723,7 +1847,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 
 	case TokenAnd:
 		// Bitwise AND - only for integers
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Integral {
@@ -742,7 +1866,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 	case TokenAndNot:
 		// Bitwise AND NOT - only for integers
 		// In Go, a &^ b is equivalent to a & (^b) - clear bits in a that are set in b
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Integral {
@@ -768,7 +1892,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 
 	case TokenShl:
 		// Shift left logical - only for integers
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Integral {
@@ -787,7 +1911,7 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 	case TokenShr:
 		// Shift right - only for integers
 		// Use arithmetic shift for signed integers, logical shift for unsigned
-		lhsType := ir.unit.semanticInfo.TypeOf(e.LHS).Type
+		lhsType := ir.typeOf(e.LHS).Type
 		props := lhsType.Properties()
 
 		if props.Integral {
@@ -819,16 +1943,79 @@ func (ir *IREmitter) emitBinaryExpr(e *BinaryExpr) spirv.Object {
 }
 
 func (ir *IREmitter) emitCallExpr(e *CallExpr) spirv.Object {
-	base := ir.emitExpression(e.Base)
-	args := make([]spirv.ID, len(e.Args))
-	for i, argExpr := range e.Args {
-		emittedExpr := ir.emitExpression(argExpr)
-		args[i] = emittedExpr.ID()
+	if builtin := ir.unit.semanticInfo.BuiltinOf(e); builtin != BuiltinFuncNone {
+		return ir.emitBuiltinCall(e, builtin)
+	}
+	if isConversion(ir.typeOf(e.Base)) {
+		return ir.emitConversion(e)
+	}
+
+	var callee *FuncSymbol
+	var typeArgs []Type
+	var receiver spirv.Object
+	argExprs := e.Args
+	if instance := ir.unit.semanticInfo.InstanceOf(e); instance != nil {
+		typeArgs = make([]Type, len(instance.TypeArgs))
+		for i, t := range instance.TypeArgs {
+			typeArgs[i] = ir.unit.semanticInfo.TypeInterner.Substitute(t, ir.typeArgs)
+		}
+		callee = ir.calleeOfCallExpr(e)
+	} else if method := ir.methodOfCallExpr(e); method != nil {
+		// method calls pass the receiver as the first argument
+		callee = method
+		selector := e.Base.(*SelectorExpr)
+		if ir.typeOf(selector.Base).IsType() {
+			// method expressions take the receiver as their first argument
+			receiver = ir.emitReceiver(method, argExprs[0], ir.unit.semanticInfo.SelectionOf(selector))
+			argExprs = argExprs[1:]
+		} else {
+			receiver = ir.emitReceiver(method, selector.Base, ir.unit.semanticInfo.SelectionOf(selector))
+		}
+	} else {
+		bound := ir.funcOfValue(e.Base)
+		callee, typeArgs = bound.sym, bound.typeArgs
+	}
+
+	// function values aren't passed, the callee is specialized for them instead
+	var funcArgs []boundFunc
+	for _, argExpr := range argExprs {
+		if isFunc(ir.typeOf(argExpr).Type) {
+			funcArgs = append(funcArgs, ir.funcOfValue(argExpr))
+		}
+	}
+
+	var base spirv.Object
+	switch {
+	case callee == nil:
+		base = ir.emitExpression(e.Base)
+	case typeArgs != nil || len(funcArgs) > 0:
+		base = ir.emitFuncInstance(callee, typeArgs, funcArgs)
+	default:
+		base = ir.objectOfSymbol(callee)
+	}
+
+	argObjects := make([]spirv.Object, 0, len(argExprs)+1)
+	if receiver != nil {
+		argObjects = append(argObjects, receiver)
+	}
+	for _, argExpr := range argExprs {
+		if !isFunc(ir.typeOf(argExpr).Type) {
+			argObjects = append(argObjects, ir.emitExpression(argExpr))
+		}
+	}
+	args := make([]spirv.ID, len(argObjects))
+	var writeBacks []func()
+	for i, arg := range argObjects {
+		arg, writeBack := ir.emitPointerArgument(arg)
+		if writeBack != nil {
+			writeBacks = append(writeBacks, writeBack)
+		}
+		args[i] = arg.ID()
 	}
 
 	block := ir.currentBlock()
 
-	tav := ir.unit.semanticInfo.TypeOf(e.Base)
+	tav := ir.typeOf(e.Base)
 	funcType := tav.Type.(*FuncType)
 
 	// TODO: Handle multiple return types
@@ -846,9 +2033,442 @@ func (ir *IREmitter) emitCallExpr(e *CallExpr) spirv.Object {
 		FunctionID: base.ID(),
 		Args:       args,
 	})
+	for _, writeBack := range writeBacks {
+		writeBack()
+	}
 	return resultValue
 }
 
+// emitPointerArgument returns the pointer to pass to a function, logical addressing only allows passing pointers to
+// variables and pointer parameters so pointers to fields are passed through a temporary variable which is written
+// back after the call, functions take pointers to function local variables so pointers into the buffers of kernels
+// are passed the same way, except for textures
+func (ir *IREmitter) emitPointerArgument(arg spirv.Object) (spirv.Object, func()) {
+	value, ok := arg.(spirv.Value)
+	if !ok {
+		return arg, nil
+	}
+	ptrType, ok := value.GetType().(*spirv.PtrType)
+	if !ok {
+		return arg, nil
+	}
+	if _, isRuntimeValue := arg.(*spirv.RuntimeValue); !isRuntimeValue && ptrType.StorageClass == spirv.StorageClassFunction {
+		return arg, nil
+	}
+	// textures are read only and passed as the pointers to their variables
+	if ptrType.StorageClass == spirv.StorageClassUniformConstant {
+		return arg, nil
+	}
+	ptrType = ir.module.InternPtr(ptrType.To, spirv.StorageClassFunction)
+
+	copyPointee := func(from, to spirv.Object) {
+		loaded := ir.module.NewValue(ptrType.To)
+		block := ir.currentBlock()
+		block.Push(&spirv.LoadInstruction{
+			ResultType: ptrType.To.ID(),
+			ResultID:   loaded.ID(),
+			Pointer:    from.ID(),
+		})
+		block.Push(&spirv.StoreInstruction{
+			Pointer: to.ID(),
+			Object:  loaded.ID(),
+		})
+	}
+
+	tmp := ir.module.NewVariable("tmp", ptrType, ptrType.StorageClass)
+	ir.currentBlock().Push(&spirv.VariableInstruction{
+		ResultType:   ptrType.ID(),
+		ResultID:     tmp.ID(),
+		StorageClass: ptrType.StorageClass,
+	})
+	copyPointee(arg, tmp)
+	return tmp, func() { copyPointee(tmp, arg) }
+}
+
+func (ir *IREmitter) emitConversion(e *CallExpr) spirv.Object {
+	tav := ir.typeOf(e)
+	if tav.Mode == AddressModeConstant {
+		return ir.emitConstantValue(tav)
+	}
+
+	// conversions of constants to type parameters are folded once the type argument is known
+	if arg := ir.typeOf(e.Args[0]); arg.Mode == AddressModeConstant {
+		if value, ok := convertConstant(arg.Value, tav.Type); ok {
+			return ir.emitConstantValue(&TypeAndValue{Mode: AddressModeConstant, Type: tav.Type, Value: value})
+		}
+	}
+
+	operand := ir.emitExpression(e.Args[0])
+	fromType := ir.typeOf(e.Args[0]).Type
+	if fromType.Resolve(true).Equal(tav.Type.Resolve(true)) {
+		// named types share the representation of their underlying type
+		return operand
+	}
+
+	from := fromType.Properties()
+	to := tav.Type.Properties()
+	resultType := ir.emitType(tav.Type)
+	if ir.emitType(fromType) == resultType {
+		// kernels have a single integer type of each width, so converting between them is a no-op
+		return operand
+	}
+	result := ir.module.NewValue(resultType)
+	block := ir.currentBlock()
+
+	switch {
+	case from.Floating && to.Floating:
+		block.Push(&spirv.FConvertInstruction{
+			ResultType: resultType.ID(),
+			ResultID:   result.ID(),
+			Operand:    operand.ID(),
+		})
+	case from.Integral && to.Floating:
+		if from.Signed {
+			block.Push(&spirv.ConvertSToFInstruction{
+				ResultType: resultType.ID(),
+				ResultID:   result.ID(),
+				Operand:    operand.ID(),
+			})
+		} else {
+			block.Push(&spirv.ConvertUToFInstruction{
+				ResultType: resultType.ID(),
+				ResultID:   result.ID(),
+				Operand:    operand.ID(),
+			})
+		}
+	case from.Floating && to.Integral:
+		if to.Signed {
+			block.Push(&spirv.ConvertFToSInstruction{
+				ResultType: resultType.ID(),
+				ResultID:   result.ID(),
+				Operand:    operand.ID(),
+			})
+		} else {
+			block.Push(&spirv.ConvertFToUInstruction{
+				ResultType: resultType.ID(),
+				ResultID:   result.ID(),
+				Operand:    operand.ID(),
+			})
+		}
+	case from.Integral && to.Integral:
+		// integers of the same width only differ in signedness
+		block.Push(&spirv.BitcastInstruction{
+			ResultType: resultType.ID(),
+			ResultID:   result.ID(),
+			Operand:    operand.ID(),
+		})
+	default:
+		panic("unsupported conversion")
+	}
+	return result
+}
+
+// emitReceiver emits the receiver argument of a method call, taking its address or dereferencing it to match the
+// method's receiver
+func (ir *IREmitter) emitReceiver(method *FuncSymbol, base Expr, selection *Selection) spirv.Object {
+	// promoted methods are called on the embedded field which declares them
+	if selection != nil && len(selection.Path) > 0 {
+		embeddedType := ir.fieldTypeOf(ir.typeOf(base).Type, selection.Path)
+		if method.HasPointerReceiver() {
+			return ir.emitFieldPointer(base, selection.Path, embeddedType)
+		}
+		return ir.emitFieldValue(base, selection.Path, embeddedType)
+	}
+
+	tav := ir.typeOf(base)
+	isPointerBase := isPointer(tav.Type)
+	switch {
+	case method.HasPointerReceiver() && !isPointerBase:
+		return ir.emitPointerTo(base)
+	case !method.HasPointerReceiver() && isPointerBase:
+		return ir.emitLoad(ir.emitExpression(base), tav.Type.Resolve(false).(*PointerType).ElementType)
+	default:
+		return ir.emitExpression(base)
+	}
+}
+
+// fieldTypeOf returns the type of the field found by following the path of field indexes from the struct type
+func (c *instanceContext) fieldTypeOf(t Type, path []int) Type {
+	if pointerType, ok := t.Resolve(false).(*PointerType); ok {
+		t = pointerType.ElementType
+	}
+	for _, index := range path {
+		t = t.Resolve(true).(*StructType).Fields[index].Type
+	}
+	return t
+}
+
+// funcOfValue returns the function referred to by the function value, parameters of function type refer to the
+// functions bound to them in the instance being emitted
+func (c *instanceContext) funcOfValue(e Expr) boundFunc {
+	switch v := e.(type) {
+	case *IdentifierExpr:
+		switch sym := c.unit.semanticInfo.SymbolOfIdentifier(v).(type) {
+		case *FuncSymbol:
+			return boundFunc{sym: sym}
+		case *VarSymbol:
+			return c.funcArgs[sym]
+		}
+	case *SelectorExpr:
+		sym, _ := c.unit.semanticInfo.SymbolOfIdentifier(v.Selector).(*FuncSymbol)
+		return boundFunc{sym: sym}
+	case *ParenExpr:
+		return c.funcOfValue(v.Base)
+	case *IndexExpr:
+		return c.instanceOfValue(e, v.Base)
+	case *IndexListExpr:
+		return c.instanceOfValue(e, v.Base)
+	}
+	return boundFunc{}
+}
+
+// instanceOfValue returns the instance of the generic function given its type arguments explicitly by the function
+// value, the type arguments may refer to the type parameters of the function instance being emitted
+func (c *instanceContext) instanceOfValue(e, base Expr) boundFunc {
+	instance := c.unit.semanticInfo.InstanceOf(e)
+	if instance == nil {
+		return boundFunc{}
+	}
+	bound := c.funcOfValue(base)
+	bound.typeArgs = make([]Type, len(instance.TypeArgs))
+	for i, t := range instance.TypeArgs {
+		bound.typeArgs[i] = c.unit.semanticInfo.TypeInterner.Substitute(t, c.typeArgs)
+	}
+	return bound
+}
+
+func (c *instanceContext) calleeOfCallExpr(e *CallExpr) *FuncSymbol {
+	switch base := e.Base.(type) {
+	case *IdentifierExpr:
+		return c.unit.semanticInfo.SymbolOfIdentifier(base).(*FuncSymbol)
+	case *SelectorExpr:
+		return c.unit.semanticInfo.SymbolOfIdentifier(base.Selector).(*FuncSymbol)
+	case *ParenExpr:
+		return c.calleeOfCallExpr(&CallExpr{Base: base.Base})
+	case *IndexExpr:
+		// generic functions instantiated with explicit type arguments
+		return c.calleeOfCallExpr(&CallExpr{Base: base.Base})
+	case *IndexListExpr:
+		return c.calleeOfCallExpr(&CallExpr{Base: base.Base})
+	default:
+		panic("unsupported callee expression")
+	}
+}
+
+func (ir *IREmitter) emitBuiltinCall(e *CallExpr, builtin BuiltinFunc) spirv.Object {
+	block := ir.currentBlock()
+	switch builtin {
+	case BuiltinFuncDpdx, BuiltinFuncDpdy, BuiltinFuncFwidth:
+		resultType := ir.emitType(ir.typeOf(e).Type)
+		operand := ir.emitExpression(e.Args[0])
+		result := ir.module.NewValue(resultType)
+		switch builtin {
+		case BuiltinFuncDpdx:
+			block.Push(&spirv.DPdxInstruction{ResultType: resultType.ID(), ResultID: result.ID(), Operand: operand.ID()})
+		case BuiltinFuncDpdy:
+			block.Push(&spirv.DPdyInstruction{ResultType: resultType.ID(), ResultID: result.ID(), Operand: operand.ID()})
+		case BuiltinFuncFwidth:
+			block.Push(&spirv.FwidthInstruction{ResultType: resultType.ID(), ResultID: result.ID(), Operand: operand.ID()})
+		}
+		return result
+	case BuiltinFuncTextureSample, BuiltinFuncTextureSampleLevel:
+		resultType := ir.emitType(ir.typeOf(e).Type)
+		// the texture is a pointer to the image and its sampler which are loaded before sampling
+		texture := ir.emitExpression(e.Args[0])
+		sampledImageType := ir.emitType(ir.typeOf(e.Args[0]).Type).(*spirv.PtrType).To
+		sampledImage := ir.module.NewValue(sampledImageType)
+		block.Push(&spirv.LoadInstruction{ResultType: sampledImageType.ID(), ResultID: sampledImage.ID(), Pointer: texture.ID()})
+		coordinate := ir.emitExpression(e.Args[1])
+		result := ir.module.NewValue(resultType)
+		if builtin == BuiltinFuncTextureSample {
+			block.Push(&spirv.ImageSampleImplicitLodInstruction{ResultType: resultType.ID(), ResultID: result.ID(), SampledImage: sampledImage.ID(), Coordinate: coordinate.ID()})
+		} else {
+			lod := ir.emitExpression(e.Args[2])
+			block.Push(&spirv.ImageSampleExplicitLodInstruction{ResultType: resultType.ID(), ResultID: result.ID(), SampledImage: sampledImage.ID(), Coordinate: coordinate.ID(), Lod: lod.ID()})
+		}
+		return result
+	case BuiltinFuncImageLoad, BuiltinFuncImageStore:
+		// the image is a pointer to it which is loaded before reading or writing it
+		pointer := ir.emitExpression(e.Args[0])
+		imageType := ir.emitType(ir.typeOf(e.Args[0]).Type).(*spirv.PtrType).To
+		image := ir.module.NewValue(imageType)
+		block.Push(&spirv.LoadInstruction{ResultType: imageType.ID(), ResultID: image.ID(), Pointer: pointer.ID()})
+		coordinate := ir.emitExpression(e.Args[1])
+		if builtin == BuiltinFuncImageStore {
+			texel := ir.emitExpression(e.Args[2])
+			block.Push(&spirv.ImageWriteInstruction{Image: image.ID(), Coordinate: coordinate.ID(), Texel: texel.ID()})
+			return nil
+		}
+		resultType := ir.emitType(ir.typeOf(e).Type)
+		result := ir.module.NewValue(resultType)
+		block.Push(&spirv.ImageReadInstruction{ResultType: resultType.ID(), ResultID: result.ID(), Image: image.ID(), Coordinate: coordinate.ID()})
+		return result
+	case BuiltinFuncWorkgroupBarrier:
+		// waits for the workgroup and makes the writes to workgroup memory visible to it, like barrier() in GLSL
+		const (
+			scopeWorkgroup                  = 2
+			semanticsAcquireRelease         = 0x8
+			semanticsWorkgroupMemory        = 0x100
+			workgroupBarrierMemorySemantics = semanticsAcquireRelease | semanticsWorkgroupMemory
+		)
+		uintType := ir.internInt(false)
+		scope := ir.module.InternIntConstant(scopeWorkgroup, uintType)
+		semantics := ir.module.InternIntConstant(workgroupBarrierMemorySemantics, uintType)
+		block.Push(&spirv.ControlBarrierInstruction{Execution: scope.ID(), Memory: scope.ID(), Semantics: semantics.ID()})
+		return nil
+	case BuiltinFuncLocalInvocationIndex, BuiltinFuncFrontFacing:
+		if ir.options.Target.isKernel() && builtin == BuiltinFuncFrontFacing {
+			ir.error(NewError(e.SourceRange(), "builtin '%v' is not supported by target '%v'", builtin, ir.options.Target))
+		}
+		if ir.options.Target.isKernel() {
+			// the builtin inputs of kernels are size_t, which is 64-bit in the physical addressing of kernels
+			input := ir.inputOf(builtin)
+			index := ir.module.NewValue(input.Type.To)
+			block.Push(&spirv.LoadInstruction{ResultType: input.Type.To.ID(), ResultID: index.ID(), Pointer: input.ID()})
+			resultType := ir.emitType(ir.typeOf(e).Type)
+			result := ir.module.NewValue(resultType)
+			block.Push(&spirv.UConvertInstruction{ResultType: resultType.ID(), ResultID: result.ID(), Operand: index.ID()})
+			return result
+		}
+		return ir.emitLoad(ir.inputOf(builtin), ir.typeOf(e).Type)
+	default:
+		if builtin.IsMath() {
+			return ir.emitMathBuiltinCall(e, builtin)
+		}
+		panic("unknown builtin function")
+	}
+}
+
+// glslStd450Instructions are the numbers of the math builtins in the GLSL.std.450 extended instruction set
+var glslStd450Instructions = map[BuiltinFunc]spirv.Word{
+	BuiltinFuncAbs:   4,
+	BuiltinFuncFloor: 8,
+	BuiltinFuncCeil:  9,
+	BuiltinFuncFract: 10,
+	BuiltinFuncSin:   13,
+	BuiltinFuncCos:   14,
+	BuiltinFuncTan:   15,
+	BuiltinFuncPow:   26,
+	BuiltinFuncExp:   27,
+	BuiltinFuncLog:   28,
+	BuiltinFuncSqrt:  31,
+	BuiltinFuncMin:   37,
+	BuiltinFuncMax:   40,
+	BuiltinFuncClamp: 43,
+	BuiltinFuncMix:   46,
+}
+
+// openCLStdInstructions are the numbers of the math builtins in the OpenCL.std extended instruction set, fract is
+// missing since the OpenCL one also stores the floor of its operand through a pointer
+var openCLStdInstructions = map[BuiltinFunc]spirv.Word{
+	BuiltinFuncAbs:   23,
+	BuiltinFuncFloor: 25,
+	BuiltinFuncCeil:  12,
+	BuiltinFuncSin:   57,
+	BuiltinFuncCos:   14,
+	BuiltinFuncTan:   62,
+	BuiltinFuncPow:   48,
+	BuiltinFuncExp:   19,
+	BuiltinFuncLog:   37,
+	BuiltinFuncSqrt:  61,
+	BuiltinFuncMin:   28,
+	BuiltinFuncMax:   27,
+	BuiltinFuncClamp: 95,
+	BuiltinFuncMix:   99,
+}
+
+// emitMathBuiltinCall calls the math builtin through the extended instruction set of the target, the scalar arguments
+// of a call on vectors are splatted since the instructions take operands of the same type
+func (ir *IREmitter) emitMathBuiltinCall(e *CallExpr, builtin BuiltinFunc) spirv.Object {
+	resultType := ir.emitType(ir.typeOf(e).Type)
+	operands := make([]spirv.ID, len(e.Args))
+	for i, argExpr := range e.Args {
+		arg := ir.emitExpression(argExpr)
+		if vectorType, ok := resultType.(*spirv.VectorType); ok {
+			if _, ok := arg.(spirv.Value).GetType().(*spirv.VectorType); !ok {
+				arg = ir.emitSplat(arg, vectorType.Count)
+			}
+		}
+		operands[i] = arg.ID()
+	}
+	if !ir.options.Target.isKernel() {
+		return ir.emitExtInst("GLSL.std.450", glslStd450Instructions[builtin], resultType, operands)
+	}
+	if builtin == BuiltinFuncFract {
+		// fract is x - floor(x) like in GLSL
+		floor := ir.emitExtInst("OpenCL.std", openCLStdInstructions[BuiltinFuncFloor], resultType, operands)
+		result := ir.module.NewValue(resultType)
+		ir.currentBlock().Push(&spirv.FSubInstruction{
+			ResultType: resultType.ID(),
+			ResultID:   result.ID(),
+			Operand1:   operands[0],
+			Operand2:   floor.ID(),
+		})
+		return result
+	}
+	return ir.emitExtInst("OpenCL.std", openCLStdInstructions[builtin], resultType, operands)
+}
+
+// emitExtInst calls the instruction of the extended instruction set with the given name
+func (ir *IREmitter) emitExtInst(setName string, instruction spirv.Word, resultType spirv.Type, operands []spirv.ID) spirv.Object {
+	set := ir.module.ImportExtInstSet(setName)
+	result := ir.module.NewValue(resultType)
+	ir.currentBlock().Push(&spirv.ExtInstInstruction{
+		ResultType:  resultType.ID(),
+		ResultID:    result.ID(),
+		Set:         set.ID(),
+		Instruction: instruction,
+		Operands:    operands,
+	})
+	return result
+}
+
+// inputOf returns the global variable holding the builtin input, it's created the first time it's read
+func (ir *IREmitter) inputOf(builtin BuiltinFunc) *spirv.Variable {
+	if variable, ok := ir.inputs[builtin]; ok {
+		return variable
+	}
+
+	var t Type
+	var decoration spirv.BuiltIn
+	switch builtin {
+	case BuiltinFuncLocalInvocationIndex:
+		t, decoration = BuiltinUintType, spirv.BuiltInLocalInvocationIndex
+	case BuiltinFuncFrontFacing:
+		t, decoration = BuiltinBoolType, spirv.BuiltInFrontFacing
+	default:
+		panic("builtin isn't an input")
+	}
+	spirvType := ir.emitType(t)
+	if ir.options.Target.isKernel() {
+		// kernel inputs are size_t
+		ir.module.AddCapability(spirv.CapabilityInt64)
+		spirvType = ir.module.InternInt(64, false)
+	}
+	ptrType := ir.module.InternPtr(spirvType, spirv.StorageClassInput)
+	variable := ir.module.NewGlobalVariable(builtin.String(), ptrType)
+	ir.module.Decorate(variable, spirv.DecorationBuiltIn, spirv.Word(decoration))
+	ir.inputs[builtin] = variable
+	return variable
+}
+
+func (c *instanceContext) methodOfCallExpr(e *CallExpr) *FuncSymbol {
+	selector, ok := e.Base.(*SelectorExpr)
+	if !ok {
+		return nil
+	}
+	if method, ok := c.unit.semanticInfo.SymbolOfIdentifier(selector.Selector).(*FuncSymbol); ok && method.IsMethod() {
+		return method
+	}
+	return nil
+}
+
+// internInt interns a 32-bit integer type, the kernel environment requires integer types to have no signedness so
+// the instructions decide how their operands are interpreted
+func (ir *IREmitter) internInt(signed bool) *spirv.IntType {
+	return ir.module.InternInt(32, signed && !ir.options.Target.isKernel())
+}
+
 func (ir *IREmitter) emitType(Type Type) spirv.Type {
 	switch t := Type.(type) {
 	case *VoidType:
@@ -856,26 +2476,48 @@ func (ir *IREmitter) emitType(Type Type) spirv.Type {
 	case *BoolType:
 		return ir.module.InternBool()
 	case *IntType:
-		return ir.module.InternInt(32, t.Properties().Signed)
+		return ir.internInt(t.Properties().Signed)
+	case *UintType:
+		return ir.internInt(t.Properties().Signed)
 	case *Float32Type:
 		return ir.module.InternFloat(32)
 	case *Float64Type:
 		return ir.module.InternFloat(64)
 	case *FuncType:
-		var spirvReturnType spirv.Type
-		if len(t.ReturnTypes) > 0 {
-			// TODO: Handle multiple return types
-			spirvReturnType = ir.emitType(t.ReturnTypes[0])
-		} else {
-			spirvReturnType = ir.module.InternVoid()
+		return ir.emitFuncType(t, nil)
+	case *VectorType:
+		return ir.module.InternVector(ir.emitType(vectorElementType(t)), t.Width)
+	case *ArrayType:
+		length := ir.module.InternIntConstant(int64(t.Length), ir.module.InternInt(32, false))
+		return ir.module.InternArray(ir.emitType(t.ElementType), length)
+	case *StructType:
+		memberTypes := make([]spirv.Type, len(t.Fields))
+		for i, field := range t.Fields {
+			memberTypes[i] = ir.emitType(field.Type)
 		}
-
-		var parameterTypes []spirv.Type
-		for _, paramType := range t.ParameterTypes {
-			parameterTypes = append(parameterTypes, ir.emitType(paramType))
+		return ir.module.InternStruct(memberTypes)
+	case *StrongAliasType:
+		return ir.emitType(t.UnderlyingType)
+	case *WeakAliasType:
+		return ir.emitType(t.UnderlyingType)
+	case *PointerType:
+		// pointers only live in function parameters and point to function local variables
+		return ir.module.InternPtr(ir.emitType(t.ElementType), spirv.StorageClassFunction)
+	case *TextureType:
+		if t.Storage {
+			image := ir.module.InternStorageImage(ir.module.InternFloat(32), spirv.ImageFormatRgba32f)
+			return ir.module.InternPtr(image, spirv.StorageClassUniformConstant)
 		}
-
-		return ir.module.InternFunc(spirvReturnType, parameterTypes)
+		image := ir.module.InternImage(ir.module.InternFloat(32))
+		return ir.module.InternPtr(ir.module.InternSampledImage(image), spirv.StorageClassUniformConstant)
+	case *UntypedType:
+		return ir.emitType(t.Default())
+	case *TypeParamType:
+		typeArg, ok := ir.typeArgs[t]
+		if !ok {
+			panic(fmt.Sprintf("type parameter '%v' has no type argument", t))
+		}
+		return ir.emitType(typeArg)
 	default:
 		panic("unexpected type")
 	}
@@ -898,11 +2540,17 @@ func (ir *IREmitter) emitStatement(stmt Stmt) {
 	case *IfStmt:
 		ir.emitIfStmt(s)
 	case *ForStmt:
-		ir.emitForStmt(s)
+		ir.emitForStmt(s, "")
+	case *LabeledStmt:
+		ir.emitLabeledStmt(s)
 	case *BreakStmt:
 		ir.emitBreakStmt(s)
 	case *ContinueStmt:
 		ir.emitContinueStmt(s)
+	case *DiscardStmt:
+		ir.emitDiscardStmt(s)
+	case *EmptyStmt:
+		// nothing to emit
 	default:
 		panic("unsupported statement")
 	}
@@ -910,7 +2558,18 @@ func (ir *IREmitter) emitStatement(stmt Stmt) {
 
 func (ir *IREmitter) emitReturnStmt(s *ReturnStmt) {
 	block := ir.currentBlock()
-	if len(s.Exprs) > 0 {
+	if ir.outputs != nil {
+		// entry points write their results to the outputs of their stage
+		values := make([]spirv.Object, len(s.Exprs))
+		for i, e := range s.Exprs {
+			values[i] = ir.emitExpression(e)
+		}
+		block = ir.currentBlock()
+		for i, value := range values {
+			block.Push(&spirv.StoreInstruction{Pointer: ir.outputs[i].ID(), Object: value.ID()})
+		}
+		block.Push(&spirv.ReturnInstruction{})
+	} else if len(s.Exprs) > 0 {
 		// TODO: Multiple return values
 		block.Push(&spirv.ReturnValueInstruction{Value: ir.emitExpression(s.Exprs[0]).ID()})
 	} else {
@@ -921,12 +2580,44 @@ func (ir *IREmitter) emitReturnStmt(s *ReturnStmt) {
 	ir.enterBlock(newBlock)
 }
 
+func (ir *IREmitter) emitDiscardStmt(s *DiscardStmt) {
+	block := ir.currentBlock()
+	switch ir.options.Discard {
+	case DiscardModeKill:
+		block.Push(&spirv.KillInstruction{})
+		ir.leaveBlock()
+		newBlock := block.Function.NewBlock(block.Function.Name())
+		ir.enterBlock(newBlock)
+	case DiscardModeDemote:
+		if ir.options.Target.isOpenGL() {
+			ir.error(NewError(s.SourceRange(), "demote discard mode is not supported by target '%v'", ir.options.Target))
+			return
+		}
+		ir.module.AddCapability(spirv.CapabilityDemoteToHelperInvocation)
+		if !ir.options.Target.hasCoreDemote() {
+			ir.module.AddExtension("SPV_EXT_demote_to_helper_invocation")
+		}
+		// the discard ends the function like a return, demoted invocations keep running as helpers in the callers
+		// so functions with results return their zero value
+		block.Push(&spirv.DemoteToHelperInvocationInstruction{})
+		if len(ir.funcType.ReturnTypes) > 0 && ir.outputs == nil {
+			block.Push(&spirv.ReturnValueInstruction{Value: ir.emitZeroValue(ir.funcType.ReturnTypes[0]).ID()})
+		} else {
+			block.Push(&spirv.ReturnInstruction{})
+		}
+		ir.leaveBlock()
+		ir.enterBlock(block.Function.NewBlock(block.Function.Name()))
+	default:
+		panic("unknown discard mode")
+	}
+}
+
 func (ir *IREmitter) emitIncDecStmt(s *IncDecStmt) {
-	tav := ir.unit.semanticInfo.TypeOf(s.Expr)
+	tav := ir.typeOf(s.Expr)
 	resultType := ir.emitType(tav.Type)
 
-	oneConst := func() spirv.Object {
-		switch t := resultType.(type) {
+	oneConst := func() spirv.ConstantValue {
+		switch t := componentType(resultType).(type) {
 		case *spirv.IntType:
 			return ir.module.InternIntConstant(1, t)
 		case *spirv.FloatType:
@@ -935,11 +2626,13 @@ func (ir *IREmitter) emitIncDecStmt(s *IncDecStmt) {
 			panic("unsupported type for inc/dec")
 		}
 	}()
+	if _, ok := resultType.(*spirv.VectorType); ok {
+		oneConst = ir.emitVectorConstant(tav.Type, oneConst)
+	}
 
 	currentBlock := ir.currentBlock()
 
-	symbol := ir.unit.semanticInfo.SymbolOfIdentifier(s.Expr.(*IdentifierExpr))
-	obj := ir.objectOfSymbol(symbol)
+	obj := ir.emitPointerTo(s.Expr)
 
 	loadedValue := ir.module.NewValue(resultType)
 	currentBlock.Push(&spirv.LoadInstruction{
@@ -949,7 +2642,7 @@ func (ir *IREmitter) emitIncDecStmt(s *IncDecStmt) {
 	})
 	resultValue := ir.module.NewValue(resultType)
 	if s.Operator.Kind() == TokenInc {
-		switch resultType.(type) {
+		switch componentType(resultType).(type) {
 		case *spirv.IntType:
 			currentBlock.Push(&spirv.IAddInstruction{
 				ResultType: resultType.ID(),
@@ -968,7 +2661,7 @@ func (ir *IREmitter) emitIncDecStmt(s *IncDecStmt) {
 			panic("unsupported type for increment")
 		}
 	} else {
-		switch resultType.(type) {
+		switch componentType(resultType).(type) {
 		case *spirv.IntType:
 			currentBlock.Push(&spirv.ISubInstruction{
 				ResultType: resultType.ID(),
@@ -999,6 +2692,8 @@ func (ir *IREmitter) emitDeclStmt(s *DeclStmt) {
 	switch d.DeclToken.Kind() {
 	case TokenVar:
 		ir.emitVarDecl(d, spirv.StorageClassFunction)
+	case TokenConst:
+		// constants are emitted inline where they're used
 	default:
 		panic("unsupported declaration in DeclStmt")
 	}
@@ -1037,8 +2732,6 @@ func (ir *IREmitter) emitAssignStmt(s *AssignStmt) {
 	switch s.Operator.Kind() {
 	case TokenColonAssign:
 		for i, lhsExpr := range s.LHS {
-			symbol := ir.unit.semanticInfo.SymbolOfIdentifier(lhsExpr.(*IdentifierExpr)).(*VarSymbol)
-
 			var initExpr Expr = nil
 			if s.RHS != nil {
 				if i < len(s.RHS) {
@@ -1048,6 +2741,13 @@ func (ir *IREmitter) emitAssignStmt(s *AssignStmt) {
 				}
 			}
 
+			// values assigned to the blank identifier are only evaluated
+			if isBlankIdentifier(lhsExpr) {
+				ir.emitExpression(initExpr)
+				continue
+			}
+
+			symbol := ir.unit.semanticInfo.SymbolOfIdentifier(lhsExpr.(*IdentifierExpr)).(*VarSymbol)
 			ir.emitVar(symbol, spirv.StorageClassFunction, initExpr)
 		}
 	case TokenAssign:
@@ -1056,8 +2756,10 @@ func (ir *IREmitter) emitAssignStmt(s *AssignStmt) {
 			rhsValues = append(rhsValues, ir.emitExpression(rhsExpr))
 		}
 		for i, lhsExpr := range s.LHS {
-			symbol := ir.unit.semanticInfo.SymbolOfIdentifier(lhsExpr.(*IdentifierExpr)).(*VarSymbol)
-			obj := ir.objectOfSymbol(symbol)
+			if isBlankIdentifier(lhsExpr) {
+				continue
+			}
+			obj := ir.emitPointerTo(lhsExpr)
 			block := ir.currentBlock()
 			block.Push(&spirv.StoreInstruction{
 				Pointer: obj.ID(),
@@ -1067,9 +2769,8 @@ func (ir *IREmitter) emitAssignStmt(s *AssignStmt) {
 	case TokenAddAssign, TokenSubAssign, TokenMulAssign, TokenDivAssign, TokenAndAssign,
 		TokenAndNotAssign, TokenOrAssign, TokenXorAssign, TokenShlAssign, TokenShrAssign:
 		for i, lhsExpr := range s.LHS {
-			symbol := ir.unit.semanticInfo.SymbolOfIdentifier(lhsExpr.(*IdentifierExpr)).(*VarSymbol)
-			obj := ir.objectOfSymbol(symbol)
-			t := obj.(*spirv.Variable).Type.To
+			obj := ir.emitPointerTo(lhsExpr)
+			t := ir.emitType(ir.typeOf(lhsExpr).Type)
 			loadedValue := ir.module.NewValue(t)
 			block := ir.currentBlock()
 			block.Push(&spirv.LoadInstruction{
@@ -1077,11 +2778,11 @@ func (ir *IREmitter) emitAssignStmt(s *AssignStmt) {
 				ResultID:   loadedValue.ID(),
 				Pointer:    obj.ID(),
 			})
-			rhsValue := ir.emitExpression(s.RHS[i])
+			_, rhsValue := ir.emitVectorOperands(loadedValue, ir.emitExpression(s.RHS[i]))
 			resultValue := ir.module.NewValue(loadedValue.Type)
 			switch s.Operator.Kind() {
 			case TokenAddAssign:
-				switch t.(type) {
+				switch componentType(t).(type) {
 				case *spirv.IntType:
 					block.Push(&spirv.IAddInstruction{
 						ResultType: resultValue.Type.ID(),
@@ -1098,7 +2799,7 @@ func (ir *IREmitter) emitAssignStmt(s *AssignStmt) {
 					})
 				}
 			case TokenSubAssign:
-				switch t.(type) {
+				switch componentType(t).(type) {
 				case *spirv.IntType:
 					block.Push(&spirv.ISubInstruction{
 						ResultType: resultValue.Type.ID(),
@@ -1115,7 +2816,7 @@ func (ir *IREmitter) emitAssignStmt(s *AssignStmt) {
 					})
 				}
 			case TokenMulAssign:
-				switch t.(type) {
+				switch componentType(t).(type) {
 				case *spirv.IntType:
 					block.Push(&spirv.IMulInstruction{
 						ResultType: resultValue.Type.ID(),
@@ -1132,7 +2833,7 @@ func (ir *IREmitter) emitAssignStmt(s *AssignStmt) {
 					})
 				}
 			case TokenDivAssign:
-				switch t.(type) {
+				switch componentType(t).(type) {
 				case *spirv.IntType:
 					block.Push(&spirv.SDivInstruction{
 						ResultType: resultValue.Type.ID(),
@@ -1190,8 +2891,8 @@ func (ir *IREmitter) emitAssignStmt(s *AssignStmt) {
 					Shift:      rhsValue.ID(),
 				})
 			case TokenShrAssign:
-				if tt, ok := t.(*spirv.IntType); ok {
-					if tt.IsSigned {
+				if _, ok := componentType(t).(*spirv.IntType); ok {
+					if ir.typeOf(lhsExpr).Type.Properties().Signed {
 						block.Push(&spirv.ShiftRightArithmeticInstruction{
 							ResultType: resultValue.Type.ID(),
 							ResultID:   resultValue.ID(),
@@ -1268,7 +2969,15 @@ func (ir *IREmitter) branchToMergeBlockIfNeeded(currentBlock, mergeBlock *spirv.
 	}
 }
 
-func (ir *IREmitter) emitForStmt(forStmt *ForStmt) {
+func (ir *IREmitter) emitLabeledStmt(s *LabeledStmt) {
+	if forStmt, ok := s.Stmt.(*ForStmt); ok {
+		ir.emitForStmt(forStmt, s.Label.Value())
+	} else {
+		ir.emitStatement(s.Stmt)
+	}
+}
+
+func (ir *IREmitter) emitForStmt(forStmt *ForStmt, label string) {
 	// Init
 	if forStmt.Init != nil {
 		ir.emitStatement(forStmt.Init)
@@ -1313,7 +3022,8 @@ func (ir *IREmitter) emitForStmt(forStmt *ForStmt) {
 
 	// for body
 	ir.enterBlock(forBody)
-	ir.enterLoop(loopContext{mergeblock: forMerge, continueBlock: forContinue})
+	loop := &loopContext{mergeblock: forMerge, continueBlock: forContinue, label: label}
+	ir.enterLoop(loop)
 	ir.emitStatement(forStmt.Body)
 	// Note: we have to use ir.currentBlock because the for loop body might change
 	// the current block to a new one
@@ -1333,16 +3043,14 @@ func (ir *IREmitter) emitForStmt(forStmt *ForStmt) {
 	ir.leaveBlock()
 
 	ir.enterBlock(forMerge)
+	for _, exit := range loop.exits {
+		ir.emitLoopExit(exit)
+	}
 }
 
 func (ir *IREmitter) emitBreakStmt(s *BreakStmt) {
-	if s.IsLabeled() {
-		panic("labeled break statement is not supported yet")
-	}
-
-	loop := ir.currentLoop()
 	block := ir.currentBlock()
-	block.Push(&spirv.Branch{TargetLabel: loop.mergeblock.ID()})
+	ir.emitLoopJump(loopExit{target: ir.loopIndexOf(s.Label)})
 	ir.leaveBlock()
 
 	newBlock := block.Function.NewBlock("after_break")
@@ -1350,15 +3058,112 @@ func (ir *IREmitter) emitBreakStmt(s *BreakStmt) {
 }
 
 func (ir *IREmitter) emitContinueStmt(s *ContinueStmt) {
-	if s.IsLabeled() {
-		panic("labeled continue statement is not supported yet")
+	block := ir.currentBlock()
+	ir.emitLoopJump(loopExit{target: ir.loopIndexOf(s.Label), isContinue: true})
+	ir.leaveBlock()
+
+	newBlock := block.Function.NewBlock("after_continue")
+	ir.enterBlock(newBlock)
+}
+
+// loopIndexOf returns the index in the loop stack of the loop with the given label, or the innermost loop if
+// there's no label
+func (ir *IREmitter) loopIndexOf(label Token) int {
+	if label.Kind() != TokenIdentifier {
+		return len(ir.loopStack) - 1
+	}
+	for i := len(ir.loopStack) - 1; i >= 0; i-- {
+		if ir.loopStack[i].label == label.Value() {
+			return i
+		}
+	}
+	panic(fmt.Sprintf("loop with label '%v' not found", label.Value()))
+}
+
+// emitLoopJump terminates the current block with a break or continue, exits to outer loops are stored in the loop
+// jump variable first
+func (ir *IREmitter) emitLoopJump(exit loopExit) {
+	if exit.target == len(ir.loopStack)-1 {
+		ir.emitLoopBranch(exit)
+		return
+	}
+
+	block := ir.currentBlock()
+	if ir.loopJump == nil {
+		uintType := ir.module.InternInt(32, false)
+		ir.loopJump = ir.module.NewVariable("loop_jump", ir.module.InternPtr(uintType, spirv.StorageClassFunction), spirv.StorageClassFunction)
+		block.Push(&spirv.VariableInstruction{
+			ResultType:   ir.loopJump.(*spirv.Variable).Type.ID(),
+			ResultID:     ir.loopJump.ID(),
+			StorageClass: spirv.StorageClassFunction,
+			Initializer:  ir.loopJumpCode(0).ID(),
+		})
 	}
+	block.Push(&spirv.StoreInstruction{Pointer: ir.loopJump.ID(), Object: ir.loopJumpCode(exit.code()).ID()})
+	ir.emitLoopBranch(exit)
+}
 
+// emitLoopBranch terminates the current block with a branch towards the target of the given exit, exits to outer
+// loops leave the innermost loop first and continue from its merge block
+func (ir *IREmitter) emitLoopBranch(exit loopExit) {
+	block := ir.currentBlock()
 	loop := ir.currentLoop()
+	if exit.target != len(ir.loopStack)-1 {
+		if !slices.Contains(loop.exits, exit) {
+			loop.exits = append(loop.exits, exit)
+		}
+		block.Push(&spirv.Branch{TargetLabel: loop.mergeblock.ID()})
+	} else if exit.isContinue {
+		block.Push(&spirv.Branch{TargetLabel: loop.continueBlock.ID()})
+	} else {
+		block.Push(&spirv.Branch{TargetLabel: loop.mergeblock.ID()})
+	}
+}
+
+// emitLoopExit continues the given exit from the merge block of the loop it left through
+func (ir *IREmitter) emitLoopExit(exit loopExit) {
 	block := ir.currentBlock()
-	block.Push(&spirv.Branch{TargetLabel: loop.continueBlock.ID()})
+	fn := block.Function
+	boolType := ir.module.InternBool()
+
+	jump := ir.module.NewValue(ir.loopJumpCode(0).Type)
+	block.Push(&spirv.LoadInstruction{
+		ResultType: jump.Type.ID(),
+		ResultID:   jump.ID(),
+		Pointer:    ir.loopJump.ID(),
+	})
+	taken := ir.module.NewValue(boolType)
+	block.Push(&spirv.IEqualInstruction{
+		ResultType: boolType.ID(),
+		ResultID:   taken.ID(),
+		Operand1:   jump.ID(),
+		Operand2:   ir.loopJumpCode(exit.code()).ID(),
+	})
+
+	exitBlock := fn.NewBlock("loop_exit")
+	mergeBlock := fn.NewBlock("loop_exit_merge")
+	block.Push(&spirv.SelectionMergeInstruction{
+		MergeBlock: mergeBlock.ID(),
+		Control:    spirv.SelectionControlNone,
+	})
+	block.Push(&spirv.BranchConditional{
+		Condition:  taken.ID(),
+		TrueLabel:  exitBlock.ID(),
+		FalseLabel: mergeBlock.ID(),
+	})
 	ir.leaveBlock()
 
-	newBlock := block.Function.NewBlock("after_continue")
-	ir.enterBlock(newBlock)
+	ir.enterBlock(exitBlock)
+	if exit.target == len(ir.loopStack)-1 {
+		// the exit reached its target, so the merge blocks it left through shouldn't take it again
+		exitBlock.Push(&spirv.StoreInstruction{Pointer: ir.loopJump.ID(), Object: ir.loopJumpCode(0).ID()})
+	}
+	ir.emitLoopBranch(exit)
+	ir.leaveBlock()
+
+	ir.enterBlock(mergeBlock)
+}
+
+func (ir *IREmitter) loopJumpCode(code int64) *spirv.IntConstant {
+	return ir.module.InternIntConstant(code, ir.module.InternInt(32, false))
 }
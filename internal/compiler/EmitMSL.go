@@ -0,0 +1,371 @@
+package compiler
+
+import (
+	"fmt"
+	"go/constant"
+	"strings"
+)
+
+// MSLEmitter translates the checked unit to Metal Shading Language source, entry points become vertex, fragment and
+// kernel functions of a single Metal library
+type MSLEmitter struct {
+	*sourceEmitter
+	cDialect
+	// fields of the struct the entry point being emitted returns its outputs in
+	outputs []string
+}
+
+func NewMSLEmitter(u *Unit) *MSLEmitter {
+	g := &MSLEmitter{}
+	g.sourceEmitter = newSourceEmitter(u, g)
+	return g
+}
+
+func (g *MSLEmitter) Emit() string {
+	g.emitFuncs(g.unit.semanticInfo.EntryPoints)
+	if g.unit.HasErrors() {
+		return ""
+	}
+
+	var out strings.Builder
+	out.WriteString("#include <metal_stdlib>\nusing namespace metal;\n")
+	for _, decl := range g.decls {
+		out.WriteString("\n")
+		out.WriteString(decl)
+	}
+	return out.String()
+}
+
+func (g *MSLEmitter) identifier(name string) string {
+	if mslReservedNames[name] || strings.HasPrefix(name, "__") || strings.HasPrefix(name, "sabre_") {
+		return name + "_"
+	}
+	return name
+}
+
+func (g *MSLEmitter) scalarTypeName(t Type) string {
+	switch t.(type) {
+	case *BoolType:
+		return "bool"
+	case *IntType:
+		return "int"
+	case *UintType:
+		return "uint"
+	case *Float32Type:
+		return "float"
+	case *Float64Type:
+		g.unsupportedType("MSL", t)
+		return "double"
+	default:
+		panic("unexpected type")
+	}
+}
+
+// vectorTypeName reports double vectors as unsupported once for the vector rather than for its element type
+func (g *MSLEmitter) vectorTypeName(t *VectorType) string {
+	element := vectorElementType(t)
+	if _, ok := element.(*Float64Type); ok {
+		g.unsupportedType("MSL", t)
+		return fmt.Sprintf("double%v", t.Width)
+	}
+	return fmt.Sprintf("%v%v", g.scalarTypeName(element), t.Width)
+}
+
+// textureTypeName returns the texture of float texels, its sampler is passed next to it. images are textures which
+// are read and written without a sampler
+func (g *MSLEmitter) textureTypeName(t *TextureType) string {
+	if t.Storage {
+		return "texture2d<float, access::read_write>"
+	}
+	return "texture2d<float>"
+}
+
+func (g *MSLEmitter) textureArg(texture sourceExpr) string {
+	return textureWithSampler(texture)
+}
+
+// bufferPointers returns true since buffers live in the device address space
+func (g *MSLEmitter) bufferPointers() bool { return true }
+
+// vectorBinary uses the operators which apply to each component, scalar operands are promoted to vectors implicitly
+func (g *MSLEmitter) vectorBinary(operator TokenKind, lhsType, rhsType Type, lhs, rhs sourceExpr) sourceExpr {
+	return g.infix(operator, lhs, rhs)
+}
+
+// splat returns the vector with all of its components set to the scalar
+func (g *MSLEmitter) splat(t *VectorType, scalar sourceExpr) sourceExpr {
+	return sourceExpr{fmt.Sprintf("%v(%v)", g.vectorTypeName(t), scalar), precPostfix}
+}
+
+func (g *MSLEmitter) arrayTypeName(t *ArrayType) string {
+	return fmt.Sprintf("array<%v, %v>", g.typeName(t.ElementType), t.Length)
+}
+
+func (g *MSLEmitter) declaration(t Type, name string) string {
+	if name == "" {
+		return g.typeName(t)
+	}
+	return fmt.Sprintf("%v %v", g.typeName(t), name)
+}
+
+func (g *MSLEmitter) variableDeclaration(t Type, name string) string {
+	return g.declaration(t, name)
+}
+
+// paramDeclaration declares pointers as references to the thread address space, which is where all the variables
+// live, or to the device address space for pointers into buffers. textures are followed by their sampler
+func (g *MSLEmitter) paramDeclaration(sym Symbol, t Type, name string, buffer bool) string {
+	switch t := t.Resolve(false).(type) {
+	case *PointerType:
+		addressSpace := "thread"
+		if buffer {
+			addressSpace = "device"
+		}
+		if name == "" {
+			return fmt.Sprintf("%v %v&", addressSpace, g.typeName(t.ElementType))
+		}
+		return fmt.Sprintf("%v %v& %v", addressSpace, g.typeName(t.ElementType), name)
+	case *TextureType:
+		if t.Storage {
+			return g.declaration(t, name)
+		}
+		if name == "" {
+			return fmt.Sprintf("%v, sampler", g.declaration(t, name))
+		}
+		return fmt.Sprintf("%v, sampler %v", g.declaration(t, name), samplerName(name))
+	default:
+		return g.declaration(t, name)
+	}
+}
+
+func (g *MSLEmitter) builtinCall(builtin BuiltinFunc, t Type, args []sourceExpr) sourceExpr {
+	if builtin.IsMath() {
+		return funcCall(builtin.String(), args)
+	}
+	switch builtin {
+	case BuiltinFuncDpdx:
+		return sourceExpr{fmt.Sprintf("dfdx(%v)", args[0]), precPostfix}
+	case BuiltinFuncDpdy:
+		return sourceExpr{fmt.Sprintf("dfdy(%v)", args[0]), precPostfix}
+	case BuiltinFuncFwidth:
+		return sourceExpr{fmt.Sprintf("fwidth(%v)", args[0]), precPostfix}
+	case BuiltinFuncTextureSample:
+		return sourceExpr{fmt.Sprintf("%v.sample(%v, %v)", args[0], samplerName(args[0].text), args[1]), precPostfix}
+	case BuiltinFuncTextureSampleLevel:
+		return sourceExpr{
+			fmt.Sprintf("%v.sample(%v, %v, level(%v))", args[0], samplerName(args[0].text), args[1], args[2]),
+			precPostfix,
+		}
+	case BuiltinFuncImageLoad:
+		return sourceExpr{fmt.Sprintf("%v.read(uint2(%v))", args[0], args[1]), precPostfix}
+	case BuiltinFuncImageStore:
+		return sourceExpr{fmt.Sprintf("%v.write(%v, uint2(%v))", args[0], args[2], args[1]), precPostfix}
+	case BuiltinFuncWorkgroupBarrier:
+		return sourceExpr{"threadgroup_barrier(mem_flags::mem_threadgroup)", precPostfix}
+	default:
+		panic("unexpected builtin function")
+	}
+}
+
+// resource passes buffers as device references at the buffer index of their binding, uniforms as constant references
+// at the buffer index, and textures at the texture index with their sampler at the sampler index. images have no
+// sampler. unnamed resources are named since they carry an attribute
+func (g *MSLEmitter) resource(binding int, t Type, name string) sourceResource {
+	name = resourceName(binding, name)
+	res := sourceResource{ref: sourceExpr{name, precPostfix}}
+	switch u := t.Resolve(false).(type) {
+	case *PointerType:
+		res.param = fmt.Sprintf("%v [[buffer(%v)]]", g.paramDeclaration(nil, t, name, true), binding)
+	case *TextureType:
+		if u.Storage {
+			res.param = fmt.Sprintf("%v [[texture(%v)]]", g.declaration(t, name), binding)
+			break
+		}
+		res.param = fmt.Sprintf(
+			"%v [[texture(%v)]], sampler %v [[sampler(%v)]]",
+			g.declaration(t, name), binding, samplerName(name), binding,
+		)
+	default:
+		res.param = fmt.Sprintf("constant %v& %v [[buffer(%v)]]", g.typeName(t), name, binding)
+	}
+	return res
+}
+
+// inputParam declares the input with its attribute
+func (g *MSLEmitter) inputParam(builtin BuiltinFunc, name string) string {
+	switch builtin {
+	case BuiltinFuncLocalInvocationIndex:
+		return fmt.Sprintf("uint %v [[thread_index_in_threadgroup]]", name)
+	case BuiltinFuncFrontFacing:
+		return fmt.Sprintf("bool %v [[front_facing]]", name)
+	default:
+		panic("unexpected builtin input")
+	}
+}
+
+func (g *MSLEmitter) fieldDeclaration(t Type, name string) string {
+	return g.declaration(t, name) + ";"
+}
+
+// signature marks the entry points with their stage, vertex and fragment entry points returning outputs return the
+// struct holding them
+func (g *MSLEmitter) signature(sym *FuncSymbol, name string, params []string, result Type) string {
+	var qualifier string
+	switch sym.Stage {
+	case ShaderStageNone:
+	case ShaderStageVertex:
+		qualifier = "vertex "
+	case ShaderStageFragment:
+		qualifier = "fragment "
+	case ShaderStageCompute:
+		qualifier = "kernel "
+	default:
+		panic("unexpected shader stage")
+	}
+
+	resultName := "void"
+	if sym.IsEntryPoint() && g.function.stageResult != "" {
+		resultName = g.function.stageResult
+	} else if result != nil {
+		resultName = g.typeName(result)
+	}
+	return fmt.Sprintf("%v%v %v(%v)", qualifier, resultName, name, strings.Join(params, ", "))
+}
+
+// floatLiteral returns float literals with a suffix since unsuffixed literals are doubles in C++
+func (g *MSLEmitter) floatLiteral(value float64, bitSize int) string {
+	if bitSize == 64 {
+		return formatFloat(value, bitSize)
+	}
+	return formatFloat(value, bitSize) + "f"
+}
+
+func (g *MSLEmitter) compositeValue(t Type, elements []string) sourceExpr {
+	return sourceExpr{fmt.Sprintf("%v{%v}", g.typeName(t), strings.Join(elements, ", ")), precPostfix}
+}
+
+func (g *MSLEmitter) vectorLiteral(t *VectorType, components []string) sourceExpr {
+	return sourceExpr{fmt.Sprintf("%v(%v)", g.vectorTypeName(t), strings.Join(components, ", ")), precPostfix}
+}
+
+func (g *MSLEmitter) compositeLiteral(t Type, fields []string) sourceExpr {
+	structType := t.Resolve(true).(*StructType)
+	for i, field := range fields {
+		// omitted fields are zero initialized
+		if field == "" {
+			fields[i] = g.zeroValue(structType.Fields[i].Type).text
+		}
+	}
+	return g.compositeValue(t, fields)
+}
+
+func (g *MSLEmitter) zeroValue(t Type) sourceExpr {
+	switch t.Resolve(true).(type) {
+	case *BoolType:
+		return g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: t, Value: constant.MakeBool(false)})
+	case *ArrayType, *StructType, *VectorType:
+		// value initialization sets all the elements, fields and components to zero
+		return g.compositeValue(t, nil)
+	default:
+		return g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: t, Value: constant.MakeInt64(0)})
+	}
+}
+
+// constantDeclaration declares the constant in the constant address space, which is the only address space of
+// program scope variables
+func (g *MSLEmitter) constantDeclaration(t Type, name string, value sourceExpr) string {
+	return fmt.Sprintf("constant %v = %v;", g.declaration(t, name), value)
+}
+
+// workgroupDeclaration reports the workgroup variable, MSL only declares threadgroup memory inside kernels which
+// would have to pass it to the functions using it
+func (g *MSLEmitter) workgroupDeclaration(v *VarSymbol, t Type, name string) string {
+	g.error(NewError(v.SourceRange(), "workgroup variable '%v' is not supported in MSL, which only declares threadgroup memory inside kernels", v.Name()))
+	return ""
+}
+
+func (g *MSLEmitter) discardStmt() string {
+	return "discard_fragment()"
+}
+
+var mslReservedNames = reservedNames(`
+	main
+	alignas alignof and and_eq asm auto bitand bitor bool break case catch char char16_t char32_t class compl const
+	constexpr const_cast continue decltype default delete do double dynamic_cast else enum explicit export extern
+	false float for friend goto if inline int long mutable namespace new noexcept not not_eq nullptr operator or
+	or_eq private protected public register reinterpret_cast return short signed sizeof static static_assert
+	static_cast struct switch template this thread_local throw true try typedef typeid typename union unsigned
+	using virtual void volatile wchar_t while xor xor_eq
+	metal array vertex fragment kernel device constant thread threadgroup threadgroup_imageblock ray_data object_data
+	stage_in visible uint ushort uchar half size_t ptrdiff_t
+	bool2 bool3 bool4 char2 char3 char4 uchar2 uchar3 uchar4 short2 short3 short4 ushort2 ushort3 ushort4 int2 int3
+	int4 uint2 uint3 uint4 half2 half3 half4 float2 float3 float4 float2x2 float2x3 float2x4 float3x2 float3x3
+	float3x4 float4x2 float4x3 float4x4 packed_float2 packed_float3 packed_float4 sampler texture1d texture2d
+	texture3d texturecube texture2d_array depth2d
+	abs absdiff acos acosh all any asin asinh as_type atan atan2 atanh ceil clamp copysign cos cosh cospi cross
+	degrees determinant distance distance_squared dot exp exp10 exp2 fabs fdim floor fma fmax fmax3 fmin fmin3 fmod
+	fract frexp ilogb isfinite isinf isnan isnormal ldexp length length_squared log log10 log2 max max3 median3 min
+	min3 mix modf nextafter normalize pow powr reflect refract rint round rsqrt saturate select sign signbit sin
+	sincos sinh sinpi smoothstep sqrt step tan tanh tanpi transpose trunc clz ctz extract_bits insert_bits popcount
+	reverse_bits rotate mulhi madhi addsat subsat hadd rhadd discard_fragment dfdx dfdy fwidth simd_sum
+	threadgroup_barrier
+`)
+
+// stageInputs declares the struct the entry point receives its inputs in through its stage_in parameter, the inputs
+// of vertex entry points are vertex attributes and the inputs of fragment entry points are matched with the outputs
+// of vertex entry points by their locations
+func (g *MSLEmitter) stageInputs(entry *FuncSymbol, inputs []stageVar) sourceStage {
+	name := fmt.Sprintf("sabre_%v_input", entry.Name())
+	fields := make([]string, len(inputs))
+	var stage sourceStage
+	for i, input := range inputs {
+		attribute := fmt.Sprintf("user(locn%v)", input.location)
+		if entry.Stage == ShaderStageVertex {
+			attribute = fmt.Sprintf("attribute(%v)", input.location)
+		}
+		fields[i] = g.stageVarDeclaration(input, attribute) + ";"
+		stage.refs = append(stage.refs, sourceExpr{"sabre_input." + input.name, precPostfix})
+	}
+	stage.decl = g.structDeclaration(name, fields)
+	stage.params = []string{name + " sabre_input [[stage_in]]"}
+	return stage
+}
+
+// stageOutputs declares the struct the entry point returns its outputs in, the fields have the attributes of the
+// position, the values passed to the fragment entry point or the color attachments. the entry point fills the struct
+// declared at its start when it returns
+func (g *MSLEmitter) stageOutputs(entry *FuncSymbol, outputs []stageVar) sourceStage {
+	name := fmt.Sprintf("sabre_%v_output", entry.Name())
+	fields := make([]string, len(outputs))
+	g.outputs = make([]string, len(outputs))
+	for i, output := range outputs {
+		attribute := fmt.Sprintf("user(locn%v)", output.location)
+		if output.position {
+			attribute = "position"
+		} else if entry.Stage == ShaderStageFragment {
+			attribute = fmt.Sprintf("color(%v)", output.location)
+		}
+		// only the inputs of fragment entry points are marked flat
+		output.flat = false
+		fields[i] = g.stageVarDeclaration(output, attribute) + ";"
+		g.outputs[i] = output.name
+	}
+	g.line("%v sabre_output;", name)
+	return sourceStage{decl: g.structDeclaration(name, fields), result: name}
+}
+
+// stageVarDeclaration declares the input or the output with its attribute, integers are declared flat
+func (g *MSLEmitter) stageVarDeclaration(v stageVar, attribute string) string {
+	if v.flat {
+		attribute += ", flat"
+	}
+	return fmt.Sprintf("%v [[%v]]", g.declaration(v.t, v.name), attribute)
+}
+
+// stageReturn sets the fields of the outputs struct and returns it
+func (g *MSLEmitter) stageReturn(values []sourceExpr) {
+	for i, value := range values {
+		g.line("sabre_output.%v = %v;", g.outputs[i], value)
+	}
+	g.line("return sabre_output;")
+}
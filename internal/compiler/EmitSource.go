@@ -0,0 +1,2072 @@
+package compiler
+
+import (
+	"fmt"
+	"go/constant"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// operator precedence of C like languages from the loosest to the tightest binding
+const (
+	precLowest = iota
+	precLOr
+	precLAnd
+	precOr
+	precXor
+	precAnd
+	precEquality
+	precRelational
+	precShift
+	precAdditive
+	precMultiplicative
+	precUnary
+	precPostfix
+)
+
+// sourceExpr is an expression in the generated source along with the precedence of its outermost operator, which
+// decides whether it should be parenthesized when used as an operand
+type sourceExpr struct {
+	text string
+	prec int
+}
+
+func (e sourceExpr) String() string { return e.text }
+
+// sourceDialect is the language specific part of the backends which emit source code in a C like language, the
+// statements and expressions are shared by all of them
+type sourceDialect interface {
+	// identifier returns the name used for the identifier, names reserved by the language are renamed
+	identifier(name string) string
+	// scalarTypeName returns the name of the bool, integer or floating point type
+	scalarTypeName(t Type) string
+	arrayTypeName(t *ArrayType) string
+	// variableDeclaration declares a local variable without its initializer
+	variableDeclaration(t Type, name string) string
+	// paramDeclaration declares the parameter of the function being emitted, pointers are passed by reference. buffer
+	// is set for pointers into the buffers of the entry point in languages where bufferPointers is set, textures are
+	// declared with their sampler in languages keeping them apart
+	paramDeclaration(sym Symbol, t Type, name string, buffer bool) string
+	fieldDeclaration(t Type, name string) string
+	// signature returns the signature of the function, the result is nil for functions which return nothing
+	signature(sym *FuncSymbol, name string, params []string, result Type) string
+	// emptyReturn returns the statement returning from a function without results
+	emptyReturn() string
+	// funcEnd emits the statements which end the body of the function being emitted
+	funcEnd()
+	floatLiteral(value float64, bitSize int) string
+	// compositeValue constructs an array or a struct from all of its elements or fields
+	compositeValue(t Type, elements []string) sourceExpr
+	// compositeLiteral constructs a struct from the given fields, omitted fields are empty and zero initialized
+	compositeLiteral(t Type, fields []string) sourceExpr
+	// vectorLiteral constructs a vector from all of its components
+	vectorLiteral(t *VectorType, components []string) sourceExpr
+	zeroValue(t Type) sourceExpr
+	// constantDeclaration declares a constant at the top level of the source
+	constantDeclaration(t Type, name string, value sourceExpr) string
+	// workgroupDeclaration declares a workgroup variable at the top level of the source, it's empty for the languages
+	// which reported the variable as unsupported
+	workgroupDeclaration(v *VarSymbol, t Type, name string) string
+	discardStmt() string
+	// discardDemotes returns whether discarded invocations keep running as helpers, the discard then returns from the
+	// function like in the SPIR-V emitter
+	discardDemotes() bool
+	// discardedValue returns the statement evaluating the expression and discarding its value
+	discardedValue(e sourceExpr) string
+	incDec(t Type, operand string, operator Token) string
+	addressOf(e sourceExpr) sourceExpr
+	dereference(e sourceExpr) sourceExpr
+	// binaryOperandPrecedence returns the precedence the operands of the binary operator should have to be written
+	// without parentheses
+	binaryOperandPrecedence(operator TokenKind) (lhs, rhs int)
+	// shiftAmount converts the amount of a shift to the type the language expects
+	shiftAmount(tav *TypeAndValue, amount sourceExpr) sourceExpr
+	// precedence returns the precedence the binary operator binds with
+	precedence(operator TokenKind) int
+	uintLiteral(value uint64) string
+	// complement returns the bitwise complement of the expression
+	complement(e sourceExpr) sourceExpr
+	// andNot returns the operator and the right operand which clear the bits of the operand
+	andNot(operand sourceExpr) (string, sourceExpr)
+	structDeclaration(name string, fields []string) string
+	// loopHeader returns the header of a loop, the parts the loop doesn't have are empty
+	loopHeader(init, cond, post string) string
+	// declaresInLoopHeader returns whether variables can be declared in the init statement of the loop header
+	declaresInLoopHeader() bool
+	// caseClause returns the line starting a case of a switch with the given values, the default case has none
+	caseClause(values []string) string
+	// caseEnd returns the line ending a case of a switch, it's empty for languages which need none
+	caseEnd() string
+	// casesFallThrough returns whether the cases of a switch fall through to the next one unless they break
+	casesFallThrough() bool
+	// requiresDefaultCase returns whether every switch must have a default case
+	requiresDefaultCase() bool
+	// multipleResults returns whether functions can return multiple results, functions return a struct holding their
+	// results in languages without them
+	multipleResults() bool
+	vectorTypeName(t *VectorType) string
+	// textureTypeName returns the type of the texture, languages keeping the textures apart from their samplers pass
+	// the sampler next to the texture
+	textureTypeName(t *TextureType) string
+	// textureArg returns the argument passing the texture to a function, which includes its sampler in languages
+	// keeping them apart
+	textureArg(texture sourceExpr) string
+	// bufferPointers returns whether pointers into buffers have other types than pointers to variables, functions are
+	// then specialized for the parameters receiving pointers into buffers
+	bufferPointers() bool
+	// vectorBinary applies the binary operator to the operands, at least one of them is a vector and the other one can
+	// be a scalar of its element type
+	vectorBinary(operator TokenKind, lhsType, rhsType Type, lhs, rhs sourceExpr) sourceExpr
+	vectorUnary(operator TokenKind, t *VectorType, operand sourceExpr) sourceExpr
+	// swizzle returns the components of the vector, the components are named by a single swizzle set
+	swizzle(t *VectorType, base sourceExpr, components string) sourceExpr
+	// splat returns the vector with all of its components set to the scalar
+	splat(t *VectorType, scalar sourceExpr) sourceExpr
+	// builtinCall calls a builtin function which isn't an input, t is the type of the call and it's nil if it doesn't
+	// return a value. the scalar arguments of math builtins called on vectors are already splatted
+	builtinCall(builtin BuiltinFunc, t Type, args []sourceExpr) sourceExpr
+	// resource binds the buffer, the texture or the uniform struct taken by the entry point at the given binding, the
+	// name is empty for unnamed parameters
+	resource(binding int, t Type, name string) sourceResource
+	// inputParam declares the parameter of the entry point receiving the builtin input, it's empty if the language
+	// provides the input as a global
+	inputParam(builtin BuiltinFunc, name string) string
+	// input reads the builtin input in the body of the entry point
+	input(builtin BuiltinFunc) sourceExpr
+	// stageInputs declares the inputs the vertex or fragment entry point receives from the previous stage
+	stageInputs(entry *FuncSymbol, inputs []stageVar) sourceStage
+	// stageOutputs declares the outputs the vertex or fragment entry point passes to the next stage
+	stageOutputs(entry *FuncSymbol, outputs []stageVar) sourceStage
+	// stageReturn returns the outputs from the entry point being emitted, which returns the result of its stage
+	stageReturn(values []sourceExpr)
+}
+
+// sourceResource is a buffer, a texture or a uniform struct taken by an entry point, which is bound by the pipeline
+type sourceResource struct {
+	// decl declares the resource at the top level of the source, it's empty for resources passed as parameters
+	decl string
+	// param declares the parameter of the entry point receiving the resource, it's empty for resources declared at
+	// the top level
+	param string
+	// ref is the pointer to the buffer, the texture or the uniform struct the entry point uses instead of its parameter
+	ref sourceExpr
+}
+
+// stageVar is an input or an output of a vertex or fragment entry point, the stages of the pipeline pass them to
+// each other by their locations
+type stageVar struct {
+	name string
+	t    Type
+	// sym is the parameter receiving the input, it's nil for the outputs and the unnamed inputs
+	sym      Symbol
+	location int
+	// position is set for the first output of vertex entry points, which isn't passed at a location
+	position bool
+	// flat is set for the integers passed to fragment entry points, which aren't interpolated
+	flat bool
+}
+
+// sourceStage declares the inputs or the outputs of a vertex or fragment entry point
+type sourceStage struct {
+	// decl declares the inputs or outputs at the top level of the source, it's empty if they need no declaration
+	decl string
+	// params declare the parameters of the entry point receiving the inputs
+	params []string
+	// refs read the inputs in the body of the entry point, inputs without them are read from their parameters
+	refs []sourceExpr
+	// result is the type the entry point returns its outputs in, it's empty for entry points which return their
+	// results like any other function
+	result string
+}
+
+// cDialect implements the parts of the dialects which follow C, pointers are passed by reference so taking the
+// address and dereferencing are implicit, the dialects override the parts they do differently
+type cDialect struct{}
+
+func (cDialect) emptyReturn() string { return "return" }
+
+func (cDialect) funcEnd() {}
+
+func (cDialect) textureArg(texture sourceExpr) string { return texture.text }
+
+func (cDialect) bufferPointers() bool { return false }
+
+func (cDialect) discardDemotes() bool { return false }
+
+func (cDialect) discardedValue(e sourceExpr) string { return e.text }
+
+func (cDialect) incDec(t Type, operand string, operator Token) string {
+	return operand + operator.Value()
+}
+
+func (cDialect) addressOf(e sourceExpr) sourceExpr { return e }
+
+func (cDialect) dereference(e sourceExpr) sourceExpr { return e }
+
+// binaryOperandPrecedence parenthesizes the right operand if it binds the same since operators are left associative
+func (cDialect) binaryOperandPrecedence(operator TokenKind) (int, int) {
+	prec := binaryPrecedence(operator)
+	return prec, prec + 1
+}
+
+func (cDialect) shiftAmount(tav *TypeAndValue, amount sourceExpr) sourceExpr { return amount }
+
+func (cDialect) precedence(operator TokenKind) int { return binaryPrecedence(operator) }
+
+func (cDialect) uintLiteral(value uint64) string { return strconv.FormatUint(value, 10) + "u" }
+
+func (cDialect) complement(e sourceExpr) sourceExpr {
+	return sourceExpr{"~" + parenthesize(e, precPostfix), precUnary}
+}
+
+// andNot ands with the complement of the operand since there's no and not operator
+func (d cDialect) andNot(operand sourceExpr) (string, sourceExpr) {
+	return "&", d.complement(operand)
+}
+
+func (cDialect) structDeclaration(name string, fields []string) string {
+	var decl strings.Builder
+	fmt.Fprintf(&decl, "struct %v {\n", name)
+	for _, field := range fields {
+		fmt.Fprintf(&decl, "\t%v\n", field)
+	}
+	decl.WriteString("};\n")
+	return decl.String()
+}
+
+func (cDialect) loopHeader(init, cond, post string) string {
+	switch {
+	case init == "" && cond == "" && post == "":
+		return "for (;;)"
+	case init == "" && post == "":
+		return fmt.Sprintf("while (%v)", cond)
+	default:
+		return fmt.Sprintf("for (%v; %v; %v)", init, cond, post)
+	}
+}
+
+func (cDialect) declaresInLoopHeader() bool { return true }
+
+// caseClause puts the body of the case in a block since jumping past the declarations of the cases before it isn't
+// allowed
+func (cDialect) caseClause(values []string) string {
+	if len(values) == 0 {
+		return "default: {"
+	}
+	labels := make([]string, len(values))
+	for i, value := range values {
+		labels[i] = fmt.Sprintf("case %v:", value)
+	}
+	return strings.Join(labels, " ") + " {"
+}
+
+func (cDialect) caseEnd() string { return "}" }
+
+func (cDialect) casesFallThrough() bool { return true }
+
+func (cDialect) requiresDefaultCase() bool { return false }
+
+func (cDialect) multipleResults() bool { return false }
+
+// vectorUnary applies the operator to each component of the vector like the shading languages do
+func (d cDialect) vectorUnary(operator TokenKind, t *VectorType, operand sourceExpr) sourceExpr {
+	if operator == TokenXor {
+		return d.complement(operand)
+	}
+	return sourceExpr{operator.String() + parenthesize(operand, precPostfix), precUnary}
+}
+
+// swizzle names the components by the xyzw set since the shading languages don't agree on the others
+func (cDialect) swizzle(t *VectorType, base sourceExpr, components string) sourceExpr {
+	return sourceExpr{fmt.Sprintf("%v.%v", parenthesize(base, precPostfix), swizzleComponents(components)), precPostfix}
+}
+
+// input reads the parameter declared by inputParam
+// stageReturn is never called for the languages where the entry points return their results like any other function
+func (cDialect) stageReturn(values []sourceExpr) {
+	panic("entry point returns its results")
+}
+
+func (cDialect) input(builtin BuiltinFunc) sourceExpr {
+	return sourceExpr{builtinName(builtin), precPostfix}
+}
+
+// builtinName returns the name of the entry point parameter receiving the builtin input, or of the function
+// implementing the builtin in languages without it
+// funcCall calls the function with the arguments
+func funcCall(function string, args []sourceExpr) sourceExpr {
+	texts := make([]string, len(args))
+	for i, arg := range args {
+		texts[i] = arg.text
+	}
+	return sourceExpr{fmt.Sprintf("%v(%v)", function, strings.Join(texts, ", ")), precPostfix}
+}
+
+func builtinName(builtin BuiltinFunc) string {
+	return "sabre_" + builtin.String()
+}
+
+// samplerName returns the name of the sampler passed next to the texture in languages keeping them apart, textures
+// are only referred to by name since they can't be stored
+func samplerName(texture string) string {
+	if strings.HasPrefix(texture, "sabre_") {
+		return texture + "_sampler"
+	}
+	return fmt.Sprintf("sabre_%v_sampler", texture)
+}
+
+// textureWithSampler returns the texture argument followed by its sampler
+func textureWithSampler(texture sourceExpr) string {
+	return fmt.Sprintf("%v, %v", texture, samplerName(texture.text))
+}
+
+// blockName returns the name of the struct or the block wrapping the buffer of the given name
+func blockName(buffer string) string {
+	if strings.HasPrefix(buffer, "sabre_") {
+		return buffer + "_block"
+	}
+	return fmt.Sprintf("sabre_%v_block", buffer)
+}
+
+// resourceName returns the name of the resource taken by the entry point, unnamed resources are named by their
+// binding
+func resourceName(binding int, name string) string {
+	if name == "" {
+		return fmt.Sprintf("sabre_resource%v", binding)
+	}
+	return name
+}
+
+// workgroupSize returns the number of invocations in each workgroup of the compute entry point along each axis, it's
+// declared by the entry point directive and defaults to a single invocation
+func workgroupSize(entry *FuncSymbol) [3]uint32 {
+	return entry.WorkgroupSize
+}
+
+// vectorComponents are the names of the components of the vectors in the generated source
+const vectorComponents = "xyzw"
+
+// swizzleComponents returns the components named by the rgba or stqp swizzle sets by their xyzw names
+func swizzleComponents(components string) string {
+	var normalized strings.Builder
+	for _, c := range components {
+		for _, set := range []string{"xyzw", "rgba", "stqp"} {
+			if i := strings.IndexRune(set, c); i >= 0 {
+				normalized.WriteByte(vectorComponents[i])
+			}
+		}
+	}
+	return normalized.String()
+}
+
+var builtinVectorTypes = []*VectorType{
+	BuiltinF32x2Type, BuiltinF32x3Type, BuiltinF32x4Type,
+	BuiltinF64x2Type, BuiltinF64x3Type, BuiltinF64x4Type,
+	BuiltinI32x2Type, BuiltinI32x3Type, BuiltinI32x4Type,
+	BuiltinU32x2Type, BuiltinU32x3Type, BuiltinU32x4Type,
+	BuiltinB32x2Type, BuiltinB32x3Type, BuiltinB32x4Type,
+}
+
+// vectorElementType returns the type of the components of the vector, bool vectors are declared with float32 as
+// their underlying type since it has their size
+func vectorElementType(t *VectorType) Type {
+	switch t {
+	case BuiltinB32x2Type, BuiltinB32x3Type, BuiltinB32x4Type:
+		return BuiltinBoolType
+	default:
+		return t.UnderlyingType
+	}
+}
+
+// builtinVectorType returns the vector type with the given element type and width
+func builtinVectorType(element Type, width int) *VectorType {
+	element = element.Resolve(true)
+	for _, t := range builtinVectorTypes {
+		if vectorElementType(t) == element && t.Width == width {
+			return t
+		}
+	}
+	panic(fmt.Sprintf("no vector of %v elements of type '%v'", width, element))
+}
+
+// vectorOperands splats the scalar operand of a vector operation to a vector as wide as the other operand, and
+// returns the vector types of both operands
+func vectorOperands(lhsType, rhsType Type, lhs, rhs sourceExpr, splat func(t *VectorType, scalar sourceExpr) sourceExpr) (*VectorType, *VectorType, sourceExpr, sourceExpr) {
+	lhsVector, lhsIsVector := lhsType.Resolve(true).(*VectorType)
+	rhsVector, rhsIsVector := rhsType.Resolve(true).(*VectorType)
+	switch {
+	case !lhsIsVector:
+		lhsVector = builtinVectorType(lhsType, rhsVector.Width)
+		lhs = splat(lhsVector, lhs)
+	case !rhsIsVector:
+		rhsVector = builtinVectorType(rhsType, lhsVector.Width)
+		rhs = splat(rhsVector, rhs)
+	}
+	return lhsVector, rhsVector, lhs, rhs
+}
+
+// sourceEmitter emits source code of a C like language from the checked AST, functions and types are emitted on
+// demand so they're declared before their users
+type sourceEmitter struct {
+	instanceContext
+	dialect sourceDialect
+	// declarations in the order they're printed
+	decls []string
+	funcs map[sourceInstanceKey]string
+	// names of the struct types by the hash key of the struct, named types with the same struct share the first name
+	// so that converting between them is free
+	structNames map[string]string
+	structs     map[string]bool
+	// names of the constant arrays by the text of their value
+	constants     map[string]string
+	constantNames map[string]bool
+	// functions passing the struct of the results of a call as the arguments of another function, by their names
+	spreads map[string]bool
+	// names of the workgroup variables declared so far
+	workgroups map[*VarSymbol]string
+	function   *sourceFunction
+}
+
+// sourceFunction is the state of the function being emitted, functions are emitted on demand while emitting their
+// callers
+type sourceFunction struct {
+	sym          *FuncSymbol
+	body         strings.Builder
+	indent       int
+	loops        []*sourceLoop
+	usesLoopJump bool
+	temporaries  int
+	// types the language doesn't support which were already reported in the function
+	unsupportedTypes map[Type]bool
+	// resources of the entry point by the symbols of their parameters
+	resources map[Symbol]sourceExpr
+	// pointer parameters which point into the buffers of the entry point, in languages where bufferPointers is set
+	buffers map[Symbol]bool
+	// stageResult is the type the entry point returns its outputs in, in languages where it's not its result
+	stageResult string
+}
+
+// sourceInstanceKey identifies the function instance, functions are also specialized for the parameters receiving
+// pointers into buffers in languages where bufferPointers is set
+type sourceInstanceKey struct {
+	instanceKey
+	buffers string
+}
+
+// sourceLoop is a loop or a switch being emitted, breaks leave the innermost of them and continues go to the innermost
+// loop
+type sourceLoop struct {
+	// label of the loop, empty if it's not labeled
+	label    string
+	isSwitch bool
+	// labeled branches to outer loops which leave through this loop, they're taken again after it ends
+	exits []loopExit
+}
+
+// sourceLoopJump is the variable used by labeled branches to outer loops, it works like the one of the SPIR-V
+// emitter since these languages have no labeled break or continue
+const sourceLoopJump = "sabre_loop_jump"
+
+func newSourceEmitter(u *Unit, dialect sourceDialect) *sourceEmitter {
+	return &sourceEmitter{
+		instanceContext: instanceContext{unit: u},
+		dialect:         dialect,
+		funcs:           make(map[sourceInstanceKey]string),
+		structNames:     make(map[string]string),
+		structs:         make(map[string]bool),
+		constants:       make(map[string]string),
+		constantNames:   make(map[string]bool),
+		spreads:         make(map[string]bool),
+		workgroups:      make(map[*VarSymbol]string),
+	}
+}
+
+func (g *sourceEmitter) error(e Error) {
+	file := e.SourceRange.File
+	if file == nil {
+		file = g.unit.rootFile
+	}
+	if e.Code == "" {
+		e.Code = DiagnosticUnsupportedTarget
+	}
+	file.error(e)
+}
+
+// unsupportedType reports the type as not supported by the language, it's reported once by each function using it
+func (g *sourceEmitter) unsupportedType(language string, t Type) {
+	if g.function.unsupportedTypes[t] {
+		return
+	}
+	g.function.unsupportedTypes[t] = true
+	funcDecl := g.function.sym.Decl().(*FuncDecl)
+	g.error(NewError(funcDecl.Name.SourceRange(), "%v has no '%v' type, it's used by function '%v'", language, t, g.function.sym.Name()))
+}
+
+// emitFuncs emits the given entry points and the functions they call, or the root functions of the unit and the
+// functions they call if there are no entry points
+func (g *sourceEmitter) emitFuncs(entries []*FuncSymbol) {
+	g.nameStructs()
+	if len(entries) > 0 {
+		for _, entry := range entries {
+			g.funcName(entry, nil, nil, nil)
+		}
+		return
+	}
+
+	for _, f := range g.unit.rootFuncs() {
+		g.funcName(f, nil, nil, nil)
+	}
+}
+
+// nameStructs names the struct types after the named types declaring them, types of imported packages are prefixed
+// with their package name like functions
+func (g *sourceEmitter) nameStructs() {
+	for _, sym := range g.unit.semanticInfo.ReachableSymbols {
+		typeSym, ok := sym.(*TypeSymbol)
+		if !ok {
+			continue
+		}
+		tav := g.unit.semanticInfo.TypeOf(typeSym.Decl())
+		if tav == nil {
+			continue
+		}
+		alias, ok := tav.Type.(*StrongAliasType)
+		if !ok {
+			continue
+		}
+		structType, ok := alias.UnderlyingType.Resolve(true).(*StructType)
+		if !ok {
+			continue
+		}
+		if _, ok := g.structNames[structType.HashKey()]; !ok {
+			g.structNames[structType.HashKey()] = g.dialect.identifier(g.qualifiedName(typeSym))
+		}
+	}
+}
+
+// workgroupName returns the name of the workgroup variable, it's declared the first time it's used
+func (g *sourceEmitter) workgroupName(v *VarSymbol) string {
+	if name, ok := g.workgroups[v]; ok {
+		return name
+	}
+	name := g.dialect.identifier(g.qualifiedName(v))
+	g.workgroups[v] = name
+	if decl := g.dialect.workgroupDeclaration(v, g.typeOf(v).Type, name); decl != "" {
+		g.decls = append(g.decls, decl+"\n")
+	}
+	return name
+}
+
+// qualifiedName returns the name of the package level symbol, symbols of imported packages are prefixed with their
+// package name
+func (g *sourceEmitter) qualifiedName(sym Symbol) string {
+	if pkg := sym.SourceRange().File.pkg; pkg != g.unit.rootPackage {
+		return fmt.Sprintf("%v_%v", pkg.Name, sym.Name())
+	}
+	return sym.Name()
+}
+
+// funcName returns the name of the function instance, the function is emitted first if it wasn't already. buffers
+// tells which parameters receive pointers into buffers, instances taking them are suffixed with their indexes
+func (g *sourceEmitter) funcName(sym *FuncSymbol, typeArgs []Type, funcArgs []boundFunc, buffers []bool) string {
+	key := sourceInstanceKey{instanceKey: newInstanceKey(sym, typeArgs, funcArgs)}
+	var suffix strings.Builder
+	for i, buffer := range buffers {
+		if buffer {
+			fmt.Fprintf(&suffix, "_buffer%v", i)
+		}
+	}
+	key.buffers = suffix.String()
+	if name, ok := g.funcs[key]; ok {
+		return name
+	}
+
+	defer g.enterInstance(sym, typeArgs, funcArgs)()
+	name := g.dialect.identifier(g.funcNameOf(sym) + key.buffers)
+	g.funcs[key] = name
+	g.emitFunc(sym, name, buffers)
+	return name
+}
+
+func (g *sourceEmitter) emitFunc(sym *FuncSymbol, name string, buffers []bool) {
+	prevFunction := g.function
+	g.function = &sourceFunction{
+		sym:              sym,
+		indent:           1,
+		unsupportedTypes: make(map[Type]bool),
+		resources:        make(map[Symbol]sourceExpr),
+		buffers:          make(map[Symbol]bool),
+	}
+	defer func() { g.function = prevFunction }()
+
+	funcDecl := sym.Decl().(*FuncDecl)
+	funcType := g.typeOf(sym).Type.(*FuncType)
+	paramSymbols := g.paramSymbolsOf(sym)
+	receivers := len(paramSymbols) - len(funcType.ParameterTypes)
+	var params []string
+	var inputs []stageVar
+	var inputSymbols []Symbol
+	for i, paramSym := range paramSymbols {
+		var paramType Type
+		if i < receivers {
+			// pointer receivers are passed as pointers, so we use the type of the receiver field not the named type
+			paramType = g.typeOf(funcDecl.Receiver.Fields[0].Type).Type
+		} else if paramType = funcType.ParameterTypes[i-receivers]; isFunc(paramType) {
+			// function values are bound at compile time
+			continue
+		}
+		paramName := ""
+		if paramSym != nil {
+			paramName = g.dialect.identifier(paramSym.Name())
+		}
+		if sym.IsEntryPoint() && sym.Stage != ShaderStageCompute && isStageValue(paramType) {
+			input := stageVar{name: paramName, t: paramType, sym: paramSym, location: len(inputs)}
+			if paramSym == nil || paramSym.Name() == "_" {
+				input = stageVar{name: fmt.Sprintf("sabre_input%v", input.location), t: paramType, location: len(inputs)}
+			}
+			input.flat = sym.Stage == ShaderStageFragment && !isInterpolated(paramType)
+			inputs = append(inputs, input)
+			inputSymbols = append(inputSymbols, paramSym)
+			continue
+		}
+		if sym.IsEntryPoint() {
+			// the other parameters of entry points are buffers, textures and uniforms
+			resourceName := paramName
+			if paramSym != nil && paramSym.Name() == "_" {
+				resourceName = ""
+			}
+			// uniforms can't be written, the entry point assigning one works on a copy of it
+			copied := isUniform(paramType) && resourceName != "" && funcDecl.Body != nil &&
+				assignsVariable(g.unit.semanticInfo, funcDecl.Body.Stmts, paramSym)
+			if copied {
+				resourceName = "sabre_param_" + resourceName
+			}
+			resource := g.dialect.resource(i, paramType, resourceName)
+			if resource.decl != "" {
+				g.decls = append(g.decls, resource.decl)
+			}
+			if resource.param != "" {
+				params = append(params, resource.param)
+			}
+			if copied {
+				g.line("%v;", g.initializedVar(paramSym.(*VarSymbol), resource.ref))
+			} else if paramSym != nil {
+				g.function.resources[paramSym] = resource.ref
+				g.function.buffers[paramSym] = g.dialect.bufferPointers() && isPointer(paramType)
+			}
+			continue
+		}
+		buffer := i < len(buffers) && buffers[i]
+		if paramSym != nil {
+			g.function.buffers[paramSym] = buffer
+		}
+		params = append(params, g.dialect.paramDeclaration(paramSym, paramType, paramName, buffer))
+	}
+	if len(inputs) > 0 {
+		stage := g.dialect.stageInputs(sym, inputs)
+		if stage.decl != "" {
+			g.decls = append(g.decls, stage.decl)
+		}
+		params = append(params, stage.params...)
+		for i, ref := range stage.refs {
+			if inputSymbols[i] != nil {
+				g.function.resources[inputSymbols[i]] = ref
+			}
+		}
+	}
+	for _, input := range g.unit.semanticInfo.Inputs[sym] {
+		if param := g.dialect.inputParam(input, builtinName(input)); param != "" {
+			params = append(params, param)
+		}
+	}
+	if sym.IsEntryPoint() && len(funcType.ReturnTypes) > 0 {
+		stage := g.dialect.stageOutputs(sym, stageOutputsOf(sym, funcType))
+		if stage.decl != "" {
+			g.decls = append(g.decls, stage.decl)
+		}
+		g.function.stageResult = stage.result
+	}
+
+	result := g.resultOf(funcType)
+
+	signature := g.dialect.signature(sym, name, params, result)
+	if funcDecl.Body == nil {
+		g.decls = append(g.decls, signature+";\n")
+		return
+	}
+
+	g.emitStmts(funcDecl.Body.Stmts)
+	g.dialect.funcEnd()
+
+	var decl strings.Builder
+	fmt.Fprintf(&decl, "%v {\n", signature)
+	if g.function.usesLoopJump {
+		fmt.Fprintf(&decl, "\t%v = %v;\n", g.dialect.variableDeclaration(BuiltinUintType, sourceLoopJump), g.uintValue(0))
+	}
+	decl.WriteString(g.function.body.String())
+	decl.WriteString("}\n")
+	g.decls = append(g.decls, decl.String())
+}
+
+// stageOutputsOf returns the outputs of the vertex or fragment entry point, which are its results. the first result
+// of vertex entry points is the position of the vertex and the rest are passed to the fragment entry point
+func stageOutputsOf(entry *FuncSymbol, funcType *FuncType) []stageVar {
+	outputs := make([]stageVar, len(funcType.ReturnTypes))
+	for i, t := range funcType.ReturnTypes {
+		outputs[i] = stageVar{name: fmt.Sprintf("sabre_output%v", i), t: t, location: i}
+		switch {
+		case entry.Stage == ShaderStageVertex && i == 0:
+			outputs[i] = stageVar{name: "sabre_position", t: t, position: true}
+		case entry.Stage == ShaderStageVertex:
+			outputs[i] = stageVar{name: fmt.Sprintf("sabre_output%v", i-1), t: t, location: i - 1, flat: !isInterpolated(t)}
+		}
+	}
+	return outputs
+}
+
+// resultOf returns the result of the function type, functions with multiple results return a tuple in languages with
+// multiple results and a struct of them in the others. it's nil for functions without results
+func (g *sourceEmitter) resultOf(funcType *FuncType) Type {
+	switch len(funcType.ReturnTypes) {
+	case 0:
+		return nil
+	case 1:
+		return funcType.ReturnTypes[0]
+	}
+	tuple := g.unit.semanticInfo.TypeInterner.InternTupleType(funcType.ReturnTypes).(*TupleType)
+	if g.dialect.multipleResults() {
+		return tuple
+	}
+	return g.resultsStruct(tuple)
+}
+
+// resultsStruct returns the struct returned by functions with multiple results in languages without them, its fields
+// are the results in order
+func (g *sourceEmitter) resultsStruct(t *TupleType) *StructType {
+	names := make([]string, len(t.Types))
+	fields := make([]StructTypeField, len(t.Types))
+	for i, result := range t.Types {
+		names[i] = fmt.Sprintf("result%v", i)
+		fields[i] = StructTypeField{
+			Identifer: &IdentifierExpr{Token: Token{kind: TokenIdentifier, value: names[i]}},
+			Type:      result,
+		}
+	}
+	structType := g.unit.semanticInfo.TypeInterner.InternStructType(names, fields).(*StructType)
+	if _, ok := g.structNames[structType.HashKey()]; !ok {
+		g.structNames[structType.HashKey()] = fmt.Sprintf("sabre_results%v", len(g.structNames))
+	}
+	return structType
+}
+
+func (g *sourceEmitter) typeName(t Type) string {
+	switch t := t.(type) {
+	case *VoidType:
+		return "void"
+	case *BoolType, *IntType, *UintType, *Float32Type, *Float64Type:
+		return g.dialect.scalarTypeName(t)
+	case *ArrayType:
+		return g.dialect.arrayTypeName(t)
+	case *VectorType:
+		return g.dialect.vectorTypeName(t)
+	case *StructType:
+		return g.structName(t)
+	case *StrongAliasType:
+		return g.typeName(t.UnderlyingType)
+	case *WeakAliasType:
+		return g.typeName(t.UnderlyingType)
+	case *UntypedType:
+		return g.typeName(t.Default())
+	case *TypeParamType:
+		typeArg, ok := g.typeArgs[t]
+		if !ok {
+			panic(fmt.Sprintf("type parameter '%v' has no type argument", t))
+		}
+		return g.typeName(typeArg)
+	case *TextureType:
+		return g.dialect.textureTypeName(t)
+	case *TupleType:
+		return g.structName(g.resultsStruct(t))
+	case *PointerType:
+		panic("pointers are only supported as function parameters")
+	default:
+		panic("unexpected type")
+	}
+}
+
+// structName returns the name of the struct type, the struct is declared first if it wasn't already
+func (g *sourceEmitter) structName(t *StructType) string {
+	key := t.HashKey()
+	name, ok := g.structNames[key]
+	if !ok {
+		name = fmt.Sprintf("Struct%v", len(g.structNames))
+		g.structNames[key] = name
+	}
+	if g.structs[key] {
+		return name
+	}
+	g.structs[key] = true
+
+	fields := make([]string, len(t.Fields))
+	for i, field := range t.Fields {
+		fields[i] = g.dialect.fieldDeclaration(field.Type, g.dialect.identifier(field.Name()))
+	}
+	g.decls = append(g.decls, g.dialect.structDeclaration(name, fields))
+	return name
+}
+
+// constantName returns the name of the constant array, it's declared first if it wasn't already, constant arrays
+// are named after the constant they come from and equal values share a single declaration
+func (g *sourceEmitter) constantName(expr Expr, tav *TypeAndValue) string {
+	value := g.constantValue(tav)
+	key := g.typeName(tav.Type) + value.text
+	if name, ok := g.constants[key]; ok {
+		return name
+	}
+
+	name := fmt.Sprintf("sabre_const%v", len(g.constants))
+	if sym := g.constantSymbolOf(expr); sym != nil && !g.constantNames[g.qualifiedName(sym)] {
+		g.constantNames[g.qualifiedName(sym)] = true
+		name = g.dialect.identifier(g.qualifiedName(sym))
+	}
+	g.constants[key] = name
+	g.decls = append(g.decls, g.dialect.constantDeclaration(tav.Type, name, value)+"\n")
+	return name
+}
+
+// constantSymbolOf returns the constant the expression refers to, or nil if it's not a named constant
+func (g *sourceEmitter) constantSymbolOf(expr Expr) *ConstSymbol {
+	switch e := expr.(type) {
+	case *IdentifierExpr:
+		sym, _ := g.unit.semanticInfo.SymbolOfIdentifier(e).(*ConstSymbol)
+		return sym
+	case *SelectorExpr:
+		if g.unit.semanticInfo.SelectionOf(e) == nil {
+			return g.constantSymbolOf(e.Selector)
+		}
+	case *ParenExpr:
+		return g.constantSymbolOf(e.Base)
+	}
+	return nil
+}
+
+func (g *sourceEmitter) line(format string, args ...any) {
+	body := &g.function.body
+	for range g.function.indent {
+		body.WriteByte('\t')
+	}
+	fmt.Fprintf(body, format, args...)
+	body.WriteByte('\n')
+}
+
+func (g *sourceEmitter) newTemporary() string {
+	name := fmt.Sprintf("sabre_tmp%v", g.function.temporaries)
+	g.function.temporaries++
+	return name
+}
+
+func (g *sourceEmitter) emitStmts(stmts []Stmt) {
+	for _, stmt := range stmts {
+		g.emitStatement(stmt)
+	}
+}
+
+func (g *sourceEmitter) emitBlock(block *BlockStmt) {
+	g.function.indent++
+	g.emitStmts(block.Stmts)
+	g.function.indent--
+}
+
+func (g *sourceEmitter) emitStatement(stmt Stmt) {
+	switch s := stmt.(type) {
+	case *ReturnStmt:
+		switch {
+		case g.function.stageResult != "":
+			g.dialect.stageReturn(g.returnValues(s))
+		case len(s.Exprs) == 0:
+			g.line("%v;", g.dialect.emptyReturn())
+		case len(s.Exprs) == 1:
+			// calls returning multiple results return the same tuple or struct as the function
+			g.line("return %v;", g.expr(s.Exprs[0]))
+		default:
+			values := make([]string, len(s.Exprs))
+			for i, e := range s.Exprs {
+				values[i] = g.expr(e).text
+			}
+			g.emitResultsReturn(values)
+		}
+	case *DeclStmt:
+		g.emitDeclStmt(s)
+	case *BlockStmt:
+		g.line("{")
+		g.emitBlock(s)
+		g.line("}")
+	case *AssignStmt:
+		g.emitAssignStmt(s)
+	case *IfStmt:
+		g.emitIfStmt(s)
+	case *ForStmt:
+		g.emitForStmt(s, "")
+	case *SwitchStmt:
+		g.emitSwitchStmt(s, "")
+	case *LabeledStmt:
+		switch labeled := s.Stmt.(type) {
+		case *ForStmt:
+			g.emitForStmt(labeled, s.Label.Value())
+		case *SwitchStmt:
+			g.emitSwitchStmt(labeled, s.Label.Value())
+		default:
+			g.emitStatement(s.Stmt)
+		}
+	case *BreakStmt:
+		g.emitLoopJump(loopExit{target: g.loopIndexOf(s.Label, false)})
+	case *ContinueStmt:
+		g.emitLoopJump(loopExit{target: g.loopIndexOf(s.Label, true), isContinue: true})
+	case *DiscardStmt:
+		g.line("%v;", g.dialect.discardStmt())
+		if g.dialect.discardDemotes() {
+			g.emitDiscardReturn()
+		}
+	case *EmptyStmt:
+		// nothing to emit
+	default:
+		text, ok := g.simpleStmt(stmt)
+		if !ok {
+			panic("unsupported statement")
+		}
+		g.line("%v;", text)
+	}
+}
+
+// simpleStmt returns the statement as a single statement without the semicolon, which can also be used in the
+// header of a for loop, it fails for statements which need more than one statement
+func (g *sourceEmitter) simpleStmt(stmt Stmt) (string, bool) {
+	switch s := stmt.(type) {
+	case *ExprStmt:
+		return g.expr(s.Expr).text, true
+	case *IncDecStmt:
+		return g.dialect.incDec(g.typeOf(s.Expr).Type, g.operand(s.Expr, precPostfix), s.Operator), true
+	case *AssignStmt:
+		if len(s.LHS) != 1 {
+			return "", false
+		}
+		lhs, rhs := s.LHS[0], s.RHS[0]
+		switch s.Operator.Kind() {
+		case TokenColonAssign:
+			if isBlankIdentifier(lhs) {
+				return g.dialect.discardedValue(g.expr(rhs)), true
+			}
+			return g.varDeclaration(g.unit.semanticInfo.SymbolOfIdentifier(lhs.(*IdentifierExpr)).(*VarSymbol), rhs), true
+		case TokenAssign:
+			// values assigned to the blank identifier are only evaluated
+			if isBlankIdentifier(lhs) {
+				return g.dialect.discardedValue(g.expr(rhs)), true
+			}
+			return fmt.Sprintf("%v = %v", g.expr(lhs), g.expr(rhs)), true
+		}
+
+		if _, ok := g.vectorTypeOf(lhs); ok {
+			// vectors are assigned the result of the operation since the languages might have no vector operators
+			operator := binaryOpOfAssign(s.Operator.Kind())
+			operation := g.dialect.vectorBinary(operator, g.typeOf(lhs).Type, g.typeOf(rhs).Type, g.expr(lhs), g.expr(rhs))
+			return fmt.Sprintf("%v = %v", g.expr(lhs), operation), true
+		}
+		switch s.Operator.Kind() {
+		case TokenAndNotAssign:
+			operator, operand := g.dialect.andNot(g.expr(rhs))
+			return fmt.Sprintf("%v %v= %v", g.expr(lhs), operator, operand), true
+		case TokenShlAssign, TokenShrAssign:
+			return fmt.Sprintf("%v %v %v", g.expr(lhs), s.Operator.Value(), g.shiftAmountOf(rhs)), true
+		default:
+			return fmt.Sprintf("%v %v %v", g.expr(lhs), s.Operator.Value(), g.expr(rhs)), true
+		}
+	default:
+		return "", false
+	}
+}
+
+// varDeclaration declares the variable, variables without an initializer are zero initialized
+func (g *sourceEmitter) varDeclaration(sym *VarSymbol, initExpr Expr) string {
+	t := g.typeOf(sym).Type
+	var init sourceExpr
+	switch initTAV := sym.InitTypeAndValue; {
+	case initTAV != nil && initTAV.Mode == AddressModeConstant && initTAV.Elements == nil:
+		// untyped initializers take the type of the variable
+		init = g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: t, Value: initTAV.Value})
+	case initExpr != nil:
+		init = g.expr(initExpr)
+	default:
+		init = g.dialect.zeroValue(t)
+	}
+	return g.initializedVar(sym, init)
+}
+
+// initializedVar declares the variable with the given initial value
+func (g *sourceEmitter) initializedVar(sym *VarSymbol, init sourceExpr) string {
+	return fmt.Sprintf("%v = %v", g.dialect.variableDeclaration(g.typeOf(sym).Type, g.dialect.identifier(sym.Name())), init)
+}
+
+func (g *sourceEmitter) emitDeclStmt(s *DeclStmt) {
+	d := s.Decl.(*GenericDecl)
+	switch d.DeclToken.Kind() {
+	case TokenVar:
+		for _, spec := range d.Specs {
+			v := spec.(*ValueSpec)
+			if len(v.RHS) == 1 && len(v.LHS) > 1 {
+				// the variables are initialized from the results of a call
+				names := make([]Expr, len(v.LHS))
+				for i, name := range v.LHS {
+					names[i] = name
+				}
+				for i, value := range g.results(v.RHS[0], names) {
+					if !isBlankIdentifier(v.LHS[i]) {
+						g.line("%v;", g.initializedVar(g.unit.semanticInfo.SymbolOfIdentifier(v.LHS[i]).(*VarSymbol), value))
+					}
+				}
+				continue
+			}
+			for i, name := range v.LHS {
+				var initExpr Expr
+				if i < len(v.RHS) {
+					initExpr = v.RHS[i]
+				}
+				if isBlankIdentifier(name) {
+					if initExpr != nil {
+						g.line("%v;", g.dialect.discardedValue(g.expr(initExpr)))
+					}
+					continue
+				}
+				g.line("%v;", g.varDeclaration(g.unit.semanticInfo.SymbolOfIdentifier(name).(*VarSymbol), initExpr))
+			}
+		}
+	case TokenConst:
+		// constants are emitted inline where they're used
+	default:
+		panic("unsupported declaration in DeclStmt")
+	}
+}
+
+func (g *sourceEmitter) emitAssignStmt(s *AssignStmt) {
+	if text, ok := g.simpleStmt(s); ok {
+		g.line("%v;", text)
+		return
+	}
+
+	if len(s.RHS) == 1 {
+		// the results of the call are held by temporaries, so they're all evaluated before any of them is assigned
+		for i, value := range g.results(s.RHS[0], s.LHS) {
+			switch lhs := s.LHS[i]; {
+			case isBlankIdentifier(lhs):
+			case s.Operator.Kind() == TokenColonAssign:
+				g.line("%v;", g.initializedVar(g.unit.semanticInfo.SymbolOfIdentifier(lhs.(*IdentifierExpr)).(*VarSymbol), value))
+			default:
+				g.line("%v = %v;", g.expr(lhs), value)
+			}
+		}
+		return
+	}
+
+	switch s.Operator.Kind() {
+	case TokenColonAssign:
+		for i, lhs := range s.LHS {
+			g.emitStatement(&AssignStmt{LHS: []Expr{lhs}, Operator: s.Operator, RHS: []Expr{s.RHS[i]}})
+		}
+	case TokenAssign:
+		// all the values are evaluated before any of them is assigned
+		temporaries := make([]string, len(s.RHS))
+		for i, rhs := range s.RHS {
+			if isBlankIdentifier(s.LHS[i]) {
+				g.line("%v;", g.dialect.discardedValue(g.expr(rhs)))
+				continue
+			}
+			temporaries[i] = g.newTemporary()
+			g.line("%v = %v;", g.dialect.variableDeclaration(g.typeOf(s.LHS[i]).Type, temporaries[i]), g.expr(rhs))
+		}
+		for i, lhs := range s.LHS {
+			if temporaries[i] != "" {
+				g.line("%v = %v;", g.expr(lhs), temporaries[i])
+			}
+		}
+	default:
+		panic("unsupported assignment operator")
+	}
+}
+
+func (g *sourceEmitter) emitIfStmt(s *IfStmt) {
+	if s.Init != nil {
+		// the init statement is scoped to the if statement
+		g.line("{")
+		g.function.indent++
+		defer func() {
+			g.function.indent--
+			g.line("}")
+		}()
+		g.emitStatement(s.Init)
+	}
+
+	g.line("if (%v) {", g.expr(s.Cond))
+	g.emitBlock(s.Body)
+	for s.Else != nil {
+		if elseIf, ok := s.Else.(*IfStmt); ok && elseIf.Init == nil {
+			g.line("} else if (%v) {", g.expr(elseIf.Cond))
+			g.emitBlock(elseIf.Body)
+			s = elseIf
+			continue
+		}
+
+		g.line("} else {")
+		g.function.indent++
+		if block, ok := s.Else.(*BlockStmt); ok {
+			g.emitStmts(block.Stmts)
+		} else {
+			g.emitStatement(s.Else)
+		}
+		g.function.indent--
+		break
+	}
+	g.line("}")
+}
+
+func (g *sourceEmitter) emitForStmt(s *ForStmt, label string) {
+	var init, cond, post string
+	if s.Init != nil {
+		ok := false
+		if g.dialect.declaresInLoopHeader() || !isVarDeclaration(s.Init) {
+			init, ok = g.simpleStmt(s.Init)
+		}
+		if !ok {
+			// init statements which don't fit in the loop header are scoped by a block around the loop
+			g.line("{")
+			g.function.indent++
+			defer func() {
+				g.function.indent--
+				g.line("}")
+			}()
+			g.emitStatement(s.Init)
+		}
+	}
+	if s.Post != nil {
+		var ok bool
+		if post, ok = g.simpleStmt(s.Post); !ok {
+			g.emitLoweredForStmt(s, label, init)
+			return
+		}
+	}
+	if s.Cond != nil {
+		cond = g.expr(s.Cond).text
+	}
+
+	g.line("%v {", g.dialect.loopHeader(init, cond, post))
+	loop := g.enterLoop(label, false)
+	g.emitBlock(s.Body)
+	g.line("}")
+	g.leaveLoop(loop)
+}
+
+// emitLoweredForStmt emits a loop whose post statement doesn't fit in the loop header, the post statement runs at the
+// start of every iteration but the first one followed by the condition, so continues still run it
+func (g *sourceEmitter) emitLoweredForStmt(s *ForStmt, label, init string) {
+	started := g.newTemporary()
+	g.line("%v = %v;", g.dialect.variableDeclaration(BuiltinBoolType, started), g.dialect.zeroValue(BuiltinBoolType))
+	g.line("%v {", g.dialect.loopHeader(init, "", ""))
+	loop := g.enterLoop(label, false)
+	g.function.indent++
+	g.line("if (%v) {", started)
+	g.emitBlock(&BlockStmt{Stmts: []Stmt{s.Post}})
+	g.line("}")
+	g.line("%v = %v;", started, g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: BuiltinBoolType, Value: constant.MakeBool(true)}))
+	if s.Cond != nil {
+		g.line("if (!%v) {", g.operand(s.Cond, precPostfix))
+		g.function.indent++
+		g.line("break;")
+		g.function.indent--
+		g.line("}")
+	}
+	g.emitStmts(s.Body.Stmts)
+	g.function.indent--
+	g.line("}")
+	g.leaveLoop(loop)
+}
+
+// enterLoop pushes the loop or the switch being emitted, breaks and continues inside of it find their target by
+// their index in the loop stack
+func (g *sourceEmitter) enterLoop(label string, isSwitch bool) *sourceLoop {
+	loop := &sourceLoop{label: label, isSwitch: isSwitch}
+	g.function.loops = append(g.function.loops, loop)
+	return loop
+}
+
+// leaveLoop pops the loop or the switch after its end was emitted, the exits to outer loops which left through it are
+// taken again
+func (g *sourceEmitter) leaveLoop(loop *sourceLoop) {
+	g.function.loops = g.function.loops[:len(g.function.loops)-1]
+	for _, exit := range loop.exits {
+		g.line("if (%v == %v) {", sourceLoopJump, g.uintValue(exit.code()))
+		g.function.indent++
+		if g.reaches(exit) {
+			// the exit reached its target, so the loops it left through shouldn't take it again
+			g.line("%v = %v;", sourceLoopJump, g.uintValue(0))
+		}
+		g.emitLoopBranch(exit)
+		g.function.indent--
+		g.line("}")
+	}
+}
+
+// emitSwitchStmt emits the switch as a switch of the language if it switches on an integer and its case values are
+// constant, other switches become a chain of if statements comparing the tag with the case values. cases falling
+// through are followed by the bodies of the cases they fall into since not all the languages fall through
+func (g *sourceEmitter) emitSwitchStmt(s *SwitchStmt, label string) {
+	if s.Init != nil {
+		// the init statement is scoped to the switch statement
+		g.line("{")
+		g.function.indent++
+		defer func() {
+			g.function.indent--
+			g.line("}")
+		}()
+		g.emitStatement(s.Init)
+	}
+
+	cases := make([]*SwitchCaseStmt, len(s.Body.Stmts))
+	for i, stmt := range s.Body.Stmts {
+		cases[i] = stmt.(*SwitchCaseStmt)
+	}
+	if g.isNativeSwitch(s, cases) {
+		g.emitNativeSwitch(s, label, cases)
+	} else {
+		g.emitSwitchChain(s, label, cases)
+	}
+}
+
+// isNativeSwitch returns whether the switch can be emitted as a switch of the language, which only switch on integers
+// and take constant case values
+func (g *sourceEmitter) isNativeSwitch(s *SwitchStmt, cases []*SwitchCaseStmt) bool {
+	if s.Tag == nil {
+		return false
+	}
+	switch g.typeOf(s.Tag).Type.Resolve(true).(type) {
+	case *IntType, *UintType:
+	default:
+		return false
+	}
+	for _, c := range cases {
+		for _, value := range c.LHS {
+			if g.typeOf(value).Mode != AddressModeConstant {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (g *sourceEmitter) emitNativeSwitch(s *SwitchStmt, label string, cases []*SwitchCaseStmt) {
+	g.line("switch (%v) {", g.expr(s.Tag))
+	loop := g.enterLoop(label, true)
+	hasDefault := false
+	for i, c := range cases {
+		values := make([]string, len(c.LHS))
+		for j, value := range c.LHS {
+			values[j] = g.expr(value).text
+		}
+		hasDefault = hasDefault || len(values) == 0
+		g.line("%v", g.dialect.caseClause(values))
+		g.function.indent++
+		if stmts := g.emitCaseBody(cases, i); g.dialect.casesFallThrough() && !isTerminating(stmts) {
+			g.line("break;")
+		}
+		g.function.indent--
+		g.emitCaseEnd()
+	}
+	if !hasDefault && g.dialect.requiresDefaultCase() {
+		g.line("%v", g.dialect.caseClause(nil))
+		g.emitCaseEnd()
+	}
+	g.line("}")
+	g.leaveLoop(loop)
+}
+
+// emitSwitchChain emits the switch as a chain of if statements, the tag is evaluated once before the case values. the
+// chain is wrapped in a switch with only a default case when breaks leave the switch
+func (g *sourceEmitter) emitSwitchChain(s *SwitchStmt, label string, cases []*SwitchCaseStmt) {
+	var tag sourceExpr
+	if s.Tag != nil {
+		tav := g.typeOf(s.Tag)
+		tag = g.expr(s.Tag)
+		if tav.Mode != AddressModeConstant {
+			name := g.newTemporary()
+			g.line("%v = %v;", g.dialect.variableDeclaration(tav.Type, name), tag)
+			tag = sourceExpr{name, precPostfix}
+		}
+	}
+
+	var loop *sourceLoop
+	if slices.ContainsFunc(cases, func(c *SwitchCaseStmt) bool { return breaksSwitch(c.RHS, label, false) }) {
+		g.line("switch (0) {")
+		g.line("%v", g.dialect.caseClause(nil))
+		g.function.indent++
+		loop = g.enterLoop(label, true)
+	}
+
+	defaultCase := -1
+	chained := false
+	for i, c := range cases {
+		if len(c.LHS) == 0 {
+			defaultCase = i
+			continue
+		}
+		var cond sourceExpr
+		for j, value := range c.LHS {
+			match := g.expr(value)
+			if s.Tag != nil {
+				match = g.infix(TokenEQ, tag, match)
+			}
+			if j == 0 {
+				cond = match
+			} else {
+				cond = g.infix(TokenLOr, cond, match)
+			}
+		}
+		if chained {
+			g.line("} else if (%v) {", cond)
+		} else {
+			g.line("if (%v) {", cond)
+		}
+		chained = true
+		g.function.indent++
+		g.emitCaseBody(cases, i)
+		g.function.indent--
+	}
+	if defaultCase >= 0 {
+		if chained {
+			g.line("} else {")
+		} else {
+			g.line("{")
+		}
+		g.function.indent++
+		g.emitCaseBody(cases, defaultCase)
+		g.function.indent--
+	}
+	if chained || defaultCase >= 0 {
+		g.line("}")
+	}
+
+	if loop != nil {
+		g.function.indent--
+		g.emitCaseEnd()
+		g.line("}")
+		g.leaveLoop(loop)
+	}
+}
+
+// emitCaseBody emits the statements of the case, cases ending with a fallthrough are followed by the statements of the
+// cases they fall into, each case in its own block so their declarations don't clash. it returns the statements of
+// the last case emitted
+func (g *sourceEmitter) emitCaseBody(cases []*SwitchCaseStmt, i int) []Stmt {
+	if !fallsThrough(cases[i].RHS) {
+		g.emitStmts(cases[i].RHS)
+		return cases[i].RHS
+	}
+	for ; ; i++ {
+		stmts := cases[i].RHS
+		last := !fallsThrough(stmts)
+		if !last {
+			stmts = stmts[:len(stmts)-1]
+		}
+		g.line("{")
+		g.emitBlock(&BlockStmt{Stmts: stmts})
+		g.line("}")
+		if last {
+			return stmts
+		}
+	}
+}
+
+func (g *sourceEmitter) emitCaseEnd() {
+	if end := g.dialect.caseEnd(); end != "" {
+		g.line("%v", end)
+	}
+}
+
+// fallsThrough returns whether the statements of a case end with a fallthrough
+func fallsThrough(stmts []Stmt) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+	_, ok := stmts[len(stmts)-1].(*FallthroughStmt)
+	return ok
+}
+
+// isTerminating returns whether the statements end with a branch, which needs no break to leave the case after it
+func isTerminating(stmts []Stmt) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+	switch stmts[len(stmts)-1].(type) {
+	case *ReturnStmt, *BreakStmt, *ContinueStmt:
+		return true
+	default:
+		return false
+	}
+}
+
+// breaksSwitch returns whether the statements break out of the switch with the given label, unlabeled breaks in
+// nested loops and switches leave those instead
+func breaksSwitch(stmts []Stmt, label string, nested bool) bool {
+	for _, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *BreakStmt:
+			if s.IsLabeled() && s.Label.Value() == label || !s.IsLabeled() && !nested {
+				return true
+			}
+		case *BlockStmt:
+			if breaksSwitch(s.Stmts, label, nested) {
+				return true
+			}
+		case *IfStmt:
+			if breaksSwitch([]Stmt{s.Body, s.Else}, label, nested) {
+				return true
+			}
+		case *LabeledStmt:
+			if breaksSwitch([]Stmt{s.Stmt}, label, nested) {
+				return true
+			}
+		case *ForStmt:
+			if breaksSwitch(s.Body.Stmts, label, true) {
+				return true
+			}
+		case *SwitchStmt:
+			if breaksSwitch(s.Body.Stmts, label, true) {
+				return true
+			}
+		case *SwitchCaseStmt:
+			if breaksSwitch(s.RHS, label, nested) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isVarDeclaration returns whether the statement declares variables
+func isVarDeclaration(stmt Stmt) bool {
+	assign, ok := stmt.(*AssignStmt)
+	return ok && assign.Operator.Kind() == TokenColonAssign
+}
+
+// loopIndexOf returns the index of the loop with the given label, or the innermost loop if there's no label. breaks
+// without a label leave the innermost switch too, while continues skip switches
+func (g *sourceEmitter) loopIndexOf(label Token, isContinue bool) int {
+	loops := g.function.loops
+	for i := len(loops) - 1; i >= 0; i-- {
+		if label.Kind() == TokenIdentifier && loops[i].label == label.Value() ||
+			label.Kind() != TokenIdentifier && !(isContinue && loops[i].isSwitch) {
+			return i
+		}
+	}
+	panic(fmt.Sprintf("loop with label '%v' not found", label.Value()))
+}
+
+// reaches returns whether the break or continue branches to the target of the exit from the innermost loop, continues
+// branch out of the switches they're in
+func (g *sourceEmitter) reaches(exit loopExit) bool {
+	loops := g.function.loops
+	if exit.target == len(loops)-1 {
+		return true
+	}
+	return exit.isContinue && !slices.ContainsFunc(loops[exit.target+1:], func(loop *sourceLoop) bool { return !loop.isSwitch })
+}
+
+// returnValues returns the values returned by the return statement, the results of a call returning multiple results
+// are held by temporaries
+func (g *sourceEmitter) returnValues(s *ReturnStmt) []sourceExpr {
+	if len(s.Exprs) == 1 {
+		if _, ok := g.typeOf(s.Exprs[0]).Type.(*TupleType); ok {
+			return g.results(s.Exprs[0], nil)
+		}
+	}
+	values := make([]sourceExpr, len(s.Exprs))
+	for i, e := range s.Exprs {
+		values[i] = g.expr(e)
+	}
+	return values
+}
+
+// emitDiscardReturn returns after a demoting discard, functions with results return their zero value
+func (g *sourceEmitter) emitDiscardReturn() {
+	funcType := g.typeOf(g.function.sym).Type.(*FuncType)
+	if g.function.stageResult != "" {
+		values := make([]sourceExpr, len(funcType.ReturnTypes))
+		for i, t := range funcType.ReturnTypes {
+			values[i] = g.dialect.zeroValue(t)
+		}
+		g.dialect.stageReturn(values)
+		return
+	}
+	switch result := g.resultOf(funcType).(type) {
+	case nil:
+		g.line("%v;", g.dialect.emptyReturn())
+	case *TupleType:
+		values := make([]string, len(result.Types))
+		for i, t := range result.Types {
+			values[i] = g.dialect.zeroValue(t).text
+		}
+		g.emitResultsReturn(values)
+	default:
+		g.line("return %v;", g.dialect.zeroValue(result))
+	}
+}
+
+// emitResultsReturn returns the values from a function with multiple results, they're put in the struct of the results
+// in languages without multiple results
+func (g *sourceEmitter) emitResultsReturn(values []string) {
+	switch result := g.resultOf(g.typeOf(g.function.sym).Type.(*FuncType)).(type) {
+	case *TupleType:
+		g.line("return %v;", strings.Join(values, ", "))
+	default:
+		g.line("return %v;", g.dialect.compositeLiteral(result, values))
+	}
+}
+
+// results evaluates the call returning multiple results and returns its results, which are held by temporaries. the
+// results assigned to the given blank identifiers are dropped
+func (g *sourceEmitter) results(call Expr, lhs []Expr) []sourceExpr {
+	tuple := g.typeOf(call).Type.(*TupleType)
+	values := make([]sourceExpr, len(tuple.Types))
+	if g.dialect.multipleResults() {
+		names := make([]string, len(tuple.Types))
+		operator := "="
+		for i := range names {
+			names[i] = "_"
+			if i < len(lhs) && isBlankIdentifier(lhs[i]) {
+				continue
+			}
+			names[i] = g.newTemporary()
+			operator = ":="
+			values[i] = sourceExpr{names[i], precPostfix}
+		}
+		g.line("%v %v %v;", strings.Join(names, ", "), operator, g.expr(call))
+		return values
+	}
+
+	name := g.newTemporary()
+	structType := g.resultsStruct(tuple)
+	g.line("%v = %v;", g.dialect.variableDeclaration(structType, name), g.expr(call))
+	for i, field := range structType.Fields {
+		values[i] = sourceExpr{fmt.Sprintf("%v.%v", name, g.dialect.identifier(field.Name())), precPostfix}
+	}
+	return values
+}
+
+// emitLoopJump emits a break or continue, exits to outer loops are stored in the loop jump variable first
+func (g *sourceEmitter) emitLoopJump(exit loopExit) {
+	if !g.reaches(exit) {
+		g.function.usesLoopJump = true
+		g.line("%v = %v;", sourceLoopJump, g.uintValue(exit.code()))
+	}
+	g.emitLoopBranch(exit)
+}
+
+// emitLoopBranch emits a branch towards the target of the given exit, exits to outer loops leave the innermost
+// loop first and are taken again after it
+func (g *sourceEmitter) emitLoopBranch(exit loopExit) {
+	loops := g.function.loops
+	loop := loops[len(loops)-1]
+	switch {
+	case !g.reaches(exit):
+		if !slices.Contains(loop.exits, exit) {
+			loop.exits = append(loop.exits, exit)
+		}
+		g.line("break;")
+	case exit.isContinue:
+		g.line("continue;")
+	default:
+		g.line("break;")
+	}
+}
+
+// operand returns the expression parenthesized if its operator binds looser than the given precedence
+func (g *sourceEmitter) operand(e Expr, prec int) string {
+	return parenthesize(g.expr(e), prec)
+}
+
+// parenthesize returns the expression parenthesized if its operator binds looser than the given precedence
+func parenthesize(e sourceExpr, prec int) string {
+	if e.prec < prec {
+		return "(" + e.text + ")"
+	}
+	return e.text
+}
+
+func (g *sourceEmitter) expr(expr Expr) sourceExpr {
+	// constant expressions are folded by the checker, constant arrays are declared once and referred to by name
+	if tav := g.typeOf(expr); tav != nil && tav.Mode == AddressModeConstant {
+		if tav.Elements != nil {
+			return sourceExpr{g.constantName(expr, tav), precPostfix}
+		}
+		return g.constantValue(tav)
+	}
+
+	switch e := expr.(type) {
+	case *IdentifierExpr:
+		return g.identifierExpr(e)
+	case *UnaryExpr:
+		return g.unaryExpr(e)
+	case *BinaryExpr:
+		return g.binaryExpr(e)
+	case *CallExpr:
+		return g.callExpr(e)
+	case *ParenExpr:
+		// parentheses are added back where the precedence needs them
+		return g.expr(e.Base)
+	case *SelectorExpr:
+		return g.selectorExpr(e)
+	case *IndexExpr:
+		return sourceExpr{fmt.Sprintf("%v[%v]", parenthesize(g.value(e.Base), precPostfix), g.expr(e.Index)), precPostfix}
+	case *ComplitExpr:
+		return g.complitExpr(e)
+	default:
+		panic("unsupported expression")
+	}
+}
+
+func (g *sourceEmitter) identifierExpr(e *IdentifierExpr) sourceExpr {
+	switch symbol := g.unit.semanticInfo.SymbolOfIdentifier(e).(type) {
+	case *VarSymbol:
+		if resource, ok := g.function.resources[symbol]; ok {
+			return resource
+		}
+		if symbol.Workgroup {
+			return sourceExpr{g.workgroupName(symbol), precPostfix}
+		}
+		return sourceExpr{g.dialect.identifier(symbol.Name()), precPostfix}
+	case nil:
+		panic(fmt.Sprintf("unable to find symbol for identifier: %v", e.Token.Value()))
+	default:
+		panic(fmt.Sprintf("unsupported identifier: %v", e.Token.Value()))
+	}
+}
+
+func (g *sourceEmitter) constantValue(tav *TypeAndValue) sourceExpr {
+	// negative numbers bind like the unary minus
+	number := func(text string) sourceExpr {
+		if strings.HasPrefix(text, "-") {
+			return sourceExpr{text, precUnary}
+		}
+		return sourceExpr{text, precPostfix}
+	}
+
+	t := tav.Type.Resolve(true)
+	if untyped, ok := t.(*UntypedType); ok {
+		t = untyped.Default()
+	}
+	switch t.(type) {
+	case *BoolType:
+		return sourceExpr{strconv.FormatBool(constant.BoolVal(tav.Value)), precPostfix}
+	case *IntType:
+		value, _ := constant.Int64Val(constant.ToInt(tav.Value))
+		if value == math.MinInt32 {
+			// the literal of the magnitude overflows int
+			return sourceExpr{"(-2147483647 - 1)", precPostfix}
+		}
+		return number(strconv.FormatInt(value, 10))
+	case *UintType:
+		value, _ := constant.Uint64Val(constant.ToInt(tav.Value))
+		return number(g.dialect.uintLiteral(value))
+	case *Float32Type:
+		value, _ := constant.Float64Val(constant.ToFloat(tav.Value))
+		return number(g.dialect.floatLiteral(value, 32))
+	case *Float64Type:
+		value, _ := constant.Float64Val(constant.ToFloat(tav.Value))
+		return number(g.dialect.floatLiteral(value, 64))
+	case *ArrayType:
+		elements := make([]string, len(tav.Elements))
+		for i, element := range tav.Elements {
+			elements[i] = g.constantValue(element).text
+		}
+		return g.dialect.compositeValue(tav.Type, elements)
+	default:
+		funcDecl := g.function.sym.Decl().(*FuncDecl)
+		g.error(NewError(funcDecl.Name.SourceRange(), "constants of type '%v' can't be emitted, they're used by function '%v'", tav.Type, g.function.sym.Name()))
+		return sourceExpr{"0", precPostfix}
+	}
+}
+
+func (g *sourceEmitter) uintValue(value int64) sourceExpr {
+	return g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: BuiltinUintType, Value: constant.MakeInt64(value)})
+}
+
+// formatFloat formats the float with the shortest representation which reads back the same value, floats always
+// have a decimal point or an exponent to tell them apart from integers
+func formatFloat(value float64, bitSize int) string {
+	text := strconv.FormatFloat(value, 'g', -1, bitSize)
+	if !strings.ContainsAny(text, ".e") {
+		text += ".0"
+	}
+	return text
+}
+
+func (g *sourceEmitter) selectorExpr(e *SelectorExpr) sourceExpr {
+	if vectorType, ok := g.vectorTypeOf(e.Base); ok {
+		return g.dialect.swizzle(vectorType, g.expr(e.Base), e.Selector.Token.Value())
+	}
+	if selection := g.unit.semanticInfo.SelectionOf(e); selection != nil && selection.Field != nil {
+		res, _ := g.fieldExpr(e.Base, selection.Path)
+		return res
+	}
+	// package qualified identifiers are emitted the same way as plain identifiers
+	if base, ok := e.Base.(*IdentifierExpr); ok {
+		if _, ok := g.unit.semanticInfo.SymbolOfIdentifier(base).(*PackageSymbol); ok {
+			return g.identifierExpr(e.Selector)
+		}
+	}
+	panic("unsupported selector expression")
+}
+
+// fieldExpr returns the field found by following the path of field indexes from the base, promoted fields are reached
+// through the embedded structs and pointers to structs are dereferenced implicitly
+func (g *sourceEmitter) fieldExpr(base Expr, path []int) (sourceExpr, Type) {
+	text := parenthesize(g.value(base), precPostfix)
+	t := g.typeOf(base).Type
+	if pointerType, ok := t.Resolve(false).(*PointerType); ok {
+		t = pointerType.ElementType
+	}
+	for _, index := range path {
+		field := t.Resolve(true).(*StructType).Fields[index]
+		text = fmt.Sprintf("%v.%v", text, g.dialect.identifier(field.Name()))
+		t = field.Type
+	}
+	return sourceExpr{text, precPostfix}, t
+}
+
+// vectorTypeOf returns the vector type of the expression, if it's a vector
+func (g *sourceEmitter) vectorTypeOf(e Expr) (*VectorType, bool) {
+	vectorType, ok := g.typeOf(e).Type.Resolve(true).(*VectorType)
+	return vectorType, ok
+}
+
+// value returns the expression, pointers are dereferenced to the value they point to
+func (g *sourceEmitter) value(e Expr) sourceExpr {
+	if _, ok := g.typeOf(e).Type.Resolve(false).(*PointerType); ok {
+		return g.dialect.dereference(g.expr(e))
+	}
+	return g.expr(e)
+}
+
+func (g *sourceEmitter) complitExpr(e *ComplitExpr) sourceExpr {
+	tav := g.typeOf(e)
+	if vectorType, ok := tav.Type.Resolve(true).(*VectorType); ok {
+		if len(e.Elements) == 0 {
+			return g.dialect.zeroValue(tav.Type)
+		}
+		components := make([]string, len(e.Elements))
+		for i, element := range e.Elements {
+			components[i] = g.expr(element.Value).text
+		}
+		return g.dialect.vectorLiteral(vectorType, components)
+	}
+	structType := tav.Type.Resolve(true).(*StructType)
+
+	fields := make([]string, len(structType.Fields))
+	for i, element := range e.Elements {
+		index := i
+		if element.Name != nil {
+			index = structType.FieldsByName[element.Name.(*IdentifierExpr).Token.Value()]
+		}
+		fields[index] = g.expr(element.Value).text
+	}
+	return g.dialect.compositeLiteral(tav.Type, fields)
+}
+
+func (g *sourceEmitter) unaryExpr(e *UnaryExpr) sourceExpr {
+	if vectorType, ok := g.vectorTypeOf(e.Base); ok && e.Operator.Kind() != TokenAdd {
+		return g.dialect.vectorUnary(e.Operator.Kind(), vectorType, g.expr(e.Base))
+	}
+
+	switch e.Operator.Kind() {
+	case TokenAnd:
+		return g.dialect.addressOf(g.expr(e.Base))
+	case TokenMul:
+		return g.dialect.dereference(g.expr(e.Base))
+	case TokenAdd:
+		return g.expr(e.Base)
+	case TokenSub, TokenNot:
+		// nested unary operators are parenthesized so that '- -x' doesn't turn into a decrement
+		return sourceExpr{e.Operator.Value() + g.operand(e.Base, precPostfix), precUnary}
+	case TokenXor:
+		return g.dialect.complement(g.expr(e.Base))
+	default:
+		panic("unsupported unary operator")
+	}
+}
+
+func binaryPrecedence(kind TokenKind) int {
+	switch kind {
+	case TokenLOr:
+		return precLOr
+	case TokenLAnd:
+		return precLAnd
+	case TokenOr:
+		return precOr
+	case TokenXor:
+		return precXor
+	case TokenAnd, TokenAndNot:
+		return precAnd
+	case TokenEQ, TokenNE:
+		return precEquality
+	case TokenLT, TokenGT, TokenLE, TokenGE:
+		return precRelational
+	case TokenShl, TokenShr:
+		return precShift
+	case TokenAdd, TokenSub:
+		return precAdditive
+	case TokenMul, TokenDiv, TokenMod:
+		return precMultiplicative
+	default:
+		panic("unsupported binary operator")
+	}
+}
+
+func (g *sourceEmitter) binaryExpr(e *BinaryExpr) sourceExpr {
+	_, lhsIsVector := g.vectorTypeOf(e.LHS)
+	_, rhsIsVector := g.vectorTypeOf(e.RHS)
+	if lhsIsVector || rhsIsVector {
+		return g.dialect.vectorBinary(e.Operator.Kind(), g.typeOf(e.LHS).Type, g.typeOf(e.RHS).Type, g.expr(e.LHS), g.expr(e.RHS))
+	}
+
+	lhs := g.expr(e.LHS)
+	switch e.Operator.Kind() {
+	case TokenShl, TokenShr:
+		return g.infix(e.Operator.Kind(), lhs, g.shiftAmountOf(e.RHS))
+	default:
+		return g.infix(e.Operator.Kind(), lhs, g.expr(e.RHS))
+	}
+}
+
+// infix applies the binary operator to the operands, which are parenthesized where the operator binds tighter than
+// them
+func (g *sourceEmitter) infix(operator TokenKind, lhs, rhs sourceExpr) sourceExpr {
+	prec := g.dialect.precedence(operator)
+	lhsPrec, rhsPrec := g.dialect.binaryOperandPrecedence(operator)
+	text := operator.String()
+	if operator == TokenAndNot {
+		text, rhs = g.dialect.andNot(rhs)
+	}
+	return sourceExpr{fmt.Sprintf("%v %v %v", parenthesize(lhs, lhsPrec), text, parenthesize(rhs, rhsPrec)), prec}
+}
+
+func (g *sourceEmitter) shiftAmountOf(amount Expr) sourceExpr {
+	return g.dialect.shiftAmount(g.typeOf(amount), g.expr(amount))
+}
+
+func (g *sourceEmitter) callExpr(e *CallExpr) sourceExpr {
+	if builtin := g.unit.semanticInfo.BuiltinOf(e); builtin != BuiltinFuncNone {
+		if builtin.IsInput() {
+			return g.dialect.input(builtin)
+		}
+		t := g.typeOf(e).Type
+		vectorType, isVector := t.Resolve(true).(*VectorType)
+		args := make([]sourceExpr, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = g.expr(arg)
+			if _, ok := g.typeOf(arg).Type.Resolve(true).(*VectorType); builtin.IsMath() && isVector && !ok {
+				args[i] = g.dialect.splat(vectorType, args[i])
+			}
+		}
+		if t == BuiltinVoidType {
+			t = nil
+		}
+		return g.dialect.builtinCall(builtin, t, args)
+	}
+	if isConversion(g.typeOf(e.Base)) {
+		return g.conversion(e)
+	}
+
+	var callee *FuncSymbol
+	var typeArgs []Type
+	var args []string
+	// whether the arguments of pointer parameters point into buffers, starting with the receiver
+	var buffers []bool
+	argExprs := e.Args
+	if instance := g.unit.semanticInfo.InstanceOf(e); instance != nil {
+		typeArgs = make([]Type, len(instance.TypeArgs))
+		for i, t := range instance.TypeArgs {
+			typeArgs[i] = g.unit.semanticInfo.TypeInterner.Substitute(t, g.typeArgs)
+		}
+		callee = g.calleeOfCallExpr(e)
+	} else if method := g.methodOfCallExpr(e); method != nil {
+		// method calls pass the receiver as the first argument
+		callee = method
+		selector := e.Base.(*SelectorExpr)
+		receiver := selector.Base
+		if g.typeOf(selector.Base).IsType() {
+			// method expressions take the receiver as their first argument
+			receiver = argExprs[0]
+			argExprs = argExprs[1:]
+		}
+		args = append(args, g.receiver(method, receiver, g.unit.semanticInfo.SelectionOf(selector)))
+		buffers = append(buffers, method.HasPointerReceiver() && g.pointsIntoBuffer(receiver))
+	} else {
+		bound := g.funcOfValue(e.Base)
+		callee, typeArgs = bound.sym, bound.typeArgs
+	}
+	if callee == nil {
+		panic("unsupported callee expression")
+	}
+
+	// function values aren't passed, the callee is specialized for them instead
+	var funcArgs []boundFunc
+	for _, argExpr := range argExprs {
+		argType := g.typeOf(argExpr).Type
+		buffers = append(buffers, isPointer(argType) && g.pointsIntoBuffer(argExpr))
+		switch {
+		case isFunc(argType):
+			funcArgs = append(funcArgs, g.funcOfValue(argExpr))
+		case isSampledTexture(argType):
+			args = append(args, g.dialect.textureArg(g.expr(argExpr)))
+		default:
+			args = append(args, g.expr(argExpr).text)
+		}
+	}
+
+	name := g.funcName(callee, typeArgs, funcArgs, buffers)
+	if len(argExprs) == 1 {
+		if tuple, ok := g.typeOf(argExprs[0]).Type.(*TupleType); ok {
+			switch {
+			case !g.dialect.multipleResults():
+				name = g.spreadResults(callee, name, e, tuple, buffers)
+			case len(args) > 1:
+				// results can only be passed as all the arguments, so the receiver is bound by a function literal
+				return g.receiverLiteral(callee, name, e, tuple, args)
+			}
+		}
+	}
+	return sourceExpr{fmt.Sprintf("%v(%v)", name, strings.Join(args, ", ")), precPostfix}
+}
+
+// receiverLiteral calls the method with the results of another call in languages with multiple results, the results
+// are passed to a function literal calling the method on the receiver
+func (g *sourceEmitter) receiverLiteral(callee *FuncSymbol, name string, call *CallExpr, tuple *TupleType, args []string) sourceExpr {
+	params := make([]string, len(tuple.Types))
+	forwarded := []string{args[0]}
+	for i, t := range tuple.Types {
+		param := fmt.Sprintf("result%v", i)
+		params[i] = g.dialect.paramDeclaration(nil, t, param, false)
+		forwarded = append(forwarded, param)
+	}
+	var result Type
+	body := fmt.Sprintf("return %v(%v)", name, strings.Join(forwarded, ", "))
+	if t := g.typeOf(call).Type; t != BuiltinVoidType {
+		result = t
+	} else {
+		body = fmt.Sprintf("%v(%v)", name, strings.Join(forwarded, ", "))
+	}
+	literal := fmt.Sprintf("%v { %v }", g.dialect.signature(callee, "", params, result), body)
+	return sourceExpr{fmt.Sprintf("%v(%v)", literal, strings.Join(args[1:], ", ")), precPostfix}
+}
+
+// spreadResults returns the function calling the function instance of the given name with the results of another
+// call, which arrive in their struct in languages without multiple results. the receiver of a method is passed before
+// the results
+func (g *sourceEmitter) spreadResults(callee *FuncSymbol, name string, call *CallExpr, tuple *TupleType, buffers []bool) string {
+	spreadName := fmt.Sprintf("sabre_spread_%v", name)
+	if g.spreads[spreadName] {
+		return spreadName
+	}
+	g.spreads[spreadName] = true
+
+	var params, args []string
+	if funcDecl := callee.Decl().(*FuncDecl); funcDecl.Receiver != nil {
+		receiverType := g.typeOf(funcDecl.Receiver.Fields[0].Type).Type
+		params = append(params, g.dialect.paramDeclaration(nil, receiverType, "receiver", buffers[0]))
+		args = append(args, "receiver")
+	}
+	structType := g.resultsStruct(tuple)
+	params = append(params, g.dialect.paramDeclaration(nil, structType, "results", false))
+	for _, field := range structType.Fields {
+		args = append(args, "results."+g.dialect.identifier(field.Name()))
+	}
+
+	result := g.typeOf(call).Type
+	body := fmt.Sprintf("\treturn %v(%v);\n", name, strings.Join(args, ", "))
+	switch t := result.(type) {
+	case *VoidType:
+		result = nil
+		body = fmt.Sprintf("\t%v(%v);\n", name, strings.Join(args, ", "))
+	case *TupleType:
+		result = g.resultsStruct(t)
+	}
+	g.decls = append(g.decls, fmt.Sprintf("%v {\n%v}\n", g.dialect.signature(callee, spreadName, params, result), body))
+	return spreadName
+}
+
+// pointsIntoBuffer returns whether the pointer expression points into a buffer of the entry point, pointers are
+// rooted at the variable or the parameter they point into
+func (g *sourceEmitter) pointsIntoBuffer(e Expr) bool {
+	for {
+		switch x := e.(type) {
+		case *ParenExpr:
+			e = x.Base
+		case *UnaryExpr:
+			e = x.Base
+		case *SelectorExpr:
+			e = x.Base
+		case *IndexExpr:
+			e = x.Base
+		case *IdentifierExpr:
+			return g.function.buffers[g.unit.semanticInfo.SymbolOfIdentifier(x)]
+		default:
+			return false
+		}
+	}
+}
+
+// receiver returns the receiver argument of a method call, the address of the receiver is taken for pointer
+// receivers and pointers are dereferenced for value receivers
+func (g *sourceEmitter) receiver(method *FuncSymbol, base Expr, selection *Selection) string {
+	var res sourceExpr
+	var t Type
+	// promoted methods are called on the embedded field which declares them
+	if selection != nil && len(selection.Path) > 0 {
+		res, t = g.fieldExpr(base, selection.Path)
+	} else {
+		res, t = g.expr(base), g.typeOf(base).Type
+	}
+
+	_, isPointer := t.Resolve(false).(*PointerType)
+	switch {
+	case method.HasPointerReceiver() && !isPointer:
+		res = g.dialect.addressOf(res)
+	case !method.HasPointerReceiver() && isPointer:
+		res = g.dialect.dereference(res)
+	}
+	return res.text
+}
+
+func (g *sourceEmitter) conversion(e *CallExpr) sourceExpr {
+	tav := g.typeOf(e)
+	// conversions of constants to type parameters are folded once the type argument is known
+	if arg := g.typeOf(e.Args[0]); arg.Mode == AddressModeConstant {
+		if value, ok := convertConstant(arg.Value, tav.Type); ok {
+			return g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: tav.Type, Value: value})
+		}
+	}
+
+	operand := g.expr(e.Args[0])
+	if g.typeOf(e.Args[0]).Type.Resolve(true).Equal(tav.Type.Resolve(true)) {
+		// named types share the representation of their underlying type
+		return operand
+	}
+	return sourceExpr{fmt.Sprintf("%v(%v)", g.typeName(tav.Type), operand), precPostfix}
+}
+
+// reservedNames returns the set of the given names separated by white space
+func reservedNames(names string) map[string]bool {
+	res := make(map[string]bool)
+	for _, name := range strings.Fields(names) {
+		res[name] = true
+	}
+	return res
+}
@@ -0,0 +1,561 @@
+package compiler
+
+import (
+	"fmt"
+	"go/constant"
+	"slices"
+	"strings"
+)
+
+// WGSLEmitter translates the checked unit to WGSL source for WebGPU, all the entry points are emitted into a single
+// shader module
+type WGSLEmitter struct {
+	*sourceEmitter
+	cDialect
+	// language features the module requires
+	features []string
+	// fields of the struct the entry point being emitted returns its outputs in
+	outputs []string
+}
+
+func NewWGSLEmitter(u *Unit) *WGSLEmitter {
+	g := &WGSLEmitter{}
+	g.sourceEmitter = newSourceEmitter(u, g)
+	return g
+}
+
+func (g *WGSLEmitter) Emit() string {
+	g.emitFuncs(g.unit.semanticInfo.EntryPoints)
+	if g.unit.HasErrors() {
+		return ""
+	}
+
+	var out strings.Builder
+	if len(g.features) > 0 {
+		fmt.Fprintf(&out, "requires %v;\n\n", strings.Join(g.features, ", "))
+	}
+	out.WriteString(strings.Join(g.decls, "\n"))
+	return out.String()
+}
+
+func (g *WGSLEmitter) require(feature string) {
+	if !slices.Contains(g.features, feature) {
+		g.features = append(g.features, feature)
+	}
+}
+
+func (g *WGSLEmitter) identifier(name string) string {
+	if wgslReservedNames[name] || strings.HasPrefix(name, "__") || strings.HasPrefix(name, "sabre_") {
+		return name + "_"
+	}
+	return name
+}
+
+func (g *WGSLEmitter) scalarTypeName(t Type) string {
+	switch t.(type) {
+	case *BoolType:
+		return "bool"
+	case *IntType:
+		return "i32"
+	case *UintType:
+		return "u32"
+	case *Float32Type:
+		return "f32"
+	case *Float64Type:
+		g.unsupportedType("WGSL", t)
+		return "f64"
+	default:
+		panic("unexpected type")
+	}
+}
+
+// vectorTypeName reports f64 vectors as unsupported once for the vector rather than for its element type
+func (g *WGSLEmitter) vectorTypeName(t *VectorType) string {
+	element := vectorElementType(t)
+	if _, ok := element.(*Float64Type); ok {
+		g.unsupportedType("WGSL", t)
+		return fmt.Sprintf("vec%v<f64>", t.Width)
+	}
+	return fmt.Sprintf("vec%v<%v>", t.Width, g.scalarTypeName(element))
+}
+
+// textureTypeName returns the texture of f32 texels, its sampler is passed next to it. images are storage textures
+// of rgba32float texels which are read and written
+func (g *WGSLEmitter) textureTypeName(t *TextureType) string {
+	if t.Storage {
+		g.require("readonly_and_readwrite_storage_textures")
+		return "texture_storage_2d<rgba32float, read_write>"
+	}
+	return "texture_2d<f32>"
+}
+
+func (g *WGSLEmitter) textureArg(texture sourceExpr) string {
+	return textureWithSampler(texture)
+}
+
+// bufferPointers returns true since buffers live in the storage address space
+func (g *WGSLEmitter) bufferPointers() bool { return true }
+
+// vectorBinary splats scalar operands to vectors except for the arithmetic operators, which are the only ones mixing
+// vectors and scalars in WGSL. shifts take a vector of u32 as the shift amount
+func (g *WGSLEmitter) vectorBinary(operator TokenKind, lhsType, rhsType Type, lhs, rhs sourceExpr) sourceExpr {
+	switch operator {
+	case TokenAdd, TokenSub, TokenMul, TokenDiv, TokenMod:
+		return g.infix(operator, lhs, rhs)
+	case TokenShl, TokenShr:
+		switch rhsType.Resolve(true).(type) {
+		case *VectorType, *UintType:
+		default:
+			rhs = sourceExpr{fmt.Sprintf("u32(%v)", rhs), precPostfix}
+			rhsType = BuiltinUintType
+		}
+		lhsVector, rhsVector, lhs, rhs := vectorOperands(lhsType, rhsType, lhs, rhs, g.splat)
+		if _, ok := vectorElementType(rhsVector).(*UintType); !ok {
+			rhs = g.compositeValue(builtinVectorType(BuiltinUintType, lhsVector.Width), []string{rhs.text})
+		}
+		return g.infix(operator, lhs, rhs)
+	default:
+		_, _, lhs, rhs = vectorOperands(lhsType, rhsType, lhs, rhs, g.splat)
+		return g.infix(operator, lhs, rhs)
+	}
+}
+
+// splat returns the vector with all of its components set to the scalar
+func (g *WGSLEmitter) splat(t *VectorType, scalar sourceExpr) sourceExpr {
+	return g.compositeValue(t, []string{scalar.text})
+}
+
+func (g *WGSLEmitter) arrayTypeName(t *ArrayType) string {
+	return fmt.Sprintf("array<%v, %v>", g.typeName(t.ElementType), t.Length)
+}
+
+func (g *WGSLEmitter) variableDeclaration(t Type, name string) string {
+	return fmt.Sprintf("var %v: %v", name, g.typeName(t))
+}
+
+// paramDeclaration declares pointers as pointers to the function address space, or to the storage address space for
+// pointers into buffers which needs the unrestricted pointer parameters feature. textures are followed by their
+// sampler. parameters are immutable in WGSL so the parameters assigned by the function are copied into variables of
+// the same name
+func (g *WGSLEmitter) paramDeclaration(sym Symbol, t Type, name string, buffer bool) string {
+	if name == "" {
+		// parameters must be named
+		name = g.newTemporary()
+	}
+	switch u := t.Resolve(false).(type) {
+	case *PointerType:
+		if buffer {
+			g.require("unrestricted_pointer_parameters")
+			return fmt.Sprintf("%v: ptr<storage, %v, read_write>", name, g.typeName(u.ElementType))
+		}
+		return fmt.Sprintf("%v: ptr<function, %v>", name, g.typeName(u.ElementType))
+	case *TextureType:
+		if u.Storage {
+			return fmt.Sprintf("%v: %v", name, g.typeName(t))
+		}
+		return fmt.Sprintf("%v: %v, %v: sampler", name, g.typeName(t), samplerName(name))
+	}
+
+	body := g.function.sym.Decl().(*FuncDecl).Body
+	if sym == nil || body == nil || !assignsVariable(g.unit.semanticInfo, body.Stmts, sym) {
+		return fmt.Sprintf("%v: %v", name, g.typeName(t))
+	}
+	g.line("var %v: %v = sabre_param_%v;", name, g.typeName(t), name)
+	return fmt.Sprintf("sabre_param_%v: %v", name, g.typeName(t))
+}
+
+func (g *WGSLEmitter) builtinCall(builtin BuiltinFunc, t Type, args []sourceExpr) sourceExpr {
+	if builtin.IsMath() {
+		return funcCall(builtin.String(), args)
+	}
+	switch builtin {
+	case BuiltinFuncDpdx:
+		return sourceExpr{fmt.Sprintf("dpdx(%v)", args[0]), precPostfix}
+	case BuiltinFuncDpdy:
+		return sourceExpr{fmt.Sprintf("dpdy(%v)", args[0]), precPostfix}
+	case BuiltinFuncFwidth:
+		return sourceExpr{fmt.Sprintf("fwidth(%v)", args[0]), precPostfix}
+	case BuiltinFuncTextureSample, BuiltinFuncTextureSampleLevel:
+		// the sampler goes after the texture
+		args = slices.Insert(args, 1, sourceExpr{samplerName(args[0].text), precPostfix})
+		return funcCall(builtin.String(), args)
+	case BuiltinFuncImageLoad:
+		return funcCall("textureLoad", args)
+	case BuiltinFuncImageStore:
+		return funcCall("textureStore", args)
+	case BuiltinFuncWorkgroupBarrier:
+		return sourceExpr{"workgroupBarrier()", precPostfix}
+	default:
+		panic("unexpected builtin function")
+	}
+}
+
+// resource declares buffers as read write storage variables in the bind group 0 at their binding, and textures as
+// variables of the bind group 0 with their sampler in the bind group 1 at the same binding
+func (g *WGSLEmitter) resource(binding int, t Type, name string) sourceResource {
+	name = resourceName(binding, name)
+	switch u := t.Resolve(false).(type) {
+	case *PointerType:
+		return sourceResource{
+			decl: fmt.Sprintf("@group(0) @binding(%v) var<storage, read_write> %v: %v;\n", binding, name, g.typeName(u.ElementType)),
+			ref:  g.addressOf(sourceExpr{name, precPostfix}),
+		}
+	case *TextureType:
+		if u.Storage {
+			decl := fmt.Sprintf("@group(0) @binding(%v) var %v: %v;\n", binding, name, g.typeName(t))
+			return sourceResource{decl: decl, ref: sourceExpr{name, precPostfix}}
+		}
+	default:
+		decl := fmt.Sprintf("@group(0) @binding(%v) var<uniform> %v: %v;\n", binding, name, g.typeName(t))
+		return sourceResource{decl: decl, ref: sourceExpr{name, precPostfix}}
+	}
+	decl := fmt.Sprintf(
+		"@group(0) @binding(%v) var %v: %v;\n@group(1) @binding(%v) var %v: sampler;\n",
+		binding, name, g.typeName(t), binding, samplerName(name),
+	)
+	return sourceResource{decl: decl, ref: sourceExpr{name, precPostfix}}
+}
+
+// inputParam declares the input with its builtin attribute
+func (g *WGSLEmitter) inputParam(builtin BuiltinFunc, name string) string {
+	switch builtin {
+	case BuiltinFuncLocalInvocationIndex:
+		return fmt.Sprintf("@builtin(local_invocation_index) %v: u32", name)
+	case BuiltinFuncFrontFacing:
+		return fmt.Sprintf("@builtin(front_facing) %v: bool", name)
+	default:
+		panic("unexpected builtin input")
+	}
+}
+
+func (g *WGSLEmitter) fieldDeclaration(t Type, name string) string {
+	return fmt.Sprintf("%v: %v,", name, g.typeName(t))
+}
+
+// signature marks the entry points with their stage, compute shaders declare their workgroup size. entry points
+// returning outputs return the struct holding them, and vertex shaders without results return the position WGSL
+// requires them to write
+func (g *WGSLEmitter) signature(sym *FuncSymbol, name string, params []string, result Type) string {
+	var attributes string
+	switch sym.Stage {
+	case ShaderStageNone:
+	case ShaderStageVertex:
+		attributes = "@vertex\n"
+	case ShaderStageFragment:
+		attributes = "@fragment\n"
+	case ShaderStageCompute:
+		size := workgroupSize(sym)
+		attributes = fmt.Sprintf("@compute @workgroup_size(%v, %v, %v)\n", size[0], size[1], size[2])
+	default:
+		panic("unexpected shader stage")
+	}
+
+	signature := fmt.Sprintf("%vfn %v(%v)", attributes, name, strings.Join(params, ", "))
+	switch {
+	case sym.IsEntryPoint() && g.function.stageResult != "":
+		signature += " -> " + g.function.stageResult
+	case sym.Stage == ShaderStageVertex:
+		signature += " -> @builtin(position) vec4<f32>"
+	case result != nil:
+		signature += " -> " + g.typeName(result)
+	}
+	return signature
+}
+
+func (g *WGSLEmitter) emptyReturn() string {
+	if g.function.sym.Stage == ShaderStageVertex && g.function.stageResult == "" {
+		return "return vec4<f32>()"
+	}
+	return "return"
+}
+
+// funcEnd returns the position at the end of vertex shaders without results since a function with a result can't
+// fall off its end
+func (g *WGSLEmitter) funcEnd() {
+	if g.function.sym.Stage != ShaderStageVertex || g.function.stageResult != "" {
+		return
+	}
+	stmts := g.function.sym.Decl().(*FuncDecl).Body.Stmts
+	if len(stmts) > 0 {
+		if _, ok := stmts[len(stmts)-1].(*ReturnStmt); ok {
+			return
+		}
+	}
+	g.line("%v;", g.emptyReturn())
+}
+
+// caseClause lists the values of the case, the bodies of the cases are blocks
+func (g *WGSLEmitter) caseClause(values []string) string {
+	if len(values) == 0 {
+		return "default: {"
+	}
+	return fmt.Sprintf("case %v: {", strings.Join(values, ", "))
+}
+
+func (g *WGSLEmitter) casesFallThrough() bool { return false }
+
+// requiresDefaultCase returns true since WGSL switches must have a default case
+func (g *WGSLEmitter) requiresDefaultCase() bool { return true }
+
+func (g *WGSLEmitter) floatLiteral(value float64, bitSize int) string {
+	if bitSize == 64 {
+		return formatFloat(value, bitSize)
+	}
+	return formatFloat(value, bitSize) + "f"
+}
+
+func (g *WGSLEmitter) compositeValue(t Type, elements []string) sourceExpr {
+	return sourceExpr{fmt.Sprintf("%v(%v)", g.typeName(t), strings.Join(elements, ", ")), precPostfix}
+}
+
+func (g *WGSLEmitter) vectorLiteral(t *VectorType, components []string) sourceExpr {
+	return g.compositeValue(t, components)
+}
+
+func (g *WGSLEmitter) compositeLiteral(t Type, fields []string) sourceExpr {
+	structType := t.Resolve(true).(*StructType)
+	for i, field := range fields {
+		// omitted fields are zero initialized
+		if field == "" {
+			fields[i] = g.zeroValue(structType.Fields[i].Type).text
+		}
+	}
+	return g.compositeValue(t, fields)
+}
+
+func (g *WGSLEmitter) zeroValue(t Type) sourceExpr {
+	switch t.Resolve(true).(type) {
+	case *BoolType:
+		return g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: t, Value: constant.MakeBool(false)})
+	case *ArrayType, *StructType, *VectorType:
+		// constructors without arguments return the zero value
+		return g.compositeValue(t, nil)
+	default:
+		return g.constantValue(&TypeAndValue{Mode: AddressModeConstant, Type: t, Value: constant.MakeInt64(0)})
+	}
+}
+
+func (g *WGSLEmitter) constantDeclaration(t Type, name string, value sourceExpr) string {
+	return fmt.Sprintf("const %v: %v = %v;", name, g.typeName(t), value)
+}
+
+func (g *WGSLEmitter) workgroupDeclaration(v *VarSymbol, t Type, name string) string {
+	return fmt.Sprintf("var<workgroup> %v: %v;", name, g.typeName(t))
+}
+
+func (g *WGSLEmitter) discardStmt() string {
+	return "discard"
+}
+
+// discardDemotes is true since discard in WGSL demotes the invocation to a helper
+func (g *WGSLEmitter) discardDemotes() bool { return true }
+
+// discardedValue assigns the value to the phony assignment, only function calls can be used as statements
+func (g *WGSLEmitter) discardedValue(e sourceExpr) string {
+	return "_ = " + e.text
+}
+
+// incDec increments and decrements floats and vectors by adding one since WGSL only supports it for scalar integers,
+// the abstract one added to vectors converts to their element type
+func (g *WGSLEmitter) incDec(t Type, operand string, operator Token) string {
+	op := "+="
+	if operator.Kind() == TokenDec {
+		op = "-="
+	}
+	switch g.unit.semanticInfo.TypeInterner.Substitute(t, g.typeArgs).Resolve(true).(type) {
+	case *Float32Type, *Float64Type:
+		return fmt.Sprintf("%v %v %v", operand, op, g.floatLiteral(1, 32))
+	case *VectorType:
+		return fmt.Sprintf("%v %v 1", operand, op)
+	default:
+		return operand + operator.Value()
+	}
+}
+
+// addressOf takes the address of the expression, pointers to parts of variables need the unrestricted pointer
+// parameters feature to be passed to functions
+func (g *WGSLEmitter) addressOf(e sourceExpr) sourceExpr {
+	if strings.ContainsAny(e.text, ".[(*") {
+		g.require("unrestricted_pointer_parameters")
+	}
+	return sourceExpr{"&" + parenthesize(e, precPostfix), precUnary}
+}
+
+// dereference drops the address taken of variables, which is how the buffers of the entry points are referred to
+func (g *WGSLEmitter) dereference(e sourceExpr) sourceExpr {
+	if e.prec == precUnary && strings.HasPrefix(e.text, "&") {
+		return sourceExpr{e.text[1:], precPostfix}
+	}
+	return sourceExpr{"*" + parenthesize(e, precPostfix), precUnary}
+}
+
+// binaryOperandPrecedence follows the WGSL grammar which doesn't let bitwise, shift and logical operators be mixed
+// with other operators without parentheses
+func (g *WGSLEmitter) binaryOperandPrecedence(operator TokenKind) (int, int) {
+	switch operator {
+	case TokenShl, TokenShr, TokenAnd, TokenOr, TokenXor, TokenAndNot:
+		return precUnary, precUnary
+	case TokenLAnd, TokenLOr:
+		return precEquality, precEquality
+	case TokenEQ, TokenNE, TokenLT, TokenGT, TokenLE, TokenGE:
+		return precShift, precShift
+	default:
+		prec := binaryPrecedence(operator)
+		return prec, prec + 1
+	}
+}
+
+// shiftAmount converts the shift amount to u32 which is the only type WGSL shifts by, constants are abstract integers
+// which convert on their own
+func (g *WGSLEmitter) shiftAmount(tav *TypeAndValue, amount sourceExpr) sourceExpr {
+	t := g.unit.semanticInfo.TypeInterner.Substitute(tav.Type, g.typeArgs)
+	if _, ok := t.Resolve(true).(*UintType); ok || tav.Mode == AddressModeConstant {
+		return amount
+	}
+	return sourceExpr{fmt.Sprintf("u32(%v)", amount), precPostfix}
+}
+
+// assignsVariable returns whether the statements assign the variable or a part of it, assignments through pointers
+// don't count since they assign the memory the pointer points to
+func assignsVariable(info *SemanticInfo, stmts []Stmt, sym Symbol) bool {
+	assigns := func(e Expr) bool {
+		for {
+			switch n := e.(type) {
+			case *IdentifierExpr:
+				return info.SymbolOfIdentifier(n) == sym
+			case *ParenExpr:
+				e = n.Base
+			case *IndexExpr:
+				e = n.Base
+			case *SelectorExpr:
+				e = n.Base
+			default:
+				return false
+			}
+		}
+	}
+
+	for _, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *AssignStmt:
+			if slices.ContainsFunc(s.LHS, assigns) {
+				return true
+			}
+		case *IncDecStmt:
+			if assigns(s.Expr) {
+				return true
+			}
+		case *BlockStmt:
+			if assignsVariable(info, s.Stmts, sym) {
+				return true
+			}
+		case *LabeledStmt:
+			if assignsVariable(info, []Stmt{s.Stmt}, sym) {
+				return true
+			}
+		case *IfStmt:
+			nested := []Stmt{s.Body}
+			if s.Init != nil {
+				nested = append(nested, s.Init)
+			}
+			if s.Else != nil {
+				nested = append(nested, s.Else)
+			}
+			if assignsVariable(info, nested, sym) {
+				return true
+			}
+		case *ForStmt:
+			nested := []Stmt{s.Body}
+			if s.Init != nil {
+				nested = append(nested, s.Init)
+			}
+			if s.Post != nil {
+				nested = append(nested, s.Post)
+			}
+			if assignsVariable(info, nested, sym) {
+				return true
+			}
+		case *SwitchStmt:
+			nested := []Stmt{s.Body}
+			if s.Init != nil {
+				nested = append(nested, s.Init)
+			}
+			if assignsVariable(info, nested, sym) {
+				return true
+			}
+		case *SwitchCaseStmt:
+			if assignsVariable(info, s.RHS, sym) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var wgslReservedNames = reservedNames(`
+	alias break case const const_assert continue continuing default diagnostic discard else enable false fn for if
+	let loop override requires return struct switch true var while
+	NULL Self abstract active alignas alignof as asm asm_fragment async attribute auto await become binding_array
+	cast catch class co_await co_return co_yield coherent column_major common compile compile_fragment concept
+	const_cast consteval constexpr constinit crate debugger decltype delete demote demote_to_helper do dynamic_cast
+	enum explicit export extends extern external fallthrough filter final finally friend from fxgroup get goto
+	groupshared highp impl implements import inline instanceof interface layout lowp macro macro_rules match mediump
+	meta mod module move mut mutable namespace new nil noexcept noinline nointerpolation noperspective null nullptr
+	of operator package packoffset partition pass patch pixelfragment precise precision premerge priv protected pub
+	public readonly ref regardless register reinterpret_cast require resource restrict self set shared sizeof smooth
+	snorm static static_assert static_cast std subroutine super target template this thread_local throw trait try
+	type typedef typeid typename typeof union unless unorm unsafe unsized use using varying virtual volatile wgsl
+	where with writeonly yield
+	bool f16 f32 f64 i32 u32 vec2 vec3 vec4 mat2x2 mat2x3 mat2x4 mat3x2 mat3x3 mat3x4 mat4x2 mat4x3 mat4x4 array
+	atomic ptr sampler sampler_comparison texture_1d texture_2d texture_2d_array texture_3d texture_cube
+	texture_cube_array texture_multisampled_2d texture_storage_2d texture_depth_2d vec2f vec3f vec4f vec2i vec3i
+	vec4i vec2u vec3u vec4u
+	dpdx dpdy fwidth workgroupBarrier
+`)
+
+// stageInputs passes the inputs to the parameters of the entry point at their locations, the inputs assigned by the
+// entry point are copied into variables like the other parameters
+func (g *WGSLEmitter) stageInputs(entry *FuncSymbol, inputs []stageVar) sourceStage {
+	var stage sourceStage
+	for _, input := range inputs {
+		param := g.paramDeclaration(input.sym, input.t, input.name, false)
+		stage.params = append(stage.params, wgslStageAttributes(input)+param)
+	}
+	return stage
+}
+
+// stageOutputs declares the struct the entry point returns its outputs in, the fields are the position, the values
+// passed to the fragment entry point or the color attachments at their locations. the entry point fills the struct
+// declared at its start when it returns
+func (g *WGSLEmitter) stageOutputs(entry *FuncSymbol, outputs []stageVar) sourceStage {
+	name := fmt.Sprintf("sabre_%v_output", entry.Name())
+	fields := make([]string, len(outputs))
+	g.outputs = make([]string, len(outputs))
+	for i, output := range outputs {
+		fields[i] = wgslStageAttributes(output) + g.fieldDeclaration(output.t, output.name)
+		g.outputs[i] = output.name
+	}
+	g.line("var sabre_output: %v;", name)
+	return sourceStage{decl: g.structDeclaration(name, fields), result: name}
+}
+
+// wgslStageAttributes returns the attributes of the input or the output, integers passed between stages must be
+// declared flat on both sides
+func wgslStageAttributes(v stageVar) string {
+	switch {
+	case v.position:
+		return "@builtin(position) "
+	case v.flat:
+		return fmt.Sprintf("@location(%v) @interpolate(flat) ", v.location)
+	default:
+		return fmt.Sprintf("@location(%v) ", v.location)
+	}
+}
+
+// stageReturn sets the fields of the outputs struct and returns it
+func (g *WGSLEmitter) stageReturn(values []sourceExpr) {
+	for i, value := range values {
+		g.line("sabre_output.%v = %v;", g.outputs[i], value)
+	}
+	g.line("return sabre_output;")
+}
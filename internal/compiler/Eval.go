@@ -0,0 +1,710 @@
+package compiler
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"math"
+)
+
+// maxEvalSteps bounds the statements executed by a single constant evaluation so that endless loops are reported
+// instead of hanging the compiler
+const maxEvalSteps = 1000000
+
+// evaluator runs the bodies of pure functions over constant values, it folds calls with constant arguments in
+// constant declarations. scalars are constant.Value and arrays are []any, arithmetic follows the runtime semantics
+// of the types so integers wrap around and floats are rounded to their precision after each operation
+type evaluator struct {
+	checker *Checker
+	steps   int
+}
+
+// evalError stops the evaluation at the given source range
+type evalError struct {
+	sourceRange SourceRange
+	message     string
+}
+
+type evalFrame struct {
+	locals  map[Symbol]any
+	results []Symbol
+}
+
+type evalFlowKind int
+
+const (
+	evalFlowNormal evalFlowKind = iota
+	evalFlowBreak
+	evalFlowContinue
+	evalFlowReturn
+)
+
+// evalFlow is how control leaves a statement, break and continue carry their label if any
+type evalFlow struct {
+	kind   evalFlowKind
+	label  string
+	values []any
+}
+
+// isConstantType returns whether values of the type can be constants, these are the scalars and arrays of them
+func isConstantType(t Type) bool {
+	switch u := t.Resolve(true).(type) {
+	case *BoolType:
+		return true
+	case *ArrayType:
+		return isConstantType(u.ElementType)
+	default:
+		return isNumericScalar(u)
+	}
+}
+
+// evalConstantCall folds the call of a pure function with constant arguments, it returns nil if the call isn't a
+// candidate for evaluation so it's left as a runtime value
+func (checker *Checker) evalConstantCall(e *CallExpr, callee *FuncSymbol, funcType *FuncType, arguments []*TypeAndValue) *TypeAndValue {
+	funcDecl := callee.Decl().(*FuncDecl)
+	if callee.IsMethod() || funcType.IsGeneric() || funcDecl.Body == nil {
+		return nil
+	}
+	if len(funcType.ReturnTypes) != 1 || !isConstantType(funcType.ReturnTypes[0]) {
+		return nil
+	}
+	for _, a := range arguments {
+		if a.Mode != AddressModeConstant {
+			return nil
+		}
+	}
+
+	ev := &evaluator{checker: checker}
+	results, err := ev.call(e, callee, arguments)
+	if err != nil {
+		checker.error(NewError(e.SourceRange(), "call to '%v' can't be evaluated at compile time", callee.Name()).
+			Note(err.sourceRange, "%v", err.message))
+		return &TypeAndValue{
+			Mode: AddressModeInvalid,
+			Type: funcType.ReturnTypes[0],
+		}
+	}
+	return typeAndValueOf(results[0], funcType.ReturnTypes[0])
+}
+
+// typeAndValueOf converts the evaluated value to a constant of the given type
+func typeAndValueOf(value any, t Type) *TypeAndValue {
+	res := &TypeAndValue{
+		Mode: AddressModeConstant,
+		Type: t,
+	}
+	if elements, ok := value.([]any); ok {
+		elementType := t.Resolve(true).(*ArrayType).ElementType
+		res.Elements = make([]*TypeAndValue, len(elements))
+		for i, element := range elements {
+			res.Elements[i] = typeAndValueOf(element, elementType)
+		}
+	} else {
+		res.Value = value.(constant.Value)
+	}
+	return res
+}
+
+// valueOf converts the constant to an evaluated value
+func valueOf(tav *TypeAndValue) any {
+	if _, ok := tav.Type.Resolve(true).(*ArrayType); ok {
+		elements := make([]any, len(tav.Elements))
+		for i, element := range tav.Elements {
+			elements[i] = valueOf(element)
+		}
+		return elements
+	}
+	return tav.Value
+}
+
+// copyValue copies arrays since they're values, assigning an array doesn't share its elements
+func copyValue(value any) any {
+	elements, ok := value.([]any)
+	if !ok {
+		return value
+	}
+	res := make([]any, len(elements))
+	for i, element := range elements {
+		res[i] = copyValue(element)
+	}
+	return res
+}
+
+func (ev *evaluator) fail(n Node, format string, args ...any) {
+	panic(&evalError{sourceRange: n.SourceRange(), message: fmt.Sprintf(format, args...)})
+}
+
+func (ev *evaluator) typeOf(n any) *TypeAndValue {
+	return ev.checker.unit.semanticInfo.TypeOf(n)
+}
+
+func (ev *evaluator) step(n Node) {
+	ev.steps++
+	if ev.steps > maxEvalSteps {
+		ev.fail(n, "evaluation exceeded the limit of %v steps", maxEvalSteps)
+	}
+}
+
+func (ev *evaluator) call(e *CallExpr, callee *FuncSymbol, arguments []*TypeAndValue) (results []any, err *evalError) {
+	defer func() {
+		if r := recover(); r != nil {
+			var ok bool
+			if err, ok = r.(*evalError); !ok {
+				panic(r)
+			}
+		}
+	}()
+
+	args := make([]any, len(arguments))
+	for i, a := range arguments {
+		args[i] = valueOf(a)
+	}
+	return ev.callFunc(e, callee, args), nil
+}
+
+func (ev *evaluator) callFunc(e *CallExpr, callee *FuncSymbol, args []any) []any {
+	funcDecl := callee.Decl().(*FuncDecl)
+	funcType, ok := ev.typeOf(callee).Type.(*FuncType)
+	if !ok || callee.IsMethod() || funcType.IsGeneric() || funcDecl.Body == nil {
+		ev.fail(e, "'%v' can't be called at compile time", callee.Name())
+	}
+	// bodies are checked after the symbol is resolved, a function can't be evaluated from its own body
+	for _, function := range ev.checker.functionStack {
+		if function == callee {
+			ev.fail(e, "'%v' is called while its body is being checked", callee.Name())
+		}
+	}
+
+	frame := &evalFrame{locals: make(map[Symbol]any)}
+	paramIndex := 0
+	for _, field := range funcDecl.Type.Parameters.Fields {
+		for _, name := range field.Names {
+			if sym := ev.checker.unit.semanticInfo.SymbolOfIdentifier(name); sym != nil {
+				frame.locals[sym] = copyValue(args[paramIndex])
+			}
+			paramIndex++
+		}
+		if len(field.Names) == 0 {
+			paramIndex++
+		}
+	}
+	if funcDecl.Type.Result != nil {
+		resultIndex := 0
+		for _, field := range funcDecl.Type.Result.Fields {
+			for _, name := range field.Names {
+				sym := ev.checker.unit.semanticInfo.SymbolOfIdentifier(name)
+				frame.locals[sym] = ev.zeroValue(name, funcType.ReturnTypes[resultIndex])
+				frame.results = append(frame.results, sym)
+				resultIndex++
+			}
+		}
+	}
+
+	flow := ev.execStmts(frame, funcDecl.Body.Stmts)
+	if flow.kind != evalFlowReturn {
+		ev.fail(funcDecl.Body, "'%v' doesn't return a value", callee.Name())
+	}
+	return flow.values
+}
+
+func (ev *evaluator) zeroValue(n Node, t Type) any {
+	switch u := t.Resolve(true).(type) {
+	case *BoolType:
+		return constant.MakeBool(false)
+	case *ArrayType:
+		elements := make([]any, u.Length)
+		for i := range elements {
+			elements[i] = ev.zeroValue(n, u.ElementType)
+		}
+		return elements
+	default:
+		if !isNumericScalar(u) {
+			ev.fail(n, "values of type '%v' can't be evaluated at compile time", t)
+		}
+		return ev.normalize(n, constant.MakeInt64(0), t)
+	}
+}
+
+// normalize represents the value in the given type the way it would be at runtime
+func (ev *evaluator) normalize(n Node, value constant.Value, t Type) constant.Value {
+	if isUntyped(t) {
+		return value
+	}
+	props := t.Properties()
+	switch {
+	case props.Integral:
+		bits := uint(props.Size * 8)
+		modulus := constant.Shift(constant.MakeInt64(1), token.SHL, bits)
+		mask := constant.BinaryOp(modulus, token.SUB, constant.MakeInt64(1))
+		value = constant.BinaryOp(constant.ToInt(value), token.AND, mask)
+		if props.Signed && constant.Compare(value, token.GEQ, constant.Shift(constant.MakeInt64(1), token.SHL, bits-1)) {
+			value = constant.BinaryOp(value, token.SUB, modulus)
+		}
+		return value
+	case props.Floating:
+		f, _ := constant.Float64Val(constant.ToFloat(value))
+		if props.Size == 4 {
+			f = float64(float32(f))
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			ev.fail(n, "value overflows '%v'", t)
+		}
+		return constant.MakeFloat64(f)
+	default:
+		return value
+	}
+}
+
+func (ev *evaluator) execStmts(frame *evalFrame, stmts []Stmt) evalFlow {
+	for _, stmt := range stmts {
+		if flow := ev.execStmt(frame, stmt, ""); flow.kind != evalFlowNormal {
+			return flow
+		}
+	}
+	return evalFlow{}
+}
+
+func (ev *evaluator) execStmt(frame *evalFrame, stmt Stmt, label string) evalFlow {
+	ev.step(stmt)
+	switch s := stmt.(type) {
+	case *ExprStmt:
+		ev.evalMulti(frame, s.Expr)
+	case *BlockStmt:
+		return ev.execStmts(frame, s.Stmts)
+	case *DeclStmt:
+		ev.execDeclStmt(frame, s)
+	case *AssignStmt:
+		ev.execAssignStmt(frame, s)
+	case *IncDecStmt:
+		op := TokenAdd
+		if s.Operator.Kind() == TokenDec {
+			op = TokenSub
+		}
+		t := ev.typeOf(s.Expr).Type
+		one := ev.normalize(s, constant.MakeInt64(1), t)
+		ev.store(frame, s.Expr, ev.binaryOp(s, op, ev.evalScalar(frame, s.Expr), one, t))
+	case *IfStmt:
+		if s.Init != nil {
+			if flow := ev.execStmt(frame, s.Init, ""); flow.kind != evalFlowNormal {
+				return flow
+			}
+		}
+		if constant.BoolVal(ev.evalScalar(frame, s.Cond)) {
+			return ev.execStmts(frame, s.Body.Stmts)
+		} else if s.Else != nil {
+			return ev.execStmt(frame, s.Else, "")
+		}
+	case *ForStmt:
+		return ev.execForStmt(frame, s, label)
+	case *LabeledStmt:
+		return ev.execStmt(frame, s.Stmt, s.Label.Value())
+	case *BreakStmt:
+		return evalFlow{kind: evalFlowBreak, label: s.Label.Value()}
+	case *ContinueStmt:
+		return evalFlow{kind: evalFlowContinue, label: s.Label.Value()}
+	case *ReturnStmt:
+		return ev.execReturnStmt(frame, s)
+	case *EmptyStmt:
+		// nothing to execute
+	default:
+		ev.fail(stmt, "statement can't be evaluated at compile time")
+	}
+	return evalFlow{}
+}
+
+func (ev *evaluator) execForStmt(frame *evalFrame, s *ForStmt, label string) evalFlow {
+	if s.Init != nil {
+		if flow := ev.execStmt(frame, s.Init, ""); flow.kind != evalFlowNormal {
+			return flow
+		}
+	}
+	// unlabeled branches target the innermost loop, labeled ones target the loop with the same label
+	ownsBranch := func(flow evalFlow) bool {
+		return flow.label == "" || flow.label == label
+	}
+	for {
+		ev.step(s)
+		if s.Cond != nil && !constant.BoolVal(ev.evalScalar(frame, s.Cond)) {
+			break
+		}
+		flow := ev.execStmts(frame, s.Body.Stmts)
+		if flow.kind == evalFlowReturn || (flow.kind != evalFlowNormal && !ownsBranch(flow)) {
+			return flow
+		}
+		if flow.kind == evalFlowBreak {
+			break
+		}
+		if s.Post != nil {
+			ev.execStmt(frame, s.Post, "")
+		}
+	}
+	return evalFlow{}
+}
+
+func (ev *evaluator) execReturnStmt(frame *evalFrame, s *ReturnStmt) evalFlow {
+	flow := evalFlow{kind: evalFlowReturn}
+	if len(s.Exprs) == 0 {
+		for _, sym := range frame.results {
+			flow.values = append(flow.values, copyValue(frame.locals[sym]))
+		}
+		return flow
+	}
+	for _, expr := range s.Exprs {
+		for _, value := range ev.evalMulti(frame, expr) {
+			flow.values = append(flow.values, copyValue(value))
+		}
+	}
+	return flow
+}
+
+func (ev *evaluator) execDeclStmt(frame *evalFrame, s *DeclStmt) {
+	d := s.Decl.(*GenericDecl)
+	// constants are folded where they're used
+	if d.DeclToken.Kind() != TokenVar {
+		return
+	}
+	for _, spec := range d.Specs {
+		v := spec.(*ValueSpec)
+		var values []any
+		for _, expr := range v.RHS {
+			values = append(values, ev.evalMulti(frame, expr)...)
+		}
+		for i, name := range v.LHS {
+			sym := ev.checker.unit.semanticInfo.SymbolOfIdentifier(name)
+			if sym == nil {
+				continue
+			}
+			t := ev.typeOf(sym).Type
+			if i < len(values) {
+				frame.locals[sym] = ev.convert(name, values[i], t)
+			} else {
+				frame.locals[sym] = ev.zeroValue(name, t)
+			}
+		}
+	}
+}
+
+func (ev *evaluator) execAssignStmt(frame *evalFrame, s *AssignStmt) {
+	switch s.Operator.Kind() {
+	case TokenColonAssign, TokenAssign:
+		var values []any
+		for _, expr := range s.RHS {
+			values = append(values, ev.evalMulti(frame, expr)...)
+		}
+		for i, lhs := range s.LHS {
+			if isBlankIdentifier(lhs) {
+				continue
+			}
+			if s.Operator.Kind() == TokenColonAssign {
+				sym := ev.checker.unit.semanticInfo.SymbolOfIdentifier(lhs.(*IdentifierExpr))
+				frame.locals[sym] = ev.convert(lhs, values[i], ev.typeOf(sym).Type)
+			} else {
+				ev.store(frame, lhs, ev.convert(lhs, values[i], ev.typeOf(lhs).Type))
+			}
+		}
+	default:
+		t := ev.typeOf(s.LHS[0]).Type
+		lhs := ev.evalScalar(frame, s.LHS[0])
+		rhs := ev.evalScalar(frame, s.RHS[0])
+		var result constant.Value
+		switch op := binaryOpOfAssign(s.Operator.Kind()); op {
+		case TokenShl, TokenShr:
+			result = ev.shift(s, op, lhs, rhs, t)
+		default:
+			result = ev.binaryOp(s, op, lhs, rhs, t)
+		}
+		ev.store(frame, s.LHS[0], result)
+	}
+}
+
+// binaryOpOfAssign returns the binary operator applied by the compound assignment operator
+func binaryOpOfAssign(op TokenKind) TokenKind {
+	switch op {
+	case TokenAddAssign:
+		return TokenAdd
+	case TokenSubAssign:
+		return TokenSub
+	case TokenMulAssign:
+		return TokenMul
+	case TokenDivAssign:
+		return TokenDiv
+	case TokenModAssign:
+		return TokenMod
+	case TokenAndAssign:
+		return TokenAnd
+	case TokenOrAssign:
+		return TokenOr
+	case TokenXorAssign:
+		return TokenXor
+	case TokenAndNotAssign:
+		return TokenAndNot
+	case TokenShlAssign:
+		return TokenShl
+	case TokenShrAssign:
+		return TokenShr
+	default:
+		panic("unexpected assignment operator")
+	}
+}
+
+// convert gives untyped constants the type of the variable they're assigned to
+func (ev *evaluator) convert(n Node, value any, t Type) any {
+	if scalar, ok := value.(constant.Value); ok {
+		return ev.normalize(n, scalar, t)
+	}
+	return copyValue(value)
+}
+
+// store assigns the value to a local variable or an element of a local array
+func (ev *evaluator) store(frame *evalFrame, lhs Expr, value any) {
+	switch e := lhs.(type) {
+	case *IdentifierExpr:
+		sym := ev.checker.unit.semanticInfo.SymbolOfIdentifier(e)
+		if _, ok := frame.locals[sym]; !ok {
+			ev.fail(e, "assigns package variable '%v'", e.Token.Value())
+		}
+		frame.locals[sym] = value
+	case *IndexExpr:
+		elements := ev.evalAddressable(frame, e.Base).([]any)
+		elements[ev.index(frame, e, len(elements))] = value
+	case *ParenExpr:
+		ev.store(frame, e.Base, value)
+	default:
+		ev.fail(lhs, "assignment can't be evaluated at compile time")
+	}
+}
+
+// evalAddressable returns the value stored in the variable without copying it, elements of arrays are assigned
+// through it
+func (ev *evaluator) evalAddressable(frame *evalFrame, expr Expr) any {
+	switch e := expr.(type) {
+	case *IndexExpr:
+		elements := ev.evalAddressable(frame, e.Base).([]any)
+		return elements[ev.index(frame, e, len(elements))]
+	case *ParenExpr:
+		return ev.evalAddressable(frame, e.Base)
+	default:
+		return ev.eval(frame, expr)
+	}
+}
+
+func (ev *evaluator) index(frame *evalFrame, e *IndexExpr, length int) int {
+	index, ok := constant.Int64Val(constant.ToInt(ev.evalScalar(frame, e.Index)))
+	if !ok || index < 0 || index >= int64(length) {
+		ev.fail(e.Index, "array index '%v' is out of range [0, %v)", ev.evalScalar(frame, e.Index), length)
+	}
+	return int(index)
+}
+
+func (ev *evaluator) evalScalar(frame *evalFrame, expr Expr) constant.Value {
+	value, ok := ev.eval(frame, expr).(constant.Value)
+	if !ok {
+		ev.fail(expr, "expected a scalar value")
+	}
+	return value
+}
+
+// evalMulti evaluates the expression which may be a call returning multiple values
+func (ev *evaluator) evalMulti(frame *evalFrame, expr Expr) []any {
+	if call, ok := expr.(*CallExpr); ok {
+		if tav := ev.typeOf(call); tav == nil || tav.Mode != AddressModeConstant {
+			if !isConversion(ev.typeOf(call.Base)) {
+				return ev.evalCall(frame, call)
+			}
+		}
+	}
+	return []any{ev.eval(frame, expr)}
+}
+
+func (ev *evaluator) eval(frame *evalFrame, expr Expr) any {
+	tav := ev.typeOf(expr)
+	if tav == nil || tav.Mode == AddressModeInvalid {
+		ev.fail(expr, "expression can't be evaluated at compile time")
+	}
+	if tav.Mode == AddressModeConstant {
+		return valueOf(tav)
+	}
+
+	switch e := expr.(type) {
+	case *IdentifierExpr:
+		sym := ev.checker.unit.semanticInfo.SymbolOfIdentifier(e)
+		value, ok := frame.locals[sym]
+		if !ok {
+			ev.fail(e, "reads package variable '%v'", e.Token.Value())
+		}
+		return value
+	case *ParenExpr:
+		return ev.eval(frame, e.Base)
+	case *IndexExpr:
+		elements, ok := ev.eval(frame, e.Base).([]any)
+		if !ok {
+			ev.fail(e.Base, "expected an array value")
+		}
+		return elements[ev.index(frame, e, len(elements))]
+	case *UnaryExpr:
+		return ev.evalUnaryExpr(frame, e)
+	case *BinaryExpr:
+		return ev.evalBinaryExpr(frame, e)
+	case *CallExpr:
+		if isConversion(ev.typeOf(e.Base)) {
+			return ev.evalConversion(frame, e)
+		}
+		results := ev.evalCall(frame, e)
+		if len(results) != 1 {
+			ev.fail(e, "expected a single value")
+		}
+		return results[0]
+	default:
+		ev.fail(expr, "expression can't be evaluated at compile time")
+		return nil
+	}
+}
+
+func (ev *evaluator) evalCall(frame *evalFrame, e *CallExpr) []any {
+	if builtin := ev.checker.unit.semanticInfo.BuiltinOf(e); builtin != BuiltinFuncNone {
+		if !builtin.IsMath() {
+			ev.fail(e, "builtin '%v' can't be called at compile time", builtin)
+		}
+		return []any{ev.evalMathBuiltin(frame, e, builtin)}
+	}
+	callee := ev.checker.calleeOf(e.Base)
+	if callee == nil {
+		ev.fail(e.Base, "function value can't be called at compile time")
+	}
+	var args []any
+	for _, arg := range e.Args {
+		args = append(args, ev.evalMulti(frame, arg)...)
+	}
+	return ev.callFunc(e, callee, args)
+}
+
+// evalMathBuiltin computes the math builtin in float64 and rounds the result to the float32 it returns
+func (ev *evaluator) evalMathBuiltin(frame *evalFrame, e *CallExpr, builtin BuiltinFunc) any {
+	args := make([]float64, len(e.Args))
+	for i, arg := range e.Args {
+		args[i], _ = constant.Float64Val(constant.ToFloat(ev.evalScalar(frame, arg)))
+	}
+
+	var result float64
+	switch builtin {
+	case BuiltinFuncAbs:
+		result = math.Abs(args[0])
+	case BuiltinFuncFloor:
+		result = math.Floor(args[0])
+	case BuiltinFuncCeil:
+		result = math.Ceil(args[0])
+	case BuiltinFuncFract:
+		result = args[0] - math.Floor(args[0])
+	case BuiltinFuncSqrt:
+		result = math.Sqrt(args[0])
+	case BuiltinFuncExp:
+		result = math.Exp(args[0])
+	case BuiltinFuncLog:
+		result = math.Log(args[0])
+	case BuiltinFuncPow:
+		result = math.Pow(args[0], args[1])
+	case BuiltinFuncSin:
+		result = math.Sin(args[0])
+	case BuiltinFuncCos:
+		result = math.Cos(args[0])
+	case BuiltinFuncTan:
+		result = math.Tan(args[0])
+	case BuiltinFuncMin:
+		result = math.Min(args[0], args[1])
+	case BuiltinFuncMax:
+		result = math.Max(args[0], args[1])
+	case BuiltinFuncClamp:
+		result = math.Min(math.Max(args[0], args[1]), args[2])
+	case BuiltinFuncMix:
+		result = args[0] + (args[1]-args[0])*args[2]
+	default:
+		panic("unknown math builtin")
+	}
+	if math.IsNaN(result) || math.IsInf(result, -1) {
+		ev.fail(e, "'%v' is undefined for the given arguments", builtin)
+	}
+	return ev.normalize(e, constant.MakeFloat64(result), ev.typeOf(e).Type)
+}
+
+func (ev *evaluator) evalConversion(frame *evalFrame, e *CallExpr) any {
+	t := ev.typeOf(e).Type
+	value := ev.evalScalar(frame, e.Args[0])
+	props := t.Properties()
+	if props.Integral && value.Kind() == constant.Float {
+		// conversions of floats to integers truncate towards zero
+		f, _ := constant.Float64Val(value)
+		if math.Abs(f) >= math.MaxInt64 {
+			ev.fail(e, "value '%v' overflows '%v'", value, t)
+		}
+		value = constant.MakeInt64(int64(f))
+	}
+	return ev.normalize(e, value, t)
+}
+
+func (ev *evaluator) evalUnaryExpr(frame *evalFrame, e *UnaryExpr) any {
+	t := ev.typeOf(e).Type
+	switch e.Operator.Kind() {
+	case TokenAdd, TokenSub, TokenNot, TokenXor:
+		var precision uint
+		if props := t.Properties(); props.Integral && !props.Signed {
+			precision = uint(props.Size * 8)
+		}
+		value := constant.UnaryOp(convertTokenToConstantToken(e.Operator.Kind()), ev.evalScalar(frame, e.Base), precision)
+		return ev.normalize(e, value, t)
+	default:
+		ev.fail(e, "operator %v can't be evaluated at compile time", e.Operator.Kind())
+		return nil
+	}
+}
+
+func (ev *evaluator) evalBinaryExpr(frame *evalFrame, e *BinaryExpr) any {
+	t := ev.typeOf(e).Type
+	op := e.Operator.Kind()
+	lhs := ev.evalScalar(frame, e.LHS)
+	switch op {
+	case TokenLAnd:
+		if !constant.BoolVal(lhs) {
+			return lhs
+		}
+		return ev.evalScalar(frame, e.RHS)
+	case TokenLOr:
+		if constant.BoolVal(lhs) {
+			return lhs
+		}
+		return ev.evalScalar(frame, e.RHS)
+	}
+
+	rhs := ev.evalScalar(frame, e.RHS)
+	switch op {
+	case TokenLT, TokenGT, TokenLE, TokenGE, TokenEQ, TokenNE:
+		return constant.MakeBool(constant.Compare(lhs, convertTokenToConstantToken(op), rhs))
+	case TokenShl, TokenShr:
+		return ev.shift(e, op, lhs, rhs, t)
+	default:
+		return ev.binaryOp(e, op, lhs, rhs, t)
+	}
+}
+
+func (ev *evaluator) binaryOp(n Node, op TokenKind, lhs, rhs constant.Value, t Type) constant.Value {
+	constantOp := convertTokenToConstantToken(op)
+	if constantOp == token.QUO || constantOp == token.REM {
+		if constant.Sign(rhs) == 0 {
+			ev.fail(n, "division by zero")
+		}
+		// go/constant does exact division unless asked for integer division
+		if constantOp == token.QUO && t.Properties().Integral {
+			constantOp = token.QUO_ASSIGN
+		}
+	}
+	return ev.normalize(n, constant.BinaryOp(lhs, constantOp, rhs), t)
+}
+
+func (ev *evaluator) shift(n Node, op TokenKind, lhs, rhs constant.Value, t Type) constant.Value {
+	count, ok := constant.Uint64Val(constant.ToInt(rhs))
+	if !ok {
+		ev.fail(n, "invalid shift count '%v'", rhs)
+	}
+	// shifting by the width of the type or more shifts all the bits out
+	count = min(count, 64)
+	return ev.normalize(n, constant.Shift(lhs, convertTokenToConstantToken(op), uint(count)), t)
+}
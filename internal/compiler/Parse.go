@@ -2,6 +2,7 @@ package compiler
 
 import (
 	"slices"
+	"strings"
 )
 
 type Parser struct {
@@ -9,6 +10,7 @@ type Parser struct {
 	tokens            []Token
 	currentTokenIndex int
 	exprLevel         int
+	directives        []Token
 }
 
 func NewParser(file *UnitFile) *Parser {
@@ -24,11 +26,19 @@ func NewParser(file *UnitFile) *Parser {
 	// example.
 	// That's why I prefer to keep the current solution which is removing comments for now, and just fix the cases where
 	// we have extra semicolon left after comments
+	// The only comments we keep are //sabre: directives written on their own line, which are attached to the
+	// declaration that follows them.
 	for i := 0; i < len(file.tokens); i++ {
 		t := file.tokens[i]
 		if t.Kind() == TokenComment {
 			// if the comment is on new line
-			if i > 0 && t.SourceRange().BeginPosition.Line > file.tokens[i-1].SourceRange().BeginPosition.Line {
+			onNewLine := i == 0 || t.SourceRange().BeginPosition.Line > file.tokens[i-1].SourceRange().BeginPosition.Line
+			// //sabre:ignore comments suppress warnings instead of being attached to declarations
+			isSuppression := parser.parseSuppression(t, onNewLine)
+			if onNewLine {
+				if !isSuppression && strings.HasPrefix(t.Value(), "//sabre:") {
+					parser.directives = append(parser.directives, t)
+				}
 				// if it has a semicolon after it, then consume the ; as well
 				if i < len(file.tokens) && file.tokens[i+1].Kind() == TokenSemicolon {
 					i++
@@ -44,6 +54,56 @@ func NewParser(file *UnitFile) *Parser {
 	return parser
 }
 
+// error reports a syntax error in the file being parsed
+func (p *Parser) error(e Error) {
+	p.file.error(e.WithCode(DiagnosticSyntaxError))
+}
+
+// parseSuppression records the warnings suppressed by a //sabre:ignore comment, a comment on its own line
+// applies to the next line while a trailing comment applies to its own line
+func (p *Parser) parseSuppression(t Token, onNewLine bool) bool {
+	fields := strings.Fields(strings.TrimPrefix(t.Value(), "//sabre:"))
+	if !strings.HasPrefix(t.Value(), "//sabre:") || len(fields) == 0 || fields[0] != "ignore" {
+		return false
+	}
+
+	line := t.SourceRange().BeginPosition.Line
+	if onNewLine {
+		line++
+	}
+	codes := make([]DiagnosticCode, 0, len(fields)-1)
+	for _, name := range fields[1:] {
+		code, ok := DiagnosticCodeFromName(name)
+		if !ok {
+			p.error(NewError(t.SourceRange(), "unknown warning code '%v'", name))
+			continue
+		}
+		codes = append(codes, code)
+	}
+	if p.file.suppressions == nil {
+		p.file.suppressions = make(map[int32][]DiagnosticCode)
+	}
+	p.file.suppressions[line] = append(p.file.suppressions[line], codes...)
+	return true
+}
+
+// directivesAbove returns the directives written on the lines right above the given token
+func (p *Parser) directivesAbove(t Token) []Token {
+	line := t.SourceRange().BeginPosition.Line
+	last, _ := slices.BinarySearchFunc(p.directives, line, func(d Token, line int32) int {
+		return int(d.SourceRange().BeginPosition.Line - line)
+	})
+	first := last
+	for first > 0 && p.directives[first-1].SourceRange().BeginPosition.Line == line-1 {
+		first--
+		line--
+	}
+	if first == last {
+		return nil
+	}
+	return p.directives[first:last]
+}
+
 func (t Token) valid() bool {
 	return t.kind != TokenEOF && t.kind != TokenInvalid
 }
@@ -122,7 +182,7 @@ func (p *Parser) eatTokenOrError(kind TokenKind) Token {
 	if tkn.Kind() == kind {
 		return tkn
 	}
-	p.file.error(NewError(tkn.SourceRange(), "expected '%v' but found '%v'", kind, tkn.Kind()))
+	p.error(NewError(tkn.SourceRange(), "expected '%v' but found '%v'", kind, tkn.Kind()))
 	return tkn
 }
 
@@ -159,7 +219,7 @@ func (p *Parser) parseBinaryExprWithPrecedenceLevels(levels [][]TokenKind) Expr
 		op := p.eatToken()
 		rhs := p.parseBinaryExprWithPrecedenceLevels(levels[1:])
 		if rhs == nil {
-			p.file.error(NewError(op.SourceRange(), "missing right handside"))
+			p.error(NewError(op.SourceRange(), "missing right handside"))
 			break
 		}
 		expr = &BinaryExpr{
@@ -173,7 +233,7 @@ func (p *Parser) parseBinaryExprWithPrecedenceLevels(levels [][]TokenKind) Expr
 
 func (p *Parser) parseUnaryExpr() Expr {
 	switch p.currentToken().Kind() {
-	case TokenAdd, TokenSub, TokenNot, TokenXor:
+	case TokenAdd, TokenSub, TokenNot, TokenXor, TokenMul, TokenAnd:
 		return &UnaryExpr{
 			Operator: p.eatToken(),
 			Base:     p.parseUnaryExpr(),
@@ -205,7 +265,7 @@ func (p *Parser) parseBaseExpr() Expr {
 			} else {
 				t := p.convertParsedExprToType(expr)
 				if t == nil {
-					p.file.error(NewError(p.currentToken().SourceRange(), "failed to parse type"))
+					p.error(NewError(p.currentToken().SourceRange(), "failed to parse type"))
 					return nil
 				}
 				if complit := p.parseComplitExpr(t); complit != nil {
@@ -238,19 +298,21 @@ func (p *Parser) parseIdentifierExpr() *IdentifierExpr {
 			Token: p.eatToken(),
 		}
 	default:
-		p.file.error(NewError(p.currentToken().SourceRange(), "expected an identifier but found '%v'", p.currentToken()))
+		p.error(NewError(p.currentToken().SourceRange(), "expected an identifier but found '%v'", p.currentToken()))
 	}
 	return nil
 }
 
-func (p *Parser) parseIndexExpr(base Expr) *IndexExpr {
+// parseIndexExpr parses an array index or the type arguments of a generic function, more than one type argument
+// makes an IndexListExpr
+func (p *Parser) parseIndexExpr(base Expr) Expr {
 	lBracket := p.eatTokenOrError(TokenLBracket)
 	if !lBracket.valid() {
 		return nil
 	}
 
-	index := p.ParseExpr()
-	if index == nil {
+	indices := p.parseExprList()
+	if indices == nil {
 		return nil
 	}
 
@@ -259,10 +321,18 @@ func (p *Parser) parseIndexExpr(base Expr) *IndexExpr {
 		return nil
 	}
 
+	if len(indices) > 1 {
+		return &IndexListExpr{
+			Base:     base,
+			LBracket: lBracket,
+			Indices:  indices,
+			RBracket: rBracket,
+		}
+	}
 	return &IndexExpr{
 		Base:     base,
 		LBracket: lBracket,
-		Index:    index,
+		Index:    indices[0],
 		RBracket: rBracket,
 	}
 }
@@ -320,7 +390,7 @@ func (p *Parser) parseAtom() Expr {
 	case TokenFunc:
 		return p.parseFuncType()
 	default:
-		p.file.error(NewError(p.currentToken().SourceRange(), "expected an expression but found '%v'", p.currentToken()))
+		p.error(NewError(p.currentToken().SourceRange(), "expected an expression but found '%v'", p.currentToken()))
 	}
 	return nil
 }
@@ -340,7 +410,7 @@ func (p *Parser) parseLiteralExpr() *LiteralExpr {
 			Token: p.eatToken(),
 		}
 	default:
-		p.file.error(NewError(p.currentToken().SourceRange(), "expected an expression but found '%v'", p.currentToken()))
+		p.error(NewError(p.currentToken().SourceRange(), "expected an expression but found '%v'", p.currentToken()))
 	}
 	return nil
 }
@@ -356,7 +426,7 @@ func (p *Parser) parseParenExpr() *ParenExpr {
 			Rparen: p.eatTokenOrError(TokenRParen),
 		}
 	default:
-		p.file.error(NewError(p.currentToken().SourceRange(), "expected a left parenthesis but found '%v'", p.currentToken()))
+		p.error(NewError(p.currentToken().SourceRange(), "expected a left parenthesis but found '%v'", p.currentToken()))
 	}
 	return nil
 }
@@ -421,6 +491,18 @@ func (p *Parser) parseStructType() *StructTypeExpr {
 // FieldDecl = (IdentifierList Type | EmbeddedField) [ Tag ] ";"
 func (p *Parser) parseFieldDecl() *Field {
 	// Implements: FieldDecl = (IdentifierList Type | EmbeddedField) [ Tag ] ';'
+	// Embedded pointer fields are the only fields starting with '*'
+	if star := p.eatTokenIfKind(TokenMul); star.valid() {
+		typeName := p.parseIdentifierExpr()
+		if typeName == nil {
+			return nil
+		}
+		embeddedType := p.parseEmbeddedField(typeName)
+		tag := p.eatTokenIfKind(TokenLiteralString)
+		p.eatSemicolonOrError()
+		return &Field{Type: &PointerTypeExpr{Star: star, BaseType: embeddedType}, Tag: tag}
+	}
+
 	// Start by parsing the first identifier (common prefix for both alternatives)
 	firstIdent := p.parseIdentifierExpr()
 	if firstIdent == nil {
@@ -454,7 +536,7 @@ func (p *Parser) parseFieldDecl() *Field {
 	return &Field{Names: names, Type: fieldType, Tag: tag}
 }
 
-// EmbeddedField = TypeName ; (subset without pointer / type args).
+// EmbeddedField = [ '*' ] TypeName ; (subset without type args), the '*' is parsed by parseFieldDecl
 func (p *Parser) parseEmbeddedField(first *IdentifierExpr) TypeExpr {
 	return p.parseTypeNameWithFirstId(first)
 }
@@ -483,7 +565,7 @@ func (p *Parser) parseSignature() (parameters *FieldList, result *FieldList) {
 func (p *Parser) parseParameters() *FieldList {
 	openToken := p.eatTokenIfKind(TokenLParen)
 	if !openToken.valid() {
-		p.file.error(NewError(p.currentToken().SourceRange(), "missing parameter list"))
+		p.error(NewError(p.currentToken().SourceRange(), "missing parameter list"))
 		return nil
 	}
 
@@ -501,7 +583,7 @@ func (p *Parser) parseParameters() *FieldList {
 			if len(f.Names) > 0 {
 				named++
 			} else if named > 0 {
-				p.file.error(NewError(f.Type.SourceRange(), "missing parameter name"))
+				p.error(NewError(f.Type.SourceRange(), "missing parameter name"))
 				return nil
 			}
 		}
@@ -519,7 +601,7 @@ func (p *Parser) parseParameters() *FieldList {
 func (p *Parser) parseParameterList() (list []Field) {
 	expr := p.tryParseIdentOrTypeExpr()
 	if expr == nil {
-		p.file.error(NewError(p.currentToken().SourceRange(), "expected an identifier but found '%v'", p.currentToken()))
+		p.error(NewError(p.currentToken().SourceRange(), "expected an identifier but found '%v'", p.currentToken()))
 		return nil
 	}
 
@@ -537,7 +619,7 @@ func (p *Parser) parseParameterListWithFirstExpr(expr Expr) (list []Field) {
 		if e := p.tryParseIdentOrTypeExpr(); e != nil {
 			exprs = append(exprs, e)
 		} else {
-			p.file.error(NewError(p.currentToken().SourceRange(), "expected an identifier or type but found '%v'", p.currentToken()))
+			p.error(NewError(p.currentToken().SourceRange(), "expected an identifier or type but found '%v'", p.currentToken()))
 			return nil
 		}
 	}
@@ -553,7 +635,7 @@ func (p *Parser) parseParameterListWithFirstExpr(expr Expr) (list []Field) {
 			if n, ok := e.(*IdentifierExpr); ok {
 				names = append(names, n)
 			} else {
-				p.file.error(NewError(e.SourceRange(), "missing parameter name"))
+				p.error(NewError(e.SourceRange(), "missing parameter name"))
 				return nil
 			}
 		}
@@ -594,6 +676,8 @@ func (p *Parser) tryParseIdentOrTypeExpr() Expr {
 		return p.parseStructType()
 	case TokenFunc:
 		return p.parseFuncType()
+	case TokenMul:
+		return p.parsePointerType()
 	default:
 		return nil
 	}
@@ -609,10 +693,30 @@ func (p *Parser) parseType() TypeExpr {
 		return p.parseStructType()
 	case TokenFunc:
 		return p.parseFuncType()
+	case TokenMul:
+		return p.parsePointerType()
 	default:
-		p.file.error(NewError(p.currentToken().SourceRange(), "expected type but found %v", p.currentToken()))
+		p.error(NewError(p.currentToken().SourceRange(), "expected type but found %v", p.currentToken()))
+		return nil
+	}
+}
+
+// PointerType = '*' Type
+func (p *Parser) parsePointerType() *PointerTypeExpr {
+	star := p.eatTokenOrError(TokenMul)
+	if !star.valid() {
+		return nil
+	}
+
+	baseType := p.parseType()
+	if baseType == nil {
 		return nil
 	}
+
+	return &PointerTypeExpr{
+		Star:     star,
+		BaseType: baseType,
+	}
 }
 
 // TypeName = identifier | identifier '.' identifier
@@ -657,6 +761,8 @@ func (p *Parser) convertParsedExprToType(e Expr) TypeExpr {
 		return n
 	case *FuncTypeExpr:
 		return n
+	case *PointerTypeExpr:
+		return n
 	}
 	return nil
 }
@@ -717,6 +823,8 @@ func (p *Parser) ParseStmt() Stmt {
 		return p.parseSwitchStmt()
 	case TokenContinue:
 		return p.parseContinueStmt()
+	case TokenDiscard:
+		return p.parseDiscardStmt()
 	case TokenLBrace:
 		stmt := p.parseBlockStmt()
 		p.eatSemicolonOrError()
@@ -727,6 +835,16 @@ func (p *Parser) ParseStmt() Stmt {
 		return p.parseForStmt()
 	case TokenType, TokenConst, TokenVar:
 		return p.parseDeclStmt()
+	case TokenIdentifier:
+		if p.lookahead(1).Kind() == TokenColon {
+			if stmt := p.parseLabeledStmt(); stmt != nil {
+				return stmt
+			}
+			return nil
+		}
+		stmt, _ := p.parseSimpleStmt()
+		p.eatSemicolonOrError()
+		return stmt
 	default:
 		stmt, _ := p.parseSimpleStmt()
 		p.eatSemicolonOrError()
@@ -765,7 +883,7 @@ func (p *Parser) parseSimpleStmt() (stmt Stmt, isRange bool) {
 	}
 
 	if len(exprs) > 1 {
-		p.file.error(NewError(exprs[0].SourceRange().Merge(exprs[len(exprs)-1].SourceRange()), "Expected 1 expression but found %v", len(exprs)))
+		p.error(NewError(exprs[0].SourceRange().Merge(exprs[len(exprs)-1].SourceRange()), "Expected 1 expression but found %v", len(exprs)))
 		// continue with first expression
 	}
 
@@ -863,6 +981,47 @@ func (p *Parser) parseBreakStmt() *BreakStmt {
 	}
 }
 
+func (p *Parser) parseLabeledStmt() *LabeledStmt {
+	labelToken := p.eatTokenOrError(TokenIdentifier)
+	colonToken := p.eatTokenOrError(TokenColon)
+	if !labelToken.valid() || !colonToken.valid() {
+		return nil
+	}
+
+	// labels at the end of a block label an empty statement
+	var stmt Stmt
+	switch p.currentToken().Kind() {
+	case TokenRBrace:
+		stmt = &EmptyStmt{Semicolon: p.currentToken()}
+	case TokenSemicolon:
+		stmt = &EmptyStmt{Semicolon: p.eatToken()}
+	default:
+		// the statement reports its own errors
+		if stmt = p.ParseStmt(); stmt == nil {
+			return nil
+		}
+	}
+
+	return &LabeledStmt{
+		Label: labelToken,
+		Colon: colonToken,
+		Stmt:  stmt,
+	}
+}
+
+func (p *Parser) parseDiscardStmt() *DiscardStmt {
+	discardToken := p.eatTokenOrError(TokenDiscard)
+	if !discardToken.valid() {
+		return nil
+	}
+
+	p.eatSemicolonOrError()
+
+	return &DiscardStmt{
+		Discard: discardToken,
+	}
+}
+
 func (p *Parser) parseFallthroughStmt() *FallthroughStmt {
 	fallthroughToken := p.eatTokenOrError(TokenFallthrough)
 	if !fallthroughToken.valid() {
@@ -1018,7 +1177,7 @@ func (p *Parser) parseIfStmt() *IfStmt {
 			elseStmt = p.parseBlockStmt()
 			p.eatSemicolonOrError()
 		default:
-			p.file.error(NewError(p.currentToken().SourceRange(), "Expected if statement or block"))
+			p.error(NewError(p.currentToken().SourceRange(), "Expected if statement or block"))
 		}
 	} else {
 		p.eatSemicolonOrError()
@@ -1058,10 +1217,10 @@ func (p *Parser) parseIfHeader() (init Stmt, cond Expr) {
 		if exprStmt, ok := condStmt.(*ExprStmt); ok {
 			cond = exprStmt.Expr
 		} else {
-			p.file.error(NewError(condStmt.SourceRange(), "Expected boolean expression as condition in if statement"))
+			p.error(NewError(condStmt.SourceRange(), "Expected boolean expression as condition in if statement"))
 		}
 	} else {
-		p.file.error(NewError(p.currentToken().SourceRange(), "Missing condition in if statement"))
+		p.error(NewError(p.currentToken().SourceRange(), "Missing condition in if statement"))
 	}
 
 	return
@@ -1122,7 +1281,7 @@ func (p *Parser) parseForStmt() Stmt {
 				case 2:
 					// nothing to do
 				default:
-					p.file.error(NewError(assignStmt.LHS[len(assignStmt.LHS)-1].SourceRange(), "expected at most two iteration variables in range for statement"))
+					p.error(NewError(assignStmt.LHS[len(assignStmt.LHS)-1].SourceRange(), "expected at most two iteration variables in range for statement"))
 					return nil
 				}
 
@@ -1186,18 +1345,29 @@ func (p *Parser) parseDeclStmt() Stmt {
 }
 
 func (p *Parser) ParseDecl() Decl {
+	var decl *GenericDecl
 	switch p.currentToken().Kind() {
+	case TokenImport:
+		decl = p.parseGenericDecl(p.eatToken(), p.parseImportSpec)
 	case TokenType:
-		return p.parseGenericDecl(p.eatToken(), p.parseTypeSpec)
+		decl = p.parseGenericDecl(p.eatToken(), p.parseTypeSpec)
 	case TokenConst:
-		return p.parseGenericDecl(p.eatToken(), p.parseConstSpec)
+		decl = p.parseGenericDecl(p.eatToken(), p.parseConstSpec)
 	case TokenVar:
-		return p.parseGenericDecl(p.eatToken(), p.parseVarSpec)
+		decl = p.parseGenericDecl(p.eatToken(), p.parseVarSpec)
 	case TokenFunc:
-		return p.parseFuncDecl()
+		if funcDecl := p.parseFuncDecl(); funcDecl != nil {
+			return funcDecl
+		}
+		return nil
 	default:
 		return nil
 	}
+	// malformed specs fail the whole declaration, a nil *GenericDecl would make a non nil Decl
+	if decl == nil {
+		return nil
+	}
+	return decl
 }
 
 func (p *Parser) parseGenericDecl(token Token, parseFunc func() Spec) *GenericDecl {
@@ -1213,10 +1383,11 @@ func (p *Parser) parseGenericDecl(token Token, parseFunc func() Spec) *GenericDe
 		rParen := p.eatTokenOrError(TokenRParen)
 		p.eatSemicolonOrError()
 		return &GenericDecl{
-			DeclToken: token,
-			LParen:    lParen,
-			Specs:     list,
-			RParen:    rParen,
+			Directives: p.directivesAbove(token),
+			DeclToken:  token,
+			LParen:     lParen,
+			Specs:      list,
+			RParen:     rParen,
 		}
 	} else {
 		s := parseFunc()
@@ -1224,8 +1395,9 @@ func (p *Parser) parseGenericDecl(token Token, parseFunc func() Spec) *GenericDe
 			return nil
 		}
 		return &GenericDecl{
-			DeclToken: token,
-			Specs:     []Spec{s},
+			Directives: p.directivesAbove(token),
+			DeclToken:  token,
+			Specs:      []Spec{s},
 		}
 	}
 }
@@ -1252,6 +1424,25 @@ func (p *Parser) parseTypeSpec() Spec {
 	}
 }
 
+func (p *Parser) parseImportSpec() Spec {
+	var name *IdentifierExpr
+	if p.currentToken().Kind() == TokenIdentifier {
+		name = p.parseIdentifierExpr()
+	}
+
+	path := p.eatTokenOrError(TokenLiteralString)
+	if path.Kind() != TokenLiteralString {
+		return nil
+	}
+
+	p.eatSemicolonOrError()
+
+	return &ImportSpec{
+		Name: name,
+		Path: path,
+	}
+}
+
 func (p *Parser) parseConstSpec() Spec {
 	lhs := p.parseIdentifierExprList()
 
@@ -1263,7 +1454,7 @@ func (p *Parser) parseConstSpec() Spec {
 	assignToken := p.eatTokenIfKind(TokenAssign)
 
 	if constType != nil && !assignToken.valid() {
-		p.file.error(NewError(p.currentToken().SourceRange(), "constant declaration must have an init value"))
+		p.error(NewError(p.currentToken().SourceRange(), "constant declaration must have an init value"))
 		return nil
 	}
 
@@ -1323,7 +1514,7 @@ func (p *Parser) parseFuncDecl() *FuncDecl {
 	if p.currentToken().Kind() == TokenLParen {
 		receiver = p.parseParameters()
 		if receiver != nil && len(receiver.Fields) > 1 {
-			p.file.error(NewError(receiver.Open.SourceRange().Merge(receiver.Close.SourceRange()), "method is expected to have only one receiver"))
+			p.error(NewError(receiver.Open.SourceRange().Merge(receiver.Close.SourceRange()), "method is expected to have only one receiver"))
 			return nil
 		}
 	}
@@ -1333,6 +1524,14 @@ func (p *Parser) parseFuncDecl() *FuncDecl {
 		return nil
 	}
 
+	var typeParams *FieldList
+	if p.currentToken().Kind() == TokenLBracket {
+		typeParams = p.parseTypeParameters()
+		if typeParams == nil {
+			return nil
+		}
+	}
+
 	parameters, result := p.parseSignature()
 	if parameters == nil {
 		return nil
@@ -1349,7 +1548,7 @@ func (p *Parser) parseFuncDecl() *FuncDecl {
 	case TokenSemicolon:
 		p.eatToken()
 		if p.currentToken().Kind() == TokenLBrace {
-			p.file.error(NewError(
+			p.error(NewError(
 				funcToken.SourceRange().Merge(p.currentToken().SourceRange()),
 				"{ should be on the same line as the function declaration",
 			))
@@ -1360,10 +1559,12 @@ func (p *Parser) parseFuncDecl() *FuncDecl {
 	}
 
 	return &FuncDecl{
-		Receiver: receiver,
-		Name:     name,
+		Directives: p.directivesAbove(funcToken),
+		Receiver:   receiver,
+		Name:       name,
 		Type: &FuncTypeExpr{
 			Func:       funcToken,
+			TypeParams: typeParams,
 			Parameters: parameters,
 			Result:     result,
 		},
@@ -1371,6 +1572,70 @@ func (p *Parser) parseFuncDecl() *FuncDecl {
 	}
 }
 
+// TypeParameters = '[' IdentifierList Constraint { ',' IdentifierList Constraint } ']'
+func (p *Parser) parseTypeParameters() *FieldList {
+	openToken := p.eatTokenOrError(TokenLBracket)
+	if openToken.Kind() != TokenLBracket {
+		return nil
+	}
+
+	var fields []Field
+	for p.currentToken().valid() && p.currentToken().Kind() != TokenRBracket {
+		names := p.parseIdentifierExprList()
+		if len(names) == 0 {
+			return nil
+		}
+
+		constraint := p.parseConstraint()
+		if constraint == nil {
+			return nil
+		}
+		fields = append(fields, Field{Names: names, Type: constraint})
+
+		if !p.eatTokenIfKind(TokenComma).valid() {
+			break
+		}
+	}
+
+	if len(fields) == 0 {
+		p.error(NewError(openToken.SourceRange().Merge(p.currentToken().SourceRange()), "empty type parameter list"))
+		return nil
+	}
+
+	closeToken := p.eatTokenOrError(TokenRBracket)
+	if closeToken.Kind() != TokenRBracket {
+		return nil
+	}
+
+	return &FieldList{
+		Open:   openToken,
+		Fields: fields,
+		Close:  closeToken,
+	}
+}
+
+// Constraint = Type { '|' Type }
+func (p *Parser) parseConstraint() TypeExpr {
+	t := p.parseType()
+	if t == nil {
+		return nil
+	}
+
+	if p.currentToken().Kind() != TokenOr {
+		return t
+	}
+
+	terms := []TypeExpr{t}
+	for p.eatTokenIfKind(TokenOr).valid() {
+		term := p.parseType()
+		if term == nil {
+			return nil
+		}
+		terms = append(terms, term)
+	}
+	return &UnionTypeExpr{Terms: terms}
+}
+
 func (p *Parser) ParsePackageClause() *PackageClause {
 	packageToken := p.eatTokenOrError(TokenPackage)
 	if !packageToken.valid() {
@@ -1379,7 +1644,7 @@ func (p *Parser) ParsePackageClause() *PackageClause {
 
 	name := p.parseIdentifierExpr()
 	if name == nil || name.Token.Value() == "_" {
-		p.file.error(NewError(p.currentToken().SourceRange(), "invalid package clause name"))
+		p.error(NewError(p.currentToken().SourceRange(), "invalid package clause name"))
 		return nil
 	}
 	p.eatSemicolonOrError()
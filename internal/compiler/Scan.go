@@ -289,7 +289,7 @@ func (s *Scanner) Scan() Token {
 
 		switch token.kind {
 		// in go spec, true and false are considered identifiers, but we don't, maybe we should fix that later
-		case TokenIdentifier, TokenBreak, TokenFallthrough, TokenContinue, TokenReturn, TokenTrue, TokenFalse:
+		case TokenIdentifier, TokenBreak, TokenFallthrough, TokenContinue, TokenReturn, TokenDiscard, TokenTrue, TokenFalse:
 			insertSemi = true
 		}
 
@@ -510,7 +510,7 @@ func (s *Scanner) Scan() Token {
 		// revert insertSemi
 		insertSemi = s.insertSemi
 		s.readChar()
-		s.file.error(NewError(s.createSourceRange(start, s.currentLocation), "unknown token"))
+		s.file.error(NewError(s.createSourceRange(start, s.currentLocation), "unknown token").WithCode(DiagnosticSyntaxError))
 		return s.createTokenFromLocationPoint(TokenInvalid, start)
 	}
 }
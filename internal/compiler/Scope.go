@@ -49,8 +49,70 @@ func (sym *SymbolBase) SetResolveState(r ResolveState) {
 	sym.SymResolveState = r
 }
 
+// ShaderStage is the pipeline stage a function is an entry point of
+type ShaderStage int
+
+const (
+	ShaderStageNone ShaderStage = iota
+	ShaderStageVertex
+	ShaderStageFragment
+	ShaderStageCompute
+)
+
+func shaderStageFromName(name string) ShaderStage {
+	switch name {
+	case "vertex":
+		return ShaderStageVertex
+	case "fragment":
+		return ShaderStageFragment
+	case "compute":
+		return ShaderStageCompute
+	default:
+		return ShaderStageNone
+	}
+}
+
+func (s ShaderStage) String() string {
+	switch s {
+	case ShaderStageNone:
+		return "none"
+	case ShaderStageVertex:
+		return "vertex"
+	case ShaderStageFragment:
+		return "fragment"
+	case ShaderStageCompute:
+		return "compute"
+	default:
+		panic("unknown shader stage")
+	}
+}
+
 type FuncSymbol struct {
 	SymbolBase
+	// Receiver is the type symbol this function is a method of, or nil for plain functions
+	Receiver *TypeSymbol
+	// Stage is set for entry points declared with a //sabre:<stage> directive
+	Stage ShaderStage
+	// WorkgroupSize is the number of invocations in each workgroup of compute entry points along each axis, it's
+	// given after the stage like //sabre:compute 8 8 and the axes which aren't given have a single invocation
+	WorkgroupSize [3]uint32
+}
+
+func (sym FuncSymbol) IsMethod() bool {
+	return sym.Receiver != nil
+}
+
+// HasPointerReceiver returns true for methods declared on *T which can modify their receiver
+func (sym FuncSymbol) HasPointerReceiver() bool {
+	if !sym.IsMethod() {
+		return false
+	}
+	_, ok := sym.SymDecl.(*FuncDecl).Receiver.Fields[0].Type.(*PointerTypeExpr)
+	return ok
+}
+
+func (sym FuncSymbol) IsEntryPoint() bool {
+	return sym.Stage != ShaderStageNone
 }
 
 func (FuncSymbol) aSymbol() {}
@@ -70,6 +132,11 @@ type VarSymbol struct {
 	SpecIndex        int
 	ExprIndex        int
 	InitTypeAndValue *TypeAndValue
+	// IsParam is set for function parameters and method receivers
+	IsParam bool
+	// Workgroup is set for the package level variables declared with the //sabre:workgroup directive, they're shared
+	// by the invocations of a compute workgroup
+	Workgroup bool
 }
 
 func (VarSymbol) aSymbol() {}
@@ -107,10 +174,30 @@ func NewConstSymbol(name Token, decl Decl, sourceRange SourceRange, specIndex, e
 	}
 }
 
+type PackageSymbol struct {
+	SymbolBase
+	Package *Package
+}
+
+func (PackageSymbol) aSymbol() {}
+func NewPackageSymbol(name Token, decl Decl, sourceRange SourceRange, pkg *Package) *PackageSymbol {
+	return &PackageSymbol{
+		SymbolBase: SymbolBase{
+			SymScope:       nil,
+			SymName:        name.Value(),
+			SymDecl:        decl,
+			SymSourceRange: sourceRange,
+		},
+		Package: pkg,
+	}
+}
+
 type TypeSymbol struct {
 	SymbolBase
-	TypeExpr TypeExpr
-	IsStrong bool
+	TypeExpr      TypeExpr
+	IsStrong      bool
+	Methods       []*FuncSymbol
+	MethodsByName map[string]int
 }
 
 func (TypeSymbol) aSymbol() {}
@@ -122,9 +209,27 @@ func NewTypeSymbol(name Token, decl Decl, sourceRange SourceRange, typeExpr Type
 			SymDecl:        decl,
 			SymSourceRange: sourceRange,
 		},
-		TypeExpr: typeExpr,
-		IsStrong: isStrong,
+		TypeExpr:      typeExpr,
+		IsStrong:      isStrong,
+		MethodsByName: make(map[string]int),
+	}
+}
+
+func (sym *TypeSymbol) FindMethod(name string) *FuncSymbol {
+	if index, ok := sym.MethodsByName[name]; ok {
+		return sym.Methods[index]
+	}
+	return nil
+}
+
+func (sym *TypeSymbol) AddMethod(method *FuncSymbol) bool {
+	if sym.FindMethod(method.Name()) != nil {
+		return false
 	}
+	sym.MethodsByName[method.Name()] = len(sym.Methods)
+	sym.Methods = append(sym.Methods, method)
+	method.Receiver = sym
+	return true
 }
 
 type Scope struct {
@@ -151,6 +256,12 @@ func (s Scope) ShallowFind(name string) Symbol {
 }
 
 func (s *Scope) Add(sym Symbol) bool {
+	// the blank identifier never clashes and can't be looked up
+	if sym.Name() == "_" {
+		s.Symbols = append(s.Symbols, sym)
+		return true
+	}
+
 	if s.ShallowFind(sym.Name()) != nil {
 		return false
 	}
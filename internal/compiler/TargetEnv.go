@@ -0,0 +1,138 @@
+package compiler
+
+import (
+	"github.com/MoustaphaSaad/sabre-go/internal/compiler/spirv"
+)
+
+// TargetEnv is the environment which consumes the emitted SPIR-V module, it selects the version of the module and
+// which features it's allowed to use
+type TargetEnv int
+
+const (
+	// TargetEnvUniversal13 is universal SPIR-V 1.3, it's the default target because it's what the emitter always
+	// produced
+	TargetEnvUniversal13 TargetEnv = iota
+	TargetEnvUniversal10
+	TargetEnvUniversal11
+	TargetEnvUniversal12
+	TargetEnvUniversal14
+	TargetEnvUniversal15
+	TargetEnvUniversal16
+	TargetEnvVulkan10
+	TargetEnvVulkan11
+	TargetEnvVulkan12
+	TargetEnvVulkan13
+	TargetEnvOpenGL45
+	TargetEnvOpenCL21
+	TargetEnvOpenCL22
+)
+
+var targetEnvNames = map[TargetEnv]string{
+	TargetEnvUniversal10: "spv1.0",
+	TargetEnvUniversal11: "spv1.1",
+	TargetEnvUniversal12: "spv1.2",
+	TargetEnvUniversal13: "spv1.3",
+	TargetEnvUniversal14: "spv1.4",
+	TargetEnvUniversal15: "spv1.5",
+	TargetEnvUniversal16: "spv1.6",
+	TargetEnvVulkan10:    "vulkan1.0",
+	TargetEnvVulkan11:    "vulkan1.1",
+	TargetEnvVulkan12:    "vulkan1.2",
+	TargetEnvVulkan13:    "vulkan1.3",
+	TargetEnvOpenGL45:    "opengl4.5",
+	TargetEnvOpenCL21:    "opencl2.1",
+	TargetEnvOpenCL22:    "opencl2.2",
+}
+
+func TargetEnvFromName(name string) (TargetEnv, bool) {
+	for target, targetName := range targetEnvNames {
+		if targetName == name {
+			return target, true
+		}
+	}
+	return TargetEnvUniversal13, false
+}
+
+func (t TargetEnv) String() string {
+	if name, ok := targetEnvNames[t]; ok {
+		return name
+	}
+	panic("unknown target environment")
+}
+
+// SPIRVVersion is the version declared in the header of the modules emitted for the target, environments use the
+// newest version their core spec accepts
+func (t TargetEnv) SPIRVVersion() spirv.Version {
+	switch t {
+	case TargetEnvUniversal10, TargetEnvVulkan10, TargetEnvOpenGL45, TargetEnvOpenCL21:
+		return spirv.Version{Major: 1, Minor: 0}
+	case TargetEnvUniversal11:
+		return spirv.Version{Major: 1, Minor: 1}
+	case TargetEnvUniversal12, TargetEnvOpenCL22:
+		return spirv.Version{Major: 1, Minor: 2}
+	case TargetEnvUniversal13, TargetEnvVulkan11:
+		return spirv.Version{Major: 1, Minor: 3}
+	case TargetEnvUniversal14:
+		return spirv.Version{Major: 1, Minor: 4}
+	case TargetEnvUniversal15, TargetEnvVulkan12:
+		return spirv.Version{Major: 1, Minor: 5}
+	case TargetEnvUniversal16, TargetEnvVulkan13:
+		return spirv.Version{Major: 1, Minor: 6}
+	default:
+		panic("unknown target environment")
+	}
+}
+
+func (t TargetEnv) isVulkan() bool {
+	return t >= TargetEnvVulkan10 && t <= TargetEnvVulkan13
+}
+
+func (t TargetEnv) isOpenGL() bool {
+	return t == TargetEnvOpenGL45
+}
+
+// isKernel reports whether the target runs kernels instead of shaders, kernels use the OpenCL memory model and
+// physical pointers
+func (t TargetEnv) isKernel() bool {
+	return t == TargetEnvOpenCL21 || t == TargetEnvOpenCL22
+}
+
+// allowsLinkage reports whether modules without entry points can be emitted with the Linkage capability, client
+// APIs only consume complete shaders
+func (t TargetEnv) allowsLinkage() bool {
+	return !t.isVulkan() && !t.isOpenGL()
+}
+
+// hasCoreDemote reports whether OpDemoteToHelperInvocation is core, otherwise it needs its extension
+func (t TargetEnv) hasCoreDemote() bool {
+	version := t.SPIRVVersion()
+	return version.Major > 1 || version.Minor >= 6
+}
+
+// interfaceListsAllGlobals reports whether the interface of the entry points lists all the global variables they use,
+// older versions only list the inputs and outputs
+func (t TargetEnv) interfaceListsAllGlobals() bool {
+	version := t.SPIRVVersion()
+	return version.Major > 1 || version.Minor >= 4
+}
+
+// hasStorageBufferClass reports whether buffers are bound in the StorageBuffer storage class, older versions bind them
+// as BufferBlock structs in the Uniform storage class
+func (t TargetEnv) hasStorageBufferClass() bool {
+	version := t.SPIRVVersion()
+	return version.Major > 1 || version.Minor >= 3
+}
+
+func (t TargetEnv) addressingModel() spirv.AddressingModel {
+	if t.isKernel() {
+		return spirv.AddressingModelPhysical64
+	}
+	return spirv.AddressingModelLogical
+}
+
+func (t TargetEnv) memoryModel() spirv.MemoryModel {
+	if t.isKernel() {
+		return spirv.MemoryModelOpenCL
+	}
+	return spirv.MemoryModelGLSL450
+}
@@ -453,12 +453,89 @@ func (r SourceRange) HighlightCodeRange() string {
 
 // Error specification
 
+// Severity decides whether a diagnostic stops the compilation or is only reported
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "Error"
+	case SeverityWarning:
+		return "Warning"
+	case SeverityInfo:
+		return "Info"
+	default:
+		panic("unknown severity")
+	}
+}
+
+// DiagnosticCode is the stable name of a kind of diagnostic, warnings are enabled, disabled or suppressed by their
+// codes while errors can't be disabled
+type DiagnosticCode string
+
+// codes of errors
+const (
+	DiagnosticSyntaxError       DiagnosticCode = "syntax-error"
+	DiagnosticImportError       DiagnosticCode = "import-error"
+	DiagnosticTypeError         DiagnosticCode = "type-error"
+	DiagnosticRecursiveCall     DiagnosticCode = "recursive-call"
+	DiagnosticNonUniformCall    DiagnosticCode = "non-uniform-call"
+	DiagnosticUnsupportedTarget DiagnosticCode = "unsupported-target"
+)
+
+// codes of warnings
+const (
+	DiagnosticUnreachableCode DiagnosticCode = "unreachable-code"
+	DiagnosticUnusedParameter DiagnosticCode = "unused-parameter"
+	DiagnosticUnusedSymbol    DiagnosticCode = "unused-symbol"
+	DiagnosticUnusedResult    DiagnosticCode = "unused-result"
+)
+
+// diagnosticCodes lists the codes of the warnings and whether they are enabled by default
+var diagnosticCodes = map[DiagnosticCode]bool{
+	DiagnosticUnreachableCode: true,
+	DiagnosticUnusedParameter: false,
+	DiagnosticUnusedSymbol:    true,
+	DiagnosticUnusedResult:    true,
+}
+
+// DiagnosticCodeFromName returns the warning code with the given name, the codes of errors aren't returned since
+// they can't be enabled, disabled or suppressed
+func DiagnosticCodeFromName(name string) (DiagnosticCode, bool) {
+	code := DiagnosticCode(name)
+	_, ok := diagnosticCodes[code]
+	return code, ok
+}
+
+// DiagnosticOptions controls which warnings are reported and how
+type DiagnosticOptions struct {
+	// Enabled overrides the default state of the given codes
+	Enabled map[DiagnosticCode]bool
+	// WarningsAsErrors reports the enabled warnings as errors
+	WarningsAsErrors bool
+}
+
+func (o DiagnosticOptions) IsEnabled(code DiagnosticCode) bool {
+	if enabled, ok := o.Enabled[code]; ok {
+		return enabled
+	}
+	return diagnosticCodes[code]
+}
+
 type ErrorNote struct {
 	SourceRange SourceRange
 	Message     string
 }
 
 type Error struct {
+	Severity    Severity
+	Code        DiagnosticCode
 	SourceRange SourceRange
 	Message     string
 	Notes       []ErrorNote
@@ -472,15 +549,32 @@ func NewError(sourceRange SourceRange, format string, a ...any) Error {
 	}
 }
 
+// NewWarning creates a diagnostic which is reported without failing the compilation
+func NewWarning(code DiagnosticCode, sourceRange SourceRange, format string, a ...any) Error {
+	e := NewError(sourceRange, format, a...)
+	e.Severity = SeverityWarning
+	e.Code = code
+	return e
+}
+
 func (e Error) String() string {
 	var result strings.Builder
-	fmt.Fprintf(&result, "%v\nError[%v]: %v", e.SourceRange.HighlightCodeRange(), e.SourceRange.Begin(), e.Message)
+	fmt.Fprintf(&result, "%v\n%v[%v]: %v", e.SourceRange.HighlightCodeRange(), e.Severity, e.SourceRange.Begin(), e.Message)
+	if e.Code != "" {
+		fmt.Fprintf(&result, " [%v]", e.Code)
+	}
 	for _, note := range e.Notes {
 		fmt.Fprintf(&result, "\n%v\nNote[%v]: %v", note.SourceRange.HighlightCodeRange(), note.SourceRange.Begin(), note.Message)
 	}
 	return result.String()
 }
 
+// WithCode returns the error with the given code
+func (e Error) WithCode(code DiagnosticCode) Error {
+	e.Code = code
+	return e
+}
+
 func (e Error) Note(sourceRange SourceRange, format string, a ...any) Error {
 	e.Notes = append(e.Notes, ErrorNote{
 		SourceRange: sourceRange,
@@ -2,6 +2,7 @@ package compiler
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -163,6 +164,70 @@ func (lhs *Float64Type) Equal(rhs Type) bool {
 	return lhs == rhs.Resolve(false)
 }
 
+// UntypedKind is the kind of value an untyped constant holds
+type UntypedKind int
+
+const (
+	UntypedKindBool UntypedKind = iota
+	UntypedKindInt
+	UntypedKindFloat
+)
+
+// UntypedType is the type of constants which didn't get a concrete type yet, they are converted to the type
+// required by the context they are used in, or to their default type if the context doesn't require one
+type UntypedType struct {
+	Kind UntypedKind
+}
+
+var (
+	BuiltinUntypedBoolType  = &UntypedType{Kind: UntypedKindBool}
+	BuiltinUntypedIntType   = &UntypedType{Kind: UntypedKindInt}
+	BuiltinUntypedFloatType = &UntypedType{Kind: UntypedKindFloat}
+)
+
+func (UntypedType) aType() {}
+func (t UntypedType) Properties() TypeProperties {
+	return t.Default().Properties()
+}
+func (t UntypedType) String() string {
+	switch t.Kind {
+	case UntypedKindBool:
+		return "untyped bool"
+	case UntypedKindInt:
+		return "untyped int"
+	case UntypedKindFloat:
+		return "untyped float"
+	default:
+		panic("unexpected untyped kind")
+	}
+}
+func (t UntypedType) HashKey() string { return t.String() }
+func (t *UntypedType) Resolve(bool) Type {
+	return t
+}
+func (lhs *UntypedType) Equal(rhs Type) bool {
+	return lhs == rhs.Resolve(false)
+}
+
+// Default returns the type the untyped constant gets when the context doesn't require a specific type
+func (t UntypedType) Default() Type {
+	switch t.Kind {
+	case UntypedKindBool:
+		return BuiltinBoolType
+	case UntypedKindInt:
+		return BuiltinIntType
+	case UntypedKindFloat:
+		return BuiltinFloat32Type
+	default:
+		panic("unexpected untyped kind")
+	}
+}
+
+func isUntyped(t Type) bool {
+	_, ok := t.(*UntypedType)
+	return ok
+}
+
 type StringType struct{}
 
 var BuiltinStringType = &StringType{}
@@ -232,7 +297,7 @@ var (
 	}
 
 	BuiltinF64x2Type = &VectorType{
-		UnderlyingType: BuiltinFloat32Type,
+		UnderlyingType: BuiltinFloat64Type,
 		Width:          2,
 		properties: TypeProperties{
 			Size:          16,
@@ -246,7 +311,7 @@ var (
 		name: "f64x2",
 	}
 	BuiltinF64x3Type = &VectorType{
-		UnderlyingType: BuiltinFloat32Type,
+		UnderlyingType: BuiltinFloat64Type,
 		Width:          3,
 		properties: TypeProperties{
 			Size:          24,
@@ -260,7 +325,7 @@ var (
 		name: "f64x3",
 	}
 	BuiltinF64x4Type = &VectorType{
-		UnderlyingType: BuiltinFloat32Type,
+		UnderlyingType: BuiltinFloat64Type,
 		Width:          4,
 		properties: TypeProperties{
 			Size:          32,
@@ -418,17 +483,33 @@ func (lhs *VectorType) Equal(rhs Type) bool {
 }
 
 type FuncType struct {
+	TypeParams     []*TypeParamType
 	ParameterTypes []Type
 	ReturnTypes    []Type
 }
 
+func (t FuncType) IsGeneric() bool {
+	return len(t.TypeParams) > 0
+}
+
 func (FuncType) aType() {}
 func (FuncType) Properties() TypeProperties {
 	return TypeProperties{}
 }
 func (t FuncType) String() string {
 	var b strings.Builder
-	b.WriteString("func(")
+	b.WriteString("func")
+	if t.IsGeneric() {
+		b.WriteRune('[')
+		for i, p := range t.TypeParams {
+			if i > 0 {
+				b.WriteRune(',')
+			}
+			fmt.Fprintf(&b, "%v %v", p.Name, p.Constraint)
+		}
+		b.WriteRune(']')
+	}
+	b.WriteRune('(')
 	for i, a := range t.ParameterTypes {
 		if i > 0 {
 			b.WriteRune(',')
@@ -450,7 +531,18 @@ func (t FuncType) String() string {
 }
 func (t FuncType) HashKey() string {
 	var b strings.Builder
-	b.WriteString("func(")
+	b.WriteString("func")
+	if t.IsGeneric() {
+		b.WriteRune('[')
+		for i, p := range t.TypeParams {
+			if i > 0 {
+				b.WriteRune(',')
+			}
+			b.WriteString(p.HashKey())
+		}
+		b.WriteRune(']')
+	}
+	b.WriteRune('(')
 	for i, a := range t.ParameterTypes {
 		if i > 0 {
 			b.WriteRune(',')
@@ -477,6 +569,274 @@ func (lhs *FuncType) Equal(rhs Type) bool {
 	return lhs == rhs.Resolve(false)
 }
 
+// TypeParamType is a type parameter of a generic function, it only supports the operations that all the
+// types of its constraint support and it's replaced by its type argument when the function is instantiated
+type TypeParamType struct {
+	Name       string
+	Identifier *IdentifierExpr
+	Constraint *ConstraintType
+	id         int
+}
+
+func (TypeParamType) aType() {}
+func (t TypeParamType) Properties() TypeProperties {
+	return t.Constraint.Properties()
+}
+func (t TypeParamType) String() string  { return t.Name }
+func (t TypeParamType) HashKey() string { return fmt.Sprintf("%v#%v", t.Name, t.id) }
+func (t *TypeParamType) Resolve(bool) Type {
+	return t
+}
+func (lhs *TypeParamType) Equal(rhs Type) bool {
+	return lhs == rhs.Resolve(false)
+}
+
+// ConstraintType is the set of types a type parameter accepts, it's either one of the builtin constraints
+// or a union of types and constraints
+type ConstraintType struct {
+	Name          string
+	Terms         []Type
+	properties    TypeProperties
+	numericScalar bool
+	accepts       func(t Type) bool
+}
+
+var (
+	BuiltinAnyConstraint = &ConstraintType{
+		Name:    "any",
+		accepts: func(Type) bool { return true },
+	}
+	BuiltinComparableConstraint = &ConstraintType{
+		Name:       "comparable",
+		properties: TypeProperties{HasEquality: true},
+		accepts:    func(t Type) bool { return t.Properties().HasEquality },
+	}
+	BuiltinNumericConstraint = &ConstraintType{
+		Name: "numeric",
+		properties: TypeProperties{
+			HasArithmetic: true,
+			HasCompare:    true,
+			HasEquality:   true,
+		},
+		numericScalar: true,
+		accepts:       isNumericScalar,
+	}
+	BuiltinIntegerConstraint = &ConstraintType{
+		Name: "integer",
+		properties: TypeProperties{
+			Integral:      true,
+			HasBitOps:     true,
+			HasArithmetic: true,
+			HasCompare:    true,
+			HasEquality:   true,
+			HasModulus:    true,
+		},
+		numericScalar: true,
+		accepts: func(t Type) bool {
+			return isNumericScalar(t) && t.Properties().Integral
+		},
+	}
+	BuiltinFloatConstraint = &ConstraintType{
+		Name: "float",
+		properties: TypeProperties{
+			Signed:        true,
+			Floating:      true,
+			HasArithmetic: true,
+			HasCompare:    true,
+			HasEquality:   true,
+		},
+		numericScalar: true,
+		accepts: func(t Type) bool {
+			return isNumericScalar(t) && t.Properties().Floating
+		},
+	}
+	BuiltinVectorConstraint = &ConstraintType{
+		Name: "vector",
+		properties: TypeProperties{
+			HasCompare:  true,
+			HasEquality: true,
+		},
+		accepts: func(t Type) bool {
+			_, ok := t.Resolve(true).(*VectorType)
+			return ok
+		},
+	}
+	BuiltinFloatVectorConstraint = &ConstraintType{
+		Name: "floatvector",
+		properties: TypeProperties{
+			Signed:        true,
+			Floating:      true,
+			HasArithmetic: true,
+			HasCompare:    true,
+			HasEquality:   true,
+		},
+		accepts: func(t Type) bool {
+			_, ok := t.Resolve(true).(*VectorType)
+			return ok && t.Properties().Floating
+		},
+	}
+)
+
+func (ConstraintType) aType() {}
+func (t ConstraintType) Properties() TypeProperties {
+	if t.Terms == nil {
+		return t.properties
+	}
+
+	// a union only supports the operations that all of its terms support
+	res := t.Terms[0].Properties()
+	for _, term := range t.Terms[1:] {
+		props := term.Properties()
+		res.Signed = res.Signed && props.Signed
+		res.Integral = res.Integral && props.Integral
+		res.Floating = res.Floating && props.Floating
+		res.HasBitOps = res.HasBitOps && props.HasBitOps
+		res.HasArithmetic = res.HasArithmetic && props.HasArithmetic
+		res.HasLogicOps = res.HasLogicOps && props.HasLogicOps
+		res.HasCompare = res.HasCompare && props.HasCompare
+		res.HasEquality = res.HasEquality && props.HasEquality
+		res.HasModulus = res.HasModulus && props.HasModulus
+	}
+	res.Size, res.Align = 0, 0
+	return res
+}
+func (t ConstraintType) String() string {
+	if t.Terms == nil {
+		return t.Name
+	}
+
+	var b strings.Builder
+	for i, term := range t.Terms {
+		if i > 0 {
+			b.WriteString(" | ")
+		}
+		b.WriteString(term.String())
+	}
+	return b.String()
+}
+func (t ConstraintType) HashKey() string {
+	if t.Terms == nil {
+		return t.Name
+	}
+
+	var b strings.Builder
+	for i, term := range t.Terms {
+		if i > 0 {
+			b.WriteRune('|')
+		}
+		b.WriteString(term.HashKey())
+	}
+	return b.String()
+}
+func (t *ConstraintType) Resolve(bool) Type {
+	return t
+}
+func (lhs *ConstraintType) Equal(rhs Type) bool {
+	return lhs == rhs.Resolve(false)
+}
+
+// SatisfiedBy returns true if the given type is in the type set of the constraint, type parameters satisfy it if
+// all the types of their own constraint do
+func (t *ConstraintType) SatisfiedBy(typ Type) bool {
+	if typeParam, ok := typ.(*TypeParamType); ok {
+		return t.Includes(typeParam.Constraint)
+	}
+	if t.Terms == nil {
+		return t.accepts(typ)
+	}
+
+	for _, term := range t.Terms {
+		if constraint, ok := term.(*ConstraintType); ok {
+			if constraint.SatisfiedBy(typ) {
+				return true
+			}
+		} else if term.Equal(typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// Includes returns true if the type set of the other constraint is a subset of the type set of the constraint
+func (t *ConstraintType) Includes(other *ConstraintType) bool {
+	if other.Terms != nil {
+		for _, term := range other.Terms {
+			if constraint, ok := term.(*ConstraintType); ok {
+				if !t.Includes(constraint) {
+					return false
+				}
+			} else if !t.SatisfiedBy(term) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// builtin constraints have unbounded type sets, so a union includes one only through one of its terms
+	if t.Terms != nil {
+		for _, term := range t.Terms {
+			if constraint, ok := term.(*ConstraintType); ok && constraint.Includes(other) {
+				return true
+			}
+		}
+		return false
+	}
+	return t == other || slices.Contains(builtinConstraintSubsets[t], other)
+}
+
+// builtinConstraintSubsets lists the builtin constraints whose type sets are included in each builtin constraint
+var builtinConstraintSubsets = map[*ConstraintType][]*ConstraintType{
+	BuiltinAnyConstraint: {
+		BuiltinComparableConstraint, BuiltinNumericConstraint, BuiltinIntegerConstraint, BuiltinFloatConstraint,
+		BuiltinVectorConstraint, BuiltinFloatVectorConstraint,
+	},
+	BuiltinComparableConstraint: {
+		BuiltinNumericConstraint, BuiltinIntegerConstraint, BuiltinFloatConstraint, BuiltinVectorConstraint,
+		BuiltinFloatVectorConstraint,
+	},
+	BuiltinNumericConstraint: {BuiltinIntegerConstraint, BuiltinFloatConstraint},
+	BuiltinVectorConstraint:  {BuiltinFloatVectorConstraint},
+}
+
+// IsNumericScalar returns true if all the types of the constraint are numeric scalars
+func (t *ConstraintType) IsNumericScalar() bool {
+	if t.Terms == nil {
+		return t.numericScalar
+	}
+
+	for _, term := range t.Terms {
+		if constraint, ok := term.(*ConstraintType); ok {
+			if !constraint.IsNumericScalar() {
+				return false
+			}
+		} else if !isNumericScalar(term) {
+			return false
+		}
+	}
+	return true
+}
+
+func constraintFromName(name Token) *ConstraintType {
+	switch name.Value() {
+	case BuiltinAnyConstraint.Name:
+		return BuiltinAnyConstraint
+	case BuiltinComparableConstraint.Name:
+		return BuiltinComparableConstraint
+	case BuiltinNumericConstraint.Name:
+		return BuiltinNumericConstraint
+	case BuiltinIntegerConstraint.Name:
+		return BuiltinIntegerConstraint
+	case BuiltinFloatConstraint.Name:
+		return BuiltinFloatConstraint
+	case BuiltinVectorConstraint.Name:
+		return BuiltinVectorConstraint
+	case BuiltinFloatVectorConstraint.Name:
+		return BuiltinFloatVectorConstraint
+	default:
+		return nil
+	}
+}
+
 type ArrayType struct {
 	Length      int
 	ElementType Type
@@ -502,6 +862,55 @@ func (lhs *ArrayType) Equal(rhs Type) bool {
 	return lhs == rhs.Resolve(false)
 }
 
+// PointerType points to function local memory, pointers follow SPIR-V logical addressing rules so they can only be
+// passed to functions, they can't be stored, compared or used in arithmetic
+type PointerType struct {
+	ElementType Type
+}
+
+func (PointerType) aType() {}
+func (t PointerType) Properties() TypeProperties {
+	return TypeProperties{}
+}
+func (t PointerType) String() string {
+	return fmt.Sprintf("*%v", t.ElementType.String())
+}
+func (t PointerType) HashKey() string {
+	return fmt.Sprintf("*%v", t.ElementType.HashKey())
+}
+func (t *PointerType) Resolve(bool) Type {
+	return t
+}
+func (lhs *PointerType) Equal(rhs Type) bool {
+	return lhs == rhs.Resolve(false)
+}
+
+// TextureType is a 2D texture of float32 colors combined with the sampler reading it, textures are resources bound
+// to the entry points so like pointers they can only be passed to functions
+type TextureType struct {
+	name string
+	// Storage is set for the images compute shaders read and write texel by texel, they have no sampler
+	Storage bool
+}
+
+var (
+	BuiltinTexture2DType = &TextureType{name: "texture2d"}
+	BuiltinImage2DType   = &TextureType{name: "image2d", Storage: true}
+)
+
+func (TextureType) aType() {}
+func (TextureType) Properties() TypeProperties {
+	return TypeProperties{}
+}
+func (t TextureType) String() string  { return t.name }
+func (t TextureType) HashKey() string { return t.String() }
+func (t *TextureType) Resolve(bool) Type {
+	return t
+}
+func (lhs *TextureType) Equal(rhs Type) bool {
+	return lhs == rhs.Resolve(false)
+}
+
 type TupleType struct {
 	Types []Type
 }
@@ -555,6 +964,29 @@ func (lhs *TupleType) Equal(rhs Type) bool {
 type StructTypeField struct {
 	Identifer *IdentifierExpr
 	Type      Type
+	// Package declares the struct type, unexported fields can only be accessed from it
+	Package *Package
+	// Embedding is the type declaring an embedded field, nil for named fields
+	Embedding TypeExpr
+}
+
+// IsEmbedded returns true for fields declared with only a type, the type name is used as the field name
+func (f StructTypeField) IsEmbedded() bool {
+	return f.Identifer == nil
+}
+
+func (f StructTypeField) Name() string {
+	if f.Identifer != nil {
+		return f.Identifer.Token.Value()
+	}
+	switch t := f.Type.(type) {
+	case *StrongAliasType:
+		return t.Name
+	case *WeakAliasType:
+		return t.Name
+	default:
+		return f.Type.String()
+	}
 }
 
 type StructType struct {
@@ -582,7 +1014,11 @@ func (t StructType) String() string {
 	b.WriteString("struct{")
 	for i, field := range t.Fields {
 		if i > 0 {
-			b.WriteRune(',')
+			b.WriteString("; ")
+		}
+		if !field.IsEmbedded() {
+			b.WriteString(field.Name())
+			b.WriteRune(' ')
 		}
 		b.WriteString(field.Type.String())
 	}
@@ -596,47 +1032,120 @@ func (t StructType) HashKey() string {
 		if i > 0 {
 			b.WriteRune(',')
 		}
+		// field names are part of the struct identity, embedded fields are named after their types
+		if !field.IsEmbedded() {
+			b.WriteString(field.Name())
+			b.WriteRune(' ')
+		}
+		// unexported fields declared by different packages are different fields even if they have the same name
+		if !isExported(field.Name()) && field.Package != nil && field.Package.Path != "" {
+			b.WriteString(field.Package.Path)
+			b.WriteRune(' ')
+		}
 		b.WriteString(field.Type.HashKey())
 	}
 	b.WriteRune('}')
 	return b.String()
 }
+
+// FindField returns the field declared directly in the struct with the given name, promoted fields aren't included
 func (t StructType) FindField(name string) *StructTypeField {
 	if index, ok := t.FieldsByName[name]; ok {
 		return &t.Fields[index]
 	}
-	for _, field := range t.Fields {
-		if field.Identifer != nil {
-			continue
-		}
+	return nil
+}
+func (t *StructType) Resolve(bool) Type {
+	return t
+}
+func (lhs *StructType) Equal(rhs Type) bool {
+	return lhs == rhs.Resolve(false)
+}
 
-		strongAlias, ok := field.Type.(*StrongAliasType)
-		if !ok {
-			continue
-		}
+// Selection is the field or method a selector refers to, fields and methods of embedded fields are promoted
+type Selection struct {
+	Field  *StructTypeField
+	Method *FuncSymbol
+	// Path lists the indices of the embedded fields leading to the selected field or the receiver of the selected
+	// method, selected fields end the path with their own index
+	Path []int
+}
+
+// LookupFieldOrMethod finds the field or method with the given name in the type following go's embedding rules,
+// the shallowest field or method wins and two of them at the same depth make the selector ambiguous, the conflicting
+// ones are returned instead. a type with a field and a method of the same name is reported where the method is
+// declared, so the field is selected
+func LookupFieldOrMethod(t Type, name string) (selection *Selection, conflicts []*Selection) {
+	type embedding struct {
+		t    Type
+		path []int
+	}
+
+	current := []embedding{{t: t}}
+	for len(current) > 0 {
+		var next []embedding
+		for _, e := range current {
+			structType, isStruct := e.t.Resolve(true).(*StructType)
+			var found *Selection
+			if index, ok := structFieldIndex(structType, name); ok {
+				found = &Selection{Field: &structType.Fields[index], Path: append(slices.Clone(e.path), index)}
+			} else if namedType, ok := e.t.Resolve(false).(*StrongAliasType); ok {
+				if method := namedType.FindMethod(name); method != nil {
+					found = &Selection{Method: method, Path: e.path}
+				}
+			}
+			if found != nil {
+				conflicts = append(conflicts, found)
+			}
 
-		underlyingType := strongAlias.Resolve(true)
-		structType, ok := underlyingType.(*StructType)
-		if !ok {
-			continue
+			if !isStruct {
+				continue
+			}
+			for i, field := range structType.Fields {
+				if field.IsEmbedded() {
+					next = append(next, embedding{t: field.Type, path: append(slices.Clone(e.path), i)})
+				}
+			}
 		}
 
-		if field := structType.FindField(name); field != nil {
-			return field
+		if len(conflicts) == 1 {
+			return conflicts[0], nil
+		} else if len(conflicts) > 1 {
+			return nil, conflicts
 		}
+		current = next
 	}
-	return nil
+	return nil, nil
 }
-func (t *StructType) Resolve(bool) Type {
-	return t
+
+// EmbeddedFieldOf returns the embedded field of the type which the selection is promoted from, nil if the selected
+// field or method is declared by the type itself
+func EmbeddedFieldOf(t Type, selection *Selection) *StructTypeField {
+	path := selection.Path
+	if selection.Field != nil {
+		path = path[:len(path)-1]
+	}
+	var field *StructTypeField
+	for _, index := range path {
+		field = &t.Resolve(true).(*StructType).Fields[index]
+		t = field.Type
+	}
+	return field
 }
-func (lhs *StructType) Equal(rhs Type) bool {
-	return lhs == rhs.Resolve(false)
+
+// structFieldIndex returns the index of the field with the given name in the struct type, which may be nil
+func structFieldIndex(t *StructType, name string) (int, bool) {
+	if t == nil {
+		return 0, false
+	}
+	index, ok := t.FieldsByName[name]
+	return index, ok
 }
 
 type StrongAliasType struct {
 	Name           string
 	UnderlyingType Type
+	Methods        []*FuncSymbol
 }
 
 func (StrongAliasType) aType() {}
@@ -651,6 +1160,14 @@ func (t *StrongAliasType) Resolve(resolveStrongAlias bool) Type {
 func (lhs *StrongAliasType) Equal(rhs Type) bool {
 	return lhs == rhs.Resolve(false)
 }
+func (t *StrongAliasType) FindMethod(name string) *FuncSymbol {
+	for _, method := range t.Methods {
+		if method.Name() == name {
+			return method
+		}
+	}
+	return nil
+}
 
 type WeakAliasType struct {
 	Name           string
@@ -686,7 +1203,8 @@ func resolveAlias(alias Type, resolveStrongAlias bool) Type {
 }
 
 type TypeInterner struct {
-	types map[string]Type
+	types        map[string]Type
+	typeParamsID int
 }
 
 func NewTypeInterner() *TypeInterner {
@@ -696,7 +1214,12 @@ func NewTypeInterner() *TypeInterner {
 }
 
 func (t *TypeInterner) InternFuncType(args []Type, returns []Type) Type {
+	return t.InternGenericFuncType(nil, args, returns)
+}
+
+func (t *TypeInterner) InternGenericFuncType(typeParams []*TypeParamType, args []Type, returns []Type) Type {
 	funcType := FuncType{
+		TypeParams:     typeParams,
 		ParameterTypes: args,
 		ReturnTypes:    returns,
 	}
@@ -725,6 +1248,20 @@ func (t *TypeInterner) InternArrayType(length int, elementType Type) Type {
 	return &arrayType
 }
 
+func (t *TypeInterner) InternPointerType(elementType Type) Type {
+	pointerType := PointerType{
+		ElementType: elementType,
+	}
+	key := pointerType.HashKey()
+
+	if v, ok := t.types[key]; ok {
+		return v
+	}
+
+	t.types[key] = &pointerType
+	return &pointerType
+}
+
 func (t *TypeInterner) InternTupleType(types []Type) Type {
 	tupleType := TupleType{
 		Types: types,
@@ -770,3 +1307,92 @@ func (t *TypeInterner) InternWeakTypeAlias(name string, underlyingType Type) *We
 		UnderlyingType: underlyingType,
 	}
 }
+
+// NewTypeParam creates a new type parameter, type parameters are never interned since each one is distinct
+// even if it has the same name and constraint as another
+func (t *TypeInterner) NewTypeParam(name *IdentifierExpr, constraint *ConstraintType) *TypeParamType {
+	t.typeParamsID++
+	return &TypeParamType{
+		Name:       name.Token.Value(),
+		Identifier: name,
+		Constraint: constraint,
+		id:         t.typeParamsID,
+	}
+}
+
+func (t *TypeInterner) InternUnionConstraint(terms []Type) *ConstraintType {
+	constraint := ConstraintType{
+		Terms: terms,
+	}
+	key := constraint.HashKey()
+
+	if v, ok := t.types[key]; ok {
+		return v.(*ConstraintType)
+	}
+
+	t.types[key] = &constraint
+	return &constraint
+}
+
+// Substitute replaces the type parameters in the given type with their type arguments
+func (t *TypeInterner) Substitute(typ Type, typeArgs map[*TypeParamType]Type) Type {
+	substituteList := func(types []Type) []Type {
+		res := make([]Type, len(types))
+		for i, typ := range types {
+			res[i] = t.Substitute(typ, typeArgs)
+		}
+		return res
+	}
+
+	switch x := typ.(type) {
+	case *TypeParamType:
+		if arg, ok := typeArgs[x]; ok {
+			return arg
+		}
+		return x
+	case *ArrayType:
+		return t.InternArrayType(x.Length, t.Substitute(x.ElementType, typeArgs))
+	case *PointerType:
+		return t.InternPointerType(t.Substitute(x.ElementType, typeArgs))
+	case *TupleType:
+		return t.InternTupleType(substituteList(x.Types))
+	case *FuncType:
+		return t.InternGenericFuncType(x.TypeParams, substituteList(x.ParameterTypes), substituteList(x.ReturnTypes))
+	case *StructType:
+		names := make([]string, len(x.Fields))
+		for name, index := range x.FieldsByName {
+			names[index] = name
+		}
+		fields := make([]StructTypeField, len(x.Fields))
+		for i, field := range x.Fields {
+			fields[i] = StructTypeField{
+				Identifer: field.Identifer,
+				Type:      t.Substitute(field.Type, typeArgs),
+				Package:   field.Package,
+				Embedding: field.Embedding,
+			}
+		}
+		return t.InternStructType(names, fields)
+	default:
+		return typ
+	}
+}
+
+// Instantiate returns the signature of the generic function type with its type parameters replaced by
+// the given type arguments
+func (t *TypeInterner) Instantiate(funcType *FuncType, typeArgs []Type) *FuncType {
+	typeArgsByParam := make(map[*TypeParamType]Type, len(typeArgs))
+	for i, param := range funcType.TypeParams {
+		typeArgsByParam[param] = typeArgs[i]
+	}
+
+	parameterTypes := make([]Type, len(funcType.ParameterTypes))
+	for i, param := range funcType.ParameterTypes {
+		parameterTypes[i] = t.Substitute(param, typeArgsByParam)
+	}
+	returnTypes := make([]Type, len(funcType.ReturnTypes))
+	for i, ret := range funcType.ReturnTypes {
+		returnTypes[i] = t.Substitute(ret, typeArgsByParam)
+	}
+	return t.InternFuncType(parameterTypes, returnTypes).(*FuncType)
+}
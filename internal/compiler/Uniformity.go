@@ -0,0 +1,835 @@
+package compiler
+
+import (
+	"fmt"
+	"slices"
+)
+
+// uniformityNote is a link in the chain explaining why a value or the control flow may differ between invocations,
+// the chain goes from where the non-uniformity is observed back to where it comes from
+type uniformityNote struct {
+	sourceRange SourceRange
+	message     string
+	next        *uniformityNote
+}
+
+// concatNotes returns a copy of the chain followed by the tail
+func concatNotes(chain, tail *uniformityNote) *uniformityNote {
+	if chain == nil {
+		return tail
+	}
+	return &uniformityNote{sourceRange: chain.sourceRange, message: chain.message, next: concatNotes(chain.next, tail)}
+}
+
+// callSiteControlFlow is the index of the dependency on the control flow a function is called from
+const callSiteControlFlow = -1
+
+// paramDependency is a dependency on the value of a parameter of the function being analyzed, or on the control flow
+// the function is called from, the note chain goes from the dependent value to the parameter
+type paramDependency struct {
+	index int
+	note  *uniformityNote
+}
+
+// uniformity tells whether a value or the control flow is the same for all the invocations, it's non-uniform if
+// nonUniform is set, otherwise it's uniform as long as the parameters it depends on are uniform
+type uniformity struct {
+	nonUniform *uniformityNote
+	params     []paramDependency
+}
+
+// join returns the uniformity of something depending on both a and b, the first reason of non-uniformity is kept
+func join(a, b uniformity) uniformity {
+	switch {
+	case a.nonUniform != nil:
+		return uniformity{nonUniform: a.nonUniform}
+	case b.nonUniform != nil:
+		return uniformity{nonUniform: b.nonUniform}
+	}
+	res := uniformity{params: slices.Clip(a.params)}
+	for _, dep := range b.params {
+		if !slices.ContainsFunc(res.params, func(d paramDependency) bool { return d.index == dep.index }) {
+			res.params = append(res.params, dep)
+		}
+	}
+	return res
+}
+
+// via prefixes the note chains of u with the given note
+func via(u uniformity, sourceRange SourceRange, format string, a ...any) uniformity {
+	message := fmt.Sprintf(format, a...)
+	res := uniformity{}
+	if u.nonUniform != nil {
+		res.nonUniform = &uniformityNote{sourceRange: sourceRange, message: message, next: u.nonUniform}
+	}
+	for _, dep := range u.params {
+		res.params = append(res.params, paramDependency{
+			index: dep.index,
+			note:  &uniformityNote{sourceRange: sourceRange, message: message, next: dep.note},
+		})
+	}
+	return res
+}
+
+// prefixNotes prefixes the note chains of u with a copy of the given chain
+func prefixNotes(chain *uniformityNote, u uniformity) uniformity {
+	res := uniformity{}
+	if u.nonUniform != nil {
+		res.nonUniform = concatNotes(chain, u.nonUniform)
+	}
+	for _, dep := range u.params {
+		res.params = append(res.params, paramDependency{index: dep.index, note: concatNotes(chain, dep.note)})
+	}
+	return res
+}
+
+// sameUniformity compares the uniformities ignoring their notes, the analysis of loops stops once nothing changes
+func sameUniformity(a, b uniformity) bool {
+	if (a.nonUniform != nil) != (b.nonUniform != nil) || len(a.params) != len(b.params) {
+		return false
+	}
+	for _, dep := range a.params {
+		if !slices.ContainsFunc(b.params, func(d paramDependency) bool { return d.index == dep.index }) {
+			return false
+		}
+	}
+	return true
+}
+
+// uniformityEnv maps the local variables to the uniformity of their values, variables which aren't in the map are
+// uniform and a nil env means that the code being analyzed is unreachable
+type uniformityEnv map[*VarSymbol]uniformity
+
+func joinEnvs(a, b uniformityEnv) uniformityEnv {
+	if a == nil && b == nil {
+		return nil
+	}
+	res := make(uniformityEnv, len(a)+len(b))
+	for sym, u := range a {
+		res[sym] = u
+	}
+	for sym, u := range b {
+		res[sym] = join(res[sym], u)
+	}
+	return res
+}
+
+func sameEnvs(a, b uniformityEnv) bool {
+	if (a == nil) != (b == nil) {
+		return false
+	}
+	for sym, u := range a {
+		if !sameUniformity(u, b[sym]) {
+			return false
+		}
+	}
+	for sym, u := range b {
+		if !sameUniformity(u, a[sym]) {
+			return false
+		}
+	}
+	return true
+}
+
+// uniformityRequirement is a call of a builtin which requires uniform control flow, the control flow depends on the
+// parameters of the function containing it so it's checked at the call sites of the function
+type uniformityRequirement struct {
+	call        *CallExpr
+	builtin     BuiltinFunc
+	controlFlow uniformity
+}
+
+// uniformitySummary describes the effects of calling a function on uniformity in terms of its parameters
+type uniformitySummary struct {
+	result uniformity
+	// stores are the uniformity of the values stored through the pointer parameters by their indexes
+	stores map[int]uniformity
+	// discards is the uniformity of the control flow of the discard statements reachable from the function, the
+	// invocations which aren't discarded run the rest of the caller under it
+	discards     uniformity
+	requirements []*uniformityRequirement
+}
+
+// uniformityAnalysis checks that the builtins which require uniform control flow are only called from uniform
+// control flow, this follows the uniformity analysis of WGSL. values read from builtin inputs differ between
+// invocations and so does the control flow depending on them, functions are summarized before their callers in
+// terms of their parameters and the control flow they're called from
+type uniformityAnalysis struct {
+	checker   *Checker
+	summaries map[*FuncSymbol]*uniformitySummary
+	reported  map[*CallExpr]bool
+}
+
+// checkUniformity reports the builtins which require uniform control flow but may be called from non-uniform control
+// flow, the analysis needs a valid program so it's skipped if there are errors
+func (checker *Checker) checkUniformity() {
+	if checker.unit.HasErrors() {
+		return
+	}
+
+	a := &uniformityAnalysis{
+		checker:   checker,
+		summaries: make(map[*FuncSymbol]*uniformitySummary),
+		reported:  make(map[*CallExpr]bool),
+	}
+	var visit func(function *FuncSymbol)
+	visit = func(function *FuncSymbol) {
+		if _, ok := a.summaries[function]; ok {
+			return
+		}
+		a.summaries[function] = nil
+		for _, call := range checker.unit.semanticInfo.CallsOf(function) {
+			visit(call.Callee)
+		}
+		a.summaries[function] = a.summarize(function)
+	}
+
+	// functions are visited in declaration order to keep the reported errors stable
+	for _, pkg := range checker.unit.sortedPackages {
+		for _, sym := range checker.unit.semanticInfo.ScopeOf(pkg).Symbols {
+			switch sym := sym.(type) {
+			case *FuncSymbol:
+				visit(sym)
+			case *TypeSymbol:
+				for _, method := range sym.Methods {
+					visit(method)
+				}
+			}
+		}
+	}
+}
+
+func (a *uniformityAnalysis) report(call *CallExpr, builtin BuiltinFunc, reason *uniformityNote) {
+	if a.reported[call] {
+		return
+	}
+	a.reported[call] = true
+
+	err := NewError(call.SourceRange(), "'%v' must only be called from uniform control flow", builtin)
+	for note := reason; note != nil; note = note.next {
+		err = err.Note(note.sourceRange, "%v", note.message)
+	}
+	a.checker.error(err.WithCode(DiagnosticNonUniformCall))
+}
+
+func (a *uniformityAnalysis) summarize(function *FuncSymbol) *uniformitySummary {
+	w := &uniformityWalker{
+		uniformityAnalysis: a,
+		function:           function,
+		summary:            &uniformitySummary{stores: make(map[int]uniformity)},
+		pointerParams:      make(map[*VarSymbol]int),
+		buffers:            make(map[*VarSymbol]bool),
+		env:                make(uniformityEnv),
+		controlFlow:        uniformity{params: []paramDependency{{index: callSiteControlFlow}}},
+	}
+
+	info := a.checker.unit.semanticInfo
+	funcDecl := function.Decl().(*FuncDecl)
+	var fields []Field
+	if function.IsMethod() {
+		fields = append(fields, funcDecl.Receiver.Fields...)
+	}
+	fields = append(fields, funcDecl.Type.Parameters.Fields...)
+	index := 0
+	for _, field := range fields {
+		if len(field.Names) == 0 {
+			index++
+		}
+		for _, name := range field.Names {
+			if sym, ok := info.SymbolOfIdentifier(name).(*VarSymbol); ok {
+				w.env[sym] = uniformity{params: []paramDependency{{index: index}}}
+				if isPointer(info.TypeOf(sym).Type) && function.IsEntryPoint() {
+					// the other invocations may write the buffer at any time, like the read_write storage of WGSL
+					w.buffers[sym] = true
+					w.env[sym] = uniformity{nonUniform: &uniformityNote{
+						sourceRange: name.SourceRange(),
+						message:     fmt.Sprintf("buffer '%v' may be written by the other invocations", sym.Name()),
+					}}
+				} else if isPointer(info.TypeOf(sym).Type) {
+					w.pointerParams[sym] = index
+				}
+			}
+			index++
+		}
+	}
+	if funcDecl.Type.Result != nil {
+		for _, field := range funcDecl.Type.Result.Fields {
+			for _, name := range field.Names {
+				if sym, ok := info.SymbolOfIdentifier(name).(*VarSymbol); ok {
+					w.results = append(w.results, sym)
+				}
+			}
+		}
+	}
+
+	if funcDecl.Body != nil {
+		w.walkStmts(funcDecl.Body.Stmts)
+	}
+	return w.summary
+}
+
+// uniformityTarget is a loop or a switch statement targeted by break and continue statements
+type uniformityTarget struct {
+	stmt  Stmt
+	label string
+	// divergence is the uniformity of the control flow of the break and continue statements targeting the
+	// statement, the invocations which didn't leave run the rest of the statement under it
+	divergence  uniformity
+	breakEnv    uniformityEnv
+	continueEnv uniformityEnv
+}
+
+// uniformityWalker walks the body of a function to summarize it
+type uniformityWalker struct {
+	*uniformityAnalysis
+	function *FuncSymbol
+	summary  *uniformitySummary
+	// indexes of the pointer parameters, the env tracks the values they point to
+	pointerParams map[*VarSymbol]int
+	// buffer parameters of the entry point, their values stay non-uniform whatever is stored to them
+	buffers map[*VarSymbol]bool
+	// named results of the function
+	results     []*VarSymbol
+	env         uniformityEnv
+	controlFlow uniformity
+	// returned is the uniformity of the control flow of the return and discard statements walked so far, the
+	// invocations which didn't return run the rest of the function under it
+	returned uniformity
+	targets  []*uniformityTarget
+}
+
+// divergence returns the uniformity of the control flow of the statements which left early
+func (w *uniformityWalker) divergence() uniformity {
+	res := w.returned
+	for _, target := range w.targets {
+		res = join(res, target.divergence)
+	}
+	return res
+}
+
+func (w *uniformityWalker) walkStmts(stmts []Stmt) {
+	for _, stmt := range stmts {
+		// the rest of the statements are unreachable
+		if w.env == nil {
+			return
+		}
+		w.walkStmt(stmt)
+		w.controlFlow = join(w.controlFlow, w.divergence())
+	}
+}
+
+func (w *uniformityWalker) walkStmt(stmt Stmt) {
+	switch s := stmt.(type) {
+	case *ExprStmt:
+		w.value(s.Expr)
+	case *IncDecStmt:
+		w.assign(s.Expr, w.value(s.Expr), false)
+	case *AssignStmt:
+		w.walkAssignStmt(s)
+	case *DeclStmt:
+		w.walkDeclStmt(s)
+	case *BlockStmt:
+		w.walkStmts(s.Stmts)
+	case *IfStmt:
+		w.walkIfStmt(s)
+	case *ForStmt:
+		w.walkForStmt(s, "")
+	case *SwitchStmt:
+		w.walkSwitchStmt(s, "")
+	case *LabeledStmt:
+		switch inner := s.Stmt.(type) {
+		case *ForStmt:
+			w.walkForStmt(inner, s.Label.Value())
+		case *SwitchStmt:
+			w.walkSwitchStmt(inner, s.Label.Value())
+		default:
+			w.walkStmt(inner)
+		}
+	case *ReturnStmt:
+		w.walkReturnStmt(s)
+	case *BreakStmt:
+		target := w.targetOf(s.Label, false)
+		message := "some invocations may leave the switch here"
+		if _, ok := target.stmt.(*ForStmt); ok {
+			message = "some invocations may leave the loop here"
+		}
+		target.divergence = join(target.divergence, via(w.controlFlow, s.SourceRange(), "%v", message))
+		target.breakEnv = joinEnvs(target.breakEnv, w.env)
+		w.env = nil
+	case *ContinueStmt:
+		target := w.targetOf(s.Label, true)
+		target.divergence = join(target.divergence, via(w.controlFlow, s.SourceRange(), "some invocations may skip to the next iteration here"))
+		target.continueEnv = joinEnvs(target.continueEnv, w.env)
+		w.env = nil
+	case *DiscardStmt:
+		// discarded invocations leave the quad, so the rest of the function runs without them like after a return
+		discards := via(w.controlFlow, s.SourceRange(), "some invocations may be discarded here")
+		w.returned = join(w.returned, discards)
+		w.summary.discards = join(w.summary.discards, discards)
+		w.env = nil
+	}
+}
+
+// targetOf returns the statement the break or continue statement leaves
+func (w *uniformityWalker) targetOf(label Token, isContinue bool) *uniformityTarget {
+	for i := len(w.targets) - 1; i >= 0; i-- {
+		target := w.targets[i]
+		_, isLoop := target.stmt.(*ForStmt)
+		if label.Kind() == TokenIdentifier {
+			if target.label == label.Value() {
+				return target
+			}
+		} else if isLoop || !isContinue {
+			return target
+		}
+	}
+	panic("break or continue outside of loops and switches")
+}
+
+func (w *uniformityWalker) walkAssignStmt(s *AssignStmt) {
+	switch s.Operator.Kind() {
+	case TokenAssign, TokenColonAssign:
+		values := make([]uniformity, len(s.LHS))
+		if len(s.RHS) == 1 && len(s.LHS) > 1 {
+			value := w.value(s.RHS[0])
+			for i := range values {
+				values[i] = value
+			}
+		} else {
+			for i, rhs := range s.RHS {
+				values[i] = w.value(rhs)
+			}
+		}
+		for i, lhs := range s.LHS {
+			w.assign(lhs, values[i], false)
+		}
+	default:
+		value := join(w.value(s.LHS[0]), w.value(s.RHS[0]))
+		w.assign(s.LHS[0], value, false)
+	}
+}
+
+func (w *uniformityWalker) walkDeclStmt(s *DeclStmt) {
+	d := s.Decl.(*GenericDecl)
+	if d.DeclToken.Kind() != TokenVar {
+		return
+	}
+	for _, spec := range d.Specs {
+		spec := spec.(*ValueSpec)
+		values := make([]uniformity, len(spec.LHS))
+		if len(spec.RHS) == 1 && len(spec.LHS) > 1 {
+			value := w.value(spec.RHS[0])
+			for i := range values {
+				values[i] = value
+			}
+		} else {
+			for i, rhs := range spec.RHS {
+				values[i] = w.value(rhs)
+			}
+		}
+		for i, name := range spec.LHS {
+			w.assign(name, values[i], false)
+		}
+	}
+}
+
+func (w *uniformityWalker) walkIfStmt(s *IfStmt) {
+	if s.Init != nil {
+		w.walkStmt(s.Init)
+	}
+	cond := w.value(s.Cond)
+
+	outer := w.controlFlow
+	w.controlFlow = join(outer, via(cond, s.Cond.SourceRange(), "control flow depends on this non-uniform condition"))
+	entry := w.env
+	w.env = joinEnvs(entry, nil)
+	w.walkStmts(s.Body.Stmts)
+	thenEnv := w.env
+	w.env = joinEnvs(entry, nil)
+	if s.Else != nil {
+		w.walkStmt(s.Else)
+	}
+	w.env = joinEnvs(thenEnv, w.env)
+	w.controlFlow = join(outer, w.divergence())
+}
+
+// walkForStmt walks the loop until the uniformity of the variables at the start of an iteration stops changing
+func (w *uniformityWalker) walkForStmt(s *ForStmt, label string) {
+	if s.Init != nil {
+		w.walkStmt(s.Init)
+	}
+
+	outer := w.controlFlow
+	target := &uniformityTarget{stmt: s, label: label}
+	w.targets = append(w.targets, target)
+	headEnv := w.env
+	var exitEnv uniformityEnv
+	for {
+		before := w.divergence()
+		target.breakEnv, target.continueEnv = nil, nil
+		w.env = joinEnvs(headEnv, nil)
+		w.controlFlow = join(outer, before)
+
+		exitEnv = nil
+		if s.Cond != nil {
+			cond := w.value(s.Cond)
+			exitEnv = joinEnvs(w.env, nil)
+			w.controlFlow = join(w.controlFlow, via(cond, s.Cond.SourceRange(), "control flow depends on this non-uniform condition"))
+		}
+		w.walkStmts(s.Body.Stmts)
+		w.env = joinEnvs(w.env, target.continueEnv)
+		if w.env != nil && s.Post != nil {
+			w.walkStmt(s.Post)
+		}
+
+		next := joinEnvs(headEnv, w.env)
+		if sameEnvs(next, headEnv) && sameUniformity(before, w.divergence()) {
+			break
+		}
+		headEnv = next
+	}
+	w.targets = w.targets[:len(w.targets)-1]
+
+	w.env = joinEnvs(exitEnv, target.breakEnv)
+	w.controlFlow = join(outer, w.divergence())
+}
+
+func (w *uniformityWalker) walkSwitchStmt(s *SwitchStmt, label string) {
+	if s.Init != nil {
+		w.walkStmt(s.Init)
+	}
+	var selector uniformity
+	if s.Tag != nil {
+		selector = via(w.value(s.Tag), s.Tag.SourceRange(), "control flow depends on this non-uniform condition")
+	}
+
+	outer := w.controlFlow
+	target := &uniformityTarget{stmt: s, label: label}
+	w.targets = append(w.targets, target)
+	entry := w.env
+	var exitEnv, fallthroughEnv uniformityEnv
+	hasDefault := false
+	for _, stmt := range s.Body.Stmts {
+		clause := stmt.(*SwitchCaseStmt)
+		hasDefault = hasDefault || clause.Case.Kind() == TokenDefault
+		// the clause which runs depends on the values of the cases before it
+		w.env = joinEnvs(entry, nil)
+		w.controlFlow = outer
+		for _, e := range clause.LHS {
+			selector = join(selector, via(w.value(e), e.SourceRange(), "control flow depends on this non-uniform condition"))
+		}
+		w.env = joinEnvs(w.env, fallthroughEnv)
+		w.controlFlow = join(join(outer, selector), w.divergence())
+		w.walkStmts(clause.RHS)
+
+		fallthroughEnv = nil
+		if len(clause.RHS) > 0 {
+			if _, ok := clause.RHS[len(clause.RHS)-1].(*FallthroughStmt); ok {
+				fallthroughEnv = w.env
+				continue
+			}
+		}
+		exitEnv = joinEnvs(exitEnv, w.env)
+	}
+	if !hasDefault {
+		exitEnv = joinEnvs(exitEnv, entry)
+	}
+	w.targets = w.targets[:len(w.targets)-1]
+
+	w.env = joinEnvs(exitEnv, target.breakEnv)
+	w.controlFlow = join(outer, w.divergence())
+}
+
+func (w *uniformityWalker) walkReturnStmt(s *ReturnStmt) {
+	var value uniformity
+	for _, e := range s.Exprs {
+		value = join(value, w.value(e))
+	}
+	if len(s.Exprs) == 0 {
+		for _, sym := range w.results {
+			value = join(value, w.env[sym])
+		}
+	}
+
+	name := w.function.Name()
+	result := join(
+		via(value, s.SourceRange(), "'%v' returns a non-uniform value here", name),
+		via(w.controlFlow, s.SourceRange(), "'%v' returns from non-uniform control flow here", name),
+	)
+	w.summary.result = join(w.summary.result, result)
+	w.returned = join(w.returned, via(w.controlFlow, s.SourceRange(), "some invocations may return here"))
+	w.env = nil
+}
+
+// assign updates the uniformity of the variable holding the memory of lhs, the value is joined to the previous one
+// if only a part of the variable is assigned or if the assignment is weak
+func (w *uniformityWalker) assign(lhs Expr, value uniformity, weak bool) {
+	sym, whole, index := w.memoryOf(lhs)
+	if sym == nil || w.checker.isPackageLevel(sym) || w.env == nil {
+		return
+	}
+
+	name := sym.Name()
+	stored := join(
+		join(
+			via(value, lhs.SourceRange(), "'%v' is assigned a non-uniform value here", name),
+			via(index, lhs.SourceRange(), "'%v' is assigned at a non-uniform index here", name),
+		),
+		via(w.controlFlow, lhs.SourceRange(), "'%v' is assigned in non-uniform control flow here", name),
+	)
+	if weak || !whole || w.buffers[sym] {
+		stored = join(w.env[sym], stored)
+	}
+	w.env[sym] = stored
+	if index, ok := w.pointerParams[sym]; ok {
+		w.summary.stores[index] = join(w.summary.stores[index], stored)
+	}
+}
+
+// memoryOf returns the variable holding the memory of the addressable expression, whether the expression is the
+// whole variable and the uniformity of the indexes used to reach the memory. pointer parameters stand for the
+// memory they point to
+func (w *uniformityWalker) memoryOf(e Expr) (sym *VarSymbol, whole bool, index uniformity) {
+	info := w.checker.unit.semanticInfo
+	switch n := e.(type) {
+	case *IdentifierExpr:
+		sym, _ = info.SymbolOfIdentifier(n).(*VarSymbol)
+		return sym, true, index
+	case *ParenExpr:
+		return w.memoryOf(n.Base)
+	case *SelectorExpr:
+		// package level variables of imported packages
+		if sym, ok := info.SymbolOfIdentifier(n.Selector).(*VarSymbol); ok {
+			return sym, true, index
+		}
+		sym, _, index = w.memoryOf(n.Base)
+		return sym, false, index
+	case *IndexExpr:
+		sym, _, index = w.memoryOf(n.Base)
+		return sym, false, join(index, w.value(n.Index))
+	case *UnaryExpr:
+		if n.Operator.Kind() == TokenMul {
+			return w.memoryOf(n.Base)
+		}
+	}
+	return nil, false, index
+}
+
+// read returns the uniformity of the value of the variable, package level variables assigned by the shader may
+// hold different values for each invocation, and workgroup variables are written by the other invocations
+func (w *uniformityWalker) read(sym Symbol, e Expr) uniformity {
+	v, ok := sym.(*VarSymbol)
+	if !ok {
+		return uniformity{}
+	}
+	if v.Workgroup {
+		return uniformity{nonUniform: &uniformityNote{
+			sourceRange: e.SourceRange(),
+			message:     fmt.Sprintf("workgroup variable '%v' may be written by the other invocations of the workgroup", v.Name()),
+		}}
+	}
+	if w.checker.isPackageLevel(v) {
+		write, ok := w.checker.globalWrites[v]
+		if !ok {
+			return uniformity{}
+		}
+		return uniformity{nonUniform: &uniformityNote{
+			sourceRange: e.SourceRange(),
+			message:     fmt.Sprintf("package level variable '%v' may hold a different value for each invocation", v.Name()),
+			next: &uniformityNote{
+				sourceRange: write.SourceRange(),
+				message:     fmt.Sprintf("'%v' is assigned here", v.Name()),
+			},
+		}}
+	}
+	return w.env[v]
+}
+
+// value returns the uniformity of the value of the expression, walking the calls inside it
+func (w *uniformityWalker) value(expr Expr) uniformity {
+	info := w.checker.unit.semanticInfo
+	if tav := info.TypeOf(expr); tav != nil && (tav.Mode == AddressModeConstant || tav.IsType()) {
+		return uniformity{}
+	}
+
+	switch e := expr.(type) {
+	case *IdentifierExpr:
+		return w.read(info.SymbolOfIdentifier(e), e)
+	case *ParenExpr:
+		return w.value(e.Base)
+	case *SelectorExpr:
+		if sym := info.SymbolOfIdentifier(e.Selector); sym != nil {
+			return w.read(sym, e)
+		}
+		return w.value(e.Base)
+	case *IndexExpr:
+		// generic functions instantiated with explicit type arguments
+		if _, ok := w.checker.typeArgLists[e]; ok {
+			return uniformity{}
+		}
+		return join(w.value(e.Base), w.value(e.Index))
+	case *UnaryExpr:
+		return w.value(e.Base)
+	case *BinaryExpr:
+		lhs := w.value(e.LHS)
+		if kind := e.Operator.Kind(); kind != TokenLAnd && kind != TokenLOr {
+			return join(lhs, w.value(e.RHS))
+		}
+		// the right operand is only evaluated by some invocations if the left one is non-uniform
+		outer := w.controlFlow
+		w.controlFlow = join(outer, via(lhs, e.LHS.SourceRange(), "control flow depends on this non-uniform condition"))
+		rhs := w.value(e.RHS)
+		w.controlFlow = outer
+		return join(lhs, rhs)
+	case *CallExpr:
+		return w.call(e)
+	case *ComplitExpr:
+		var res uniformity
+		for _, element := range e.Elements {
+			res = join(res, w.value(element.Value))
+		}
+		return res
+	default:
+		return uniformity{}
+	}
+}
+
+func (w *uniformityWalker) call(e *CallExpr) uniformity {
+	info := w.checker.unit.semanticInfo
+	if isConversion(info.TypeOf(e.Base)) {
+		var res uniformity
+		for _, arg := range e.Args {
+			res = join(res, w.value(arg))
+		}
+		return res
+	}
+	if builtin := info.BuiltinOf(e); builtin != BuiltinFuncNone {
+		return w.builtinCall(e, builtin)
+	}
+
+	// arguments by the index of their parameters, receivers come first
+	args := e.Args
+	if selector, ok := e.Base.(*SelectorExpr); ok {
+		if method, ok := info.SymbolOfIdentifier(selector.Selector).(*FuncSymbol); ok && method.IsMethod() && !info.TypeOf(selector.Base).IsType() {
+			args = append([]Expr{selector.Base}, args...)
+		}
+	}
+	values := make([]uniformity, len(args))
+	for i, arg := range args {
+		values[i] = w.value(arg)
+	}
+	// a call returning multiple values passes all of them
+	valueOf := func(index int) (uniformity, Expr) {
+		if len(args) == 1 {
+			return values[0], args[0]
+		}
+		return values[index], args[index]
+	}
+
+	var callees []*FuncSymbol
+	if callee := w.checker.calleeOf(e.Base); callee != nil {
+		callees = append(callees, callee)
+	} else if w.checker.isFuncParam(e.Base) {
+		// calls through parameters of function type may call any of the functions bound to the caller
+		for _, bound := range w.checker.boundFuncs[w.function] {
+			if !slices.Contains(callees, bound.Callee) {
+				callees = append(callees, bound.Callee)
+			}
+		}
+	}
+
+	var res uniformity
+	for _, callee := range callees {
+		summary := w.summaries[callee]
+		if summary == nil {
+			continue
+		}
+
+		// substitute replaces the dependencies on the parameters of the callee by the uniformity of the arguments
+		substitute := func(u uniformity) uniformity {
+			res := uniformity{nonUniform: u.nonUniform}
+			for _, dep := range u.params {
+				var actual uniformity
+				if dep.index == callSiteControlFlow {
+					actual = via(w.controlFlow, e.SourceRange(), "'%v' is called from non-uniform control flow here", callee.Name())
+				} else if len(args) > 0 && (len(args) == 1 || dep.index < len(args)) {
+					value, arg := valueOf(dep.index)
+					actual = via(value, arg.SourceRange(), "'%v' is passed a non-uniform argument here", callee.Name())
+				}
+				res = join(res, prefixNotes(dep.note, actual))
+			}
+			return res
+		}
+
+		res = join(res, substitute(summary.result))
+		for index, stored := range summary.stores {
+			if index < len(args) && len(args) > 1 || index == 0 && len(args) == 1 {
+				w.storeThrough(args[index], substitute(stored))
+			}
+		}
+		if discards := via(substitute(summary.discards), e.SourceRange(), "'%v' is called here", callee.Name()); discards.nonUniform != nil || len(discards.params) > 0 {
+			w.returned = join(w.returned, discards)
+			w.summary.discards = join(w.summary.discards, discards)
+			w.controlFlow = join(w.controlFlow, discards)
+		}
+		for _, requirement := range summary.requirements {
+			w.require(requirement.call, requirement.builtin, substitute(requirement.controlFlow))
+		}
+	}
+	return res
+}
+
+// storeThrough assigns the value to the memory pointed to by the pointer argument
+func (w *uniformityWalker) storeThrough(arg Expr, value uniformity) {
+	if unary, ok := unparen(arg).(*UnaryExpr); ok && unary.Operator.Kind() == TokenAnd {
+		arg = unary.Base
+	}
+	w.assign(arg, value, true)
+}
+
+func (w *uniformityWalker) builtinCall(e *CallExpr, builtin BuiltinFunc) uniformity {
+	var value uniformity
+	for _, arg := range e.Args {
+		value = join(value, w.value(arg))
+	}
+	if builtin.RequiresUniformity() {
+		w.require(e, builtin, w.controlFlow)
+	}
+	if builtin.IsInput() {
+		return uniformity{nonUniform: &uniformityNote{
+			sourceRange: e.SourceRange(),
+			message:     fmt.Sprintf("'%v' returns a different value for each invocation", builtin),
+		}}
+	}
+	if builtin == BuiltinFuncImageLoad {
+		// like buffers the other invocations may write the image at any time
+		return uniformity{nonUniform: &uniformityNote{
+			sourceRange: e.SourceRange(),
+			message:     "the image may be written by the other invocations",
+		}}
+	}
+	return value
+}
+
+// require reports the builtin call if the control flow is non-uniform, if it depends on the parameters then it's
+// checked at the call sites of the function
+func (w *uniformityWalker) require(call *CallExpr, builtin BuiltinFunc, controlFlow uniformity) {
+	if controlFlow.nonUniform != nil {
+		w.report(call, builtin, controlFlow.nonUniform)
+		return
+	}
+	if len(controlFlow.params) == 0 {
+		return
+	}
+	for _, requirement := range w.summary.requirements {
+		if requirement.call == call {
+			requirement.controlFlow = join(requirement.controlFlow, controlFlow)
+			return
+		}
+	}
+	w.summary.requirements = append(w.summary.requirements, &uniformityRequirement{
+		call:        call,
+		builtin:     builtin,
+		controlFlow: controlFlow,
+	})
+}
@@ -1,13 +1,19 @@
 package compiler
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/MoustaphaSaad/sabre-go/internal/compiler/spirv"
+	"github.com/MoustaphaSaad/sabre-go/internal/stdlib"
 )
 
 type UnitFile struct {
@@ -18,7 +24,12 @@ type UnitFile struct {
 	tokens       []Token
 	errors       []Error
 	decls        []Decl
+	imports      []*ImportSpec
+	pkg          *Package
 	Package      *PackageClause
+	// suppressions maps a line to the warning codes suppressed on it using //sabre:ignore, an empty list
+	// suppresses all the warnings
+	suppressions map[int32][]DiagnosticCode
 }
 
 func UnitFileFromFile(path string) (unitFile *UnitFile, err error) {
@@ -32,28 +43,41 @@ func UnitFileFromFile(path string) (unitFile *UnitFile, err error) {
 	if err != nil {
 		return nil, err
 	}
-	contentStr := strings.ReplaceAll(string(content), "\r\n", "\n")
 
 	absolutePath, err := filepath.Abs(path)
 	if err != nil {
 		return nil, err
 	}
-	unitFile = &UnitFile{
+	return newUnitFile(path, absolutePath, content), nil
+}
+
+func newUnitFile(path, absolutePath string, content []byte) *UnitFile {
+	contentStr := strings.ReplaceAll(string(content), "\r\n", "\n")
+	return &UnitFile{
 		path:         path,
 		absolutePath: absolutePath,
 		content:      contentStr,
 		lines:        strings.Split(contentStr, "\n"),
 	}
-
-	return
 }
 
 func (u *UnitFile) error(e Error) {
 	u.errors = append(u.errors, e)
 }
 
+// isSuppressed returns whether the warning with the given code is suppressed at the given line
+func (u *UnitFile) isSuppressed(code DiagnosticCode, line int32) bool {
+	codes, ok := u.suppressions[line]
+	return ok && (len(codes) == 0 || slices.Contains(codes, code))
+}
+
 func (u *UnitFile) HasErrors() bool {
-	return len(u.errors) > 0
+	for _, e := range u.errors {
+		if e.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
 }
 
 func (u *UnitFile) Scan() bool {
@@ -73,15 +97,29 @@ func (u *UnitFile) Parse() bool {
 
 	u.Package = parser.ParsePackageClause()
 	if u.Package == nil {
-		u.error(NewError(parser.currentToken().SourceRange(), "file should start with package clause"))
+		u.error(NewError(parser.currentToken().SourceRange(), "file should start with package clause").WithCode(DiagnosticSyntaxError))
 		return false
 	}
 
+	seenNonImportDecl := false
 	for {
 		decl := parser.ParseDecl()
 		if decl == nil {
 			break
 		}
+
+		if genericDecl, ok := decl.(*GenericDecl); ok && genericDecl.DeclToken.Kind() == TokenImport {
+			if seenNonImportDecl {
+				u.error(NewError(genericDecl.SourceRange(), "imports must appear before other declarations").WithCode(DiagnosticSyntaxError))
+			}
+			for _, spec := range genericDecl.Specs {
+				if importSpec, ok := spec.(*ImportSpec); ok {
+					u.imports = append(u.imports, importSpec)
+				}
+			}
+		} else {
+			seenNonImportDecl = true
+		}
 		u.decls = append(u.decls, decl)
 	}
 	return !u.HasErrors()
@@ -102,43 +140,214 @@ const (
 	CompilationStageFailure
 )
 
+type Package struct {
+	Name    string
+	Path    string // import path, empty for the root package
+	Files   []*UnitFile
+	Imports []*Package
+
+	importState ResolveState
+}
+
+// searchPath is a location packages are imported from, it's either a directory on disk or an
+// embedded file system which is reported under the dir name
+type searchPath struct {
+	dir  string
+	fsys fs.FS
+}
+
+func (sp searchPath) readDir(importPath string) ([]fs.DirEntry, error) {
+	if sp.fsys != nil {
+		return fs.ReadDir(sp.fsys, importPath)
+	}
+	return os.ReadDir(filepath.Join(sp.dir, filepath.FromSlash(importPath)))
+}
+
+func (sp searchPath) loadFile(importPath, name string) (*UnitFile, error) {
+	if sp.fsys != nil {
+		content, err := fs.ReadFile(sp.fsys, path.Join(importPath, name))
+		if err != nil {
+			return nil, err
+		}
+		displayPath := path.Join(sp.dir, importPath, name)
+		return newUnitFile(displayPath, displayPath, content), nil
+	}
+	return UnitFileFromFile(filepath.ToSlash(filepath.Join(sp.dir, filepath.FromSlash(importPath), name)))
+}
+
+// loadPackage loads all the sabre files of the package, it returns a nil package if the import path
+// doesn't exist or has no sabre files
+func (sp searchPath) loadPackage(importPath string) (*Package, error) {
+	entries, err := sp.readDir(importPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	pkg := &Package{Path: importPath}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sabre") {
+			continue
+		}
+		file, err := sp.loadFile(importPath, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		file.pkg = pkg
+		pkg.Files = append(pkg.Files, file)
+	}
+
+	if len(pkg.Files) == 0 {
+		return nil, nil
+	}
+	return pkg, nil
+}
+
 type Unit struct {
 	compilationStage CompilationStage
 	rootFile         *UnitFile
-	semanticInfo     *SemanticInfo
+	rootPackage      *Package
+	// packages lists all the loaded packages in the order they were discovered, root package first
+	packages []*Package
+	// sortedPackages lists all the loaded packages with dependencies before their importers
+	sortedPackages  []*Package
+	packageByPath   map[string]*Package
+	packageOfImport map[*ImportSpec]*Package
+	searchPaths     []searchPath
+	semanticInfo    *SemanticInfo
+	diagnostics     DiagnosticOptions
 }
 
+func newUnit(rootPackage *Package) *Unit {
+	unit := &Unit{
+		compilationStage: CompilationStageStart,
+		rootFile:         rootPackage.Files[0],
+		rootPackage:      rootPackage,
+		packages:         []*Package{rootPackage},
+		packageByPath:    make(map[string]*Package),
+		packageOfImport:  make(map[*ImportSpec]*Package),
+	}
+	// the standard library comes first so that it can't be shadowed by local packages
+	unit.AddSearchFS("std", stdlib.FS)
+	return unit
+}
+
+// UnitFromFile creates a unit with a single file package, imports are searched for in the file's directory
 func UnitFromFile(path string) (unit *Unit, err error) {
 	unitFile, err := UnitFileFromFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	unit = &Unit{
-		compilationStage: CompilationStageStart,
-		rootFile:         unitFile,
+	rootPackage := &Package{Files: []*UnitFile{unitFile}}
+	unitFile.pkg = rootPackage
+
+	unit = newUnit(rootPackage)
+	unit.AddSearchPath(filepath.Dir(path))
+	return
+}
+
+// UnitFromPackage creates a unit from all the sabre files in the given directory, imports are searched
+// for in that directory
+func UnitFromPackage(dir string) (unit *Unit, err error) {
+	rootPackage, err := searchPath{dir: dir}.loadPackage(".")
+	if err != nil {
+		return nil, err
 	}
+	if rootPackage == nil {
+		return nil, fmt.Errorf("no sabre files found in '%v'", dir)
+	}
+	rootPackage.Path = ""
+
+	unit = newUnit(rootPackage)
+	unit.AddSearchPath(dir)
 	return
 }
 
+// SetDiagnosticOptions controls which warnings are reported by the checker
+func (u *Unit) SetDiagnosticOptions(options DiagnosticOptions) {
+	u.diagnostics = options
+}
+
+// AddSearchPath adds a directory to the list of directories imports are resolved from
+func (u *Unit) AddSearchPath(dir string) {
+	u.searchPaths = append(u.searchPaths, searchPath{dir: dir})
+}
+
+// AddSearchFS adds a file system to the list of locations imports are resolved from, files loaded from
+// it are reported under the given name
+func (u *Unit) AddSearchFS(name string, fsys fs.FS) {
+	u.searchPaths = append(u.searchPaths, searchPath{dir: name, fsys: fsys})
+}
+
 // RootFile returns the root file of the unit
 func (u *Unit) RootFile() *UnitFile {
 	return u.rootFile
 }
 
+// RootPackage returns the package the unit was created from
+func (u *Unit) RootPackage() *Package {
+	return u.rootPackage
+}
+
 func (u *Unit) HasErrors() bool {
-	return len(u.rootFile.errors) > 0
+	for _, pkg := range u.packages {
+		for _, file := range pkg.Files {
+			if file.HasErrors() {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func (u *Unit) PrintErrors(w io.Writer) {
-	for _, e := range u.rootFile.errors {
-		fmt.Fprintln(w, e)
+	for _, pkg := range u.packages {
+		for _, file := range pkg.Files {
+			for _, e := range file.errors {
+				fmt.Fprintln(w, e)
+			}
+		}
 	}
 }
 
+func scanPackage(pkg *Package) bool {
+	res := true
+	for _, file := range pkg.Files {
+		if !file.Scan() {
+			res = false
+		}
+	}
+	return res
+}
+
+func parsePackage(pkg *Package) bool {
+	res := true
+	for _, file := range pkg.Files {
+		if !file.Parse() {
+			res = false
+			continue
+		}
+
+		if pkg.Name == "" {
+			pkg.Name = file.Package.Name.Token.Value()
+		} else if name := file.Package.Name.Token.Value(); name != pkg.Name {
+			file.error(
+				NewError(file.Package.Name.SourceRange(), "expected package '%v' but found '%v'", pkg.Name, name).
+					Note(pkg.Files[0].Package.Name.SourceRange(), "package '%v' declared here", pkg.Name).
+					WithCode(DiagnosticImportError),
+			)
+			res = false
+		}
+	}
+	return res
+}
+
 func (u *Unit) Scan() bool {
 	if u.compilationStage == CompilationStageStart {
-		if u.rootFile.Scan() {
+		if scanPackage(u.rootPackage) {
 			u.compilationStage = CompilationStageScanned
 			return true
 		} else {
@@ -151,7 +360,7 @@ func (u *Unit) Scan() bool {
 
 func (u *Unit) Parse() bool {
 	if u.compilationStage == CompilationStageScanned {
-		if u.rootFile.Parse() {
+		if parsePackage(u.rootPackage) && u.loadImports(u.rootPackage, nil) {
 			u.compilationStage = CompliationStageParsed
 			return true
 		} else {
@@ -162,6 +371,113 @@ func (u *Unit) Parse() bool {
 	return !u.HasErrors()
 }
 
+// importEdge is a single import of the chain of imports that's currently being loaded
+type importEdge struct {
+	importer *Package
+	spec     *ImportSpec
+}
+
+func importPathOf(spec *ImportSpec) string {
+	importPath, err := strconv.Unquote(spec.Path.Value())
+	if err != nil {
+		return spec.Path.Value()
+	}
+	return importPath
+}
+
+// isValidImportPath returns whether the import path names a package inside the search paths, relative elements would
+// escape them and absolute paths aren't looked up in them
+func isValidImportPath(importPath string) bool {
+	if strings.HasPrefix(importPath, "/") || strings.Contains(importPath, "\\") {
+		return false
+	}
+	for _, element := range strings.Split(importPath, "/") {
+		if element == "" || element == "." || element == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func (u *Unit) findPackage(importPath string) (*Package, error) {
+	for _, sp := range u.searchPaths {
+		pkg, err := sp.loadPackage(importPath)
+		if err != nil {
+			return nil, err
+		}
+		if pkg != nil {
+			return pkg, nil
+		}
+	}
+	return nil, nil
+}
+
+// loadImports loads the packages imported by pkg recursively, scanning and parsing them along the way
+func (u *Unit) loadImports(pkg *Package, chain []importEdge) bool {
+	res := true
+	pkg.importState = ResolveStateResolving
+	for _, file := range pkg.Files {
+		for _, spec := range file.imports {
+			importPath := importPathOf(spec)
+			if importPath == "" {
+				file.error(NewError(spec.Path.SourceRange(), "invalid import path").WithCode(DiagnosticImportError))
+				res = false
+				continue
+			}
+			if !isValidImportPath(importPath) {
+				file.error(NewError(spec.Path.SourceRange(), "invalid import path '%v', import paths can't be absolute or contain '.' or '..' elements", importPath).WithCode(DiagnosticImportError))
+				res = false
+				continue
+			}
+
+			dep, ok := u.packageByPath[importPath]
+			if ok && dep.importState == ResolveStateResolving {
+				err := NewError(spec.Path.SourceRange(), "import cycle not allowed")
+				cycleStart := slices.IndexFunc(chain, func(edge importEdge) bool { return edge.importer == dep })
+				if cycleStart != -1 {
+					for _, edge := range chain[cycleStart:] {
+						err = err.Note(edge.spec.Path.SourceRange(), "package '%v' imports '%v'", edge.importer.Name, importPathOf(edge.spec))
+					}
+				}
+				err = err.Note(spec.Path.SourceRange(), "package '%v' imports '%v'", pkg.Name, importPath)
+				file.error(err.WithCode(DiagnosticImportError))
+				res = false
+				continue
+			}
+
+			if !ok {
+				var loadErr error
+				dep, loadErr = u.findPackage(importPath)
+				if loadErr != nil {
+					file.error(NewError(spec.Path.SourceRange(), "failed to load package '%v': %v", importPath, loadErr).WithCode(DiagnosticImportError))
+					res = false
+					continue
+				}
+				if dep == nil {
+					file.error(NewError(spec.Path.SourceRange(), "package '%v' not found", importPath).WithCode(DiagnosticImportError))
+					res = false
+					continue
+				}
+
+				u.packageByPath[importPath] = dep
+				u.packages = append(u.packages, dep)
+				if !scanPackage(dep) || !parsePackage(dep) || !u.loadImports(dep, append(chain, importEdge{pkg, spec})) {
+					res = false
+					continue
+				}
+			}
+
+			u.packageOfImport[spec] = dep
+			if !slices.Contains(pkg.Imports, dep) {
+				pkg.Imports = append(pkg.Imports, dep)
+			}
+		}
+	}
+	pkg.importState = ResolveStateResolved
+	u.sortedPackages = append(u.sortedPackages, pkg)
+	return res
+}
+
 func (u *Unit) Check() bool {
 	if u.compilationStage == CompliationStageParsed {
 		checker := NewChecker(u)
@@ -176,11 +492,103 @@ func (u *Unit) Check() bool {
 	return !u.HasErrors()
 }
 
-func (u *Unit) EmitSPIRV() *spirv.Module {
+// rootFuncs returns the functions the backends start emitting from, the entry points of the unit. units without
+// entry points are libraries which export the functions of their root package, or all of them if none is exported.
+// generic functions and functions taking functions are only emitted as instances at their call sites
+func (u *Unit) rootFuncs() []*FuncSymbol {
+	info := u.semanticInfo
+	if len(info.EntryPoints) > 0 {
+		return info.EntryPoints
+	}
+	var funcs, exported []*FuncSymbol
+	for _, sym := range info.ReachableSymbols {
+		f, ok := sym.(*FuncSymbol)
+		if !ok || f.SourceRange().File.pkg != u.rootPackage {
+			continue
+		}
+		if funcType := info.TypeOf(f).Type.(*FuncType); !funcType.IsGeneric() && !hasFuncParams(funcType) {
+			funcs = append(funcs, f)
+			if isExported(f.Name()) && !f.IsMethod() {
+				exported = append(exported, f)
+			}
+		}
+	}
+	if len(exported) > 0 {
+		return exported
+	}
+	return funcs
+}
+
+// emittedFuncs returns the functions reachable from the root functions, the functions of the imported packages and
+// of the root package which aren't called are left out
+func (u *Unit) emittedFuncs() map[*FuncSymbol]bool {
+	emitted := make(map[*FuncSymbol]bool)
+	for _, root := range u.rootFuncs() {
+		order, _ := u.semanticInfo.reachableFrom(root)
+		for _, f := range order {
+			emitted[f] = true
+		}
+	}
+	return emitted
+}
+
+func (u *Unit) EmitSPIRV(options EmitOptions) *spirv.Module {
 	if u.compilationStage == CompilationStageChecked {
 		u.compilationStage = CompilationStagedEmitted
-		emitter := NewIREmitter(u)
+		emitter := NewIREmitter(u, options)
 		return emitter.Emit()
 	}
 	return nil
 }
+
+// EmitGLSL translates the checked unit to GLSL source, constructs which GLSL can't express and missing or ambiguous
+// entry points are reported as errors
+func (u *Unit) EmitGLSL(options GLSLOptions) string {
+	if u.compilationStage == CompilationStageChecked {
+		u.compilationStage = CompilationStagedEmitted
+		emitter := NewGLSLEmitter(u, options)
+		return emitter.Emit()
+	}
+	return ""
+}
+
+// EmitHLSL translates the checked unit to HLSL source, constructs which HLSL can't express are reported as errors
+func (u *Unit) EmitHLSL() string {
+	if u.compilationStage == CompilationStageChecked {
+		u.compilationStage = CompilationStagedEmitted
+		emitter := NewHLSLEmitter(u)
+		return emitter.Emit()
+	}
+	return ""
+}
+
+// EmitMSL translates the checked unit to Metal Shading Language source, constructs which MSL can't express are
+// reported as errors
+func (u *Unit) EmitMSL() string {
+	if u.compilationStage == CompilationStageChecked {
+		u.compilationStage = CompilationStagedEmitted
+		emitter := NewMSLEmitter(u)
+		return emitter.Emit()
+	}
+	return ""
+}
+
+// EmitGo translates the checked unit to the source of a Go package which runs the shaders on the CPU
+func (u *Unit) EmitGo(options GoOptions) string {
+	if u.compilationStage == CompilationStageChecked {
+		u.compilationStage = CompilationStagedEmitted
+		emitter := NewGoEmitter(u, options)
+		return emitter.Emit()
+	}
+	return ""
+}
+
+// EmitWGSL translates the checked unit to WGSL source, constructs which WGSL can't express are reported as errors
+func (u *Unit) EmitWGSL() string {
+	if u.compilationStage == CompilationStageChecked {
+		u.compilationStage = CompilationStagedEmitted
+		emitter := NewWGSLEmitter(u)
+		return emitter.Emit()
+	}
+	return ""
+}
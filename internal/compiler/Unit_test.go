@@ -1,9 +1,12 @@
 package compiler
 
 import (
+	"io/fs"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/MoustaphaSaad/sabre-go/internal/stdlib"
 )
 
 func TestUnitFileFromFile(t *testing.T) {
@@ -182,3 +185,57 @@ func TestUnitFromFile(t *testing.T) {
 		})
 	}
 }
+
+func TestUnitFromPackage(t *testing.T) {
+	unit, err := UnitFromPackage(filepath.Join("testdata", "Unit", "package"))
+	if err != nil {
+		t.Fatalf("UnitFromPackage() unexpected error: %v", err)
+	}
+
+	// only .sabre files are loaded
+	if len(unit.rootPackage.Files) != 2 {
+		t.Fatalf("UnitFromPackage() files count = %d, want 2", len(unit.rootPackage.Files))
+	}
+
+	if !unit.Scan() || !unit.Parse() {
+		t.Fatalf("UnitFromPackage() failed to scan and parse the package")
+	}
+
+	if unit.rootPackage.Name != "shapes" {
+		t.Errorf("UnitFromPackage() package name = %q, want %q", unit.rootPackage.Name, "shapes")
+	}
+
+	if _, err := UnitFromPackage(t.TempDir()); err == nil {
+		t.Errorf("UnitFromPackage() expected error for a directory without sabre files, but got none")
+	}
+
+	if _, err := UnitFromPackage(filepath.Join("testdata", "Unit", "non_existent")); err == nil {
+		t.Errorf("UnitFromPackage() expected error for non-existent directory, but got none")
+	}
+}
+
+func TestStdlibPackages(t *testing.T) {
+	entries, err := fs.ReadDir(stdlib.FS, ".")
+	if err != nil {
+		t.Fatalf("ReadDir() unexpected error: %v", err)
+	}
+
+	for _, entry := range entries {
+		t.Run(entry.Name(), func(t *testing.T) {
+			unit, err := UnitFromPackage(filepath.Join("..", "stdlib", entry.Name()))
+			if err != nil {
+				t.Fatalf("UnitFromPackage() unexpected error: %v", err)
+			}
+
+			if !unit.Scan() || !unit.Parse() || !unit.Check() {
+				var errors strings.Builder
+				unit.PrintErrors(&errors)
+				t.Fatalf("package '%v' has errors:\n%v", entry.Name(), errors.String())
+			}
+
+			if unit.rootPackage.Name != entry.Name() {
+				t.Errorf("package name = %q, want %q", unit.rootPackage.Name, entry.Name())
+			}
+		})
+	}
+}
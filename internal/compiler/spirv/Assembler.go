@@ -0,0 +1,1250 @@
+package spirv
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// AssemblerError reports where the assembly text is malformed
+type AssemblerError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *AssemblerError) Error() string {
+	return fmt.Sprintf("%v:%v: %v", e.Line, e.Column, e.Message)
+}
+
+type asmTokenKind int
+
+const (
+	asmTokenWord asmTokenKind = iota
+	asmTokenID
+	asmTokenString
+	asmTokenEqual
+)
+
+type asmToken struct {
+	kind   asmTokenKind
+	value  string
+	line   int
+	column int
+}
+
+// text returns the token as it's written in the source
+func (t asmToken) text() string {
+	switch t.kind {
+	case asmTokenID:
+		return "%" + t.value
+	case asmTokenString:
+		return strconv.Quote(t.value)
+	default:
+		return t.value
+	}
+}
+
+type asmInstruction struct {
+	result   *asmToken
+	opcode   asmToken
+	operands []asmToken
+}
+
+type assembler struct {
+	module       *Module
+	instructions []*asmInstruction
+	// ids maps the names used in the text to their ids, names ending with _<number> keep that number as their id
+	ids map[string]ID
+	// instruction being assembled and its next operand
+	current *asmInstruction
+	next    int
+	// function and block the instructions are added to
+	function      *Function
+	functionStart asmToken
+	block         *Block
+	// entry points and execution modes refer to functions which are declared after them
+	deferred []func()
+	err      *AssemblerError
+}
+
+// Assemble parses the assembly written by TextPrinter into a module, ids named %<name>_<number> keep their number and
+// other names are given fresh ids, printing the assembled module gives back the names as they're written. The IR
+// has no debug information nor phi instructions so OpName, OpMemberName, OpString, OpSource, OpLine and OpPhi are
+// reported as unsupported
+func Assemble(source string) (*Module, error) {
+	a := &assembler{
+		module: NewModule(AddressingModelLogical, MemoryModelGLSL450),
+		ids:    make(map[string]ID),
+	}
+	a.lex(source)
+	if a.err == nil {
+		a.defineIDs()
+	}
+	for _, in := range a.instructions {
+		if a.err != nil {
+			break
+		}
+		a.current, a.next = in, 0
+		a.assembleInstruction()
+		if a.err == nil && a.next < len(in.operands) {
+			a.errorf(in.operands[a.next], "unexpected operand '%v'", in.operands[a.next].text())
+		}
+	}
+	if a.err == nil && a.function != nil {
+		a.errorf(a.functionStart, "function '%v' has no OpFunctionEnd", a.function.Name())
+	}
+	for _, f := range a.deferred {
+		if a.err != nil {
+			break
+		}
+		f()
+	}
+	if a.err != nil {
+		return nil, a.err
+	}
+	return a.module, nil
+}
+
+func (a *assembler) errorf(tok asmToken, format string, args ...any) {
+	if a.err == nil {
+		a.err = &AssemblerError{
+			Line:    tok.line,
+			Column:  tok.column,
+			Message: fmt.Sprintf(format, args...),
+		}
+	}
+}
+
+func (a *assembler) lex(source string) {
+	for lineIndex, line := range strings.Split(source, "\n") {
+		var tokens []asmToken
+		for i := 0; i < len(line); {
+			c := line[i]
+			tok := asmToken{line: lineIndex + 1, column: i + 1}
+			switch {
+			case c == ' ' || c == '\t' || c == '\r':
+				i++
+				continue
+			case c == ';':
+				// comments run to the end of the line
+				i = len(line)
+				continue
+			case c == '=':
+				tok.kind, tok.value = asmTokenEqual, "="
+				i++
+			case c == '"':
+				quoted, err := strconv.QuotedPrefix(line[i:])
+				if err != nil {
+					a.errorf(tok, "unterminated string")
+					return
+				}
+				tok.kind = asmTokenString
+				tok.value, _ = strconv.Unquote(quoted)
+				i += len(quoted)
+			default:
+				end := i
+				for end < len(line) && !strings.ContainsRune(" \t\r;=\"", rune(line[end])) {
+					end++
+				}
+				tok.kind, tok.value = asmTokenWord, line[i:end]
+				if c == '%' {
+					tok.kind, tok.value = asmTokenID, line[i+1:end]
+					if !isIDName(tok.value) {
+						a.errorf(tok, "invalid id '%v', names can only contain letters, digits and '_'", line[i:end])
+						return
+					}
+				}
+				i = end
+			}
+			tokens = append(tokens, tok)
+		}
+		if len(tokens) > 0 {
+			a.parseInstruction(tokens)
+		}
+	}
+}
+
+func isIDName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for _, c := range name {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *assembler) parseInstruction(tokens []asmToken) {
+	in := &asmInstruction{}
+	if len(tokens) > 1 && tokens[1].kind == asmTokenEqual {
+		if tokens[0].kind != asmTokenID {
+			a.errorf(tokens[0], "expected a result id but found '%v'", tokens[0].text())
+			return
+		}
+		in.result = &tokens[0]
+		tokens = tokens[2:]
+		if len(tokens) == 0 {
+			a.errorf(*in.result, "expected an opcode after '%%%v ='", in.result.value)
+			return
+		}
+	}
+
+	in.opcode = tokens[0]
+	if in.opcode.kind != asmTokenWord || !strings.HasPrefix(in.opcode.value, "Op") {
+		a.errorf(in.opcode, "expected an opcode but found '%v'", in.opcode.text())
+		return
+	}
+	if reason, ok := unsupportedOpcodes[in.opcode.value]; ok {
+		a.errorf(in.opcode, "%v is not supported, %v", in.opcode.value, reason)
+		return
+	}
+	if _, ok := opcodesByName[in.opcode.value]; !ok {
+		a.errorf(in.opcode, "unknown opcode '%v'", in.opcode.value)
+		return
+	}
+	for _, tok := range tokens[1:] {
+		if tok.kind == asmTokenEqual {
+			a.errorf(tok, "unexpected '='")
+			return
+		}
+	}
+	in.operands = tokens[1:]
+	a.instructions = append(a.instructions, in)
+}
+
+// splitIDName splits a name into the object's name and the number at its end, the text printer names objects
+// %<name>_<id> and unnamed values %_<id>
+func splitIDName(name string) (string, ID, bool) {
+	index := strings.LastIndexByte(name, '_')
+	id, err := strconv.ParseInt(name[index+1:], 10, 32)
+	if err != nil || id <= 0 {
+		return name, 0, false
+	}
+	return name[:max(index, 0)], ID(id), true
+}
+
+// defineIDs gives every result its id before the instructions are assembled, so instructions can refer to the
+// results of later ones like branches to the blocks after them
+func (a *assembler) defineIDs() {
+	definitions := make(map[string]asmToken)
+	owners := make(map[ID]string)
+	var unnumbered []string
+	for _, in := range a.instructions {
+		if in.result == nil {
+			continue
+		}
+		name := in.result.value
+		if previous, ok := definitions[name]; ok {
+			a.errorf(*in.result, "'%%%v' is already defined at %v:%v", name, previous.line, previous.column)
+			return
+		}
+		definitions[name] = *in.result
+
+		_, id, ok := splitIDName(name)
+		if !ok {
+			unnumbered = append(unnumbered, name)
+			continue
+		}
+		if owner, ok := owners[id]; ok {
+			a.errorf(*in.result, "id %v of '%%%v' is already used by '%%%v'", id, name, owner)
+			return
+		}
+		owners[id] = name
+		a.ids[name] = id
+		a.module.idGenerator = max(a.module.idGenerator, int(id))
+	}
+	for _, name := range unnumbered {
+		a.ids[name] = a.module.NewID()
+	}
+	a.module.textNames = make(map[ID]string, len(a.ids))
+	for name, id := range a.ids {
+		a.module.textNames[id] = name
+	}
+}
+
+func (a *assembler) opcode() Opcode {
+	return opcodesByName[a.current.opcode.value]
+}
+
+func (a *assembler) operand(what string) (asmToken, bool) {
+	if a.err != nil {
+		return asmToken{}, false
+	}
+	if a.next >= len(a.current.operands) {
+		a.errorf(a.current.opcode, "%v is missing its %v", a.current.opcode.value, what)
+		return asmToken{}, false
+	}
+	tok := a.current.operands[a.next]
+	a.next++
+	return tok, true
+}
+
+func (a *assembler) hasOperand() bool {
+	return a.err == nil && a.next < len(a.current.operands)
+}
+
+func (a *assembler) id(what string) ID {
+	tok, ok := a.operand(what)
+	if !ok {
+		return 0
+	}
+	if tok.kind != asmTokenID {
+		a.errorf(tok, "expected an id for the %v but found '%v'", what, tok.text())
+		return 0
+	}
+	id, ok := a.ids[tok.value]
+	if !ok {
+		a.errorf(tok, "'%%%v' is not defined", tok.value)
+		return 0
+	}
+	return id
+}
+
+func (a *assembler) idList(what string) []ID {
+	var ids []ID
+	for a.hasOperand() {
+		ids = append(ids, a.id(what))
+	}
+	return ids
+}
+
+// object returns the object an operand refers to, module level objects have to be declared before they're used
+func (a *assembler) object(what string) (Object, asmToken) {
+	id := a.id(what)
+	if a.err != nil {
+		return nil, asmToken{}
+	}
+	tok := a.current.operands[a.next-1]
+	obj := a.module.GetObject(id)
+	if obj == nil {
+		a.errorf(tok, "'%%%v' is used before it's declared", tok.value)
+	}
+	return obj, tok
+}
+
+func (a *assembler) typ(what string) Type {
+	obj, tok := a.object(what)
+	if obj == nil {
+		return nil
+	}
+	t, ok := obj.(Type)
+	if !ok {
+		a.errorf(tok, "'%%%v' is not a type", tok.value)
+	}
+	return t
+}
+
+func (a *assembler) constant(what string) ConstantValue {
+	obj, tok := a.object(what)
+	if obj == nil {
+		return nil
+	}
+	c, ok := obj.(ConstantValue)
+	if !ok {
+		a.errorf(tok, "'%%%v' is not a constant", tok.value)
+	}
+	return c
+}
+
+func (a *assembler) word(what string) Word {
+	tok, ok := a.operand(what)
+	if !ok {
+		return 0
+	}
+	value, err := strconv.ParseUint(tok.value, 0, 32)
+	if tok.kind != asmTokenWord || err != nil {
+		a.errorf(tok, "expected a 32-bit number for the %v but found '%v'", what, tok.text())
+	}
+	return Word(value)
+}
+
+func (a *assembler) words(what string) []Word {
+	var words []Word
+	for a.hasOperand() {
+		words = append(words, a.word(what))
+	}
+	return words
+}
+
+func (a *assembler) str(what string) string {
+	tok, ok := a.operand(what)
+	if !ok {
+		return ""
+	}
+	if tok.kind != asmTokenString {
+		a.errorf(tok, "expected a string for the %v but found '%v'", what, tok.text())
+	}
+	return tok.value
+}
+
+func enumerant[T any](a *assembler, names map[string]T, what string) T {
+	var value T
+	tok, ok := a.operand(what)
+	if !ok {
+		return value
+	}
+	value, ok = names[tok.value]
+	if tok.kind != asmTokenWord || !ok {
+		a.errorf(tok, "unknown %v '%v'", what, tok.text())
+	}
+	return value
+}
+
+// result returns the id and name of the instruction's result, the name loses the prefix the text printer adds for
+// its kind of object
+func (a *assembler) result(kindPrefix string) (ID, string) {
+	if a.current.result == nil {
+		a.errorf(a.current.opcode, "%v needs a result id", a.current.opcode.value)
+		return 0, ""
+	}
+	name, _, _ := splitIDName(a.current.result.value)
+	name, _ = strings.CutPrefix(name, kindPrefix)
+	return a.ids[a.current.result.value], name
+}
+
+func (a *assembler) noResult() {
+	if a.current.result != nil {
+		a.errorf(*a.current.result, "%v has no result", a.current.opcode.value)
+	}
+}
+
+func (a *assembler) outsideFunction() {
+	if a.function != nil {
+		a.errorf(a.current.opcode, "%v must be outside of functions", a.current.opcode.value)
+	}
+}
+
+func (a *assembler) insideBlock() {
+	if a.block == nil {
+		a.errorf(a.current.opcode, "%v must be inside a block", a.current.opcode.value)
+	}
+}
+
+func (a *assembler) assembleInstruction() {
+	op := a.opcode()
+	switch op {
+	case OpCapability, OpExtension, OpMemoryModel, OpEntryPoint, OpExecutionMode:
+		a.noResult()
+		a.outsideFunction()
+		a.assembleModeSetting(op)
+	case OpExtInstImport:
+		a.outsideFunction()
+		a.assembleExtInstImport()
+	case OpTypeVoid, OpTypeBool, OpTypeInt, OpTypeFloat, OpTypeVector, OpTypeArray, OpTypeStruct, OpTypePointer,
+		OpTypeImage, OpTypeSampledImage, OpTypeFunction:
+		a.outsideFunction()
+		a.assembleType(op)
+	case OpConstantTrue, OpConstantFalse, OpConstant, OpConstantComposite:
+		a.outsideFunction()
+		a.assembleConstant(op)
+	case OpDecorate:
+		a.noResult()
+		a.outsideFunction()
+		a.assembleDecoration()
+	case OpMemberDecorate:
+		a.noResult()
+		a.outsideFunction()
+		a.assembleMemberDecoration()
+	case OpFunction, OpFunctionParameter, OpFunctionEnd, OpLabel:
+		a.assembleFunction(op)
+	case OpVariable:
+		// variables outside of functions are global variables
+		if a.function == nil {
+			a.assembleGlobalVariable()
+			return
+		}
+		a.insideBlock()
+		if a.err == nil {
+			a.assembleBlockInstruction(op)
+		}
+	default:
+		a.insideBlock()
+		if a.err == nil {
+			a.assembleBlockInstruction(op)
+		}
+	}
+}
+
+func (a *assembler) assembleModeSetting(op Opcode) {
+	switch op {
+	case OpCapability:
+		capability := enumerant(a, capabilitiesByName, "capability")
+		if a.err == nil {
+			a.module.AddCapability(capability)
+		}
+	case OpExtension:
+		extension := a.str("extension name")
+		if a.err == nil {
+			a.module.AddExtension(extension)
+		}
+	case OpMemoryModel:
+		addressingModel := enumerant(a, addressingModelsByName, "addressing model")
+		memoryModel := enumerant(a, memoryModelsByName, "memory model")
+		a.module.AddressingModel = addressingModel
+		a.module.MemoryModel = memoryModel
+	case OpEntryPoint:
+		model := enumerant(a, executionModelsByName, "execution model")
+		function := a.deferFunction()
+		name := a.str("entry point name")
+		var interfaceVariables []func() *Variable
+		for a.hasOperand() {
+			interfaceVariables = append(interfaceVariables, a.deferVariable("interface variable"))
+		}
+		entryPoint := a.module.AddEntryPoint(model, nil, name)
+		a.deferred = append(a.deferred, func() {
+			entryPoint.Function = function()
+			for _, variable := range interfaceVariables {
+				entryPoint.Interface = append(entryPoint.Interface, variable())
+			}
+		})
+	case OpExecutionMode:
+		function := a.deferFunction()
+		mode := enumerant(a, executionModesByName, "execution mode")
+		a.module.AddExecutionMode(nil, mode, a.words("execution mode literal")...)
+		executionMode := a.module.executionModes[len(a.module.executionModes)-1]
+		a.deferred = append(a.deferred, func() { executionMode.Function = function() })
+	}
+}
+
+// deferFunction reads a function operand which is resolved after the whole module is assembled, since entry points
+// come before the functions they refer to
+func (a *assembler) deferFunction() func() *Function {
+	id := a.id("function")
+	if a.err != nil {
+		return nil
+	}
+	tok := a.current.operands[a.next-1]
+	return func() *Function {
+		function, ok := a.module.GetObject(id).(*Function)
+		if !ok {
+			a.errorf(tok, "'%%%v' is not a function", tok.value)
+		}
+		return function
+	}
+}
+
+// deferVariable reads a global variable operand which is resolved after the whole module is assembled, since entry
+// points and decorations come before the variables they refer to
+func (a *assembler) deferVariable(what string) func() *Variable {
+	id := a.id(what)
+	if a.err != nil {
+		return nil
+	}
+	tok := a.current.operands[a.next-1]
+	return func() *Variable {
+		variable, ok := a.module.GetObject(id).(*Variable)
+		if !ok || !slices.Contains(a.module.globals, variable) {
+			a.errorf(tok, "'%%%v' is not a global variable", tok.value)
+		}
+		return variable
+	}
+}
+
+func (a *assembler) assembleDecoration() {
+	target := a.id("target")
+	if a.err != nil {
+		return
+	}
+	targetToken := a.current.operands[a.next-1]
+	decoration := &DecorateInstruction{Target: target, Decoration: enumerant(a, decorationsByName, "decoration")}
+	switch decoration.Decoration {
+	case DecorationBuiltIn:
+		decoration.Literals = append(decoration.Literals, Word(enumerant(a, builtInsByName, "builtin")))
+	case DecorationBinding:
+		decoration.Literals = append(decoration.Literals, a.word("binding point"))
+	case DecorationDescriptorSet:
+		decoration.Literals = append(decoration.Literals, a.word("descriptor set"))
+	case DecorationArrayStride:
+		decoration.Literals = append(decoration.Literals, a.word("array stride"))
+	case DecorationLocation:
+		decoration.Literals = append(decoration.Literals, a.word("location"))
+	}
+	if a.err != nil {
+		return
+	}
+	a.module.decorations = append(a.module.decorations, decoration)
+	// decorations come before the objects they decorate
+	a.deferred = append(a.deferred, func() {
+		if a.module.GetObject(target) == nil {
+			a.errorf(targetToken, "'%%%v' is not declared", targetToken.value)
+		}
+	})
+}
+
+func (a *assembler) assembleMemberDecoration() {
+	target := a.id("target")
+	if a.err != nil {
+		return
+	}
+	targetToken := a.current.operands[a.next-1]
+	decoration := &MemberDecorateInstruction{Target: target, Member: a.word("member")}
+	decoration.Decoration = enumerant(a, decorationsByName, "decoration")
+	if decoration.Decoration == DecorationOffset {
+		decoration.Literals = append(decoration.Literals, a.word("byte offset"))
+	}
+	if a.err != nil {
+		return
+	}
+	a.module.memberDecorations = append(a.module.memberDecorations, decoration)
+	// decorations come before the structs they decorate
+	a.deferred = append(a.deferred, func() {
+		if _, ok := a.module.GetObject(target).(*StructType); !ok {
+			a.errorf(targetToken, "'%%%v' is not a struct type", targetToken.value)
+		}
+	})
+}
+
+// isBlock reports whether the struct is decorated as the contents of a buffer, decorations come before the types
+func (a *assembler) isBlock(id ID) bool {
+	return slices.ContainsFunc(a.module.decorations, func(d *DecorateInstruction) bool {
+		return d.Target == id && (d.Decoration == DecorationBlock || d.Decoration == DecorationBufferBlock)
+	})
+}
+
+func (a *assembler) assembleExtInstImport() {
+	id, name := a.result("ext_")
+	set := a.str("extended instruction set name")
+	if a.err != nil {
+		return
+	}
+	e := &ExtInstImport{BaseObject: BaseObject{ObjectID: id, ObjectName: name}, Set: set}
+	a.module.addObject(e)
+	a.module.extInstImports = append(a.module.extInstImports, e)
+}
+
+func (a *assembler) assembleGlobalVariable() {
+	id, name := a.result("")
+	t, tok := a.object("result type")
+	if a.err != nil {
+		return
+	}
+	ptrType, ok := t.(*PtrType)
+	if !ok {
+		a.errorf(tok, "the type of a variable must be a pointer type but found '%%%v'", tok.value)
+		return
+	}
+	storageClass := enumerant(a, storageClassesByName, "storage class")
+	if a.err == nil && storageClass != ptrType.StorageClass {
+		a.errorf(a.current.operands[1], "storage class '%v' doesn't match the variable's pointer type", a.current.operands[1].value)
+	}
+	if a.err != nil {
+		return
+	}
+	variable := &Variable{BaseObject: BaseObject{ObjectID: id, ObjectName: name}, Type: ptrType, StorageClass: storageClass}
+	a.module.addObject(variable)
+	a.module.globals = append(a.module.globals, variable)
+}
+
+func (a *assembler) assembleType(op Opcode) {
+	id, name := a.result("type_")
+	var t Type
+	switch op {
+	case OpTypeVoid:
+		t = &VoidType{ObjectID: id, ObjectName: name, Module: a.module}
+	case OpTypeBool:
+		t = &BoolType{ObjectID: id, ObjectName: name, Module: a.module}
+	case OpTypeInt:
+		bitWidth := a.word("width")
+		signedness := a.word("signedness")
+		if a.err == nil && signedness > 1 {
+			a.errorf(a.current.operands[a.next-1], "signedness must be 0 or 1 but found '%v'", signedness)
+		}
+		t = &IntType{ObjectID: id, ObjectName: name, Module: a.module, BitWidth: int(bitWidth), IsSigned: signedness == 1}
+	case OpTypeFloat:
+		bitWidth := a.word("width")
+		t = &FloatType{ObjectID: id, ObjectName: name, Module: a.module, BitWidth: int(bitWidth)}
+	case OpTypeVector:
+		componentType := a.typ("component type")
+		count := a.word("component count")
+		t = &VectorType{ObjectID: id, ObjectName: name, Module: a.module, ComponentType: componentType, Count: int(count)}
+	case OpTypeArray:
+		elementType := a.typ("element type")
+		length, tok := a.object("length")
+		intLength, ok := length.(*IntConstant)
+		if a.err == nil && !ok {
+			a.errorf(tok, "the length of an array must be an integer constant but found '%%%v'", tok.value)
+		}
+		t = &ArrayType{ObjectID: id, ObjectName: name, Module: a.module, ElementType: elementType, Length: intLength}
+	case OpTypeStruct:
+		var memberTypes []Type
+		for a.hasOperand() {
+			memberTypes = append(memberTypes, a.typ("member type"))
+		}
+		t = &StructType{ObjectID: id, ObjectName: name, Module: a.module, MemberTypes: memberTypes, Block: a.isBlock(id)}
+	case OpTypePointer:
+		storageClass := enumerant(a, storageClassesByName, "storage class")
+		to := a.typ("pointee type")
+		t = &PtrType{ObjectID: id, ObjectName: name, Module: a.module, To: to, StorageClass: storageClass}
+	case OpTypeImage:
+		image := &ImageType{ObjectID: id, ObjectName: name, Module: a.module, SampledType: a.typ("sampled type")}
+		image.Dim = enumerant(a, dimsByName, "dimensionality")
+		image.Depth = a.word("depth")
+		image.Arrayed = a.word("arrayed")
+		image.Multisampled = a.word("multisampled")
+		image.Sampled = a.word("sampled")
+		image.Format = enumerant(a, imageFormatsByName, "image format")
+		t = image
+	case OpTypeSampledImage:
+		imageType, tok := a.object("image type")
+		image, ok := imageType.(*ImageType)
+		if a.err == nil && !ok {
+			a.errorf(tok, "'%%%v' is not an image type", tok.value)
+		}
+		t = &SampledImageType{ObjectID: id, ObjectName: name, Module: a.module, ImageType: image}
+	case OpTypeFunction:
+		returnType := a.typ("return type")
+		var argTypes []Type
+		for a.hasOperand() {
+			argTypes = append(argTypes, a.typ("parameter type"))
+		}
+		t = &FuncType{ObjectID: id, ObjectName: name, Module: a.module, ReturnType: returnType, ArgTypes: argTypes}
+	}
+	if a.err == nil {
+		a.module.addObject(t)
+	}
+}
+
+func (a *assembler) assembleConstant(op Opcode) {
+	id, name := a.result("")
+	t, typeToken := a.object("type")
+	var c ConstantValue
+	switch op {
+	case OpConstantTrue, OpConstantFalse:
+		boolType, ok := t.(*BoolType)
+		if a.err == nil && !ok {
+			a.errorf(typeToken, "the type of a boolean constant must be a bool type but found '%%%v'", typeToken.value)
+		}
+		c = &BoolConstant{BaseObject: BaseObject{ObjectID: id, ObjectName: name}, Type: boolType, Value: op == OpConstantTrue}
+	case OpConstant:
+		tok, _ := a.operand("value")
+		switch t := t.(type) {
+		case *IntType:
+			value, err := parseIntLiteral(tok.value, t)
+			if a.err == nil && tok.kind == asmTokenWord && errors.Is(err, strconv.ErrRange) {
+				a.errorf(tok, "'%v' doesn't fit in the %v", tok.text(), describeIntType(t))
+			} else if a.err == nil && (tok.kind != asmTokenWord || err != nil) {
+				a.errorf(tok, "expected an integer but found '%v'", tok.text())
+			}
+			c = &IntConstant{BaseObject: BaseObject{ObjectID: id, ObjectName: name}, Type: t, Value: value}
+		case *FloatType:
+			value, err := strconv.ParseFloat(tok.value, 64)
+			if a.err == nil && (tok.kind != asmTokenWord || err != nil) {
+				a.errorf(tok, "expected a floating point number but found '%v'", tok.text())
+			}
+			c = &FloatConstant{BaseObject: BaseObject{ObjectID: id, ObjectName: name}, Type: t, Value: value}
+		default:
+			a.errorf(typeToken, "the type of a constant must be an integer or floating point type but found '%%%v'", typeToken.value)
+		}
+	case OpConstantComposite:
+		compositeType, ok := t.(Type)
+		if a.err == nil && !ok {
+			a.errorf(typeToken, "'%%%v' is not a type", typeToken.value)
+		}
+		var constituents []ConstantValue
+		for a.hasOperand() {
+			constituents = append(constituents, a.constant("constituent"))
+		}
+		c = &CompositeConstant{BaseObject: BaseObject{ObjectID: id, ObjectName: name}, Type: compositeType, Constituents: constituents}
+	}
+	if a.err == nil {
+		a.module.addObject(c)
+	}
+}
+
+// parseIntLiteral parses the value of an integer constant, it has to fit in the width and signedness of its type
+func parseIntLiteral(literal string, t *IntType) (int64, error) {
+	if t.IsSigned {
+		return strconv.ParseInt(literal, 0, t.BitWidth)
+	}
+	value, err := strconv.ParseUint(literal, 0, t.BitWidth)
+	// negative values are out of the range of unsigned types
+	if err != nil && strings.HasPrefix(literal, "-") {
+		if _, signedErr := strconv.ParseInt(literal, 0, 64); signedErr == nil || errors.Is(signedErr, strconv.ErrRange) {
+			err = strconv.ErrRange
+		}
+	}
+	return int64(value), err
+}
+
+func describeIntType(t *IntType) string {
+	if t.IsSigned {
+		return fmt.Sprintf("%v-bit signed integer type", t.BitWidth)
+	}
+	return fmt.Sprintf("%v-bit unsigned integer type", t.BitWidth)
+}
+
+func (a *assembler) assembleFunction(op Opcode) {
+	switch op {
+	case OpFunction:
+		a.outsideFunction()
+		id, name := a.result("func_")
+		returnType := a.typ("return type")
+		// the IR has no function control, the text printer always writes None
+		a.functionControl()
+		t, tok := a.object("function type")
+		funcType, ok := t.(*FuncType)
+		if a.err != nil {
+			return
+		}
+		if !ok {
+			a.errorf(tok, "'%%%v' is not a function type", tok.value)
+			return
+		}
+		if funcType.ReturnType != returnType {
+			a.errorf(a.current.operands[0], "return type '%%%v' doesn't match the function type '%%%v'", a.current.operands[0].value, tok.value)
+			return
+		}
+		a.function = &Function{
+			BaseObject: BaseObject{ObjectID: id, ObjectName: name},
+			Module:     a.module,
+			Type:       funcType,
+			Params:     make([]*FuncParam, 0),
+			Blocks:     make([]*Block, 0),
+		}
+		a.functionStart = a.current.opcode
+		a.module.addObject(a.function)
+	case OpFunctionParameter:
+		id, name := a.result("")
+		paramType := a.typ("type")
+		if a.err != nil {
+			return
+		}
+		if a.function == nil || len(a.function.Blocks) > 0 {
+			a.errorf(a.current.opcode, "OpFunctionParameter must come after OpFunction and before the function's first block")
+			return
+		}
+		index := len(a.function.Params)
+		if index >= len(a.function.Type.ArgTypes) {
+			a.errorf(a.current.opcode, "function '%v' has more parameters than its type", a.function.Name())
+			return
+		}
+		if a.function.Type.ArgTypes[index] != paramType {
+			a.errorf(a.current.operands[0], "parameter type '%%%v' doesn't match the function type", a.current.operands[0].value)
+			return
+		}
+		param := &FuncParam{BaseObject: BaseObject{ObjectID: id, ObjectName: name}, Type: paramType}
+		a.module.addObject(param)
+		a.function.Params = append(a.function.Params, param)
+	case OpFunctionEnd:
+		a.noResult()
+		if a.err != nil {
+			return
+		}
+		if a.function == nil {
+			a.errorf(a.current.opcode, "OpFunctionEnd without OpFunction")
+			return
+		}
+		if len(a.function.Params) != len(a.function.Type.ArgTypes) {
+			a.errorf(a.current.opcode, "function '%v' has fewer parameters than its type", a.function.Name())
+			return
+		}
+		a.function, a.block = nil, nil
+	case OpLabel:
+		id, name := a.result("block_")
+		if a.err != nil {
+			return
+		}
+		if a.function == nil {
+			a.errorf(a.current.opcode, "OpLabel must be inside a function")
+			return
+		}
+		a.block = &Block{
+			BaseObject:   BaseObject{ObjectID: id, ObjectName: name},
+			Function:     a.function,
+			Instructions: make([]Instruction, 0),
+		}
+		a.function.Blocks = append(a.function.Blocks, a.block)
+		a.module.addObject(a.block)
+	}
+}
+
+func (a *assembler) functionControl() {
+	tok, ok := a.operand("function control")
+	if !ok {
+		return
+	}
+	for flag := range strings.SplitSeq(tok.value, "|") {
+		if _, ok := functionControlsByName[flag]; tok.kind != asmTokenWord || !ok {
+			a.errorf(tok, "unknown function control '%v'", tok.value)
+			return
+		}
+	}
+}
+
+// value creates the object for the result of an instruction inside a block, its type is the first operand
+func (a *assembler) value() (ID, ID) {
+	id, name := a.result("")
+	t := a.typ("result type")
+	if a.err != nil {
+		return 0, 0
+	}
+	if a.opcode() == OpVariable {
+		ptrType, ok := t.(*PtrType)
+		if !ok {
+			a.errorf(a.current.operands[0], "the type of a variable must be a pointer type but found '%%%v'", a.current.operands[0].value)
+			return 0, 0
+		}
+		a.module.addObject(&Variable{BaseObject: BaseObject{ObjectID: id, ObjectName: name}, Type: ptrType, StorageClass: ptrType.StorageClass})
+	} else {
+		a.module.addObject(&RuntimeValue{BaseObject: BaseObject{ObjectID: id, ObjectName: name}, Type: t})
+	}
+	return t.ID(), id
+}
+
+var unaryInstructions = map[Opcode]func(resultType, resultID, operand ID) Instruction{
+	OpConvertFToU: func(t, r, o ID) Instruction { return &ConvertFToUInstruction{ResultType: t, ResultID: r, Operand: o} },
+	OpConvertFToS: func(t, r, o ID) Instruction { return &ConvertFToSInstruction{ResultType: t, ResultID: r, Operand: o} },
+	OpConvertSToF: func(t, r, o ID) Instruction { return &ConvertSToFInstruction{ResultType: t, ResultID: r, Operand: o} },
+	OpConvertUToF: func(t, r, o ID) Instruction { return &ConvertUToFInstruction{ResultType: t, ResultID: r, Operand: o} },
+	OpUConvert:    func(t, r, o ID) Instruction { return &UConvertInstruction{ResultType: t, ResultID: r, Operand: o} },
+	OpFConvert:    func(t, r, o ID) Instruction { return &FConvertInstruction{ResultType: t, ResultID: r, Operand: o} },
+	OpBitcast:     func(t, r, o ID) Instruction { return &BitcastInstruction{ResultType: t, ResultID: r, Operand: o} },
+	OpSNegate:     func(t, r, o ID) Instruction { return &SNegateInstruction{ResultType: t, ResultID: r, Operand: o} },
+	OpFNegate:     func(t, r, o ID) Instruction { return &FNegateInstruction{ResultType: t, ResultID: r, Operand: o} },
+	OpLogicalNot:  func(t, r, o ID) Instruction { return &LogicalNotInstruction{ResultType: t, ResultID: r, Operand: o} },
+	OpNot:         func(t, r, o ID) Instruction { return &NotInstruction{ResultType: t, ResultID: r, Operand: o} },
+	OpLoad:        func(t, r, o ID) Instruction { return &LoadInstruction{ResultType: t, ResultID: r, Pointer: o} },
+	OpDPdx:        func(t, r, o ID) Instruction { return &DPdxInstruction{ResultType: t, ResultID: r, Operand: o} },
+	OpDPdy:        func(t, r, o ID) Instruction { return &DPdyInstruction{ResultType: t, ResultID: r, Operand: o} },
+	OpFwidth:      func(t, r, o ID) Instruction { return &FwidthInstruction{ResultType: t, ResultID: r, Operand: o} },
+}
+
+var binaryInstructions = map[Opcode]func(resultType, resultID, operand1, operand2 ID) Instruction{
+	OpLogicalEqual: func(t, r, a, b ID) Instruction {
+		return &LogicalEqualInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpLogicalNotEqual: func(t, r, a, b ID) Instruction {
+		return &LogicalNotEqualInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpLogicalOr: func(t, r, a, b ID) Instruction {
+		return &LogicalOrInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpLogicalAnd: func(t, r, a, b ID) Instruction {
+		return &LogicalAndInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpIEqual: func(t, r, a, b ID) Instruction {
+		return &IEqualInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpINotEqual: func(t, r, a, b ID) Instruction {
+		return &INotEqualInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpUGreaterThan: func(t, r, a, b ID) Instruction {
+		return &UGreaterThanInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpSGreaterThan: func(t, r, a, b ID) Instruction {
+		return &SGreaterThanInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpUGreaterThanEqual: func(t, r, a, b ID) Instruction {
+		return &UGreaterThanEqualInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpSGreaterThanEqual: func(t, r, a, b ID) Instruction {
+		return &SGreaterThanEqualInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpULessThan: func(t, r, a, b ID) Instruction {
+		return &ULessThanInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpSLessThan: func(t, r, a, b ID) Instruction {
+		return &SLessThanInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpULessThanEqual: func(t, r, a, b ID) Instruction {
+		return &ULessThanEqualInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpSLessThanEqual: func(t, r, a, b ID) Instruction {
+		return &SLessThanEqualInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpFOrdEqual: func(t, r, a, b ID) Instruction {
+		return &FOrdEqualInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpFOrdNotEqual: func(t, r, a, b ID) Instruction {
+		return &FOrdNotEqualInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpFOrdLessThan: func(t, r, a, b ID) Instruction {
+		return &FOrdLessThanInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpFOrdGreaterThan: func(t, r, a, b ID) Instruction {
+		return &FOrdGreaterThanInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpFOrdLessThanEqual: func(t, r, a, b ID) Instruction {
+		return &FOrdLessThanEqualInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpFOrdGreaterThanEqual: func(t, r, a, b ID) Instruction {
+		return &FOrdGreaterThanEqualInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpIAdd: func(t, r, a, b ID) Instruction {
+		return &IAddInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpFAdd: func(t, r, a, b ID) Instruction {
+		return &FAddInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpISub: func(t, r, a, b ID) Instruction {
+		return &ISubInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpFSub: func(t, r, a, b ID) Instruction {
+		return &FSubInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpIMul: func(t, r, a, b ID) Instruction {
+		return &IMulInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpFMul: func(t, r, a, b ID) Instruction {
+		return &FMulInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpUDiv: func(t, r, a, b ID) Instruction {
+		return &UDivInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpSDiv: func(t, r, a, b ID) Instruction {
+		return &SDivInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpFDiv: func(t, r, a, b ID) Instruction {
+		return &FDivInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpUMod: func(t, r, a, b ID) Instruction {
+		return &UModInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpSRem: func(t, r, a, b ID) Instruction {
+		return &SRemInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpFRem: func(t, r, a, b ID) Instruction {
+		return &FRemInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpBitwiseOr: func(t, r, a, b ID) Instruction {
+		return &BitwiseOrInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpBitwiseXor: func(t, r, a, b ID) Instruction {
+		return &BitwiseXorInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpBitwiseAnd: func(t, r, a, b ID) Instruction {
+		return &BitwiseAndInstruction{ResultType: t, ResultID: r, Operand1: a, Operand2: b}
+	},
+	OpShiftLeftLogical: func(t, r, a, b ID) Instruction {
+		return &ShiftLeftLogicalInstruction{ResultType: t, ResultID: r, Base: a, Shift: b}
+	},
+	OpShiftRightLogical: func(t, r, a, b ID) Instruction {
+		return &ShiftRightLogicalInstruction{ResultType: t, ResultID: r, Base: a, Shift: b}
+	},
+	OpShiftRightArithmetic: func(t, r, a, b ID) Instruction {
+		return &ShiftRightArithmeticInstruction{ResultType: t, ResultID: r, Base: a, Shift: b}
+	},
+}
+
+func (a *assembler) assembleBlockInstruction(op Opcode) {
+	var inst Instruction
+	if newInstruction, ok := unaryInstructions[op]; ok {
+		resultType, resultID := a.value()
+		inst = newInstruction(resultType, resultID, a.id("operand"))
+	} else if newInstruction, ok := binaryInstructions[op]; ok {
+		resultType, resultID := a.value()
+		operand1 := a.id("first operand")
+		inst = newInstruction(resultType, resultID, operand1, a.id("second operand"))
+	} else {
+		switch op {
+		case OpFunctionCall:
+			resultType, resultID := a.value()
+			function := a.id("function")
+			inst = &FunctionCallInstruction{ResultType: resultType, ResultID: resultID, FunctionID: function, Args: a.idList("argument")}
+		case OpVariable:
+			resultType, resultID := a.value()
+			variable := &VariableInstruction{ResultType: resultType, ResultID: resultID, StorageClass: enumerant(a, storageClassesByName, "storage class")}
+			if a.err == nil && variable.StorageClass != a.module.GetObject(resultID).(*Variable).StorageClass {
+				a.errorf(a.current.operands[1], "storage class '%v' doesn't match the variable's pointer type", a.current.operands[1].value)
+			}
+			if a.hasOperand() {
+				variable.Initializer = a.id("initializer")
+			}
+			inst = variable
+		case OpStore:
+			a.noResult()
+			pointer := a.id("pointer")
+			inst = &StoreInstruction{Pointer: pointer, Object: a.id("object")}
+		case OpAccessChain:
+			resultType, resultID := a.value()
+			base := a.id("base")
+			inst = &AccessChainInstruction{ResultType: resultType, ResultID: resultID, Base: base, Indexes: a.idList("index")}
+		case OpExtInst:
+			resultType, resultID := a.value()
+			set, tok := a.object("instruction set")
+			if _, ok := set.(*ExtInstImport); a.err == nil && !ok {
+				a.errorf(tok, "'%%%v' is not an imported instruction set", tok.value)
+			}
+			if a.err != nil {
+				return
+			}
+			instruction := a.word("instruction")
+			inst = &ExtInstInstruction{ResultType: resultType, ResultID: resultID, Set: set.ID(), Instruction: instruction, Operands: a.idList("operand")}
+		case OpVectorShuffle:
+			resultType, resultID := a.value()
+			vector1 := a.id("vector")
+			vector2 := a.id("vector")
+			inst = &VectorShuffleInstruction{ResultType: resultType, ResultID: resultID, Vector1: vector1, Vector2: vector2, Components: a.words("component")}
+		case OpCompositeConstruct:
+			resultType, resultID := a.value()
+			inst = &CompositeConstructInstruction{ResultType: resultType, ResultID: resultID, Constituents: a.idList("constituent")}
+		case OpCompositeExtract:
+			resultType, resultID := a.value()
+			composite := a.id("composite")
+			inst = &CompositeExtractInstruction{ResultType: resultType, ResultID: resultID, Composite: composite, Indexes: a.words("index")}
+		case OpImageSampleImplicitLod:
+			resultType, resultID := a.value()
+			sampledImage := a.id("sampled image")
+			inst = &ImageSampleImplicitLodInstruction{ResultType: resultType, ResultID: resultID, SampledImage: sampledImage, Coordinate: a.id("coordinate")}
+		case OpImageSampleExplicitLod:
+			resultType, resultID := a.value()
+			sampledImage := a.id("sampled image")
+			coordinate := a.id("coordinate")
+			enumerant(a, imageOperandsByName, "image operands")
+			inst = &ImageSampleExplicitLodInstruction{ResultType: resultType, ResultID: resultID, SampledImage: sampledImage, Coordinate: coordinate, Lod: a.id("level of detail")}
+		case OpImageRead:
+			resultType, resultID := a.value()
+			image := a.id("image")
+			inst = &ImageReadInstruction{ResultType: resultType, ResultID: resultID, Image: image, Coordinate: a.id("coordinate")}
+		case OpImageWrite:
+			a.noResult()
+			image := a.id("image")
+			coordinate := a.id("coordinate")
+			inst = &ImageWriteInstruction{Image: image, Coordinate: coordinate, Texel: a.id("texel")}
+		case OpControlBarrier:
+			a.noResult()
+			execution := a.id("execution scope")
+			memory := a.id("memory scope")
+			inst = &ControlBarrierInstruction{Execution: execution, Memory: memory, Semantics: a.id("memory semantics")}
+		case OpReturn:
+			a.noResult()
+			inst = &ReturnInstruction{}
+		case OpReturnValue:
+			a.noResult()
+			inst = &ReturnValueInstruction{Value: a.id("value")}
+		case OpUnreachable:
+			a.noResult()
+			inst = &UnreachableInstruction{}
+		case OpKill:
+			a.noResult()
+			inst = &KillInstruction{}
+		case OpDemoteToHelperInvocation:
+			a.noResult()
+			inst = &DemoteToHelperInvocationInstruction{}
+		case OpSelectionMerge:
+			a.noResult()
+			mergeBlock := a.id("merge block")
+			inst = &SelectionMergeInstruction{MergeBlock: mergeBlock, Control: enumerant(a, selectionControlsByName, "selection control")}
+		case OpLoopMerge:
+			a.noResult()
+			mergeBlock := a.id("merge block")
+			continueBlock := a.id("continue block")
+			inst = &LoopMergeInstruction{MergeBlock: mergeBlock, ContinueBlock: continueBlock, Control: enumerant(a, loopControlsByName, "loop control")}
+		case OpBranchConditional:
+			a.noResult()
+			condition := a.id("condition")
+			trueLabel := a.id("true label")
+			inst = &BranchConditional{Condition: condition, TrueLabel: trueLabel, FalseLabel: a.id("false label")}
+		case OpBranch:
+			a.noResult()
+			inst = &Branch{TargetLabel: a.id("target label")}
+		default:
+			a.errorf(a.current.opcode, "%v can't be assembled", a.current.opcode.value)
+		}
+	}
+	if a.err == nil {
+		a.block.Push(inst)
+	}
+}
+
+func namesOf[T fmt.Stringer](values ...T) map[string]T {
+	names := make(map[string]T, len(values))
+	for _, value := range values {
+		names[value.String()] = value
+	}
+	return names
+}
+
+var opcodesByName = namesOf(
+	OpExtension, OpMemoryModel, OpEntryPoint, OpExecutionMode, OpCapability, OpTypeVoid, OpTypeBool, OpTypeInt,
+	OpTypeFloat, OpTypeVector, OpTypeArray, OpTypeStruct, OpTypePointer, OpTypeFunction, OpConstantTrue, OpConstantFalse,
+	OpConstant, OpConstantComposite, OpFunction, OpFunctionParameter, OpFunctionEnd, OpFunctionCall, OpVariable, OpLoad,
+	OpStore, OpAccessChain, OpVectorShuffle, OpCompositeConstruct, OpCompositeExtract, OpConvertFToU, OpConvertFToS, OpConvertSToF,
+	OpConvertUToF, OpUConvert, OpFConvert, OpBitcast, OpSNegate, OpFNegate, OpIAdd, OpFAdd, OpISub, OpFSub, OpIMul, OpFMul, OpUDiv,
+	OpSDiv, OpFDiv, OpUMod, OpSRem, OpFRem, OpLogicalEqual, OpLogicalNotEqual, OpLogicalOr, OpLogicalAnd,
+	OpLogicalNot, OpIEqual, OpINotEqual, OpUGreaterThan, OpSGreaterThan, OpUGreaterThanEqual, OpSGreaterThanEqual,
+	OpULessThan, OpSLessThan, OpULessThanEqual, OpSLessThanEqual, OpFOrdEqual, OpFOrdNotEqual, OpFOrdLessThan,
+	OpFOrdGreaterThan, OpFOrdLessThanEqual, OpFOrdGreaterThanEqual, OpShiftRightLogical, OpShiftRightArithmetic,
+	OpShiftLeftLogical, OpBitwiseOr, OpBitwiseXor, OpBitwiseAnd, OpNot, OpLoopMerge, OpSelectionMerge, OpLabel,
+	OpBranch, OpBranchConditional, OpKill, OpReturn, OpReturnValue, OpUnreachable, OpDemoteToHelperInvocation,
+	OpDecorate, OpMemberDecorate, OpDPdx, OpDPdy, OpFwidth, OpControlBarrier, OpExtInstImport, OpExtInst, OpTypeImage,
+	OpTypeSampledImage, OpImageSampleImplicitLod, OpImageSampleExplicitLod, OpImageRead, OpImageWrite,
+)
+
+// unsupportedOpcodes are the opcodes the IR can't hold and the reason they're rejected
+var unsupportedOpcodes = map[string]string{
+	"OpName":       "objects are named by their ids",
+	"OpMemberName": "objects are named by their ids",
+	"OpString":     "the IR has no debug information",
+	"OpSource":     "the IR has no debug information",
+	"OpLine":       "the IR has no debug information",
+	"OpPhi":        "values flow between blocks through function variables",
+}
+
+var capabilitiesByName = namesOf(
+	CapabilityMatrix, CapabilityShader, CapabilityGeometry, CapabilityTessellation, CapabilityAddresses,
+	CapabilityLinkage, CapabilityKernel, CapabilityVector16, CapabilityFloat16Buffer, CapabilityFloat16,
+	CapabilityFloat64, CapabilityInt64, CapabilityInt64Atomics, CapabilityImageBasic, CapabilityImageReadWrite,
+	CapabilityImageMipmap, CapabilityPipes, CapabilityGroups, CapabilityDeviceEnqueue, CapabilityLiteralSampler,
+	CapabilityAtomicStorage, CapabilityInt16, CapabilityTessellationPointSize, CapabilityGeometryPointSize,
+	CapabilityImageGatherExtended, CapabilityStorageImageMultisample, CapabilityUniformBufferArrayDynamicIndexing,
+	CapabilitySampledImageArrayDynamicIndexing, CapabilityStorageBufferArrayDynamicIndexing,
+	CapabilityStorageImageArrayDynamicIndexing, CapabilityClipDistance, CapabilityCullDistance,
+	CapabilityImageCubeArray, CapabilitySampleRateShading, CapabilityImageRect, CapabilitySampledRect,
+	CapabilityGenericPointer, CapabilityInt8, CapabilityInputAttachment, CapabilitySparseResidency, CapabilityMinLod,
+	CapabilitySampled1D, CapabilityImage1D, CapabilitySampledCubeArray, CapabilitySampledBuffer, CapabilityImageBuffer,
+	CapabilityImageMSArray, CapabilityStorageImageExtendedFormats, CapabilityImageQuery, CapabilityDerivativeControl,
+	CapabilityInterpolationFunction, CapabilityTransformFeedback, CapabilityGeometryStreams,
+	CapabilityStorageImageReadWithoutFormat, CapabilityStorageImageWriteWithoutFormat, CapabilityMultiViewport,
+	CapabilitySubgroupDispatch, CapabilityNamedBarrier, CapabilityPipeStorage, CapabilityGroupNonUniform,
+	CapabilityGroupNonUniformVote, CapabilityGroupNonUniformArithmetic, CapabilityGroupNonUniformBallot,
+	CapabilityGroupNonUniformShuffle, CapabilityGroupNonUniformShuffleRelative, CapabilityGroupNonUniformClustered,
+	CapabilityGroupNonUniformQuad, CapabilityShaderLayer, CapabilityShaderViewportIndex, CapabilityUniformDecoration,
+	CapabilityDemoteToHelperInvocation,
+)
+
+var executionModelsByName = namesOf(
+	ExecutionModelVertex, ExecutionModelFragment, ExecutionModelGLCompute, ExecutionModelKernel,
+)
+
+var executionModesByName = namesOf(ExecutionModeOriginUpperLeft, ExecutionModeLocalSize)
+
+var decorationsByName = namesOf(
+	DecorationBlock, DecorationBufferBlock, DecorationArrayStride, DecorationBuiltIn, DecorationFlat,
+	DecorationLocation, DecorationBinding, DecorationDescriptorSet, DecorationOffset,
+)
+
+var dimsByName = namesOf(Dim2D)
+
+var imageFormatsByName = namesOf(ImageFormatUnknown, ImageFormatRgba32f)
+
+// only the level of detail operand of explicit-LOD sampling is supported
+var imageOperandsByName = namesOf(ImageOperandsLod)
+
+var builtInsByName = namesOf(BuiltInPosition, BuiltInFrontFacing, BuiltInLocalInvocationIndex)
+
+var addressingModelsByName = namesOf(
+	AddressingModelLogical, AddressingModelPhysical32, AddressingModelPhysical64,
+	AddressingModelPhysicalStorageBuffer64,
+)
+
+var memoryModelsByName = namesOf(MemoryModelSimple, MemoryModelGLSL450, MemoryModelOpenCL, MemoryModelVulkan)
+
+var storageClassesByName = namesOf(
+	StorageClassUniformConstant, StorageClassInput, StorageClassUniform, StorageClassOutput, StorageClassWorkgroup,
+	StorageClassCrossWorkgroup, StorageClassPrivate, StorageClassFunction, StorageClassGeneric,
+	StorageClassPushConstant, StorageClassAtomicCounter, StorageClassImage, StorageClassStorageBuffer,
+)
+
+var functionControlsByName = namesOf(
+	FunctionControlNone, FunctionControlInline, FunctionControlDontInline, FunctionControlPure, FunctionControlConst,
+)
+
+var selectionControlsByName = namesOf(SelectionControlNone, SelectionControlFlatten, SelectionControlDontFlatten)
+
+var loopControlsByName = namesOf(LoopControlNone, LoopControlUnroll, LoopControlDontUnroll)
@@ -0,0 +1,194 @@
+package spirv
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAssembleRoundTrip(t *testing.T) {
+	// the goldens of failed emissions are in SPIRVErrors, so every golden here is a module
+	dir := filepath.Join("..", "testdata", "SPIRV")
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".golden") {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		// the test runner appends a newline to the golden output
+		text := strings.TrimRight(string(content), "\n") + "\n"
+
+		t.Run(path, func(t *testing.T) {
+			module, err := Assemble(text)
+			if err != nil {
+				t.Fatalf("failed to assemble: %v", err)
+			}
+			var out bytes.Buffer
+			NewTextPrinter(&out, module).Emit()
+			if out.String() != text {
+				t.Errorf("printing the assembled module doesn't give back the text\nexpected:\n%v\nactual:\n%v", text, out.String())
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected AssemblerError
+	}{
+		{
+			name:     "unknown opcode",
+			source:   "OpCapability Shader\n  OpFoo",
+			expected: AssemblerError{Line: 2, Column: 3, Message: "unknown opcode 'OpFoo'"},
+		},
+		{
+			name:     "unknown enumerant",
+			source:   "OpCapability Shaders",
+			expected: AssemblerError{Line: 1, Column: 14, Message: "unknown capability 'Shaders'"},
+		},
+		{
+			name:     "undefined id",
+			source:   "%type_ptr_1 = OpTypePointer Function %type_int32_2",
+			expected: AssemblerError{Line: 1, Column: 38, Message: "'%type_int32_2' is not defined"},
+		},
+		{
+			name:     "use before declaration",
+			source:   "%type_ptr_1 = OpTypePointer Function %type_int32_2\n%type_int32_2 = OpTypeInt 32 1",
+			expected: AssemblerError{Line: 1, Column: 38, Message: "'%type_int32_2' is used before it's declared"},
+		},
+		{
+			name:     "duplicate id",
+			source:   "%type_int32_1 = OpTypeInt 32 1\n%type_uint32_1 = OpTypeInt 32 0",
+			expected: AssemblerError{Line: 2, Column: 1, Message: "id 1 of '%type_uint32_1' is already used by '%type_int32_1'"},
+		},
+		{
+			name:     "missing operand",
+			source:   "%type_int32_1 = OpTypeInt 32",
+			expected: AssemblerError{Line: 1, Column: 17, Message: "OpTypeInt is missing its signedness"},
+		},
+		{
+			name:     "extra operand",
+			source:   "%type_void_1 = OpTypeVoid %type_void_1",
+			expected: AssemblerError{Line: 1, Column: 27, Message: "unexpected operand '%type_void_1'"},
+		},
+		{
+			name:     "instruction outside of a block",
+			source:   "OpReturn",
+			expected: AssemblerError{Line: 1, Column: 1, Message: "OpReturn must be inside a block"},
+		},
+		{
+			name:     "unterminated string",
+			source:   "OpExtension \"SPV_EXT",
+			expected: AssemblerError{Line: 1, Column: 13, Message: "unterminated string"},
+		},
+		{
+			name: "missing function end",
+			source: `%type_void_1 = OpTypeVoid
+%type_func_ret_void_2 = OpTypeFunction %type_void_1
+%func_main_3 = OpFunction %type_void_1 None %type_func_ret_void_2
+%block_entry_4 = OpLabel
+OpReturn`,
+			expected: AssemblerError{Line: 3, Column: 16, Message: "function 'main' has no OpFunctionEnd"},
+		},
+		{
+			name:     "signed constant out of range",
+			source:   "%type_int32_1 = OpTypeInt 32 1\n%const_2 = OpConstant %type_int32_1 2147483648",
+			expected: AssemblerError{Line: 2, Column: 37, Message: "'2147483648' doesn't fit in the 32-bit signed integer type"},
+		},
+		{
+			name:     "negative unsigned constant",
+			source:   "%type_uint32_1 = OpTypeInt 32 0\n%const_2 = OpConstant %type_uint32_1 -1",
+			expected: AssemblerError{Line: 2, Column: 38, Message: "'-1' doesn't fit in the 32-bit unsigned integer type"},
+		},
+		{
+			name:     "unsigned constant out of range",
+			source:   "%type_uint32_1 = OpTypeInt 32 0\n%const_2 = OpConstant %type_uint32_1 0x100000000",
+			expected: AssemblerError{Line: 2, Column: 38, Message: "'0x100000000' doesn't fit in the 32-bit unsigned integer type"},
+		},
+		{
+			name:     "unsupported name",
+			source:   "%type_void_1 = OpTypeVoid\nOpName %type_void_1 \"void\"",
+			expected: AssemblerError{Line: 2, Column: 1, Message: "OpName is not supported, objects are named by their ids"},
+		},
+		{
+			name:     "unsupported string",
+			source:   "%file_1 = OpString \"shader.sabre\"",
+			expected: AssemblerError{Line: 1, Column: 11, Message: "OpString is not supported, the IR has no debug information"},
+		},
+		{
+			name:     "unsupported phi",
+			source:   "%value_3 = OpPhi %type_int32_1 %const_2 %block_entry_4",
+			expected: AssemblerError{Line: 1, Column: 12, Message: "OpPhi is not supported, values flow between blocks through function variables"},
+		},
+		{
+			name:     "entry point of a type",
+			source:   "OpEntryPoint Vertex %type_void_1 \"main\"\n%type_void_1 = OpTypeVoid",
+			expected: AssemblerError{Line: 1, Column: 21, Message: "'%type_void_1' is not a function"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Assemble(tt.source)
+			var asmErr *AssemblerError
+			if !errors.As(err, &asmErr) {
+				t.Fatalf("expected an assembler error but got %v", err)
+			}
+			if *asmErr != tt.expected {
+				t.Errorf("expected error %v but got %v", tt.expected.Error(), asmErr.Error())
+			}
+		})
+	}
+}
+
+func TestAssembleUnnumberedIDs(t *testing.T) {
+	source := `OpCapability Shader
+OpMemoryModel Logical GLSL450
+OpEntryPoint Fragment %main "main"
+OpExecutionMode %main OriginUpperLeft
+%void = OpTypeVoid
+%fn = OpTypeFunction %void
+%main = OpFunction %void None %fn ; a hand written function
+%entry = OpLabel
+OpReturn
+OpFunctionEnd
+`
+	module, err := Assemble(source)
+	if err != nil {
+		t.Fatalf("failed to assemble: %v", err)
+	}
+	var out bytes.Buffer
+	NewTextPrinter(&out, module).Emit()
+	// the names are kept as they're written while their objects get fresh ids
+	expected := `         OpCapability Shader
+         OpMemoryModel Logical GLSL450
+         OpEntryPoint Fragment %main "main"
+         OpExecutionMode %main OriginUpperLeft
+ %void = OpTypeVoid
+   %fn = OpTypeFunction %void
+ %main = OpFunction %void None %fn
+%entry = OpLabel
+         OpReturn
+         OpFunctionEnd
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%v\nactual:\n%v", expected, out.String())
+	}
+	if _, ok := module.GetObject(1).(*VoidType); !ok {
+		t.Errorf("expected '%%void' to get id 1 but found %v", module.GetObject(1))
+	}
+	if function, ok := module.GetObject(3).(*Function); !ok || function.Name() != "main" {
+		t.Errorf("expected '%%main' to get id 3 but found %v", module.GetObject(3))
+	}
+}
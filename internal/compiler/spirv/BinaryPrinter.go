@@ -24,21 +24,34 @@ func NewBinaryPrinter(out io.Writer, module *Module) *BinaryPrinter {
 func (bp *BinaryPrinter) Emit() {
 	bp.emitHeader()
 	bp.emitCapabilities()
+	bp.emitExtensions()
+	bp.emitExtInstImports()
 	bp.emitMemoryModel()
+	bp.emitEntryPoints()
+	bp.emitDecorations()
 
 	for _, obj := range bp.module.Objects {
-		if _, isType := obj.(Type); isType {
+		if t, isType := obj.(Type); isType && !dependsOnConstant(t) {
 			bp.emitObject(obj)
 		}
 	}
 
+	// types which refer to constants are declared among the constants in the order they were created
 	for _, obj := range bp.module.Objects {
-		switch obj.(type) {
+		switch v := obj.(type) {
 		case ConstantValue:
 			bp.emitObject(obj)
+		case Type:
+			if dependsOnConstant(v) {
+				bp.emitObject(obj)
+			}
 		}
 	}
 
+	for _, v := range bp.module.Globals() {
+		bp.emitOp(Word(OpVariable), Word(v.Type.ID()), Word(v.ID()), Word(v.StorageClass))
+	}
+
 	for _, obj := range bp.module.Objects {
 		if _, isFunction := obj.(*Function); isFunction {
 			bp.emitObject(obj)
@@ -91,6 +104,20 @@ func (bp *BinaryPrinter) emitInstruction(inst Instruction) {
 		bp.emitOp(Word(OpReturn))
 	case *ReturnValueInstruction:
 		bp.emitOp(Word(OpReturnValue), Word(i.Value))
+	case *ConvertFToUInstruction:
+		bp.emitOp(Word(OpConvertFToU), Word(i.ResultType), Word(i.ResultID), Word(i.Operand))
+	case *ConvertFToSInstruction:
+		bp.emitOp(Word(OpConvertFToS), Word(i.ResultType), Word(i.ResultID), Word(i.Operand))
+	case *ConvertSToFInstruction:
+		bp.emitOp(Word(OpConvertSToF), Word(i.ResultType), Word(i.ResultID), Word(i.Operand))
+	case *ConvertUToFInstruction:
+		bp.emitOp(Word(OpConvertUToF), Word(i.ResultType), Word(i.ResultID), Word(i.Operand))
+	case *UConvertInstruction:
+		bp.emitOp(Word(OpUConvert), Word(i.ResultType), Word(i.ResultID), Word(i.Operand))
+	case *FConvertInstruction:
+		bp.emitOp(Word(OpFConvert), Word(i.ResultType), Word(i.ResultID), Word(i.Operand))
+	case *BitcastInstruction:
+		bp.emitOp(Word(OpBitcast), Word(i.ResultType), Word(i.ResultID), Word(i.Operand))
 	case *SNegateInstruction:
 		bp.emitOp(Word(OpSNegate), Word(i.ResultType), Word(i.ResultID), Word(i.Operand))
 	case *FNegateInstruction:
@@ -175,6 +202,33 @@ func (bp *BinaryPrinter) emitInstruction(inst Instruction) {
 		bp.emitOp(Word(OpShiftRightLogical), Word(i.ResultType), Word(i.ResultID), Word(i.Base), Word(i.Shift))
 	case *ShiftRightArithmeticInstruction:
 		bp.emitOp(Word(OpShiftRightArithmetic), Word(i.ResultType), Word(i.ResultID), Word(i.Base), Word(i.Shift))
+	case *ExtInstInstruction:
+		words := []Word{Word(i.ResultType), Word(i.ResultID), Word(i.Set), i.Instruction}
+		for _, operand := range i.Operands {
+			words = append(words, Word(operand))
+		}
+		bp.emitOp(Word(OpExtInst), words...)
+	case *DPdxInstruction:
+		bp.emitOp(Word(OpDPdx), Word(i.ResultType), Word(i.ResultID), Word(i.Operand))
+	case *DPdyInstruction:
+		bp.emitOp(Word(OpDPdy), Word(i.ResultType), Word(i.ResultID), Word(i.Operand))
+	case *FwidthInstruction:
+		bp.emitOp(Word(OpFwidth), Word(i.ResultType), Word(i.ResultID), Word(i.Operand))
+	case *ImageSampleImplicitLodInstruction:
+		bp.emitOp(
+			Word(OpImageSampleImplicitLod), Word(i.ResultType), Word(i.ResultID), Word(i.SampledImage), Word(i.Coordinate),
+		)
+	case *ImageSampleExplicitLodInstruction:
+		bp.emitOp(
+			Word(OpImageSampleExplicitLod), Word(i.ResultType), Word(i.ResultID), Word(i.SampledImage), Word(i.Coordinate),
+			Word(ImageOperandsLod), Word(i.Lod),
+		)
+	case *ImageReadInstruction:
+		bp.emitOp(Word(OpImageRead), Word(i.ResultType), Word(i.ResultID), Word(i.Image), Word(i.Coordinate))
+	case *ImageWriteInstruction:
+		bp.emitOp(Word(OpImageWrite), Word(i.Image), Word(i.Coordinate), Word(i.Texel))
+	case *ControlBarrierInstruction:
+		bp.emitOp(Word(OpControlBarrier), Word(i.Execution), Word(i.Memory), Word(i.Semantics))
 	case *FunctionCallInstruction:
 		words := make([]Word, 0, len(i.Args)+3)
 		words = append(words, Word(i.ResultType))
@@ -194,8 +248,32 @@ func (bp *BinaryPrinter) emitInstruction(inst Instruction) {
 		bp.emitOp(Word(OpLoad), Word(i.ResultType), Word(i.ResultID), Word(i.Pointer))
 	case *StoreInstruction:
 		bp.emitOp(Word(OpStore), Word(i.Pointer), Word(i.Object))
+	case *AccessChainInstruction:
+		words := []Word{Word(i.ResultType), Word(i.ResultID), Word(i.Base)}
+		for _, index := range i.Indexes {
+			words = append(words, Word(index))
+		}
+		bp.emitOp(Word(OpAccessChain), words...)
+	case *VectorShuffleInstruction:
+		words := []Word{Word(i.ResultType), Word(i.ResultID), Word(i.Vector1), Word(i.Vector2)}
+		words = append(words, i.Components...)
+		bp.emitOp(Word(OpVectorShuffle), words...)
+	case *CompositeConstructInstruction:
+		words := []Word{Word(i.ResultType), Word(i.ResultID)}
+		for _, constituent := range i.Constituents {
+			words = append(words, Word(constituent))
+		}
+		bp.emitOp(Word(OpCompositeConstruct), words...)
+	case *CompositeExtractInstruction:
+		words := []Word{Word(i.ResultType), Word(i.ResultID), Word(i.Composite)}
+		words = append(words, i.Indexes...)
+		bp.emitOp(Word(OpCompositeExtract), words...)
 	case *UnreachableInstruction:
 		bp.emitOp(Word(OpUnreachable))
+	case *KillInstruction:
+		bp.emitOp(Word(OpKill))
+	case *DemoteToHelperInvocationInstruction:
+		bp.emitOp(Word(OpDemoteToHelperInvocation))
 	case *SelectionMergeInstruction:
 		bp.emitOp(Word(OpSelectionMerge), Word(i.MergeBlock), Word(i.Control))
 	case *BranchConditional:
@@ -219,8 +297,18 @@ func (bp *BinaryPrinter) emitType(abstractType Type) {
 		bp.emitIntType(t)
 	case *FloatType:
 		bp.emitFloatType(t)
+	case *VectorType:
+		bp.emitVectorType(t)
+	case *ArrayType:
+		bp.emitArrayType(t)
+	case *StructType:
+		bp.emitStructType(t)
 	case *PtrType:
 		bp.emitPtrType(t)
+	case *ImageType:
+		bp.emitImageType(t)
+	case *SampledImageType:
+		bp.emitSampledImageType(t)
 	case *FuncType:
 		bp.emitFuncType(t)
 	default:
@@ -236,6 +324,8 @@ func (bp *BinaryPrinter) emitConstant(constant ConstantValue) {
 		bp.emitIntConstant(c)
 	case *FloatConstant:
 		bp.emitFloatConstant(c)
+	case *CompositeConstant:
+		bp.emitCompositeConstant(c)
 	default:
 		panic(fmt.Sprintf("unsupported constant: %T", c))
 	}
@@ -267,6 +357,15 @@ func (bp *BinaryPrinter) emitFloatConstant(c *FloatConstant) {
 	}
 }
 
+func (bp *BinaryPrinter) emitCompositeConstant(c *CompositeConstant) {
+	args := make([]Word, 0, len(c.Constituents)+2)
+	args = append(args, Word(c.Type.ID()), Word(c.ID()))
+	for _, constituent := range c.Constituents {
+		args = append(args, Word(constituent.ID()))
+	}
+	bp.emitOp(Word(OpConstantComposite), args...)
+}
+
 func (bp *BinaryPrinter) emitVoidType(t *VoidType) {
 	bp.emitOp(Word(OpTypeVoid), Word(t.ID()))
 }
@@ -283,10 +382,38 @@ func (bp *BinaryPrinter) emitFloatType(t *FloatType) {
 	bp.emitOp(Word(OpTypeFloat), Word(t.ID()), Word(t.BitWidth))
 }
 
+func (bp *BinaryPrinter) emitVectorType(t *VectorType) {
+	bp.emitOp(Word(OpTypeVector), Word(t.ID()), Word(t.ComponentType.ID()), Word(t.Count))
+}
+
+func (bp *BinaryPrinter) emitArrayType(t *ArrayType) {
+	bp.emitOp(Word(OpTypeArray), Word(t.ID()), Word(t.ElementType.ID()), Word(t.Length.ID()))
+}
+
+func (bp *BinaryPrinter) emitStructType(t *StructType) {
+	args := make([]Word, 0, len(t.MemberTypes)+1)
+	args = append(args, Word(t.ID()))
+	for _, member := range t.MemberTypes {
+		args = append(args, Word(member.ID()))
+	}
+	bp.emitOp(Word(OpTypeStruct), args...)
+}
+
 func (bp *BinaryPrinter) emitPtrType(t *PtrType) {
 	bp.emitOp(Word(OpTypePointer), Word(t.ID()), Word(t.StorageClass), Word(t.To.ID()))
 }
 
+func (bp *BinaryPrinter) emitImageType(t *ImageType) {
+	bp.emitOp(
+		Word(OpTypeImage), Word(t.ID()), Word(t.SampledType.ID()), Word(t.Dim),
+		t.Depth, t.Arrayed, t.Multisampled, t.Sampled, Word(t.Format),
+	)
+}
+
+func (bp *BinaryPrinter) emitSampledImageType(t *SampledImageType) {
+	bp.emitOp(Word(OpTypeSampledImage), Word(t.ID()), Word(t.ImageType.ID()))
+}
+
 func (bp *BinaryPrinter) emitFuncType(t *FuncType) {
 	args := make([]Word, 0, len(t.ArgTypes)+2)
 	args = append(args, Word(t.ID()))
@@ -307,11 +434,51 @@ func (bp *BinaryPrinter) emitCapabilities() {
 	}
 }
 
+func (bp *BinaryPrinter) emitExtensions() {
+	for _, e := range bp.module.Extensions() {
+		bp.emitOp(Word(OpExtension), stringToWords(e)...)
+	}
+}
+
+func (bp *BinaryPrinter) emitExtInstImports() {
+	for _, e := range bp.module.ExtInstImports() {
+		bp.emitOp(Word(OpExtInstImport), append([]Word{Word(e.ID())}, stringToWords(e.Set)...)...)
+	}
+}
+
+func (bp *BinaryPrinter) emitEntryPoints() {
+	for _, e := range bp.module.EntryPoints() {
+		operands := []Word{Word(e.Model), Word(e.Function.ID())}
+		operands = append(operands, stringToWords(e.Name)...)
+		for _, v := range e.Interface {
+			operands = append(operands, Word(v.ID()))
+		}
+		bp.emitOp(Word(OpEntryPoint), operands...)
+	}
+	for _, e := range bp.module.ExecutionModes() {
+		operands := []Word{Word(e.Function.ID()), Word(e.Mode)}
+		operands = append(operands, e.Literals...)
+		bp.emitOp(Word(OpExecutionMode), operands...)
+	}
+}
+
+func (bp *BinaryPrinter) emitDecorations() {
+	for _, d := range bp.module.Decorations() {
+		operands := []Word{Word(d.Target), Word(d.Decoration)}
+		operands = append(operands, d.Literals...)
+		bp.emitOp(Word(OpDecorate), operands...)
+	}
+	for _, d := range bp.module.MemberDecorations() {
+		operands := []Word{Word(d.Target), d.Member, Word(d.Decoration)}
+		operands = append(operands, d.Literals...)
+		bp.emitOp(Word(OpMemberDecorate), operands...)
+	}
+}
+
 func (bp *BinaryPrinter) emitHeader() {
 	// SPIR-V Magic
 	bp.emitMagicNumber()
-	// Version 1.3, I chose 1.3 arbitrarily.
-	bp.emitVersion(1, 3)
+	bp.emitVersion(bp.module.Version.Major, bp.module.Version.Minor)
 	// Generator's magic number (arbitrary)
 	// Generator’s magic number. It is associated with the tool that generated the module.
 	// Its value does not affect any semantics, and is allowed to be 0. Using a non-0 value is encouraged,
@@ -349,6 +516,15 @@ func (bp *BinaryPrinter) emitWord(value Word) {
 	bp.out.Write(buf[:])
 }
 
+// stringToWords encodes a literal string as a nul terminated UTF-8 string packed into words
+func stringToWords(s string) []Word {
+	words := make([]Word, len(s)/4+1)
+	for i := 0; i < len(s); i++ {
+		words[i/4] |= Word(s[i]) << (8 * (i % 4))
+	}
+	return words
+}
+
 func boolToWord(b bool) Word {
 	if b {
 		return 1
@@ -66,6 +66,16 @@ type FloatConstant struct {
 func (c *FloatConstant) GetType() Type    { return c.Type }
 func (c *FloatConstant) isConstantValue() {}
 
+// CompositeConstant is a constant array or struct made of other constants
+type CompositeConstant struct {
+	BaseObject
+	Type         Type
+	Constituents []ConstantValue
+}
+
+func (c *CompositeConstant) GetType() Type    { return c.Type }
+func (c *CompositeConstant) isConstantValue() {}
+
 // RuntimeValue represents a value produced by an instruction at runtime.
 type RuntimeValue struct {
 	BaseObject
@@ -74,20 +84,47 @@ type RuntimeValue struct {
 
 func (v *RuntimeValue) GetType() Type { return v.Type }
 
+// ExtInstImport is an imported extended instruction set, its instructions are called through its id
+type ExtInstImport struct {
+	BaseObject
+	Set string
+}
+
+// Version is the SPIR-V version declared in the module header
+type Version struct {
+	Major uint8
+	Minor uint8
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%v.%v", v.Major, v.Minor)
+}
+
 // Module represents a SPIR-V module containing functions.
 type Module struct {
-	idGenerator     int
-	Objects         []Object
-	objectsByID     map[ID]int
-	typesByKey      map[string]int
-	constantsByKey  map[string]int
-	capabilities    []Capability
+	Version           Version
+	idGenerator       int
+	Objects           []Object
+	objectsByID       map[ID]int
+	typesByKey        map[string]int
+	constantsByKey    map[string]int
+	capabilities      []Capability
+	extensions        []string
+	extInstImports    []*ExtInstImport
+	entryPoints       []*EntryPoint
+	executionModes    []*ExecutionModeInstruction
+	decorations       []*DecorateInstruction
+	memberDecorations []*MemberDecorateInstruction
+	globals           []*Variable
+	// textNames keeps the ids of assembled modules as they're written, so printing them gives back the same names
+	textNames       map[ID]string
 	AddressingModel AddressingModel
 	MemoryModel     MemoryModel
 }
 
 func NewModule(addressingModel AddressingModel, memoryModel MemoryModel) *Module {
 	return &Module{
+		Version:         Version{Major: 1, Minor: 3},
 		idGenerator:     0,
 		Objects:         make([]Object, 0),
 		objectsByID:     make(map[ID]int),
@@ -179,6 +216,42 @@ func (m *Module) NewVariable(name string, ptrType *PtrType, sc StorageClass) *Va
 	return v
 }
 
+// NewGlobalVariable creates a variable declared at module level, like the inputs of entry points
+func (m *Module) NewGlobalVariable(name string, ptrType *PtrType) *Variable {
+	v := m.NewVariable(name, ptrType, ptrType.StorageClass)
+	m.globals = append(m.globals, v)
+	return v
+}
+
+func (m *Module) Globals() []*Variable {
+	return m.globals
+}
+
+func (m *Module) Decorate(target Object, decoration Decoration, literals ...Word) {
+	m.decorations = append(m.decorations, &DecorateInstruction{
+		Target:     target.ID(),
+		Decoration: decoration,
+		Literals:   literals,
+	})
+}
+
+func (m *Module) Decorations() []*DecorateInstruction {
+	return m.decorations
+}
+
+func (m *Module) MemberDecorate(target *StructType, member int, decoration Decoration, literals ...Word) {
+	m.memberDecorations = append(m.memberDecorations, &MemberDecorateInstruction{
+		Target:     target.ID(),
+		Member:     Word(member),
+		Decoration: decoration,
+		Literals:   literals,
+	})
+}
+
+func (m *Module) MemberDecorations() []*MemberDecorateInstruction {
+	return m.memberDecorations
+}
+
 func (m *Module) InternVoid() *VoidType {
 	t := &VoidType{}
 	if index, ok := m.typesByKey[t.HashKey()]; ok {
@@ -247,6 +320,93 @@ func (m *Module) InternPtr(to Type, sc StorageClass) *PtrType {
 	return t
 }
 
+func (m *Module) InternStruct(memberTypes []Type) *StructType {
+	return m.internStruct(&StructType{MemberTypes: memberTypes})
+}
+
+// InternBlock interns the struct holding the contents of a buffer, it's apart from the struct with the same members
+// since it's decorated as a block
+func (m *Module) InternBlock(memberTypes []Type) *StructType {
+	return m.internStruct(&StructType{MemberTypes: memberTypes, Block: true})
+}
+
+func (m *Module) internStruct(t *StructType) *StructType {
+	if index, ok := m.typesByKey[t.HashKey()]; ok {
+		return m.Objects[index].(*StructType)
+	}
+	t.ObjectID = m.NewID()
+	t.ObjectName = t.TypeName()
+	t.Module = m
+	m.addObject(t)
+	return t
+}
+
+func (m *Module) InternArray(elementType Type, length *IntConstant) *ArrayType {
+	t := &ArrayType{
+		ElementType: elementType,
+		Length:      length,
+	}
+	if index, ok := m.typesByKey[t.HashKey()]; ok {
+		return m.Objects[index].(*ArrayType)
+	}
+	t.ObjectID = m.NewID()
+	t.ObjectName = t.TypeName()
+	t.Module = m
+	m.addObject(t)
+	return t
+}
+
+func (m *Module) InternVector(componentType Type, count int) *VectorType {
+	t := &VectorType{
+		ComponentType: componentType,
+		Count:         count,
+	}
+	if index, ok := m.typesByKey[t.HashKey()]; ok {
+		return m.Objects[index].(*VectorType)
+	}
+	t.ObjectID = m.NewID()
+	t.ObjectName = t.TypeName()
+	t.Module = m
+	m.addObject(t)
+	return t
+}
+
+// InternImage interns the type of 2D images of the sampled type which are read through samplers
+func (m *Module) InternImage(sampledType Type) *ImageType {
+	return m.internImage(&ImageType{SampledType: sampledType, Dim: Dim2D, Sampled: 1, Format: ImageFormatUnknown})
+}
+
+// InternStorageImage interns the 2D image read and written without a sampler, storage images have the format of their
+// texels
+func (m *Module) InternStorageImage(sampledType Type, format ImageFormat) *ImageType {
+	return m.internImage(&ImageType{SampledType: sampledType, Dim: Dim2D, Sampled: 2, Format: format})
+}
+
+func (m *Module) internImage(t *ImageType) *ImageType {
+	if index, ok := m.typesByKey[t.HashKey()]; ok {
+		return m.Objects[index].(*ImageType)
+	}
+	t.ObjectID = m.NewID()
+	t.ObjectName = t.TypeName()
+	t.Module = m
+	m.addObject(t)
+	return t
+}
+
+func (m *Module) InternSampledImage(imageType *ImageType) *SampledImageType {
+	t := &SampledImageType{
+		ImageType: imageType,
+	}
+	if index, ok := m.typesByKey[t.HashKey()]; ok {
+		return m.Objects[index].(*SampledImageType)
+	}
+	t.ObjectID = m.NewID()
+	t.ObjectName = t.TypeName()
+	t.Module = m
+	m.addObject(t)
+	return t
+}
+
 func (m *Module) InternFunc(returnType Type, args []Type) *FuncType {
 	t := &FuncType{
 		ReturnType: returnType,
@@ -275,6 +435,69 @@ func (m *Module) Capabilities() []Capability {
 	return m.capabilities
 }
 
+func (m *Module) AddExtension(name string) {
+	for _, e := range m.extensions {
+		if e == name {
+			return
+		}
+	}
+	m.extensions = append(m.extensions, name)
+}
+
+func (m *Module) Extensions() []string {
+	return m.extensions
+}
+
+// ImportExtInstSet imports the extended instruction set with the given name, like "OpenCL.std", each set is
+// imported once
+func (m *Module) ImportExtInstSet(set string) *ExtInstImport {
+	for _, e := range m.extInstImports {
+		if e.Set == set {
+			return e
+		}
+	}
+	e := &ExtInstImport{
+		BaseObject: BaseObject{
+			ObjectID:   m.NewID(),
+			ObjectName: strings.ReplaceAll(set, ".", "_"),
+		},
+		Set: set,
+	}
+	m.addObject(e)
+	m.extInstImports = append(m.extInstImports, e)
+	return e
+}
+
+func (m *Module) ExtInstImports() []*ExtInstImport {
+	return m.extInstImports
+}
+
+func (m *Module) AddEntryPoint(model ExecutionModel, function *Function, name string) *EntryPoint {
+	e := &EntryPoint{
+		Model:    model,
+		Function: function,
+		Name:     name,
+	}
+	m.entryPoints = append(m.entryPoints, e)
+	return e
+}
+
+func (m *Module) EntryPoints() []*EntryPoint {
+	return m.entryPoints
+}
+
+func (m *Module) AddExecutionMode(function *Function, mode ExecutionMode, literals ...Word) {
+	m.executionModes = append(m.executionModes, &ExecutionModeInstruction{
+		Function: function,
+		Mode:     mode,
+		Literals: literals,
+	})
+}
+
+func (m *Module) ExecutionModes() []*ExecutionModeInstruction {
+	return m.executionModes
+}
+
 func (m *Module) InternBoolConstant(value bool, t *BoolType) *BoolConstant {
 	key := fmt.Sprintf("const_%v_%v", t.HashKey(), value)
 	if index, ok := m.constantsByKey[key]; ok {
@@ -296,7 +519,10 @@ func (m *Module) InternBoolConstant(value bool, t *BoolType) *BoolConstant {
 }
 
 func (m *Module) InternIntConstant(value int64, t *IntType) *IntConstant {
-	key := fmt.Sprintf("const_%v_%v", t.HashKey(), value)
+	// names can't contain '-' so negative values are prefixed with neg instead
+	valueName := strings.ReplaceAll(fmt.Sprintf("%v", value), "-", "neg")
+
+	key := fmt.Sprintf("const_%v_%v", t.HashKey(), valueName)
 	if index, ok := m.constantsByKey[key]; ok {
 		return m.Objects[index].(*IntConstant)
 	}
@@ -315,7 +541,7 @@ func (m *Module) InternIntConstant(value int64, t *IntType) *IntConstant {
 
 func (m *Module) InternFloatConstant(value float64, t *FloatType) *FloatConstant {
 	valueFmt := fmt.Sprintf("%f", value)
-	valueName := strings.ReplaceAll(valueFmt, ".", "_")
+	valueName := strings.ReplaceAll(strings.ReplaceAll(valueFmt, ".", "_"), "-", "neg")
 
 	key := fmt.Sprintf("const_%v_%v", t.HashKey(), valueName)
 	if index, ok := m.constantsByKey[key]; ok {
@@ -334,6 +560,29 @@ func (m *Module) InternFloatConstant(value float64, t *FloatType) *FloatConstant
 	return constant
 }
 
+func (m *Module) InternCompositeConstant(t Type, constituents []ConstantValue) *CompositeConstant {
+	var b strings.Builder
+	fmt.Fprintf(&b, "const_%v", t.TypeName())
+	for _, c := range constituents {
+		fmt.Fprintf(&b, "_%v", c.ID())
+	}
+	key := b.String()
+	if index, ok := m.constantsByKey[key]; ok {
+		return m.Objects[index].(*CompositeConstant)
+	}
+	id := m.NewID()
+	constant := &CompositeConstant{
+		BaseObject: BaseObject{
+			ObjectID:   id,
+			ObjectName: key,
+		},
+		Type:         t,
+		Constituents: constituents,
+	}
+	m.addObject(constant)
+	return constant
+}
+
 // NewNamedValue creates a new runtime value with the given name and type.
 func (m *Module) NewNamedValue(name string, valueType Type) *RuntimeValue {
 	id := m.NewID()
@@ -358,6 +607,8 @@ type FuncParam struct {
 	Type Type
 }
 
+func (p *FuncParam) GetType() Type { return p.Type }
+
 // Function represents a SPIR-V function containing a sequence of basic blocks.
 type Function struct {
 	BaseObject
@@ -422,6 +673,8 @@ type Variable struct {
 	StorageClass StorageClass
 }
 
+func (v *Variable) GetType() Type { return v.Type }
+
 // Instruction represents a single SPIR-V instruction with an opcode.
 type Instruction interface {
 	Opcode() Opcode
@@ -471,6 +724,83 @@ func (r *ReturnValueInstruction) Opcode() Opcode {
 	return OpReturnValue
 }
 
+type ConvertFToUInstruction struct {
+	DefaultInstruction
+	ResultType ID
+	ResultID   ID
+	Operand    ID
+}
+
+func (i *ConvertFToUInstruction) Opcode() Opcode {
+	return OpConvertFToU
+}
+
+type ConvertFToSInstruction struct {
+	DefaultInstruction
+	ResultType ID
+	ResultID   ID
+	Operand    ID
+}
+
+func (i *ConvertFToSInstruction) Opcode() Opcode {
+	return OpConvertFToS
+}
+
+type ConvertSToFInstruction struct {
+	DefaultInstruction
+	ResultType ID
+	ResultID   ID
+	Operand    ID
+}
+
+func (i *ConvertSToFInstruction) Opcode() Opcode {
+	return OpConvertSToF
+}
+
+type ConvertUToFInstruction struct {
+	DefaultInstruction
+	ResultType ID
+	ResultID   ID
+	Operand    ID
+}
+
+func (i *ConvertUToFInstruction) Opcode() Opcode {
+	return OpConvertUToF
+}
+
+type UConvertInstruction struct {
+	DefaultInstruction
+	ResultType ID
+	ResultID   ID
+	Operand    ID
+}
+
+func (i *UConvertInstruction) Opcode() Opcode {
+	return OpUConvert
+}
+
+type FConvertInstruction struct {
+	DefaultInstruction
+	ResultType ID
+	ResultID   ID
+	Operand    ID
+}
+
+func (i *FConvertInstruction) Opcode() Opcode {
+	return OpFConvert
+}
+
+type BitcastInstruction struct {
+	DefaultInstruction
+	ResultType ID
+	ResultID   ID
+	Operand    ID
+}
+
+func (i *BitcastInstruction) Opcode() Opcode {
+	return OpBitcast
+}
+
 type SNegateInstruction struct {
 	DefaultInstruction
 	ResultType ID
@@ -971,6 +1301,121 @@ func (i *ShiftRightArithmeticInstruction) Opcode() Opcode {
 	return OpShiftRightArithmetic
 }
 
+// ExtInstInstruction calls the instruction of an imported extended instruction set, the instruction is the literal
+// number of the instruction in the set
+type ExtInstInstruction struct {
+	DefaultInstruction
+	ResultType  ID
+	ResultID    ID
+	Set         ID
+	Instruction Word
+	Operands    []ID
+}
+
+func (i *ExtInstInstruction) Opcode() Opcode {
+	return OpExtInst
+}
+
+type DPdxInstruction struct {
+	DefaultInstruction
+	ResultType ID
+	ResultID   ID
+	Operand    ID
+}
+
+func (i *DPdxInstruction) Opcode() Opcode {
+	return OpDPdx
+}
+
+type DPdyInstruction struct {
+	DefaultInstruction
+	ResultType ID
+	ResultID   ID
+	Operand    ID
+}
+
+func (i *DPdyInstruction) Opcode() Opcode {
+	return OpDPdy
+}
+
+type FwidthInstruction struct {
+	DefaultInstruction
+	ResultType ID
+	ResultID   ID
+	Operand    ID
+}
+
+func (i *FwidthInstruction) Opcode() Opcode {
+	return OpFwidth
+}
+
+// ImageSampleImplicitLodInstruction samples the sampled image at the coordinate, the level of detail is computed
+// from the derivatives of the coordinate
+type ImageSampleImplicitLodInstruction struct {
+	DefaultInstruction
+	ResultType   ID
+	ResultID     ID
+	SampledImage ID
+	Coordinate   ID
+}
+
+func (i *ImageSampleImplicitLodInstruction) Opcode() Opcode {
+	return OpImageSampleImplicitLod
+}
+
+// ImageSampleExplicitLodInstruction samples the given level of detail of the sampled image at the coordinate, it's
+// always written with the Lod image operand
+type ImageSampleExplicitLodInstruction struct {
+	DefaultInstruction
+	ResultType   ID
+	ResultID     ID
+	SampledImage ID
+	Coordinate   ID
+	Lod          ID
+}
+
+func (i *ImageSampleExplicitLodInstruction) Opcode() Opcode {
+	return OpImageSampleExplicitLod
+}
+
+// ImageReadInstruction reads the texel of the storage image at the integer coordinate
+type ImageReadInstruction struct {
+	DefaultInstruction
+	ResultType ID
+	ResultID   ID
+	Image      ID
+	Coordinate ID
+}
+
+func (i *ImageReadInstruction) Opcode() Opcode {
+	return OpImageRead
+}
+
+// ImageWriteInstruction writes the texel of the storage image at the integer coordinate
+type ImageWriteInstruction struct {
+	DefaultInstruction
+	Image      ID
+	Coordinate ID
+	Texel      ID
+}
+
+func (i *ImageWriteInstruction) Opcode() Opcode {
+	return OpImageWrite
+}
+
+// ControlBarrierInstruction waits for the invocations of the execution scope, the scopes and the memory semantics
+// are IDs of integer constants
+type ControlBarrierInstruction struct {
+	DefaultInstruction
+	Execution ID
+	Memory    ID
+	Semantics ID
+}
+
+func (i *ControlBarrierInstruction) Opcode() Opcode {
+	return OpControlBarrier
+}
+
 type FunctionCallInstruction struct {
 	DefaultInstruction
 	ResultType ID
@@ -1016,6 +1461,58 @@ func (i *StoreInstruction) Opcode() Opcode {
 	return OpStore
 }
 
+// AccessChainInstruction creates a pointer to a member of the composite pointed to by base
+type AccessChainInstruction struct {
+	DefaultInstruction
+	ResultType ID
+	ResultID   ID
+	Base       ID
+	Indexes    []ID
+}
+
+func (i *AccessChainInstruction) Opcode() Opcode {
+	return OpAccessChain
+}
+
+// VectorShuffleInstruction selects the components of a vector from the components of two vectors, components are
+// literal indexes into the concatenation of both vectors
+type VectorShuffleInstruction struct {
+	DefaultInstruction
+	ResultType ID
+	ResultID   ID
+	Vector1    ID
+	Vector2    ID
+	Components []Word
+}
+
+func (i *VectorShuffleInstruction) Opcode() Opcode {
+	return OpVectorShuffle
+}
+
+type CompositeConstructInstruction struct {
+	DefaultInstruction
+	ResultType   ID
+	ResultID     ID
+	Constituents []ID
+}
+
+func (i *CompositeConstructInstruction) Opcode() Opcode {
+	return OpCompositeConstruct
+}
+
+// CompositeExtractInstruction extracts a member of a composite value, indexes are literals not IDs
+type CompositeExtractInstruction struct {
+	DefaultInstruction
+	ResultType ID
+	ResultID   ID
+	Composite  ID
+	Indexes    []Word
+}
+
+func (i *CompositeExtractInstruction) Opcode() Opcode {
+	return OpCompositeExtract
+}
+
 type UnreachableInstruction struct {
 	DefaultInstruction
 }
@@ -1024,6 +1521,67 @@ func (r *UnreachableInstruction) Opcode() Opcode {
 	return OpUnreachable
 }
 
+type KillInstruction struct {
+	DefaultInstruction
+}
+
+func (i *KillInstruction) Opcode() Opcode {
+	return OpKill
+}
+
+type DemoteToHelperInvocationInstruction struct {
+	DefaultInstruction
+}
+
+func (i *DemoteToHelperInvocationInstruction) Opcode() Opcode {
+	return OpDemoteToHelperInvocation
+}
+
+// EntryPoint declares a function as an entry point of the given stage, the interface lists the global variables
+// the entry point reads its inputs from
+type EntryPoint struct {
+	Model     ExecutionModel
+	Function  *Function
+	Name      string
+	Interface []*Variable
+}
+
+type ExecutionModeInstruction struct {
+	DefaultInstruction
+	Function *Function
+	Mode     ExecutionMode
+	Literals []Word
+}
+
+func (i *ExecutionModeInstruction) Opcode() Opcode {
+	return OpExecutionMode
+}
+
+// DecorateInstruction decorates the target, the meaning of the literals depends on the decoration
+type DecorateInstruction struct {
+	DefaultInstruction
+	Target     ID
+	Decoration Decoration
+	Literals   []Word
+}
+
+func (i *DecorateInstruction) Opcode() Opcode {
+	return OpDecorate
+}
+
+// MemberDecorateInstruction decorates the member of the target struct
+type MemberDecorateInstruction struct {
+	DefaultInstruction
+	Target     ID
+	Member     Word
+	Decoration Decoration
+	Literals   []Word
+}
+
+func (i *MemberDecorateInstruction) Opcode() Opcode {
+	return OpMemberDecorate
+}
+
 type SelectionMergeInstruction struct {
 	DefaultInstruction
 	MergeBlock ID
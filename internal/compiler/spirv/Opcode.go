@@ -6,81 +6,126 @@ import "strings"
 type Opcode int
 
 const (
-	OpNone                 Opcode = 0
-	OpMemoryModel          Opcode = 14
-	OpCapability           Opcode = 17
-	OpTypeVoid             Opcode = 19
-	OpTypeBool             Opcode = 20
-	OpTypeInt              Opcode = 21
-	OpTypeFloat            Opcode = 22
-	OpTypePointer          Opcode = 32
-	OpTypeFunction         Opcode = 33
-	OpConstantTrue         Opcode = 41
-	OpConstantFalse        Opcode = 42
-	OpConstant             Opcode = 43
-	OpFunction             Opcode = 54
-	OpFunctionParameter    Opcode = 55
-	OpFunctionEnd          Opcode = 56
-	OpFunctionCall         Opcode = 57
-	OpVariable             Opcode = 59
-	OpLoad                 Opcode = 61
-	OpStore                Opcode = 62
-	OpSNegate              Opcode = 126
-	OpFNegate              Opcode = 127
-	OpIAdd                 Opcode = 128
-	OpFAdd                 Opcode = 129
-	OpISub                 Opcode = 130
-	OpFSub                 Opcode = 131
-	OpIMul                 Opcode = 132
-	OpFMul                 Opcode = 133
-	OpUDiv                 Opcode = 134
-	OpSDiv                 Opcode = 135
-	OpFDiv                 Opcode = 136
-	OpUMod                 Opcode = 137
-	OpSRem                 Opcode = 139
-	OpFRem                 Opcode = 141
-	OpLogicalEqual         Opcode = 164
-	OpLogicalNotEqual      Opcode = 165
-	OpLogicalOr            Opcode = 166
-	OpLogicalAnd           Opcode = 167
-	OpLogicalNot           Opcode = 168
-	OpIEqual               Opcode = 170
-	OpINotEqual            Opcode = 171
-	OpUGreaterThan         Opcode = 172
-	OpSGreaterThan         Opcode = 173
-	OpUGreaterThanEqual    Opcode = 174
-	OpSGreaterThanEqual    Opcode = 175
-	OpULessThan            Opcode = 176
-	OpSLessThan            Opcode = 177
-	OpULessThanEqual       Opcode = 178
-	OpSLessThanEqual       Opcode = 179
-	OpFOrdEqual            Opcode = 180
-	OpFOrdNotEqual         Opcode = 182
-	OpFOrdLessThan         Opcode = 184
-	OpFOrdGreaterThan      Opcode = 186
-	OpFOrdLessThanEqual    Opcode = 188
-	OpFOrdGreaterThanEqual Opcode = 190
-	OpShiftRightLogical    Opcode = 194
-	OpShiftRightArithmetic Opcode = 195
-	OpShiftLeftLogical     Opcode = 196
-	OpBitwiseOr            Opcode = 197
-	OpBitwiseXor           Opcode = 198
-	OpBitwiseAnd           Opcode = 199
-	OpNot                  Opcode = 200
-	OpLoopMerge            Opcode = 246
-	OpSelectionMerge       Opcode = 247
-	OpLabel                Opcode = 248
-	OpBranch               Opcode = 249
-	OpBranchConditional    Opcode = 250
-	OpReturn               Opcode = 253
-	OpReturnValue          Opcode = 254
-	OpUnreachable          Opcode = 255
+	OpNone                   Opcode = 0
+	OpExtension              Opcode = 10
+	OpExtInstImport          Opcode = 11
+	OpExtInst                Opcode = 12
+	OpMemoryModel            Opcode = 14
+	OpEntryPoint             Opcode = 15
+	OpExecutionMode          Opcode = 16
+	OpCapability             Opcode = 17
+	OpTypeVoid               Opcode = 19
+	OpTypeBool               Opcode = 20
+	OpTypeInt                Opcode = 21
+	OpTypeFloat              Opcode = 22
+	OpTypeVector             Opcode = 23
+	OpTypeImage              Opcode = 25
+	OpTypeSampledImage       Opcode = 27
+	OpTypeArray              Opcode = 28
+	OpTypeStruct             Opcode = 30
+	OpTypePointer            Opcode = 32
+	OpTypeFunction           Opcode = 33
+	OpConstantTrue           Opcode = 41
+	OpConstantFalse          Opcode = 42
+	OpConstant               Opcode = 43
+	OpConstantComposite      Opcode = 44
+	OpDecorate               Opcode = 71
+	OpMemberDecorate         Opcode = 72
+	OpFunction               Opcode = 54
+	OpFunctionParameter      Opcode = 55
+	OpFunctionEnd            Opcode = 56
+	OpFunctionCall           Opcode = 57
+	OpVariable               Opcode = 59
+	OpLoad                   Opcode = 61
+	OpStore                  Opcode = 62
+	OpAccessChain            Opcode = 65
+	OpVectorShuffle          Opcode = 79
+	OpCompositeConstruct     Opcode = 80
+	OpCompositeExtract       Opcode = 81
+	OpImageSampleImplicitLod Opcode = 87
+	OpImageSampleExplicitLod Opcode = 88
+	OpImageRead              Opcode = 98
+	OpImageWrite             Opcode = 99
+	OpConvertFToU            Opcode = 109
+	OpConvertFToS            Opcode = 110
+	OpConvertSToF            Opcode = 111
+	OpConvertUToF            Opcode = 112
+	OpUConvert               Opcode = 113
+	OpFConvert               Opcode = 115
+	OpBitcast                Opcode = 124
+	OpSNegate                Opcode = 126
+	OpFNegate                Opcode = 127
+	OpIAdd                   Opcode = 128
+	OpFAdd                   Opcode = 129
+	OpISub                   Opcode = 130
+	OpFSub                   Opcode = 131
+	OpIMul                   Opcode = 132
+	OpFMul                   Opcode = 133
+	OpUDiv                   Opcode = 134
+	OpSDiv                   Opcode = 135
+	OpFDiv                   Opcode = 136
+	OpUMod                   Opcode = 137
+	OpSRem                   Opcode = 139
+	OpFRem                   Opcode = 141
+	OpLogicalEqual           Opcode = 164
+	OpLogicalNotEqual        Opcode = 165
+	OpLogicalOr              Opcode = 166
+	OpLogicalAnd             Opcode = 167
+	OpLogicalNot             Opcode = 168
+	OpIEqual                 Opcode = 170
+	OpINotEqual              Opcode = 171
+	OpUGreaterThan           Opcode = 172
+	OpSGreaterThan           Opcode = 173
+	OpUGreaterThanEqual      Opcode = 174
+	OpSGreaterThanEqual      Opcode = 175
+	OpULessThan              Opcode = 176
+	OpSLessThan              Opcode = 177
+	OpULessThanEqual         Opcode = 178
+	OpSLessThanEqual         Opcode = 179
+	OpFOrdEqual              Opcode = 180
+	OpFOrdNotEqual           Opcode = 182
+	OpFOrdLessThan           Opcode = 184
+	OpFOrdGreaterThan        Opcode = 186
+	OpFOrdLessThanEqual      Opcode = 188
+	OpFOrdGreaterThanEqual   Opcode = 190
+	OpShiftRightLogical      Opcode = 194
+	OpShiftRightArithmetic   Opcode = 195
+	OpShiftLeftLogical       Opcode = 196
+	OpBitwiseOr              Opcode = 197
+	OpBitwiseXor             Opcode = 198
+	OpBitwiseAnd             Opcode = 199
+	OpNot                    Opcode = 200
+	OpDPdx                   Opcode = 207
+	OpDPdy                   Opcode = 208
+	OpFwidth                 Opcode = 209
+	OpControlBarrier         Opcode = 224
+	OpLoopMerge              Opcode = 246
+	OpSelectionMerge         Opcode = 247
+	OpLabel                  Opcode = 248
+	OpBranch                 Opcode = 249
+	OpBranchConditional      Opcode = 250
+	OpKill                   Opcode = 252
+	OpReturn                 Opcode = 253
+	OpReturnValue            Opcode = 254
+	OpUnreachable            Opcode = 255
+
+	OpDemoteToHelperInvocation Opcode = 5380
 )
 
 func (op Opcode) String() string {
 	switch op {
+	case OpExtension:
+		return "OpExtension"
+	case OpExtInstImport:
+		return "OpExtInstImport"
+	case OpExtInst:
+		return "OpExtInst"
 	case OpMemoryModel:
 		return "OpMemoryModel"
+	case OpEntryPoint:
+		return "OpEntryPoint"
+	case OpExecutionMode:
+		return "OpExecutionMode"
 	case OpCapability:
 		return "OpCapability"
 	case OpTypeVoid:
@@ -91,6 +136,16 @@ func (op Opcode) String() string {
 		return "OpTypeInt"
 	case OpTypeFloat:
 		return "OpTypeFloat"
+	case OpTypeVector:
+		return "OpTypeVector"
+	case OpTypeImage:
+		return "OpTypeImage"
+	case OpTypeSampledImage:
+		return "OpTypeSampledImage"
+	case OpTypeArray:
+		return "OpTypeArray"
+	case OpTypeStruct:
+		return "OpTypeStruct"
 	case OpTypePointer:
 		return "OpTypePointer"
 	case OpTypeFunction:
@@ -101,6 +156,12 @@ func (op Opcode) String() string {
 		return "OpConstantFalse"
 	case OpConstant:
 		return "OpConstant"
+	case OpConstantComposite:
+		return "OpConstantComposite"
+	case OpDecorate:
+		return "OpDecorate"
+	case OpMemberDecorate:
+		return "OpMemberDecorate"
 	case OpFunction:
 		return "OpFunction"
 	case OpFunctionParameter:
@@ -115,6 +176,36 @@ func (op Opcode) String() string {
 		return "OpLoad"
 	case OpStore:
 		return "OpStore"
+	case OpAccessChain:
+		return "OpAccessChain"
+	case OpVectorShuffle:
+		return "OpVectorShuffle"
+	case OpCompositeConstruct:
+		return "OpCompositeConstruct"
+	case OpCompositeExtract:
+		return "OpCompositeExtract"
+	case OpImageSampleImplicitLod:
+		return "OpImageSampleImplicitLod"
+	case OpImageSampleExplicitLod:
+		return "OpImageSampleExplicitLod"
+	case OpImageRead:
+		return "OpImageRead"
+	case OpImageWrite:
+		return "OpImageWrite"
+	case OpConvertFToU:
+		return "OpConvertFToU"
+	case OpConvertFToS:
+		return "OpConvertFToS"
+	case OpConvertSToF:
+		return "OpConvertSToF"
+	case OpConvertUToF:
+		return "OpConvertUToF"
+	case OpUConvert:
+		return "OpUConvert"
+	case OpFConvert:
+		return "OpFConvert"
+	case OpBitcast:
+		return "OpBitcast"
 	case OpSNegate:
 		return "OpSNegate"
 	case OpFNegate:
@@ -197,6 +288,14 @@ func (op Opcode) String() string {
 		return "OpBitwiseAnd"
 	case OpNot:
 		return "OpNot"
+	case OpDPdx:
+		return "OpDPdx"
+	case OpDPdy:
+		return "OpDPdy"
+	case OpFwidth:
+		return "OpFwidth"
+	case OpControlBarrier:
+		return "OpControlBarrier"
 	case OpShiftLeftLogical:
 		return "OpShiftLeftLogical"
 	case OpLabel:
@@ -215,6 +314,10 @@ func (op Opcode) String() string {
 		return "OpBranch"
 	case OpLoopMerge:
 		return "OpLoopMerge"
+	case OpKill:
+		return "OpKill"
+	case OpDemoteToHelperInvocation:
+		return "OpDemoteToHelperInvocation"
 	default:
 		panic("unknown opcode")
 	}
@@ -225,7 +328,8 @@ func (op Opcode) IsTerminator() bool {
 		op == OpBranchConditional ||
 		op == OpReturn ||
 		op == OpReturnValue ||
-		op == OpUnreachable
+		op == OpUnreachable ||
+		op == OpKill
 }
 
 // Capability represents capabilities a module can declare it uses.
@@ -304,6 +408,7 @@ const (
 	CapabilityShaderLayer                       Capability = 69
 	CapabilityShaderViewportIndex               Capability = 70
 	CapabilityUniformDecoration                 Capability = 71
+	CapabilityDemoteToHelperInvocation          Capability = 5379
 )
 
 func (c Capability) String() string {
@@ -448,11 +553,59 @@ func (c Capability) String() string {
 		return "ShaderViewportIndex"
 	case CapabilityUniformDecoration:
 		return "UniformDecoration"
+	case CapabilityDemoteToHelperInvocation:
+		return "DemoteToHelperInvocation"
 	default:
 		panic("unknown capability")
 	}
 }
 
+// ExecutionModel specifies the pipeline stage of an entry point.
+// Used by OpEntryPoint.
+type ExecutionModel int
+
+const (
+	ExecutionModelVertex    ExecutionModel = 0
+	ExecutionModelFragment  ExecutionModel = 4
+	ExecutionModelGLCompute ExecutionModel = 5
+	ExecutionModelKernel    ExecutionModel = 6
+)
+
+func (e ExecutionModel) String() string {
+	switch e {
+	case ExecutionModelVertex:
+		return "Vertex"
+	case ExecutionModelFragment:
+		return "Fragment"
+	case ExecutionModelGLCompute:
+		return "GLCompute"
+	case ExecutionModelKernel:
+		return "Kernel"
+	default:
+		panic("unknown execution model")
+	}
+}
+
+// ExecutionMode declares a mode an entry point will execute in.
+// Used by OpExecutionMode.
+type ExecutionMode int
+
+const (
+	ExecutionModeOriginUpperLeft ExecutionMode = 7
+	ExecutionModeLocalSize       ExecutionMode = 17
+)
+
+func (e ExecutionMode) String() string {
+	switch e {
+	case ExecutionModeOriginUpperLeft:
+		return "OriginUpperLeft"
+	case ExecutionModeLocalSize:
+		return "LocalSize"
+	default:
+		panic("unknown execution mode")
+	}
+}
+
 // AddressingModel specifies the addressing model used by the module.
 // Used by OpMemoryModel.
 type AddressingModel int
@@ -578,6 +731,130 @@ func (s StorageClass) String() string {
 	}
 }
 
+// Decoration adds information to the object it's applied to.
+// Used by OpDecorate and OpMemberDecorate.
+type Decoration int
+
+const (
+	// Apply to a struct to indicate that it holds the contents of a buffer.
+	DecorationBlock Decoration = 2
+	// Apply to a struct in the Uniform storage class to indicate that it holds the contents of a storage buffer, it's
+	// replaced by Block in the StorageBuffer storage class since SPIR-V 1.3.
+	DecorationBufferBlock Decoration = 3
+	// Apply to an array type to give the distance between its elements in memory.
+	DecorationArrayStride Decoration = 6
+	// Apply to a variable to indicate that it holds the given builtin input or output.
+	DecorationBuiltIn Decoration = 11
+	// Apply to an input or an output variable to indicate that it isn't interpolated between the vertices.
+	DecorationFlat Decoration = 14
+	// Apply to an input or an output variable to give the location it's passed between the stages at.
+	DecorationLocation Decoration = 30
+	// Apply to a resource variable to give its binding number in its descriptor set.
+	DecorationBinding Decoration = 33
+	// Apply to a resource variable to give the descriptor set it's bound in.
+	DecorationDescriptorSet Decoration = 34
+	// Apply to a member of a struct to give its byte offset in memory.
+	DecorationOffset Decoration = 35
+)
+
+func (d Decoration) String() string {
+	switch d {
+	case DecorationBlock:
+		return "Block"
+	case DecorationBufferBlock:
+		return "BufferBlock"
+	case DecorationArrayStride:
+		return "ArrayStride"
+	case DecorationOffset:
+		return "Offset"
+	case DecorationBuiltIn:
+		return "BuiltIn"
+	case DecorationFlat:
+		return "Flat"
+	case DecorationLocation:
+		return "Location"
+	case DecorationBinding:
+		return "Binding"
+	case DecorationDescriptorSet:
+		return "DescriptorSet"
+	default:
+		panic("unknown decoration")
+	}
+}
+
+// BuiltIn is the value provided by the pipeline to a variable decorated with BuiltIn.
+type BuiltIn int
+
+const (
+	BuiltInPosition             BuiltIn = 0
+	BuiltInFrontFacing          BuiltIn = 17
+	BuiltInLocalInvocationIndex BuiltIn = 29
+)
+
+func (b BuiltIn) String() string {
+	switch b {
+	case BuiltInPosition:
+		return "Position"
+	case BuiltInFrontFacing:
+		return "FrontFacing"
+	case BuiltInLocalInvocationIndex:
+		return "LocalInvocationIndex"
+	default:
+		panic("unknown builtin")
+	}
+}
+
+// Dim is the dimensionality of an image type.
+type Dim int
+
+const (
+	Dim2D Dim = 1
+)
+
+func (d Dim) String() string {
+	switch d {
+	case Dim2D:
+		return "2D"
+	default:
+		panic("unknown dim")
+	}
+}
+
+// ImageFormat is the format of the texels of an image type, sampled images leave it unknown.
+type ImageFormat int
+
+const (
+	ImageFormatUnknown ImageFormat = 0
+	ImageFormatRgba32f ImageFormat = 1
+)
+
+func (f ImageFormat) String() string {
+	switch f {
+	case ImageFormatUnknown:
+		return "Unknown"
+	case ImageFormatRgba32f:
+		return "Rgba32f"
+	default:
+		panic("unknown image format")
+	}
+}
+
+// ImageOperands are the optional operands of the image instructions, each set bit is followed by its operands.
+type ImageOperands int
+
+const (
+	ImageOperandsLod ImageOperands = 0x2
+)
+
+func (o ImageOperands) String() string {
+	switch o {
+	case ImageOperandsLod:
+		return "Lod"
+	default:
+		panic("unknown image operands")
+	}
+}
+
 type FunctionControl int
 
 const (
@@ -30,21 +30,34 @@ func (tp *TextPrinter) println(a ...any) {
 
 func (tp *TextPrinter) Emit() {
 	tp.emitCapabilities()
+	tp.emitExtensions()
+	tp.emitExtInstImports()
 	tp.emitMemoryModel()
+	tp.emitEntryPoints()
+	tp.emitDecorations()
 
 	for _, obj := range tp.module.Objects {
-		if _, isType := obj.(Type); isType {
+		if t, isType := obj.(Type); isType && !dependsOnConstant(t) {
 			tp.emitObject(obj)
 		}
 	}
 
+	// types which refer to constants are declared among the constants in the order they were created
 	for _, obj := range tp.module.Objects {
-		switch obj.(type) {
+		switch v := obj.(type) {
 		case ConstantValue:
 			tp.emitObject(obj)
+		case Type:
+			if dependsOnConstant(v) {
+				tp.emitObject(obj)
+			}
 		}
 	}
 
+	for _, v := range tp.module.Globals() {
+		tp.emitWithObject(v, OpVariable, tp.nameOf(v.Type), v.StorageClass)
+	}
+
 	for _, obj := range tp.module.Objects {
 		if _, isFunction := obj.(*Function); isFunction {
 			tp.emitObject(obj)
@@ -97,6 +110,56 @@ func (tp *TextPrinter) emitCapabilities() {
 	}
 }
 
+func (tp *TextPrinter) emitExtensions() {
+	for _, e := range tp.module.Extensions() {
+		tp.printf("OpExtension %q\n", e)
+	}
+}
+
+func (tp *TextPrinter) emitExtInstImports() {
+	for _, e := range tp.module.ExtInstImports() {
+		tp.emitWithObject(e, OpExtInstImport, fmt.Sprintf("%q", e.Set))
+	}
+}
+
+func (tp *TextPrinter) emitEntryPoints() {
+	for _, e := range tp.module.EntryPoints() {
+		args := []any{e.Model, tp.nameOf(e.Function), fmt.Sprintf("%q", e.Name)}
+		for _, v := range e.Interface {
+			args = append(args, tp.nameOf(v))
+		}
+		tp.emit(OpEntryPoint, args...)
+	}
+	for _, e := range tp.module.ExecutionModes() {
+		args := []any{tp.nameOf(e.Function), e.Mode}
+		for _, l := range e.Literals {
+			args = append(args, l)
+		}
+		tp.emit(OpExecutionMode, args...)
+	}
+}
+
+func (tp *TextPrinter) emitDecorations() {
+	for _, d := range tp.module.Decorations() {
+		args := []any{tp.nameOfByID(d.Target), d.Decoration}
+		for _, l := range d.Literals {
+			if d.Decoration == DecorationBuiltIn {
+				args = append(args, BuiltIn(l))
+			} else {
+				args = append(args, l)
+			}
+		}
+		tp.emit(OpDecorate, args...)
+	}
+	for _, d := range tp.module.MemberDecorations() {
+		args := []any{tp.nameOfByID(d.Target), d.Member, d.Decoration}
+		for _, l := range d.Literals {
+			args = append(args, l)
+		}
+		tp.emit(OpMemberDecorate, args...)
+	}
+}
+
 func (tp *TextPrinter) emitMemoryModel() {
 	tp.printf("OpMemoryModel %s %s\n", tp.module.AddressingModel, tp.module.MemoryModel)
 }
@@ -142,6 +205,27 @@ func (tp *TextPrinter) emitInstruction(inst Instruction) {
 		tp.emit(OpReturn)
 	case *ReturnValueInstruction:
 		tp.emit(OpReturnValue, tp.nameOfByID(i.Value))
+	case *ConvertFToUInstruction:
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpConvertFToU, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Operand))
+	case *ConvertFToSInstruction:
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpConvertFToS, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Operand))
+	case *ConvertSToFInstruction:
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpConvertSToF, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Operand))
+	case *ConvertUToFInstruction:
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpConvertUToF, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Operand))
+	case *UConvertInstruction:
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpUConvert, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Operand))
+	case *FConvertInstruction:
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpFConvert, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Operand))
+	case *BitcastInstruction:
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpBitcast, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Operand))
 	case *SNegateInstruction:
 		resultObj := tp.module.GetObject(i.ResultID)
 		tp.emitWithObject(resultObj, OpSNegate, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Operand))
@@ -268,6 +352,45 @@ func (tp *TextPrinter) emitInstruction(inst Instruction) {
 	case *ShiftRightArithmeticInstruction:
 		resultObj := tp.module.GetObject(i.ResultID)
 		tp.emitWithObject(resultObj, OpShiftRightArithmetic, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Base), tp.nameOfByID(i.Shift))
+	case *ExtInstInstruction:
+		args := make([]any, 0, len(i.Operands)+3)
+		args = append(args, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Set), i.Instruction)
+		for _, operand := range i.Operands {
+			args = append(args, tp.nameOfByID(operand))
+		}
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpExtInst, args...)
+	case *DPdxInstruction:
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpDPdx, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Operand))
+	case *DPdyInstruction:
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpDPdy, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Operand))
+	case *FwidthInstruction:
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpFwidth, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Operand))
+	case *ImageSampleImplicitLodInstruction:
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(
+			resultObj, OpImageSampleImplicitLod,
+			tp.nameOfByID(i.ResultType), tp.nameOfByID(i.SampledImage), tp.nameOfByID(i.Coordinate),
+		)
+	case *ImageSampleExplicitLodInstruction:
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(
+			resultObj, OpImageSampleExplicitLod,
+			tp.nameOfByID(i.ResultType), tp.nameOfByID(i.SampledImage), tp.nameOfByID(i.Coordinate),
+			ImageOperandsLod, tp.nameOfByID(i.Lod),
+		)
+	case *ImageReadInstruction:
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(
+			resultObj, OpImageRead, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Image), tp.nameOfByID(i.Coordinate),
+		)
+	case *ImageWriteInstruction:
+		tp.emit(OpImageWrite, tp.nameOfByID(i.Image), tp.nameOfByID(i.Coordinate), tp.nameOfByID(i.Texel))
+	case *ControlBarrierInstruction:
+		tp.emit(OpControlBarrier, tp.nameOfByID(i.Execution), tp.nameOfByID(i.Memory), tp.nameOfByID(i.Semantics))
 	case *FunctionCallInstruction:
 		args := make([]any, 0, len(i.Args)+2)
 		args = append(args, tp.nameOfByID(i.ResultType))
@@ -289,8 +412,44 @@ func (tp *TextPrinter) emitInstruction(inst Instruction) {
 		tp.emitWithObject(resultObj, OpLoad, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Pointer))
 	case *StoreInstruction:
 		tp.emit(OpStore, tp.nameOfByID(i.Pointer), tp.nameOfByID(i.Object))
+	case *AccessChainInstruction:
+		args := make([]any, 0, len(i.Indexes)+2)
+		args = append(args, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Base))
+		for _, index := range i.Indexes {
+			args = append(args, tp.nameOfByID(index))
+		}
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpAccessChain, args...)
+	case *VectorShuffleInstruction:
+		args := make([]any, 0, len(i.Components)+3)
+		args = append(args, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Vector1), tp.nameOfByID(i.Vector2))
+		for _, component := range i.Components {
+			args = append(args, component)
+		}
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpVectorShuffle, args...)
+	case *CompositeConstructInstruction:
+		args := make([]any, 0, len(i.Constituents)+1)
+		args = append(args, tp.nameOfByID(i.ResultType))
+		for _, constituent := range i.Constituents {
+			args = append(args, tp.nameOfByID(constituent))
+		}
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpCompositeConstruct, args...)
+	case *CompositeExtractInstruction:
+		args := make([]any, 0, len(i.Indexes)+2)
+		args = append(args, tp.nameOfByID(i.ResultType), tp.nameOfByID(i.Composite))
+		for _, index := range i.Indexes {
+			args = append(args, index)
+		}
+		resultObj := tp.module.GetObject(i.ResultID)
+		tp.emitWithObject(resultObj, OpCompositeExtract, args...)
 	case *UnreachableInstruction:
 		tp.emit(OpUnreachable)
+	case *KillInstruction:
+		tp.emit(OpKill)
+	case *DemoteToHelperInvocationInstruction:
+		tp.emit(OpDemoteToHelperInvocation)
 	case *SelectionMergeInstruction:
 		tp.emit(OpSelectionMerge, tp.nameOfByID(i.MergeBlock), i.Control)
 	case *BranchConditional:
@@ -314,8 +473,18 @@ func (tp *TextPrinter) emitType(abstractType Type) {
 		tp.emitIntType(t)
 	case *FloatType:
 		tp.emitFloatType(t)
+	case *VectorType:
+		tp.emitVectorType(t)
+	case *ArrayType:
+		tp.emitArrayType(t)
+	case *StructType:
+		tp.emitStructType(t)
 	case *PtrType:
 		tp.emitPtrType(t)
+	case *ImageType:
+		tp.emitImageType(t)
+	case *SampledImageType:
+		tp.emitSampledImageType(t)
 	case *FuncType:
 		tp.emitFuncType(t)
 	default:
@@ -331,6 +500,8 @@ func (tp *TextPrinter) emitConstant(constant ConstantValue) {
 		tp.emitIntConstant(c)
 	case *FloatConstant:
 		tp.emitFloatConstant(c)
+	case *CompositeConstant:
+		tp.emitCompositeConstant(c)
 	default:
 		panic(fmt.Sprintf("unsupported constant: %T", c))
 	}
@@ -345,13 +516,32 @@ func (tp *TextPrinter) emitBoolConstant(c *BoolConstant) {
 }
 
 func (tp *TextPrinter) emitIntConstant(c *IntConstant) {
+	// unsigned literals are written as their bit pattern, kernels keep negative values in unsigned types
+	if !c.Type.IsSigned && c.Type.BitWidth < 64 {
+		tp.emitWithObject(c, OpConstant, tp.nameOf(c.Type), uint64(c.Value)&(1<<c.Type.BitWidth-1))
+		return
+	}
 	tp.emitWithObject(c, OpConstant, tp.nameOf(c.Type), c.Value)
 }
 
 func (tp *TextPrinter) emitFloatConstant(c *FloatConstant) {
+	// 32-bit values are printed with the shortest digits that round to them
+	if c.Type.BitWidth == 32 {
+		tp.emitWithObject(c, OpConstant, tp.nameOf(c.Type), float32(c.Value))
+		return
+	}
 	tp.emitWithObject(c, OpConstant, tp.nameOf(c.Type), c.Value)
 }
 
+func (tp *TextPrinter) emitCompositeConstant(c *CompositeConstant) {
+	args := make([]any, 0, len(c.Constituents)+1)
+	args = append(args, tp.nameOf(c.Type))
+	for _, constituent := range c.Constituents {
+		args = append(args, tp.nameOf(constituent))
+	}
+	tp.emitWithObject(c, OpConstantComposite, args...)
+}
+
 func (tp *TextPrinter) emitVoidType(t *VoidType) {
 	tp.emitWithObject(t, OpTypeVoid)
 }
@@ -368,10 +558,36 @@ func (tp *TextPrinter) emitFloatType(t *FloatType) {
 	tp.emitWithObject(t, OpTypeFloat, t.BitWidth)
 }
 
+func (tp *TextPrinter) emitVectorType(t *VectorType) {
+	tp.emitWithObject(t, OpTypeVector, tp.nameOf(t.ComponentType), t.Count)
+}
+
+func (tp *TextPrinter) emitArrayType(t *ArrayType) {
+	tp.emitWithObject(t, OpTypeArray, tp.nameOf(t.ElementType), tp.nameOf(t.Length))
+}
+
+func (tp *TextPrinter) emitStructType(t *StructType) {
+	args := make([]any, 0, len(t.MemberTypes))
+	for _, member := range t.MemberTypes {
+		args = append(args, tp.nameOf(member))
+	}
+	tp.emitWithObject(t, OpTypeStruct, args...)
+}
+
 func (tp *TextPrinter) emitPtrType(t *PtrType) {
 	tp.emitWithObject(t, OpTypePointer, t.StorageClass, tp.nameOf(t.To))
 }
 
+func (tp *TextPrinter) emitImageType(t *ImageType) {
+	tp.emitWithObject(
+		t, OpTypeImage, tp.nameOf(t.SampledType), t.Dim, t.Depth, t.Arrayed, t.Multisampled, t.Sampled, t.Format,
+	)
+}
+
+func (tp *TextPrinter) emitSampledImageType(t *SampledImageType) {
+	tp.emitWithObject(t, OpTypeSampledImage, tp.nameOf(t.ImageType))
+}
+
 func (tp *TextPrinter) emitFuncType(t *FuncType) {
 	args := make([]any, 0, len(t.ArgTypes)+1)
 	args = append(args, tp.nameOf(t.ReturnType))
@@ -405,6 +621,9 @@ func (tp *TextPrinter) nameOfByID(id ID) string {
 }
 
 func (tp *TextPrinter) nameOf(obj Object) string {
+	if name, ok := tp.module.textNames[obj.ID()]; ok {
+		return "%" + name
+	}
 	kind := ""
 	switch obj.(type) {
 	case *Function:
@@ -413,8 +632,8 @@ func (tp *TextPrinter) nameOf(obj Object) string {
 		kind = "block"
 	case Type:
 		kind = "type"
-	case *BoolConstant:
-		kind = "const"
+	case *ExtInstImport:
+		kind = "ext"
 	}
 	if len(kind) == 0 {
 		return fmt.Sprintf("%%%s_%d", obj.Name(), obj.ID())
@@ -2,6 +2,7 @@ package spirv
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -99,6 +100,170 @@ func (t FloatType) HashKey() string {
 	return t.TypeName()
 }
 
+type StructType struct {
+	ObjectID    ID
+	ObjectName  string
+	Module      *Module
+	MemberTypes []Type
+	// Block is set for the structs holding the contents of buffers
+	Block bool
+}
+
+func (t StructType) ID() ID {
+	return t.ObjectID
+}
+func (t StructType) Name() string {
+	return t.ObjectName
+}
+func (StructType) aType() {}
+func (t StructType) TypeName() string {
+	var b strings.Builder
+	if t.Block {
+		b.WriteString("block")
+	} else {
+		b.WriteString("struct")
+	}
+	for _, member := range t.MemberTypes {
+		b.WriteString("_")
+		b.WriteString(member.TypeName())
+	}
+	return b.String()
+}
+func (t StructType) HashKey() string {
+	var b strings.Builder
+	if t.Block {
+		b.WriteString("block(")
+	} else {
+		b.WriteString("struct(")
+	}
+	for i, member := range t.MemberTypes {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(member.HashKey())
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+type ArrayType struct {
+	ObjectID    ID
+	ObjectName  string
+	Module      *Module
+	ElementType Type
+	Length      *IntConstant
+}
+
+func (t ArrayType) ID() ID {
+	return t.ObjectID
+}
+func (t ArrayType) Name() string {
+	return t.ObjectName
+}
+func (ArrayType) aType() {}
+func (t ArrayType) TypeName() string {
+	return fmt.Sprintf("array_%v_%v", t.ElementType.TypeName(), t.Length.Value)
+}
+func (t ArrayType) HashKey() string {
+	return fmt.Sprintf("array(%v,%v)", t.ElementType.HashKey(), t.Length.Value)
+}
+
+type VectorType struct {
+	ObjectID      ID
+	ObjectName    string
+	Module        *Module
+	ComponentType Type
+	Count         int
+}
+
+func (t VectorType) ID() ID {
+	return t.ObjectID
+}
+func (t VectorType) Name() string {
+	return t.ObjectName
+}
+func (VectorType) aType() {}
+func (t VectorType) TypeName() string {
+	return fmt.Sprintf("vector_%v_%v", t.ComponentType.TypeName(), t.Count)
+}
+func (t VectorType) HashKey() string {
+	return fmt.Sprintf("vector(%v,%v)", t.ComponentType.HashKey(), t.Count)
+}
+
+// ImageType is an image of values of the sampled type, images are read through the samplers combined with them
+type ImageType struct {
+	ObjectID     ID
+	ObjectName   string
+	Module       *Module
+	SampledType  Type
+	Dim          Dim
+	Depth        Word
+	Arrayed      Word
+	Multisampled Word
+	// Sampled is 1 for images read through samplers and 2 for storage images
+	Sampled Word
+	Format  ImageFormat
+}
+
+func (t ImageType) ID() ID {
+	return t.ObjectID
+}
+func (t ImageType) Name() string {
+	return t.ObjectName
+}
+func (ImageType) aType() {}
+func (t ImageType) TypeName() string {
+	if t.Sampled == 2 {
+		return fmt.Sprintf("storage_image%v_%v", t.Dim, t.SampledType.TypeName())
+	}
+	return fmt.Sprintf("image%v_%v", t.Dim, t.SampledType.TypeName())
+}
+func (t ImageType) HashKey() string {
+	return fmt.Sprintf(
+		"image(%v,%v,%v,%v,%v,%v,%v)",
+		t.SampledType.HashKey(), t.Dim, t.Depth, t.Arrayed, t.Multisampled, t.Sampled, t.Format,
+	)
+}
+
+// SampledImageType is an image combined with the sampler reading it
+type SampledImageType struct {
+	ObjectID   ID
+	ObjectName string
+	Module     *Module
+	ImageType  *ImageType
+}
+
+func (t SampledImageType) ID() ID {
+	return t.ObjectID
+}
+func (t SampledImageType) Name() string {
+	return t.ObjectName
+}
+func (SampledImageType) aType() {}
+func (t SampledImageType) TypeName() string {
+	return fmt.Sprintf("sampled_%v", t.ImageType.TypeName())
+}
+func (t SampledImageType) HashKey() string {
+	return fmt.Sprintf("sampled(%v)", t.ImageType.HashKey())
+}
+
+// dependsOnConstant returns whether the type refers to a constant, array types refer to their length constant so
+// they're declared after it
+func dependsOnConstant(t Type) bool {
+	switch u := t.(type) {
+	case *ArrayType:
+		return true
+	case *StructType:
+		return slices.ContainsFunc(u.MemberTypes, dependsOnConstant)
+	case *PtrType:
+		return dependsOnConstant(u.To)
+	case *FuncType:
+		return dependsOnConstant(u.ReturnType) || slices.ContainsFunc(u.ArgTypes, dependsOnConstant)
+	default:
+		return false
+	}
+}
+
 type PtrType struct {
 	ObjectID     ID
 	ObjectName   string
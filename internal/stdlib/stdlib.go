@@ -0,0 +1,10 @@
+// Package stdlib bundles the sabre standard library packages into the compiler binary
+package stdlib
+
+import "embed"
+
+// FS holds the sources of the standard library packages, each directory is a package
+// which is imported using its directory name, e.g. import "math"
+//
+//go:embed math color noise random pbr
+var FS embed.FS